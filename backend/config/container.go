@@ -62,6 +62,118 @@ func SetupContainer() *utils.Container {
 		},
 	)
 
+	container.RegisterSingleton(
+		reflect.TypeOf((*services.TaxDeductionServiceInterface)(nil)).Elem(),
+		func(db *gorm.DB) services.TaxDeductionServiceInterface {
+			return services.NewTaxDeductionService(db)
+		},
+	)
+
+	container.RegisterSingleton(
+		reflect.TypeOf((*services.SocialInsuranceServiceInterface)(nil)).Elem(),
+		func(db *gorm.DB) services.SocialInsuranceServiceInterface {
+			return services.NewSocialInsuranceService(db)
+		},
+	)
+
+	container.RegisterSingleton(
+		reflect.TypeOf((*services.WorkCalendarServiceInterface)(nil)).Elem(),
+		func(db *gorm.DB) services.WorkCalendarServiceInterface {
+			return services.NewWorkCalendarService(db)
+		},
+	)
+
+	container.RegisterSingleton(
+		reflect.TypeOf((*services.RetroPayServiceInterface)(nil)).Elem(),
+		func(db *gorm.DB) services.RetroPayServiceInterface {
+			return services.NewRetroPayService(db)
+		},
+	)
+
+	container.RegisterSingleton(
+		reflect.TypeOf((*services.BankFileServiceInterface)(nil)).Elem(),
+		func(db *gorm.DB) services.BankFileServiceInterface {
+			return services.NewBankFileService(db)
+		},
+	)
+
+	container.RegisterSingleton(
+		reflect.TypeOf((*services.BankReconciliationServiceInterface)(nil)).Elem(),
+		func(db *gorm.DB) services.BankReconciliationServiceInterface {
+			return services.NewBankReconciliationService(db)
+		},
+	)
+
+	container.RegisterSingleton(
+		reflect.TypeOf((*services.PayslipServiceInterface)(nil)).Elem(),
+		func(db *gorm.DB) services.PayslipServiceInterface {
+			return services.NewPayslipService(db)
+		},
+	)
+
+	container.RegisterSingleton(
+		reflect.TypeOf((*services.PayrollVarianceServiceInterface)(nil)).Elem(),
+		func(db *gorm.DB) services.PayrollVarianceServiceInterface {
+			return services.NewPayrollVarianceService(db)
+		},
+	)
+
+	container.RegisterSingleton(
+		reflect.TypeOf((*services.GLJournalServiceInterface)(nil)).Elem(),
+		func(db *gorm.DB) services.GLJournalServiceInterface {
+			return services.NewGLJournalService(db)
+		},
+	)
+
+	container.RegisterSingleton(
+		reflect.TypeOf((*services.SalaryStructureVersionServiceInterface)(nil)).Elem(),
+		func(db *gorm.DB) services.SalaryStructureVersionServiceInterface {
+			return services.NewSalaryStructureVersionService(db)
+		},
+	)
+
+	container.RegisterSingleton(
+		reflect.TypeOf((*services.CompensationBandServiceInterface)(nil)).Elem(),
+		func(db *gorm.DB) services.CompensationBandServiceInterface {
+			return services.NewCompensationBandService(db)
+		},
+	)
+
+	container.RegisterSingleton(
+		reflect.TypeOf((*services.CompensationReviewServiceInterface)(nil)).Elem(),
+		func(db *gorm.DB) services.CompensationReviewServiceInterface {
+			return services.NewCompensationReviewService(db)
+		},
+	)
+
+	container.RegisterSingleton(
+		reflect.TypeOf((*services.ExchangeRateServiceInterface)(nil)).Elem(),
+		func(db *gorm.DB) services.ExchangeRateServiceInterface {
+			return services.NewExchangeRateService(db)
+		},
+	)
+
+	container.RegisterSingleton(
+		reflect.TypeOf((*services.TerminationSettlementServiceInterface)(nil)).Elem(),
+		func(db *gorm.DB) services.TerminationSettlementServiceInterface {
+			return services.NewTerminationSettlementService(db)
+		},
+	)
+
+	container.RegisterSingleton(
+		reflect.TypeOf((*services.SalarySelfServiceInterface)(nil)).Elem(),
+		func(db *gorm.DB, notificationService *services.NotificationService) services.SalarySelfServiceInterface {
+			return services.NewSalarySelfService(db, notificationService)
+		},
+	)
+
+	container.RegisterSingleton(
+		reflect.TypeOf((*services.PayrollJobServiceInterface)(nil)).Elem(),
+		func(db *gorm.DB, salaryService services.SalaryServiceInterface, wsService *services.WebSocketService) services.PayrollJobServiceInterface {
+			return services.NewPayrollJobService(db, salaryService, wsService)
+		},
+	)
+
 	container.RegisterSingleton(
 		reflect.TypeOf((*services.AttendanceServiceInterface)(nil)).Elem(),
 		func(db *gorm.DB) services.AttendanceServiceInterface {
@@ -178,6 +290,118 @@ func SetupContainer() *utils.Container {
 		},
 	)
 
+	container.RegisterTransient(
+		reflect.TypeOf((*controllers.TaxDeductionController)(nil)),
+		func(deductionService services.TaxDeductionServiceInterface) *controllers.TaxDeductionController {
+			return controllers.NewTaxDeductionController(deductionService)
+		},
+	)
+
+	container.RegisterTransient(
+		reflect.TypeOf((*controllers.SocialInsuranceController)(nil)),
+		func(insuranceService services.SocialInsuranceServiceInterface) *controllers.SocialInsuranceController {
+			return controllers.NewSocialInsuranceController(insuranceService)
+		},
+	)
+
+	container.RegisterTransient(
+		reflect.TypeOf((*controllers.WorkCalendarController)(nil)),
+		func(calendarService services.WorkCalendarServiceInterface) *controllers.WorkCalendarController {
+			return controllers.NewWorkCalendarController(calendarService)
+		},
+	)
+
+	container.RegisterTransient(
+		reflect.TypeOf((*controllers.RetroPayController)(nil)),
+		func(retroPayService services.RetroPayServiceInterface) *controllers.RetroPayController {
+			return controllers.NewRetroPayController(retroPayService)
+		},
+	)
+
+	container.RegisterTransient(
+		reflect.TypeOf((*controllers.BankFileController)(nil)),
+		func(bankFileService services.BankFileServiceInterface) *controllers.BankFileController {
+			return controllers.NewBankFileController(bankFileService)
+		},
+	)
+
+	container.RegisterTransient(
+		reflect.TypeOf((*controllers.BankReconciliationController)(nil)),
+		func(reconciliationService services.BankReconciliationServiceInterface) *controllers.BankReconciliationController {
+			return controllers.NewBankReconciliationController(reconciliationService)
+		},
+	)
+
+	container.RegisterTransient(
+		reflect.TypeOf((*controllers.PayslipController)(nil)),
+		func(payslipService services.PayslipServiceInterface) *controllers.PayslipController {
+			return controllers.NewPayslipController(payslipService)
+		},
+	)
+
+	container.RegisterTransient(
+		reflect.TypeOf((*controllers.PayrollVarianceController)(nil)),
+		func(varianceService services.PayrollVarianceServiceInterface) *controllers.PayrollVarianceController {
+			return controllers.NewPayrollVarianceController(varianceService)
+		},
+	)
+
+	container.RegisterTransient(
+		reflect.TypeOf((*controllers.GLJournalController)(nil)),
+		func(journalService services.GLJournalServiceInterface) *controllers.GLJournalController {
+			return controllers.NewGLJournalController(journalService)
+		},
+	)
+
+	container.RegisterTransient(
+		reflect.TypeOf((*controllers.SalaryStructureVersionController)(nil)),
+		func(versionService services.SalaryStructureVersionServiceInterface) *controllers.SalaryStructureVersionController {
+			return controllers.NewSalaryStructureVersionController(versionService)
+		},
+	)
+
+	container.RegisterTransient(
+		reflect.TypeOf((*controllers.CompensationBandController)(nil)),
+		func(bandService services.CompensationBandServiceInterface) *controllers.CompensationBandController {
+			return controllers.NewCompensationBandController(bandService)
+		},
+	)
+
+	container.RegisterTransient(
+		reflect.TypeOf((*controllers.CompensationReviewController)(nil)),
+		func(reviewService services.CompensationReviewServiceInterface) *controllers.CompensationReviewController {
+			return controllers.NewCompensationReviewController(reviewService)
+		},
+	)
+
+	container.RegisterTransient(
+		reflect.TypeOf((*controllers.ExchangeRateController)(nil)),
+		func(rateService services.ExchangeRateServiceInterface) *controllers.ExchangeRateController {
+			return controllers.NewExchangeRateController(rateService)
+		},
+	)
+
+	container.RegisterTransient(
+		reflect.TypeOf((*controllers.TerminationSettlementController)(nil)),
+		func(settlementService services.TerminationSettlementServiceInterface) *controllers.TerminationSettlementController {
+			return controllers.NewTerminationSettlementController(settlementService)
+		},
+	)
+
+	container.RegisterTransient(
+		reflect.TypeOf((*controllers.SalarySelfServiceController)(nil)),
+		func(selfService services.SalarySelfServiceInterface) *controllers.SalarySelfServiceController {
+			return controllers.NewSalarySelfServiceController(selfService)
+		},
+	)
+
+	container.RegisterTransient(
+		reflect.TypeOf((*controllers.PayrollJobController)(nil)),
+		func(jobService services.PayrollJobServiceInterface) *controllers.PayrollJobController {
+			return controllers.NewPayrollJobController(jobService)
+		},
+	)
+
 	container.RegisterTransient(
 		reflect.TypeOf((*controllers.AttendanceController)(nil)),
 		func(attendanceService services.AttendanceServiceInterface) *controllers.AttendanceController {
@@ -274,3 +498,8 @@ func (sc *ServiceContainer) SalaryService() services.SalaryServiceInterface {
 	service, _ := sc.container.Resolve(reflect.TypeOf((*services.SalaryServiceInterface)(nil)).Elem())
 	return service.(services.SalaryServiceInterface)
 }
+
+func (sc *ServiceContainer) PayrollJobService() services.PayrollJobServiceInterface {
+	service, _ := sc.container.Resolve(reflect.TypeOf((*services.PayrollJobServiceInterface)(nil)).Elem())
+	return service.(services.PayrollJobServiceInterface)
+}
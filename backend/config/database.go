@@ -110,6 +110,31 @@ func AutoMigrate() {
 		&models.Leave{},
 		&models.Salary{},
 		&models.PayrollRecord{},
+		&models.SpecialDeduction{},
+		&models.SocialInsurancePolicy{},
+		&models.WorkCalendarDay{},
+		&models.SalaryDetailSegment{},
+		&models.RetroPayItem{},
+		&models.PayrollJob{},
+		&models.PayrollJobItem{},
+		&models.PayrollPeriodEvent{},
+		&models.PaymentBatchFile{},
+		&models.BankReturnImport{},
+		&models.BankReturnException{},
+		&models.PayslipTemplate{},
+		&models.GLAccountMapping{},
+		&models.SalaryStructureVersion{},
+		&models.SalaryStructureVersionComponent{},
+		&models.CompensationReviewCycle{},
+		&models.CompensationReviewBudget{},
+		&models.CompensationReviewGuideline{},
+		&models.CompensationReviewProposal{},
+		&models.ExchangeRate{},
+		&models.TerminationSettlement{},
+		&models.LeaveBalance{},
+		&models.SalaryAccessCredential{},
+		&models.PayslipAcknowledgement{},
+		&models.PayslipDispute{},
 		&models.Recruitment{},
 		&models.Candidate{},
 		&models.Performance{},
@@ -41,6 +41,10 @@ func (ac *AttendanceController) CheckIn(c *gin.Context) {
 
 	attendance, err := ac.attendanceService.CheckIn(userID, req.Location, req.Remark)
 	if err != nil {
+		if conflictErr, ok := utils.AsConflictError(err); ok {
+			utils.ConflictResponse(c, conflictErr.Message)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "签到失败")
 		return
 	}
@@ -67,6 +71,10 @@ func (ac *AttendanceController) CheckOut(c *gin.Context) {
 
 	attendance, err := ac.attendanceService.CheckOut(userID, req.Location, req.Remark)
 	if err != nil {
+		if conflictErr, ok := utils.AsConflictError(err); ok {
+			utils.ConflictResponse(c, conflictErr.Message)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "签退失败")
 		return
 	}
@@ -151,6 +159,10 @@ func (ac *AttendanceController) CreateLeave(c *gin.Context) {
 	req.EmployeeID = userID
 	leave, err := ac.attendanceService.CreateLeave(&req)
 	if err != nil {
+		if conflictErr, ok := utils.AsConflictError(err); ok {
+			utils.ConflictResponse(c, conflictErr.Message)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "创建请假申请失败")
 		return
 	}
@@ -201,6 +213,10 @@ func (ac *AttendanceController) ApproveLeave(c *gin.Context) {
 
 	leave, err := ac.attendanceService.ApproveLeave(uint(leaveID), userID, req.Status, req.ApproveNote)
 	if err != nil {
+		if conflictErr, ok := utils.AsConflictError(err); ok {
+			utils.ConflictResponse(c, conflictErr.Message)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "审批失败")
 		return
 	}
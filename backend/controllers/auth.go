@@ -166,7 +166,7 @@ func (ac *AuthController) Logout(c *gin.Context) {
 	}
 
 	// 验证token并获取用户ID
-	claims, err := utils.DefaultJWTService.ValidateToken(token)
+	claims, err := utils.DefaultJWTService.ValidateSessionToken(token)
 	if err != nil {
 		utils.UnauthorizedResponse(c, "Invalid token")
 		return
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BankFileController struct {
+	bankFileService services.BankFileServiceInterface
+}
+
+func NewBankFileController(bankFileService services.BankFileServiceInterface) *BankFileController {
+	return &BankFileController{
+		bankFileService: bankFileService,
+	}
+}
+
+// GetBankFileFormats 获取可用的银行文件格式
+func (bc *BankFileController) GetBankFileFormats(c *gin.Context) {
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", bc.bankFileService.GetBankFileFormats())
+}
+
+// DownloadBankFile 生成并下载支付批次的银行代发文件
+func (bc *BankFileController) DownloadBankFile(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的批次ID")
+		return
+	}
+
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	format := services.BankFileFormat(c.DefaultQuery("format", string(services.BankFileCSV)))
+	result, err := bc.bankFileService.ExportPaymentBatch(uint(id), format, userID)
+	if err != nil {
+		if conflictErr, ok := utils.AsConflictError(err); ok {
+			utils.ConflictResponse(c, conflictErr.Message)
+			return
+		}
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "生成银行文件失败: "+err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+result.FileName)
+	c.Header("X-Record-Count", strconv.Itoa(result.File.RecordCount))
+	c.Header("X-Control-Sum", result.File.ControlSum.String())
+	c.Header("X-Batch-Checksum", result.File.BatchChecksum)
+	c.Data(http.StatusOK, result.ContentType, result.Content)
+}
+
+// GetBankFiles 获取支付批次的银行文件生成记录
+func (bc *BankFileController) GetBankFiles(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的批次ID")
+		return
+	}
+
+	files, err := bc.bankFileService.GetPaymentBatchFiles(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取银行文件记录失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", files)
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBankReturnFileSize 回盘文件大小上限（10MB）
+const maxBankReturnFileSize = 10 << 20
+
+type BankReconciliationController struct {
+	reconciliationService services.BankReconciliationServiceInterface
+}
+
+func NewBankReconciliationController(reconciliationService services.BankReconciliationServiceInterface) *BankReconciliationController {
+	return &BankReconciliationController{
+		reconciliationService: reconciliationService,
+	}
+}
+
+// ImportReturnFile 导入银行回盘文件并对账
+func (rc *BankReconciliationController) ImportReturnFile(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的批次ID")
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请选择文件")
+		return
+	}
+	if fileHeader.Size > maxBankReturnFileSize {
+		utils.ErrorResponse(c, http.StatusBadRequest, "文件大小不能超过10MB")
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "读取文件失败")
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "读取文件失败")
+		return
+	}
+
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	format := services.BankFileFormat(c.DefaultPostForm("format", string(services.BankFileCSV)))
+	result, err := rc.reconciliationService.ImportReturnFile(uint(id), format, fileHeader.Filename, content, userID)
+	if err != nil {
+		if conflictErr, ok := utils.AsConflictError(err); ok {
+			utils.ConflictResponse(c, conflictErr.Message)
+			return
+		}
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "导入回盘文件失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "对账完成", result)
+}
+
+// GetReturnImports 获取支付批次的回盘导入记录
+func (rc *BankReconciliationController) GetReturnImports(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的批次ID")
+		return
+	}
+
+	imports, err := rc.reconciliationService.GetReturnImports(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取回盘记录失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", imports)
+}
+
+// GetReturnImport 获取回盘导入详情及异常明细
+func (rc *BankReconciliationController) GetReturnImport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的回盘记录ID")
+		return
+	}
+
+	result, err := rc.reconciliationService.GetReturnImport(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "回盘记录不存在")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", result)
+}
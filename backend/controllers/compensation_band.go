@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CompensationBandController struct {
+	bandService services.CompensationBandServiceInterface
+}
+
+func NewCompensationBandController(bandService services.CompensationBandServiceInterface) *CompensationBandController {
+	return &CompensationBandController{
+		bandService: bandService,
+	}
+}
+
+// GetCompaRatioReport 获取薪酬带宽分析（可按 department_id 过滤）
+func (bc *CompensationBandController) GetCompaRatioReport(c *gin.Context) {
+	var params services.CompaRatioParams
+	if value := c.Query("department_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "无效的部门ID")
+			return
+		}
+		departmentID := uint(id)
+		params.DepartmentID = &departmentID
+	}
+
+	report, err := bc.bandService.GetCompaRatioReport(params)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取薪酬带宽分析失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", report)
+}
@@ -0,0 +1,306 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"gin-project/models"
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CompensationReviewController struct {
+	reviewService services.CompensationReviewServiceInterface
+}
+
+func NewCompensationReviewController(reviewService services.CompensationReviewServiceInterface) *CompensationReviewController {
+	return &CompensationReviewController{
+		reviewService: reviewService,
+	}
+}
+
+// GetCycles 获取调薪周期列表
+func (rc *CompensationReviewController) GetCycles(c *gin.Context) {
+	cycles, err := rc.reviewService.GetCycles()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取调薪周期失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", cycles)
+}
+
+// GetCycle 获取调薪周期详情（含部门预算与指导规则）
+func (rc *CompensationReviewController) GetCycle(c *gin.Context) {
+	id, ok := rc.cycleID(c)
+	if !ok {
+		return
+	}
+
+	cycle, err := rc.reviewService.GetCycle(id)
+	if err != nil {
+		rc.errorResponse(c, err, "获取调薪周期失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", cycle)
+}
+
+// CreateCycle 创建调薪周期
+func (rc *CompensationReviewController) CreateCycle(c *gin.Context) {
+	var req models.CompensationReviewCycle
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	cycle, err := rc.reviewService.CreateCycle(&req, userID)
+	if err != nil {
+		rc.errorResponse(c, err, "创建调薪周期失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "创建成功", cycle)
+}
+
+// UpdateCycle 更新调薪周期、部门预算与指导规则
+func (rc *CompensationReviewController) UpdateCycle(c *gin.Context) {
+	id, ok := rc.cycleID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CompensationReviewCycle
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	cycle, err := rc.reviewService.UpdateCycle(id, &req)
+	if err != nil {
+		rc.errorResponse(c, err, "更新调薪周期失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "更新成功", cycle)
+}
+
+// OpenCycle 开放调薪周期，经理可提交调薪建议
+func (rc *CompensationReviewController) OpenCycle(c *gin.Context) {
+	rc.transition(c, models.ReviewCycleOpen, "调薪周期已开放")
+}
+
+// CloseProposals 停止提交，进入审批
+func (rc *CompensationReviewController) CloseProposals(c *gin.Context) {
+	rc.transition(c, models.ReviewCycleReview, "已停止提交，进入审批")
+}
+
+// CancelCycle 取消调薪周期
+func (rc *CompensationReviewController) CancelCycle(c *gin.Context) {
+	rc.transition(c, models.ReviewCycleCancelled, "调薪周期已取消")
+}
+
+func (rc *CompensationReviewController) transition(c *gin.Context, status models.CompensationReviewStatus, message string) {
+	id, ok := rc.cycleID(c)
+	if !ok {
+		return
+	}
+
+	cycle, err := rc.reviewService.TransitionCycle(id, status)
+	if err != nil {
+		rc.errorResponse(c, err, "更新调薪周期状态失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, message, cycle)
+}
+
+// GetWorksheet 获取调薪工作表：经理看到直接下属，人事可按 department_id 查看
+func (rc *CompensationReviewController) GetWorksheet(c *gin.Context) {
+	id, ok := rc.cycleID(c)
+	if !ok {
+		return
+	}
+
+	var departmentID *uint
+	if value := c.Query("department_id"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "无效的部门ID")
+			return
+		}
+		department := uint(parsed)
+		departmentID = &department
+	}
+
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	worksheet, err := rc.reviewService.GetWorksheet(id, userID, c.GetString("user_role"), departmentID)
+	if err != nil {
+		rc.errorResponse(c, err, "获取调薪工作表失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", worksheet)
+}
+
+// SaveProposals 批量保存调薪建议
+func (rc *CompensationReviewController) SaveProposals(c *gin.Context) {
+	id, ok := rc.cycleID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Proposals []services.ProposalInput `json:"proposals" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	proposals, err := rc.reviewService.SaveProposals(id, userID, c.GetString("user_role"), req.Proposals)
+	if err != nil {
+		rc.errorResponse(c, err, "保存调薪建议失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "保存成功", proposals)
+}
+
+// SubmitProposals 提交调薪建议进入审批
+func (rc *CompensationReviewController) SubmitProposals(c *gin.Context) {
+	id, ok := rc.cycleID(c)
+	if !ok {
+		return
+	}
+
+	// 未传 proposal_ids 时提交本人范围内的全部草稿
+	var req struct {
+		ProposalIDs []uint `json:"proposal_ids"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+			return
+		}
+	}
+
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	count, err := rc.reviewService.SubmitProposals(id, userID, c.GetString("user_role"), req.ProposalIDs)
+	if err != nil {
+		rc.errorResponse(c, err, "提交调薪建议失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "提交成功", gin.H{"submitted": count})
+}
+
+// GetSummary 获取调薪周期按部门的预算执行汇总
+func (rc *CompensationReviewController) GetSummary(c *gin.Context) {
+	id, ok := rc.cycleID(c)
+	if !ok {
+		return
+	}
+
+	summary, err := rc.reviewService.GetSummary(id)
+	if err != nil {
+		rc.errorResponse(c, err, "获取调薪汇总失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", summary)
+}
+
+// ReviewProposals 批准或驳回调薪建议
+func (rc *CompensationReviewController) ReviewProposals(c *gin.Context) {
+	id, ok := rc.cycleID(c)
+	if !ok {
+		return
+	}
+
+	var req services.ProposalDecision
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	count, err := rc.reviewService.ReviewProposals(id, userID, req)
+	if err != nil {
+		rc.errorResponse(c, err, "审批调薪建议失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "审批成功", gin.H{"reviewed": count})
+}
+
+// FinalizeCycle 为已批准的建议生成调薪记录
+func (rc *CompensationReviewController) FinalizeCycle(c *gin.Context) {
+	id, ok := rc.cycleID(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	result, err := rc.reviewService.FinalizeCycle(id, userID)
+	if err != nil {
+		rc.errorResponse(c, err, "生成调薪记录失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "调薪记录已生成", result)
+}
+
+func (rc *CompensationReviewController) cycleID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的调薪周期ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (rc *CompensationReviewController) errorResponse(c *gin.Context, err error, message string) {
+	if errors.Is(err, services.ErrReviewScope) {
+		utils.ForbiddenResponse(c, err.Error())
+		return
+	}
+	if conflictErr, ok := utils.AsConflictError(err); ok {
+		utils.ConflictResponse(c, conflictErr.Message)
+		return
+	}
+	if validationErr, ok := err.(*utils.ValidationError); ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+		return
+	}
+	utils.ErrorResponse(c, http.StatusInternalServerError, message+": "+err.Error())
+}
@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"gin-project/models"
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExchangeRateController struct {
+	rateService services.ExchangeRateServiceInterface
+}
+
+func NewExchangeRateController(rateService services.ExchangeRateServiceInterface) *ExchangeRateController {
+	return &ExchangeRateController{
+		rateService: rateService,
+	}
+}
+
+// exchangeRateRequest 汇率录入请求，生效日期按 YYYY-MM-DD 传入
+type exchangeRateRequest struct {
+	FromCurrency  string            `json:"from_currency" binding:"required"`
+	ToCurrency    string            `json:"to_currency" binding:"required"`
+	EffectiveDate models.CustomDate `json:"effective_date" binding:"required"`
+	Rate          float64           `json:"rate" binding:"required"`
+	Source        string            `json:"source"`
+}
+
+func (r *exchangeRateRequest) toModel() *models.ExchangeRate {
+	return &models.ExchangeRate{
+		FromCurrency:  r.FromCurrency,
+		ToCurrency:    r.ToCurrency,
+		EffectiveDate: r.EffectiveDate.Time,
+		Rate:          r.Rate,
+		Source:        r.Source,
+	}
+}
+
+// GetExchangeRates 获取汇率列表
+func (ec *ExchangeRateController) GetExchangeRates(c *gin.Context) {
+	rates, err := ec.rateService.GetExchangeRates(services.ExchangeRateQueryParams{
+		FromCurrency: c.Query("from_currency"),
+		ToCurrency:   c.Query("to_currency"),
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取汇率失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", gin.H{
+		"reporting_currency": services.ReportingCurrency(),
+		"rates":              rates,
+	})
+}
+
+// CreateExchangeRate 录入汇率
+func (ec *ExchangeRateController) CreateExchangeRate(c *gin.Context) {
+	var req exchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	rate, err := ec.rateService.CreateExchangeRate(req.toModel())
+	if err != nil {
+		ec.errorResponse(c, err, "录入汇率失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "创建成功", rate)
+}
+
+// UpdateExchangeRate 更新汇率
+func (ec *ExchangeRateController) UpdateExchangeRate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的汇率ID")
+		return
+	}
+
+	var req exchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	rate, err := ec.rateService.UpdateExchangeRate(uint(id), req.toModel())
+	if err != nil {
+		ec.errorResponse(c, err, "更新汇率失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "更新成功", rate)
+}
+
+// DeleteExchangeRate 删除汇率
+func (ec *ExchangeRateController) DeleteExchangeRate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的汇率ID")
+		return
+	}
+
+	if err := ec.rateService.DeleteExchangeRate(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "删除汇率失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "删除成功", nil)
+}
+
+// ConvertAmount 按指定日期（默认当天）有效的汇率将金额折算为集团报告币种
+func (ec *ExchangeRateController) ConvertAmount(c *gin.Context) {
+	amount, err := models.ParseMoney(c.Query("amount"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的金额")
+		return
+	}
+
+	on := time.Now()
+	if value := c.Query("date"); value != "" {
+		if on, err = time.ParseInLocation("2006-01-02", value, time.Local); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "无效的日期")
+			return
+		}
+	}
+
+	conversion, err := ec.rateService.ConvertToReporting(amount, c.Query("currency"), on)
+	if err != nil {
+		ec.errorResponse(c, err, "金额折算失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", conversion)
+}
+
+func (ec *ExchangeRateController) errorResponse(c *gin.Context, err error, message string) {
+	if validationErr, ok := err.(*utils.ValidationError); ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+		return
+	}
+	utils.ErrorResponse(c, http.StatusInternalServerError, message+": "+err.Error())
+}
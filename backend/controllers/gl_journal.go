@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"gin-project/models"
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GLJournalController struct {
+	journalService services.GLJournalServiceInterface
+}
+
+func NewGLJournalController(journalService services.GLJournalServiceInterface) *GLJournalController {
+	return &GLJournalController{
+		journalService: journalService,
+	}
+}
+
+// GetAccountMappings 获取薪资组件科目映射
+func (jc *GLJournalController) GetAccountMappings(c *gin.Context) {
+	mappings, err := jc.journalService.GetAccountMappings()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取科目映射失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", mappings)
+}
+
+// CreateAccountMapping 创建薪资组件科目映射
+func (jc *GLJournalController) CreateAccountMapping(c *gin.Context) {
+	var req models.GLAccountMapping
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	mapping, err := jc.journalService.CreateAccountMapping(&req)
+	if err != nil {
+		jc.errorResponse(c, err, "创建科目映射失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "创建成功", mapping)
+}
+
+// UpdateAccountMapping 更新薪资组件科目映射
+func (jc *GLJournalController) UpdateAccountMapping(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的映射ID")
+		return
+	}
+
+	var req models.GLAccountMapping
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	mapping, err := jc.journalService.UpdateAccountMapping(uint(id), &req)
+	if err != nil {
+		jc.errorResponse(c, err, "更新科目映射失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "更新成功", mapping)
+}
+
+// DeleteAccountMapping 删除薪资组件科目映射
+func (jc *GLJournalController) DeleteAccountMapping(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的映射ID")
+		return
+	}
+
+	if err := jc.journalService.DeleteAccountMapping(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "删除科目映射失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "删除成功", nil)
+}
+
+// GetPeriodJournal 生成薪资周期总账凭证，format=csv 时下载 CSV，否则返回通用 JSON 格式
+func (jc *GLJournalController) GetPeriodJournal(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的周期ID")
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "不支持的导出格式: "+format)
+		return
+	}
+
+	journal, err := jc.journalService.GeneratePeriodJournal(uint(id))
+	if err != nil {
+		jc.errorResponse(c, err, "生成总账凭证失败")
+		return
+	}
+
+	if format == "json" {
+		utils.SuccessResponse(c, http.StatusOK, "获取成功", journal)
+		return
+	}
+
+	data, err := services.WriteJournalCSV(journal)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "导出总账凭证失败: "+err.Error())
+		return
+	}
+	filename := fmt.Sprintf("总账凭证_%s.csv", journal.Reference)
+	c.Header("Content-Disposition", "attachment; filename*=UTF-8''"+url.PathEscape(filename))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
+
+func (jc *GLJournalController) errorResponse(c *gin.Context, err error, message string) {
+	if conflictErr, ok := utils.AsConflictError(err); ok {
+		utils.ConflictResponse(c, conflictErr.Message)
+		return
+	}
+	if validationErr, ok := err.(*utils.ValidationError); ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+		return
+	}
+	utils.ErrorResponse(c, http.StatusInternalServerError, message+": "+err.Error())
+}
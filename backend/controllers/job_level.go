@@ -175,7 +175,13 @@ func (jlc *JobLevelController) ValidateSalaryRange(c *gin.Context) {
 		return
 	}
 
-	utils.SuccessResponse(c, 200, "薪资范围验证通过", nil)
+	check, err := jlc.jobLevelService.CheckSalaryBand(req.JobLevelID, req.Salary)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "验证薪资范围失败")
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "薪资范围验证通过", check)
 }
 
 // GetSalaryRangeByLevel 根据职级等级获取薪资范围
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PayrollJobController struct {
+	jobService services.PayrollJobServiceInterface
+}
+
+func NewPayrollJobController(jobService services.PayrollJobServiceInterface) *PayrollJobController {
+	return &PayrollJobController{
+		jobService: jobService,
+	}
+}
+
+// StartJob 创建后台薪资计算任务，进度通过 WebSocket 推送给发起人
+func (jc *PayrollJobController) StartJob(c *gin.Context) {
+	var req services.PayrollJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	job, err := jc.jobService.StartJob(req, userID)
+	if err != nil {
+		if conflictErr, ok := utils.AsConflictError(err); ok {
+			utils.ConflictResponse(c, conflictErr.Message)
+			return
+		}
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "创建薪资计算任务失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusAccepted, "任务已创建", job)
+}
+
+// GetJobs 获取薪资计算任务列表
+func (jc *PayrollJobController) GetJobs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+	periodID, _ := strconv.ParseUint(c.Query("period_id"), 10, 32)
+
+	params := services.PayrollJobQueryParams{
+		PeriodID: uint(periodID),
+		Status:   c.Query("status"),
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	result, err := jc.jobService.GetJobs(params)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取薪资计算任务失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", result)
+}
+
+// GetJob 获取薪资计算任务详情
+func (jc *PayrollJobController) GetJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的任务ID")
+		return
+	}
+
+	job, err := jc.jobService.GetJob(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "薪资计算任务不存在")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", job)
+}
+
+// GetJobItems 获取任务的员工计算明细
+func (jc *PayrollJobController) GetJobItems(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的任务ID")
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+
+	result, err := jc.jobService.GetJobItems(uint(id), c.Query("status"), page, pageSize)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取任务明细失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", result)
+}
+
+// CancelJob 取消薪资计算任务
+func (jc *PayrollJobController) CancelJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的任务ID")
+		return
+	}
+
+	job, err := jc.jobService.CancelJob(uint(id))
+	if err != nil {
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "取消任务失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "取消成功", job)
+}
+
+// ResumeJob 恢复薪资计算任务，重新计算未成功的员工
+func (jc *PayrollJobController) ResumeJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的任务ID")
+		return
+	}
+
+	job, err := jc.jobService.ResumeJob(uint(id))
+	if err != nil {
+		if conflictErr, ok := utils.AsConflictError(err); ok {
+			utils.ConflictResponse(c, conflictErr.Message)
+			return
+		}
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "恢复任务失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusAccepted, "任务已恢复", job)
+}
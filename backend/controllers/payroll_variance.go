@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PayrollVarianceController struct {
+	varianceService services.PayrollVarianceServiceInterface
+}
+
+func NewPayrollVarianceController(varianceService services.PayrollVarianceServiceInterface) *PayrollVarianceController {
+	return &PayrollVarianceController{
+		varianceService: varianceService,
+	}
+}
+
+// GetVarianceReport 对比薪资周期与上一周期（或指定周期）的薪资差异
+func (vc *PayrollVarianceController) GetVarianceReport(c *gin.Context) {
+	params, ok := vc.parseParams(c)
+	if !ok {
+		return
+	}
+
+	report, err := vc.varianceService.ComparePeriods(params)
+	if err != nil {
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "生成薪资差异报告失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", report)
+}
+
+// ExportVarianceReport 导出薪资差异报告 Excel
+func (vc *PayrollVarianceController) ExportVarianceReport(c *gin.Context) {
+	params, ok := vc.parseParams(c)
+	if !ok {
+		return
+	}
+
+	data, filename, err := vc.varianceService.ExportVarianceReport(params)
+	if err != nil {
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "导出薪资差异报告失败: "+err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename*=UTF-8''"+url.PathEscape(filename))
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", data)
+}
+
+// parseParams 解析周期与阈值参数，未传阈值时使用默认阈值
+func (vc *PayrollVarianceController) parseParams(c *gin.Context) (services.PayrollVarianceParams, bool) {
+	params := services.PayrollVarianceParams{Thresholds: services.DefaultVarianceThresholds}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的周期ID")
+		return params, false
+	}
+	params.CurrentPeriodID = uint(id)
+
+	if value := c.Query("previous_period_id"); value != "" {
+		previousID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "无效的对比周期ID")
+			return params, false
+		}
+		previous := uint(previousID)
+		params.PreviousPeriodID = &previous
+	}
+
+	for key, target := range map[string]*float64{
+		"threshold_amount":  &params.Thresholds.Amount,
+		"threshold_percent": &params.Thresholds.Percent,
+	} {
+		if value := c.Query(key); value != "" {
+			threshold, err := strconv.ParseFloat(value, 64)
+			if err != nil || threshold < 0 {
+				utils.ErrorResponse(c, http.StatusBadRequest, "无效的差异阈值: "+key)
+				return params, false
+			}
+			*target = threshold
+		}
+	}
+
+	params.FlaggedOnly = c.Query("flagged_only") == "true"
+	return params, true
+}
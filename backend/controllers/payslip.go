@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"gin-project/models"
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PayslipController struct {
+	payslipService services.PayslipServiceInterface
+}
+
+func NewPayslipController(payslipService services.PayslipServiceInterface) *PayslipController {
+	return &PayslipController{
+		payslipService: payslipService,
+	}
+}
+
+// GetTemplates 获取工资单模板列表
+func (pc *PayslipController) GetTemplates(c *gin.Context) {
+	templates, err := pc.payslipService.GetTemplates()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取工资单模板失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", templates)
+}
+
+// CreateTemplate 创建工资单模板
+func (pc *PayslipController) CreateTemplate(c *gin.Context) {
+	var req models.PayslipTemplate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	template, err := pc.payslipService.CreateTemplate(&req)
+	if err != nil {
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "创建工资单模板失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "创建成功", template)
+}
+
+// UpdateTemplate 更新工资单模板
+func (pc *PayslipController) UpdateTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的模板ID")
+		return
+	}
+
+	var req models.PayslipTemplate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	template, err := pc.payslipService.UpdateTemplate(uint(id), &req)
+	if err != nil {
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "更新工资单模板失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "更新成功", template)
+}
+
+// DeleteTemplate 删除工资单模板
+func (pc *PayslipController) DeleteTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的模板ID")
+		return
+	}
+
+	if err := pc.payslipService.DeleteTemplate(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "删除工资单模板失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "删除成功", nil)
+}
+
+// GetPayslip 获取工资单数据（人事）
+func (pc *PayslipController) GetPayslip(c *gin.Context) {
+	salaryID, ok := pc.salaryID(c)
+	if !ok {
+		return
+	}
+	pc.getPayslip(c, salaryID)
+}
+
+// DownloadPayslip 下载 PDF 工资单（人事）
+func (pc *PayslipController) DownloadPayslip(c *gin.Context) {
+	salaryID, ok := pc.salaryID(c)
+	if !ok {
+		return
+	}
+	pc.downloadPayslip(c, salaryID)
+}
+
+// GetMyPayslip 员工查看本人工资单
+func (pc *PayslipController) GetMyPayslip(c *gin.Context) {
+	salaryID, ok := pc.authorizeOwner(c)
+	if !ok {
+		return
+	}
+	pc.getPayslip(c, salaryID)
+}
+
+// DownloadMyPayslip 员工下载本人 PDF 工资单
+func (pc *PayslipController) DownloadMyPayslip(c *gin.Context) {
+	salaryID, ok := pc.authorizeOwner(c)
+	if !ok {
+		return
+	}
+	pc.downloadPayslip(c, salaryID)
+}
+
+// DownloadPeriodPayslips 批量下载薪资周期的全部工资单（ZIP）
+func (pc *PayslipController) DownloadPeriodPayslips(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的周期ID")
+		return
+	}
+
+	file, err := pc.payslipService.RenderPeriodPayslips(uint(id))
+	if err != nil {
+		pc.errorResponse(c, err, "批量生成工资单失败")
+		return
+	}
+
+	pc.sendFile(c, file)
+}
+
+func (pc *PayslipController) getPayslip(c *gin.Context, salaryID uint) {
+	payslip, err := pc.payslipService.GetPayslip(salaryID)
+	if err != nil {
+		pc.errorResponse(c, err, "获取工资单失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", payslip)
+}
+
+func (pc *PayslipController) downloadPayslip(c *gin.Context, salaryID uint) {
+	file, err := pc.payslipService.RenderPayslip(salaryID)
+	if err != nil {
+		pc.errorResponse(c, err, "生成工资单失败")
+		return
+	}
+
+	pc.sendFile(c, file)
+}
+
+func (pc *PayslipController) salaryID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的薪资记录ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// authorizeOwner 校验工资单属于二次验证令牌中的员工，失败时已写入响应
+func (pc *PayslipController) authorizeOwner(c *gin.Context) (uint, bool) {
+	salaryID, ok := pc.salaryID(c)
+	if !ok {
+		return 0, false
+	}
+
+	allowed, err := pc.payslipService.CanEmployeeViewPayslip(salaryID, c.GetUint("employee_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "校验工资单权限失败")
+		return 0, false
+	}
+	if !allowed {
+		utils.ForbiddenResponse(c, "无权查看该工资单")
+		return 0, false
+	}
+	return salaryID, true
+}
+
+func (pc *PayslipController) sendFile(c *gin.Context, file *services.PayslipFile) {
+	c.Header("Content-Disposition", "attachment; filename*=UTF-8''"+url.PathEscape(file.FileName))
+	c.Header("X-Payslip-Count", strconv.Itoa(file.Count))
+	c.Data(http.StatusOK, file.ContentType, file.Content)
+}
+
+func (pc *PayslipController) errorResponse(c *gin.Context, err error, message string) {
+	if validationErr, ok := err.(*utils.ValidationError); ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+		return
+	}
+	utils.ErrorResponse(c, http.StatusInternalServerError, message+": "+err.Error())
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RetroPayController struct {
+	retroPayService services.RetroPayServiceInterface
+}
+
+func NewRetroPayController(retroPayService services.RetroPayServiceInterface) *RetroPayController {
+	return &RetroPayController{
+		retroPayService: retroPayService,
+	}
+}
+
+// GetRetroPayReport 获取追溯补发报表
+func (rc *RetroPayController) GetRetroPayReport(c *gin.Context) {
+	employeeID, _ := strconv.ParseUint(c.Query("employee_id"), 10, 32)
+	adjustmentID, _ := strconv.ParseUint(c.Query("adjustment_id"), 10, 32)
+	sourcePeriodID, _ := strconv.ParseUint(c.Query("source_period_id"), 10, 32)
+	targetPeriodID, _ := strconv.ParseUint(c.Query("target_period_id"), 10, 32)
+
+	params := services.RetroPayQueryParams{
+		EmployeeID:     uint(employeeID),
+		AdjustmentID:   uint(adjustmentID),
+		SourcePeriodID: uint(sourcePeriodID),
+		TargetPeriodID: uint(targetPeriodID),
+		Status:         c.Query("status"),
+	}
+
+	report, err := rc.retroPayService.GetRetroPayReport(params)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取追溯补发报表失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", report)
+}
+
+// ProcessAdjustment 对调薪记录重新执行追溯重算
+func (rc *RetroPayController) ProcessAdjustment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的调薪记录ID")
+		return
+	}
+
+	items, err := rc.retroPayService.ProcessAdjustment(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "追溯重算失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "重算成功", items)
+}
+
+// CancelRetroPayItem 取消待处理的补发明细
+func (rc *RetroPayController) CancelRetroPayItem(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的补发明细ID")
+		return
+	}
+
+	if err := rc.retroPayService.CancelRetroPayItem(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "取消补发失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "取消成功", nil)
+}
@@ -1,8 +1,10 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"gin-project/models"
 	"gin-project/services"
@@ -125,9 +127,10 @@ func (sc *SalaryController) DeleteSalary(c *gin.Context) {
 
 // GetMySalary 获取我的薪资
 func (sc *SalaryController) GetMySalary(c *gin.Context) {
-	userID := c.GetUint("user_id")
-	if userID == 0 {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "用户未登录")
+	// 员工ID取自二次验证令牌，见 middleware.RequireStepUp
+	employeeID := c.GetUint("employee_id")
+	if employeeID == 0 {
+		utils.ErrorResponse(c, http.StatusForbidden, "请先完成二次验证")
 		return
 	}
 
@@ -136,7 +139,7 @@ func (sc *SalaryController) GetMySalary(c *gin.Context) {
 		month = "2024-01"
 	}
 
-	salary, err := sc.salaryService.GetEmployeeSalary(userID, month)
+	salary, err := sc.salaryService.GetEmployeeSalary(employeeID, month)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusNotFound, "未找到薪资记录")
 		return
@@ -147,9 +150,9 @@ func (sc *SalaryController) GetMySalary(c *gin.Context) {
 
 // GetMyEnhancedSalary 获取我的增强版薪资详情
 func (sc *SalaryController) GetMyEnhancedSalary(c *gin.Context) {
-	userID := c.GetUint("user_id")
-	if userID == 0 {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "用户未登录")
+	employeeID := c.GetUint("employee_id")
+	if employeeID == 0 {
+		utils.ErrorResponse(c, http.StatusForbidden, "请先完成二次验证")
 		return
 	}
 
@@ -170,7 +173,7 @@ func (sc *SalaryController) GetMyEnhancedSalary(c *gin.Context) {
 	}
 
 	params := services.PersonalSalaryParams{
-		EmployeeID: userID,
+		EmployeeID: employeeID,
 		PeriodID:   periodID,
 		Year:       year,
 		Month:      month,
@@ -187,16 +190,16 @@ func (sc *SalaryController) GetMyEnhancedSalary(c *gin.Context) {
 
 // GetMySalaryHistory 获取我的薪资历史
 func (sc *SalaryController) GetMySalaryHistory(c *gin.Context) {
-	userID := c.GetUint("user_id")
-	if userID == 0 {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "用户未登录")
+	employeeID := c.GetUint("employee_id")
+	if employeeID == 0 {
+		utils.ErrorResponse(c, http.StatusForbidden, "请先完成二次验证")
 		return
 	}
 
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "12"))
 	
 	params := services.SalaryHistoryParams{
-		EmployeeID: userID,
+		EmployeeID: employeeID,
 		Limit:      limit,
 	}
 
@@ -211,9 +214,9 @@ func (sc *SalaryController) GetMySalaryHistory(c *gin.Context) {
 
 // GetMyPayrollRecords 获取我的发放记录
 func (sc *SalaryController) GetMyPayrollRecords(c *gin.Context) {
-	userID := c.GetUint("user_id")
-	if userID == 0 {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "用户未登录")
+	employeeID := c.GetUint("employee_id")
+	if employeeID == 0 {
+		utils.ErrorResponse(c, http.StatusForbidden, "请先完成二次验证")
 		return
 	}
 
@@ -221,7 +224,7 @@ func (sc *SalaryController) GetMyPayrollRecords(c *gin.Context) {
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
 
 	params := services.PersonalPayrollParams{
-		EmployeeID: userID,
+		EmployeeID: employeeID,
 		Page:       page,
 		PageSize:   pageSize,
 	}
@@ -237,13 +240,13 @@ func (sc *SalaryController) GetMyPayrollRecords(c *gin.Context) {
 
 // GetMySalaryDashboard 获取我的薪资仪表板
 func (sc *SalaryController) GetMySalaryDashboard(c *gin.Context) {
-	userID := c.GetUint("user_id")
-	if userID == 0 {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "用户未登录")
+	employeeID := c.GetUint("employee_id")
+	if employeeID == 0 {
+		utils.ErrorResponse(c, http.StatusForbidden, "请先完成二次验证")
 		return
 	}
 
-	dashboard, err := sc.salaryService.GetPersonalSalaryDashboard(userID)
+	dashboard, err := sc.salaryService.GetPersonalSalaryDashboard(employeeID)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "获取薪资仪表板失败: "+err.Error())
 		return
@@ -411,6 +414,54 @@ func (sc *SalaryController) CreateSalaryStructure(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "创建成功", result)
 }
 
+// GetSalaryStructure 获取薪资结构详情
+func (sc *SalaryController) GetSalaryStructure(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的结构ID")
+		return
+	}
+
+	result, err := sc.salaryService.GetSalaryStructureByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "薪资结构不存在")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", result)
+}
+
+// UpdateSalaryStructure 更新薪资结构，按 version_effective_date 生成新版本
+func (sc *SalaryController) UpdateSalaryStructure(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的结构ID")
+		return
+	}
+
+	var structure models.SalaryStructure
+	if err := c.ShouldBindJSON(&structure); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	result, err := sc.salaryService.UpdateSalaryStructure(uint(id), &structure)
+	if err != nil {
+		if conflictErr, ok := utils.AsConflictError(err); ok {
+			utils.ConflictResponse(c, conflictErr.Message)
+			return
+		}
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "更新薪资结构失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "更新成功", result)
+}
+
 // GetSalaryStructures 获取薪资结构列表
 func (sc *SalaryController) GetSalaryStructures(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -464,7 +515,7 @@ func (sc *SalaryController) CreatePayrollPeriod(c *gin.Context) {
 
 	result, err := sc.salaryService.CreatePayrollPeriod(&period)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "创建薪资周期失败: "+err.Error())
+		sc.periodErrorResponse(c, err, "创建薪资周期失败")
 		return
 	}
 
@@ -502,9 +553,14 @@ func (sc *SalaryController) LockPayrollPeriod(c *gin.Context) {
 		return
 	}
 
-	err = sc.salaryService.LockPayrollPeriod(uint(id))
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	err = sc.salaryService.LockPayrollPeriod(uint(id), userID)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "锁定薪资周期失败: "+err.Error())
+		sc.periodErrorResponse(c, err, "锁定薪资周期失败")
 		return
 	}
 
@@ -519,15 +575,171 @@ func (sc *SalaryController) UnlockPayrollPeriod(c *gin.Context) {
 		return
 	}
 
-	err = sc.salaryService.UnlockPayrollPeriod(uint(id))
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请填写解锁原因")
+		return
+	}
+
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	err = sc.salaryService.UnlockPayrollPeriod(uint(id), req.Reason, userID)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "解锁薪资周期失败: "+err.Error())
+		sc.periodErrorResponse(c, err, "解锁薪资周期失败")
 		return
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, "解锁成功", nil)
 }
 
+// TransitionPayrollPeriod 推进薪资周期关账流程
+func (sc *SalaryController) TransitionPayrollPeriod(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的周期ID")
+		return
+	}
+
+	var req struct {
+		Status models.PayrollPeriodStatus `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	period, err := sc.salaryService.TransitionPayrollPeriod(uint(id), req.Status, userID)
+	if err != nil {
+		sc.periodErrorResponse(c, err, "变更薪资周期状态失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "状态已更新", period)
+}
+
+// ReopenPayrollPeriod 重新开放已锁定的薪资周期
+func (sc *SalaryController) ReopenPayrollPeriod(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的周期ID")
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请填写重新开放原因")
+		return
+	}
+
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	period, err := sc.salaryService.ReopenPayrollPeriod(uint(id), req.Reason, userID)
+	if err != nil {
+		sc.periodErrorResponse(c, err, "重新开放薪资周期失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "已重新开放", period)
+}
+
+// GetPayrollPeriodEvents 获取薪资周期审计记录
+func (sc *SalaryController) GetPayrollPeriodEvents(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的周期ID")
+		return
+	}
+
+	events, err := sc.salaryService.GetPayrollPeriodEvents(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取审计记录失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", events)
+}
+
+// CreateOffCycleRun 在常规周期下创建非周期发放
+func (sc *SalaryController) CreateOffCycleRun(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的周期ID")
+		return
+	}
+
+	var req struct {
+		Name              string                `json:"name"`
+		Reason            models.OffCycleReason `json:"reason" binding:"required"`
+		AllowedComponents []string              `json:"allowed_components" binding:"required,min=1"`
+		PayDate           *models.CustomDate    `json:"pay_date"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	run := &models.PayrollPeriod{
+		Name:              req.Name,
+		OffCycleReason:    req.Reason,
+		AllowedComponents: strings.Join(req.AllowedComponents, ","),
+	}
+	if req.PayDate != nil {
+		run.PayDate = &req.PayDate.Time
+	}
+
+	run, err = sc.salaryService.CreateOffCycleRun(uint(id), run)
+	if err != nil {
+		sc.periodErrorResponse(c, err, "创建非周期发放失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "创建成功", run)
+}
+
+// GetOffCycleRuns 获取常规周期下的非周期发放
+func (sc *SalaryController) GetOffCycleRuns(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的周期ID")
+		return
+	}
+
+	runs, err := sc.salaryService.GetOffCycleRuns(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取非周期发放失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", runs)
+}
+
+func (sc *SalaryController) periodErrorResponse(c *gin.Context, err error, message string) {
+	if conflictErr, ok := utils.AsConflictError(err); ok {
+		utils.ConflictResponse(c, conflictErr.Message)
+		return
+	}
+	if validationErr, ok := err.(*utils.ValidationError); ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+		return
+	}
+	utils.ErrorResponse(c, http.StatusInternalServerError, message+": "+err.Error())
+}
+
 // ========================= Enhanced Salary Calculation =========================
 
 // CalculateSalary 计算薪资 (Legacy)
@@ -553,9 +765,8 @@ func (sc *SalaryController) CalculateSalary(c *gin.Context) {
 
 // CalculateEmployeeSalary 计算员工薪资 (Enhanced)
 func (sc *SalaryController) CalculateEmployeeSalary(c *gin.Context) {
-	userID := c.GetUint("user_id")
-	if userID == 0 {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "用户未登录")
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
 		return
 	}
 
@@ -571,6 +782,10 @@ func (sc *SalaryController) CalculateEmployeeSalary(c *gin.Context) {
 
 	salary, err := sc.salaryService.CalculateEmployeeSalary(req.EmployeeID, req.PeriodID, userID)
 	if err != nil {
+		if conflictErr, ok := utils.AsConflictError(err); ok {
+			utils.ConflictResponse(c, conflictErr.Message)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "计算薪资失败: "+err.Error())
 		return
 	}
@@ -578,6 +793,59 @@ func (sc *SalaryController) CalculateEmployeeSalary(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "计算成功", salary)
 }
 
+// CalculateBonusPayments 计算全年一次性奖金
+func (sc *SalaryController) CalculateBonusPayments(c *gin.Context) {
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		PeriodID uint                       `json:"period_id" binding:"required"`
+		Method   models.BonusTaxMethod      `json:"method"`
+		Items    []services.BonusPaymentItem `json:"items" binding:"required,min=1,dive"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	result, err := sc.salaryService.CalculateBonusPayments(req.PeriodID, req.Items, req.Method, userID)
+	if err != nil {
+		sc.periodErrorResponse(c, err, "计算奖金失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "计算完成", result)
+}
+
+// CalculateOffCyclePayments 计算非周期发放的一次性款项
+func (sc *SalaryController) CalculateOffCyclePayments(c *gin.Context) {
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		PeriodID uint                           `json:"period_id" binding:"required"`
+		Items    []services.OffCyclePaymentItem `json:"items" binding:"required,min=1,dive"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	result, err := sc.salaryService.CalculateOffCyclePayments(req.PeriodID, req.Items, userID)
+	if err != nil {
+		sc.periodErrorResponse(c, err, "计算非周期发放失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "计算完成", result)
+}
+
 // BatchCalculateSalary 批量计算薪资 (Legacy)
 func (sc *SalaryController) BatchCalculateSalary(c *gin.Context) {
 	var req struct {
@@ -602,9 +870,8 @@ func (sc *SalaryController) BatchCalculateSalary(c *gin.Context) {
 
 // BatchCalculateSalaries 批量计算薪资 (Enhanced)
 func (sc *SalaryController) BatchCalculateSalaries(c *gin.Context) {
-	userID := c.GetUint("user_id")
-	if userID == 0 {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "用户未登录")
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
 		return
 	}
 
@@ -621,6 +888,10 @@ func (sc *SalaryController) BatchCalculateSalaries(c *gin.Context) {
 
 	result, err := sc.salaryService.BatchCalculateSalaries(req.PeriodID, req.DepartmentID, req.EmployeeIDs, userID)
 	if err != nil {
+		if conflictErr, ok := utils.AsConflictError(err); ok {
+			utils.ConflictResponse(c, conflictErr.Message)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "批量计算薪资失败: "+err.Error())
 		return
 	}
@@ -688,9 +959,8 @@ func (sc *SalaryController) GetEnhancedSalary(c *gin.Context) {
 
 // UpdateSalaryDetails 更新薪资详情
 func (sc *SalaryController) UpdateSalaryDetails(c *gin.Context) {
-	userID := c.GetUint("user_id")
-	if userID == 0 {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "用户未登录")
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
 		return
 	}
 
@@ -711,6 +981,10 @@ func (sc *SalaryController) UpdateSalaryDetails(c *gin.Context) {
 
 	salary, err := sc.salaryService.UpdateSalaryDetails(uint(id), req.Details, userID)
 	if err != nil {
+		if conflictErr, ok := utils.AsConflictError(err); ok {
+			utils.ConflictResponse(c, conflictErr.Message)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "更新薪资详情失败: "+err.Error())
 		return
 	}
@@ -749,9 +1023,8 @@ func (sc *SalaryController) ApproveSalary(c *gin.Context) {
 
 // ReviewSalary 审核薪资 (Enhanced)
 func (sc *SalaryController) ReviewSalary(c *gin.Context) {
-	userID := c.GetUint("user_id")
-	if userID == 0 {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "用户未登录")
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
 		return
 	}
 
@@ -773,6 +1046,10 @@ func (sc *SalaryController) ReviewSalary(c *gin.Context) {
 
 	salary, err := sc.salaryService.ReviewSalary(uint(id), userID, req.Notes, req.Approve)
 	if err != nil {
+		if conflictErr, ok := utils.AsConflictError(err); ok {
+			utils.ConflictResponse(c, conflictErr.Message)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "审核薪资失败: "+err.Error())
 		return
 	}
@@ -782,9 +1059,8 @@ func (sc *SalaryController) ReviewSalary(c *gin.Context) {
 
 // ApproveEnhancedSalary 批准薪资 (Enhanced)
 func (sc *SalaryController) ApproveEnhancedSalary(c *gin.Context) {
-	userID := c.GetUint("user_id")
-	if userID == 0 {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "用户未登录")
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
 		return
 	}
 
@@ -805,6 +1081,10 @@ func (sc *SalaryController) ApproveEnhancedSalary(c *gin.Context) {
 
 	salary, err := sc.salaryService.ApproveEnhancedSalary(uint(id), userID, req.Notes)
 	if err != nil {
+		if conflictErr, ok := utils.AsConflictError(err); ok {
+			utils.ConflictResponse(c, conflictErr.Message)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "批准薪资失败: "+err.Error())
 		return
 	}
@@ -814,9 +1094,8 @@ func (sc *SalaryController) ApproveEnhancedSalary(c *gin.Context) {
 
 // BulkApproveSalaries 批量批准薪资
 func (sc *SalaryController) BulkApproveSalaries(c *gin.Context) {
-	userID := c.GetUint("user_id")
-	if userID == 0 {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "用户未登录")
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
 		return
 	}
 
@@ -914,9 +1193,8 @@ func (sc *SalaryController) UpdatePayrollStatus(c *gin.Context) {
 
 // CreatePaymentBatch 创建支付批次 (Enhanced)
 func (sc *SalaryController) CreatePaymentBatch(c *gin.Context) {
-	userID := c.GetUint("user_id")
-	if userID == 0 {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "用户未登录")
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
 		return
 	}
 
@@ -932,7 +1210,7 @@ func (sc *SalaryController) CreatePaymentBatch(c *gin.Context) {
 
 	batch, err := sc.salaryService.CreatePaymentBatch(&req.Batch, req.SalaryIDs, userID)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "创建支付批次失败: "+err.Error())
+		sc.periodErrorResponse(c, err, "创建支付批次失败")
 		return
 	}
 
@@ -941,9 +1219,8 @@ func (sc *SalaryController) CreatePaymentBatch(c *gin.Context) {
 
 // ProcessPaymentBatch 处理支付批次 (Enhanced)
 func (sc *SalaryController) ProcessPaymentBatch(c *gin.Context) {
-	userID := c.GetUint("user_id")
-	if userID == 0 {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "用户未登录")
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
 		return
 	}
 
@@ -955,6 +1232,10 @@ func (sc *SalaryController) ProcessPaymentBatch(c *gin.Context) {
 
 	batch, err := sc.salaryService.ProcessPaymentBatch(uint(id), userID)
 	if err != nil {
+		if conflictErr, ok := utils.AsConflictError(err); ok {
+			utils.ConflictResponse(c, conflictErr.Message)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "处理支付批次失败: "+err.Error())
 		return
 	}
@@ -1081,23 +1362,17 @@ func (sc *SalaryController) ExportSalaryReport(c *gin.Context) {
 	departmentID, _ := strconv.ParseUint(c.Query("department_id"), 10, 32)
 	format := c.DefaultQuery("format", "excel")
 
-	data, filename, err := sc.salaryService.ExportSalaryReport(month, uint(departmentID), format)
+	export, err := sc.salaryService.ExportSalaryReport(month, uint(departmentID), format)
 	if err != nil {
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "导出薪资报表失败")
 		return
 	}
 
-	var contentType string
-	switch format {
-	case "excel":
-		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
-	default:
-		contentType = "text/csv"
-	}
-
-	c.Header("Content-Disposition", "attachment; filename="+filename)
-	c.Header("Content-Type", contentType)
-	c.Data(http.StatusOK, contentType, data)
+	sc.streamPayrollRegister(c, export)
 }
 
 // ExportEnhancedSalaryReport 导出薪资报告 (Enhanced)
@@ -1117,29 +1392,33 @@ func (sc *SalaryController) ExportEnhancedSalaryReport(c *gin.Context) {
 	params := services.ExportParams{
 		PeriodID:     periodID,
 		DepartmentID: departmentID,
+		Status:       c.Query("status"),
 		Format:       c.DefaultQuery("format", "excel"),
 		Template:     c.Query("template"),
 	}
 
-	data, filename, err := sc.salaryService.ExportEnhancedSalaryReport(params)
+	export, err := sc.salaryService.ExportEnhancedSalaryReport(params)
 	if err != nil {
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "导出薪资报告失败: "+err.Error())
 		return
 	}
 
-	var contentType string
-	switch params.Format {
-	case "excel":
-		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
-	case "pdf":
-		contentType = "application/pdf"
-	default:
-		contentType = "text/csv"
-	}
+	sc.streamPayrollRegister(c, export)
+}
 
-	c.Header("Content-Disposition", "attachment; filename="+filename)
-	c.Header("Content-Type", contentType)
-	c.Data(http.StatusOK, contentType, data)
+// streamPayrollRegister 直接向响应流式写出登记表；开始写出后出错只能中断连接
+func (sc *SalaryController) streamPayrollRegister(c *gin.Context, export *services.PayrollRegisterExport) {
+	c.Header("Content-Disposition", "attachment; filename="+export.FileName)
+	c.Header("Content-Type", export.ContentType)
+	c.Status(http.StatusOK)
+	if err := export.Stream(c.Writer); err != nil {
+		c.Error(err)
+		c.Abort()
+	}
 }
 
 // ValidateFormula 验证薪资公式
@@ -1155,6 +1434,15 @@ func (sc *SalaryController) ValidateFormula(c *gin.Context) {
 
 	err := sc.salaryService.ValidateFormula(req.Formula)
 	if err != nil {
+		var validationErr *services.FormulaValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusBadRequest, utils.APIResponse{
+				Success: false,
+				Error:   "公式验证失败: " + err.Error(),
+				Data:    gin.H{"valid": false, "issues": validationErr.Issues},
+			})
+			return
+		}
 		utils.ErrorResponse(c, http.StatusBadRequest, "公式验证失败: "+err.Error())
 		return
 	}
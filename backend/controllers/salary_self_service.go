@@ -0,0 +1,239 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SalarySelfServiceController struct {
+	selfService services.SalarySelfServiceInterface
+}
+
+func NewSalarySelfServiceController(selfService services.SalarySelfServiceInterface) *SalarySelfServiceController {
+	return &SalarySelfServiceController{
+		selfService: selfService,
+	}
+}
+
+// VerifyStepUp 二次验证，通过后返回个人薪资接口使用的短期令牌
+func (sc *SalarySelfServiceController) VerifyStepUp(c *gin.Context) {
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req services.StepUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	token, err := sc.selfService.VerifyStepUp(userID, c.GetString("user_role"), req)
+	if err != nil {
+		sc.errorResponse(c, err, "二次验证失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "验证成功", token)
+}
+
+// SetPayslipPIN 设置工资单查询密码
+func (sc *SalarySelfServiceController) SetPayslipPIN(c *gin.Context) {
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Password string `json:"password" binding:"required"`
+		PIN      string `json:"pin" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	if err := sc.selfService.SetPayslipPIN(userID, req.Password, req.PIN); err != nil {
+		sc.errorResponse(c, err, "设置工资单查询密码失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "设置成功", nil)
+}
+
+// ConfirmPayslip 确认工资单无误
+func (sc *SalarySelfServiceController) ConfirmPayslip(c *gin.Context) {
+	salaryID, ok := sc.pathID(c, "无效的薪资记录ID")
+	if !ok {
+		return
+	}
+
+	ack, err := sc.selfService.ConfirmPayslip(c.GetUint("employee_id"), salaryID)
+	if err != nil {
+		sc.errorResponse(c, err, "确认工资单失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "确认成功", ack)
+}
+
+// DisputePayslip 对工资单提出异议
+func (sc *SalarySelfServiceController) DisputePayslip(c *gin.Context) {
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+	salaryID, ok := sc.pathID(c, "无效的薪资记录ID")
+	if !ok {
+		return
+	}
+
+	var req services.PayslipDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	dispute, err := sc.selfService.DisputePayslip(c.GetUint("employee_id"), userID, salaryID, req)
+	if err != nil {
+		sc.errorResponse(c, err, "提交异议失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "提交成功", dispute)
+}
+
+// GetMyDisputes 获取我提出的工资单异议
+func (sc *SalarySelfServiceController) GetMyDisputes(c *gin.Context) {
+	disputes, err := sc.selfService.GetMyDisputes(c.GetUint("employee_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取异议记录失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", disputes)
+}
+
+// GetDisputes 获取工资单异议列表
+func (sc *SalarySelfServiceController) GetDisputes(c *gin.Context) {
+	employeeID, _ := strconv.ParseUint(c.Query("employee_id"), 10, 32)
+	assignedTo, _ := strconv.ParseUint(c.Query("assigned_to"), 10, 32)
+
+	disputes, err := sc.selfService.GetDisputes(services.PayslipDisputeQueryParams{
+		Status:     c.Query("status"),
+		EmployeeID: uint(employeeID),
+		AssignedTo: uint(assignedTo),
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取异议列表失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", disputes)
+}
+
+// GetDispute 获取工资单异议详情
+func (sc *SalarySelfServiceController) GetDispute(c *gin.Context) {
+	id, ok := sc.pathID(c, "无效的异议ID")
+	if !ok {
+		return
+	}
+
+	dispute, err := sc.selfService.GetDispute(id)
+	if err != nil {
+		sc.errorResponse(c, err, "获取异议详情失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", dispute)
+}
+
+// AssignDispute 指派异议处理人
+func (sc *SalarySelfServiceController) AssignDispute(c *gin.Context) {
+	id, ok := sc.pathID(c, "无效的异议ID")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		AssigneeID uint `json:"assignee_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请指定处理人")
+		return
+	}
+
+	dispute, err := sc.selfService.AssignDispute(id, req.AssigneeID)
+	if err != nil {
+		sc.errorResponse(c, err, "指派处理人失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "指派成功", dispute)
+}
+
+// ResolveDispute 完结工资单异议
+func (sc *SalarySelfServiceController) ResolveDispute(c *gin.Context) {
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := sc.pathID(c, "无效的异议ID")
+	if !ok {
+		return
+	}
+
+	var req services.PayslipDisputeResolution
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	dispute, err := sc.selfService.ResolveDispute(id, userID, req)
+	if err != nil {
+		sc.errorResponse(c, err, "处理异议失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "处理成功", dispute)
+}
+
+func (sc *SalarySelfServiceController) pathID(c *gin.Context, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, message)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (sc *SalarySelfServiceController) errorResponse(c *gin.Context, err error, message string) {
+	if stepUpErr, ok := services.AsStepUpError(err); ok {
+		if stepUpErr.LockedUntil != nil {
+			utils.ErrorResponse(c, http.StatusTooManyRequests, stepUpErr.Message)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusUnauthorized, stepUpErr.Message)
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.ErrorResponse(c, http.StatusNotFound, "记录不存在")
+		return
+	}
+	if conflictErr, ok := utils.AsConflictError(err); ok {
+		utils.ConflictResponse(c, conflictErr.Message)
+		return
+	}
+	if validationErr, ok := err.(*utils.ValidationError); ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+		return
+	}
+	utils.ErrorResponse(c, http.StatusInternalServerError, message+": "+err.Error())
+}
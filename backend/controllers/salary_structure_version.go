@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SalaryStructureVersionController struct {
+	versionService services.SalaryStructureVersionServiceInterface
+}
+
+func NewSalaryStructureVersionController(versionService services.SalaryStructureVersionServiceInterface) *SalaryStructureVersionController {
+	return &SalaryStructureVersionController{
+		versionService: versionService,
+	}
+}
+
+// GetVersions 获取薪资结构的版本历史
+func (vc *SalaryStructureVersionController) GetVersions(c *gin.Context) {
+	structureID, ok := vc.structureID(c)
+	if !ok {
+		return
+	}
+
+	versions, err := vc.versionService.GetVersions(structureID)
+	if err != nil {
+		vc.errorResponse(c, err, "获取结构版本失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", versions)
+}
+
+// GetVersion 获取薪资结构的指定版本
+func (vc *SalaryStructureVersionController) GetVersion(c *gin.Context) {
+	structureID, ok := vc.structureID(c)
+	if !ok {
+		return
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的版本号")
+		return
+	}
+
+	result, err := vc.versionService.GetVersion(structureID, version)
+	if err != nil {
+		vc.errorResponse(c, err, "获取结构版本失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", result)
+}
+
+// CompareVersions 对比两个结构版本（from、to 为版本号）
+func (vc *SalaryStructureVersionController) CompareVersions(c *gin.Context) {
+	structureID, ok := vc.structureID(c)
+	if !ok {
+		return
+	}
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from <= 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的起始版本号")
+		return
+	}
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil || to <= 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的目标版本号")
+		return
+	}
+
+	diff, err := vc.versionService.CompareVersions(structureID, from, to)
+	if err != nil {
+		vc.errorResponse(c, err, "对比结构版本失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", diff)
+}
+
+func (vc *SalaryStructureVersionController) structureID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的结构ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (vc *SalaryStructureVersionController) errorResponse(c *gin.Context, err error, message string) {
+	if validationErr, ok := err.(*utils.ValidationError); ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+		return
+	}
+	utils.ErrorResponse(c, http.StatusInternalServerError, message+": "+err.Error())
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"gin-project/models"
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SocialInsuranceController struct {
+	insuranceService services.SocialInsuranceServiceInterface
+}
+
+func NewSocialInsuranceController(insuranceService services.SocialInsuranceServiceInterface) *SocialInsuranceController {
+	return &SocialInsuranceController{
+		insuranceService: insuranceService,
+	}
+}
+
+// GetPolicies 获取社保公积金政策列表
+func (ic *SocialInsuranceController) GetPolicies(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+
+	params := services.PolicyQueryParams{
+		CityCode: c.Query("city_code"),
+		Status:   c.Query("status"),
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	result, err := ic.insuranceService.GetPolicies(params)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取社保公积金政策失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", result)
+}
+
+// GetPolicy 获取社保公积金政策详情
+func (ic *SocialInsuranceController) GetPolicy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的政策ID")
+		return
+	}
+
+	policy, err := ic.insuranceService.GetPolicyByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "社保公积金政策不存在")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", policy)
+}
+
+// CreatePolicy 创建社保公积金政策
+func (ic *SocialInsuranceController) CreatePolicy(c *gin.Context) {
+	var req models.SocialInsurancePolicy
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	policy, err := ic.insuranceService.CreatePolicy(&req)
+	if err != nil {
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "创建社保公积金政策失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "创建成功", policy)
+}
+
+// UpdatePolicy 更新社保公积金政策
+func (ic *SocialInsuranceController) UpdatePolicy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的政策ID")
+		return
+	}
+
+	var req models.SocialInsurancePolicy
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	policy, err := ic.insuranceService.UpdatePolicy(uint(id), &req)
+	if err != nil {
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "更新社保公积金政策失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "更新成功", policy)
+}
+
+// DeletePolicy 删除社保公积金政策
+func (ic *SocialInsuranceController) DeletePolicy(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的政策ID")
+		return
+	}
+
+	if err := ic.insuranceService.DeletePolicy(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "删除社保公积金政策失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "删除成功", nil)
+}
+
+// PreviewContributions 试算员工社保公积金缴费
+func (ic *SocialInsuranceController) PreviewContributions(c *gin.Context) {
+	employeeID, err := strconv.ParseUint(c.Query("employee_id"), 10, 32)
+	if err != nil || employeeID == 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的员工ID")
+		return
+	}
+
+	date := time.Now()
+	if value := c.Query("date"); value != "" {
+		if date, err = time.Parse("2006-01-02", value); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "日期格式错误")
+			return
+		}
+	}
+
+	preview, err := ic.insuranceService.PreviewContributions(uint(employeeID), date)
+	if err != nil {
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "社保公积金试算失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "试算成功", preview)
+}
@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"gin-project/models"
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaxDeductionController struct {
+	deductionService services.TaxDeductionServiceInterface
+}
+
+func NewTaxDeductionController(deductionService services.TaxDeductionServiceInterface) *TaxDeductionController {
+	return &TaxDeductionController{
+		deductionService: deductionService,
+	}
+}
+
+// GetDeductions 获取专项附加扣除申报列表
+func (tc *TaxDeductionController) GetDeductions(c *gin.Context) {
+	employeeID, _ := strconv.ParseUint(c.Query("employee_id"), 10, 32)
+	taxYear, _ := strconv.Atoi(c.Query("tax_year"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "10"))
+
+	params := services.DeductionQueryParams{
+		EmployeeID: uint(employeeID),
+		TaxYear:    taxYear,
+		Type:       c.Query("type"),
+		Status:     c.Query("status"),
+		Page:       page,
+		PageSize:   pageSize,
+	}
+
+	result, err := tc.deductionService.GetDeductions(params)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取专项附加扣除失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", result)
+}
+
+// GetDeduction 获取专项附加扣除申报详情
+func (tc *TaxDeductionController) GetDeduction(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的申报ID")
+		return
+	}
+
+	deduction, err := tc.deductionService.GetDeductionByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "专项附加扣除申报不存在")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", deduction)
+}
+
+// CreateDeduction 创建专项附加扣除申报
+func (tc *TaxDeductionController) CreateDeduction(c *gin.Context) {
+	var req models.SpecialDeduction
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	deduction, err := tc.deductionService.CreateDeduction(&req)
+	if err != nil {
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "创建专项附加扣除失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "创建成功", deduction)
+}
+
+// UpdateDeduction 更新专项附加扣除申报
+func (tc *TaxDeductionController) UpdateDeduction(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的申报ID")
+		return
+	}
+
+	var req models.SpecialDeduction
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	deduction, err := tc.deductionService.UpdateDeduction(uint(id), &req)
+	if err != nil {
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "更新专项附加扣除失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "更新成功", deduction)
+}
+
+// DeleteDeduction 删除专项附加扣除申报
+func (tc *TaxDeductionController) DeleteDeduction(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的申报ID")
+		return
+	}
+
+	if err := tc.deductionService.DeleteDeduction(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "删除专项附加扣除失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "删除成功", nil)
+}
+
+// RollOverDeductions 将专项附加扣除申报结转到下一纳税年度
+func (tc *TaxDeductionController) RollOverDeductions(c *gin.Context) {
+	var req struct {
+		FromYear int `json:"from_year" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	result, err := tc.deductionService.RollOverDeductions(req.FromYear)
+	if err != nil {
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "年度结转失败: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "结转成功", result)
+}
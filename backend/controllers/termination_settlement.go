@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"gin-project/models"
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TerminationSettlementController struct {
+	settlementService services.TerminationSettlementServiceInterface
+}
+
+func NewTerminationSettlementController(settlementService services.TerminationSettlementServiceInterface) *TerminationSettlementController {
+	return &TerminationSettlementController{
+		settlementService: settlementService,
+	}
+}
+
+// PreviewSettlement 试算离职结算
+func (tc *TerminationSettlementController) PreviewSettlement(c *gin.Context) {
+	var req services.SettlementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	settlement, err := tc.settlementService.PreviewSettlement(req)
+	if err != nil {
+		tc.errorResponse(c, err, "试算离职结算失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "试算成功", settlement)
+}
+
+// CreateSettlement 计算并保存离职结算草稿
+func (tc *TerminationSettlementController) CreateSettlement(c *gin.Context) {
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req services.SettlementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	settlement, err := tc.settlementService.CreateSettlement(req, userID)
+	if err != nil {
+		tc.errorResponse(c, err, "创建离职结算失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "创建成功", settlement)
+}
+
+// GetSettlements 获取离职结算列表
+func (tc *TerminationSettlementController) GetSettlements(c *gin.Context) {
+	employeeID, _ := strconv.ParseUint(c.Query("employee_id"), 10, 32)
+
+	settlements, err := tc.settlementService.GetSettlements(services.SettlementQueryParams{
+		EmployeeID: uint(employeeID),
+		Status:     c.Query("status"),
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取离职结算失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", settlements)
+}
+
+// GetSettlement 获取离职结算详情
+func (tc *TerminationSettlementController) GetSettlement(c *gin.Context) {
+	id, ok := tc.settlementID(c)
+	if !ok {
+		return
+	}
+
+	settlement, err := tc.settlementService.GetSettlement(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, http.StatusNotFound, "离职结算不存在")
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取离职结算失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", settlement)
+}
+
+// ApproveSettlement 复核离职结算
+func (tc *TerminationSettlementController) ApproveSettlement(c *gin.Context) {
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := tc.settlementID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Notes string `json:"notes"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	settlement, err := tc.settlementService.ApproveSettlement(id, userID, req.Notes)
+	if err != nil {
+		tc.errorResponse(c, err, "复核离职结算失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "复核成功", settlement)
+}
+
+// CancelSettlement 取消离职结算
+func (tc *TerminationSettlementController) CancelSettlement(c *gin.Context) {
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := tc.settlementID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请填写取消原因")
+		return
+	}
+
+	settlement, err := tc.settlementService.CancelSettlement(id, userID, req.Reason)
+	if err != nil {
+		tc.errorResponse(c, err, "取消离职结算失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "取消成功", settlement)
+}
+
+// IssueSettlement 为已复核的离职结算生成非周期发放
+func (tc *TerminationSettlementController) IssueSettlement(c *gin.Context) {
+	userID, ok := utils.GetCurrentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := tc.settlementID(c)
+	if !ok {
+		return
+	}
+
+	var req services.SettlementIssueRequest
+	_ = c.ShouldBindJSON(&req)
+
+	settlement, err := tc.settlementService.IssueSettlement(id, req, userID)
+	if err != nil {
+		tc.errorResponse(c, err, "生成离职结算发放失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "生成成功", settlement)
+}
+
+// GetLeaveBalances 获取员工年假额度
+func (tc *TerminationSettlementController) GetLeaveBalances(c *gin.Context) {
+	employeeID, err := strconv.ParseUint(c.Query("employee_id"), 10, 32)
+	if err != nil || employeeID == 0 {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的员工ID")
+		return
+	}
+	year, _ := strconv.Atoi(c.Query("year"))
+
+	balances, err := tc.settlementService.GetLeaveBalances(uint(employeeID), year)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取假期额度失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", balances)
+}
+
+// SaveLeaveBalance 登记员工年度假期额度
+func (tc *TerminationSettlementController) SaveLeaveBalance(c *gin.Context) {
+	var req struct {
+		EmployeeID      uint    `json:"employee_id" binding:"required"`
+		Year            int     `json:"year" binding:"required"`
+		LeaveType       string  `json:"leave_type"`
+		EntitledDays    float64 `json:"entitled_days"`
+		CarriedOverDays float64 `json:"carried_over_days"`
+		Notes           string  `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	balance, err := tc.settlementService.SaveLeaveBalance(&models.LeaveBalance{
+		EmployeeID:      req.EmployeeID,
+		Year:            req.Year,
+		LeaveType:       req.LeaveType,
+		EntitledDays:    req.EntitledDays,
+		CarriedOverDays: req.CarriedOverDays,
+		Notes:           req.Notes,
+	})
+	if err != nil {
+		tc.errorResponse(c, err, "保存假期额度失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "保存成功", balance)
+}
+
+func (tc *TerminationSettlementController) settlementID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的离职结算ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (tc *TerminationSettlementController) errorResponse(c *gin.Context, err error, message string) {
+	if conflictErr, ok := utils.AsConflictError(err); ok {
+		utils.ConflictResponse(c, conflictErr.Message)
+		return
+	}
+	if validationErr, ok := err.(*utils.ValidationError); ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+		return
+	}
+	utils.ErrorResponse(c, http.StatusInternalServerError, message+": "+err.Error())
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"gin-project/models"
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WorkCalendarController struct {
+	calendarService services.WorkCalendarServiceInterface
+}
+
+func NewWorkCalendarController(calendarService services.WorkCalendarServiceInterface) *WorkCalendarController {
+	return &WorkCalendarController{
+		calendarService: calendarService,
+	}
+}
+
+// GetCalendarDays 获取指定年份的节假日及调休日期
+func (wc *WorkCalendarController) GetCalendarDays(c *gin.Context) {
+	year, err := strconv.Atoi(c.DefaultQuery("year", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的年份")
+		return
+	}
+
+	days, err := wc.calendarService.GetCalendarDays(year)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "获取工作日历失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", days)
+}
+
+// SaveCalendarDays 批量登记节假日及调休日期
+func (wc *WorkCalendarController) SaveCalendarDays(c *gin.Context) {
+	var req struct {
+		Days []struct {
+			Date      models.CustomDate      `json:"date" binding:"required"`
+			Type      models.CalendarDayType `json:"type" binding:"required"`
+			Name      string                 `json:"name"`
+			Statutory bool                   `json:"statutory"`
+		} `json:"days" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "请求参数错误: "+err.Error())
+		return
+	}
+
+	days := make([]models.WorkCalendarDay, 0, len(req.Days))
+	for _, day := range req.Days {
+		days = append(days, models.WorkCalendarDay{
+			Date:      day.Date.Time,
+			Type:      day.Type,
+			Name:      day.Name,
+			Statutory: day.Statutory,
+		})
+	}
+
+	saved, err := wc.calendarService.SaveCalendarDays(days)
+	if err != nil {
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "保存工作日历失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "保存成功", saved)
+}
+
+// DeleteCalendarDay 删除节假日或调休日期
+func (wc *WorkCalendarController) DeleteCalendarDay(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "无效的日期ID")
+		return
+	}
+
+	if err := wc.calendarService.DeleteCalendarDay(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "删除工作日历日期失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "删除成功", nil)
+}
+
+// GetWorkingDays 统计日期区间内的应出勤天数
+func (wc *WorkCalendarController) GetWorkingDays(c *gin.Context) {
+	start, err := time.Parse("2006-01-02", c.Query("start_date"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "开始日期格式错误")
+		return
+	}
+	end, err := time.Parse("2006-01-02", c.Query("end_date"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "结束日期格式错误")
+		return
+	}
+
+	summary, err := wc.calendarService.GetWorkingDays(start, end)
+	if err != nil {
+		if validationErr, ok := err.(*utils.ValidationError); ok {
+			utils.ErrorResponse(c, http.StatusBadRequest, validationErr.Message)
+			return
+		}
+		utils.ErrorResponse(c, http.StatusInternalServerError, "统计工作日失败")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "获取成功", summary)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 
@@ -21,6 +22,15 @@ func main() {
 	// 初始化依赖注入容器
 	config.InitContainer()
 
+	// 恢复因进程中断而未完成的后台薪资计算任务
+	if resumed, err := config.GetContainer().PayrollJobService().ResumeInterruptedJobs(); err != nil {
+		log.Println("Failed to resume payroll jobs:", err)
+	} else if resumed > 0 {
+		log.Printf("Resumed %d interrupted payroll jobs", resumed)
+	}
+	// 巡检心跳过期的任务（如进程在心跳过期前重启而未被上面认领的任务）
+	go config.GetContainer().PayrollJobService().RunSweeper(context.Background())
+
 	r := gin.New()
 
 	// 添加中间件
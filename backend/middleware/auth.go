@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"net/http"
 	"strings"
 
 	"gin-project/utils"
@@ -25,7 +26,7 @@ func JWTAuth() gin.HandlerFunc {
 		}
 
 		tokenString := tokenParts[1]
-		claims, err := utils.DefaultJWTService.ValidateToken(tokenString)
+		claims, err := utils.DefaultJWTService.ValidateSessionToken(tokenString)
 		if err != nil {
 			utils.UnauthorizedResponse(c, "Invalid or expired token")
 			c.Abort()
@@ -38,6 +39,28 @@ func JWTAuth() gin.HandlerFunc {
 	}
 }
 
+// RequireStepUp 要求请求携带与当前会话同一用户的二次验证范围令牌，并写入令牌绑定的员工ID
+func RequireStepUp(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := c.GetHeader(utils.StepUpTokenHeader)
+		if tokenString == "" {
+			utils.ErrorResponse(c, http.StatusForbidden, "请先完成二次验证")
+			c.Abort()
+			return
+		}
+
+		claims, err := utils.DefaultJWTService.ValidateScopedToken(tokenString, scope)
+		if err != nil || claims.UserID != c.GetString("user_id") || claims.EmployeeID == 0 {
+			utils.ErrorResponse(c, http.StatusForbidden, "二次验证已失效，请重新验证")
+			c.Abort()
+			return
+		}
+
+		c.Set("employee_id", claims.EmployeeID)
+		c.Next()
+	}
+}
+
 
 func RequireAnyRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
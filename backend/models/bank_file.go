@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// PaymentBatchFile 支付批次生成的银行代发文件记录
+type PaymentBatchFile struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	PaymentBatchID uint      `json:"payment_batch_id" gorm:"not null;index;comment:支付批次ID"`
+	Format         string    `json:"format" gorm:"size:30;not null;comment:文件格式"`
+	FileName       string    `json:"file_name" gorm:"size:255;not null;comment:文件名"`
+	RecordCount    int       `json:"record_count" gorm:"comment:明细笔数"`
+	ControlSum     Money     `json:"control_sum" gorm:"type:decimal(15,2);comment:控制总金额"`
+	BatchChecksum  string    `json:"batch_checksum" gorm:"size:64;comment:批次明细校验码"`
+	FileHash       string    `json:"file_hash" gorm:"size:64;comment:文件SHA-256"`
+	GeneratedBy    uint      `json:"generated_by" gorm:"comment:生成人ID"`
+	CreatedAt      time.Time `json:"created_at"`
+}
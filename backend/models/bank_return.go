@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+// BankReturnExceptionReason 回盘异常原因
+type BankReturnExceptionReason string
+
+const (
+	ReturnExceptionUnmatched      BankReturnExceptionReason = "unmatched"       // 未匹配到发放记录
+	ReturnExceptionAmountMismatch BankReturnExceptionReason = "amount_mismatch" // 金额不一致
+	ReturnExceptionDuplicate      BankReturnExceptionReason = "duplicate"       // 同一文件重复回盘
+	ReturnExceptionConflict       BankReturnExceptionReason = "conflict"        // 与已确认结果冲突
+)
+
+// BankReturnImport 银行回盘文件导入记录
+type BankReturnImport struct {
+	ID             uint                  `json:"id" gorm:"primaryKey"`
+	PaymentBatchID uint                  `json:"payment_batch_id" gorm:"not null;index;comment:支付批次ID"`
+	Format         string                `json:"format" gorm:"size:30;not null;comment:文件格式"`
+	FileName       string                `json:"file_name" gorm:"size:255;comment:文件名"`
+	FileHash       string                `json:"file_hash" gorm:"size:64;index;comment:文件SHA-256"`
+	TotalLines     int                   `json:"total_lines" gorm:"comment:明细行数"`
+	SucceededLines int                   `json:"succeeded_lines" gorm:"comment:成功笔数"`
+	FailedLines    int                   `json:"failed_lines" gorm:"comment:失败笔数"`
+	PendingLines   int                   `json:"pending_lines" gorm:"comment:处理中笔数"`
+	ExceptionLines int                   `json:"exception_lines" gorm:"comment:异常笔数"`
+	ReissueBatchID *uint                 `json:"reissue_batch_id" gorm:"comment:失败重发批次ID"`
+	ImportedBy     uint                  `json:"imported_by" gorm:"comment:导入人ID"`
+	Exceptions     []BankReturnException `json:"exceptions,omitempty" gorm:"foreignKey:ImportID"`
+	CreatedAt      time.Time             `json:"created_at"`
+}
+
+// BankReturnException 回盘文件中无法自动对账的明细
+type BankReturnException struct {
+	ID         uint                      `json:"id" gorm:"primaryKey"`
+	ImportID   uint                      `json:"import_id" gorm:"not null;index;comment:回盘导入ID"`
+	LineNumber int                       `json:"line_number" gorm:"comment:文件行号"`
+	Reference  string                    `json:"reference" gorm:"size:50;comment:参考号"`
+	Account    string                    `json:"account" gorm:"size:50;comment:收款账号"`
+	Amount     Money                     `json:"amount" gorm:"type:decimal(15,2);comment:金额"`
+	RecordID   *uint                     `json:"record_id" gorm:"comment:关联发放记录ID"`
+	Reason     BankReturnExceptionReason `json:"reason" gorm:"size:30;comment:异常原因"`
+	Message    string                    `json:"message" gorm:"type:text;comment:说明"`
+	RawLine    string                    `json:"raw_line" gorm:"type:text;comment:原始内容"`
+	CreatedAt  time.Time                 `json:"created_at"`
+}
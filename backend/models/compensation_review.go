@@ -0,0 +1,92 @@
+package models
+
+import (
+	"time"
+)
+
+// CompensationReviewStatus 调薪周期状态
+type CompensationReviewStatus string
+
+const (
+	ReviewCycleDraft     CompensationReviewStatus = "draft"     // 草稿：配置预算与指导规则
+	ReviewCycleOpen      CompensationReviewStatus = "open"      // 开放：经理提交调薪建议
+	ReviewCycleReview    CompensationReviewStatus = "review"    // 审批中：停止提交，人事审批
+	ReviewCycleCompleted CompensationReviewStatus = "completed" // 已完成：已生成调薪记录
+	ReviewCycleCancelled CompensationReviewStatus = "cancelled" // 已取消
+)
+
+// CompensationReviewCycle 年度调薪周期
+type CompensationReviewCycle struct {
+	ID              uint                          `json:"id" gorm:"primaryKey"`
+	Name            string                        `json:"name" gorm:"size:100;not null;comment:周期名称"`
+	PerformanceYear int                           `json:"performance_year" gorm:"not null;comment:参考绩效年度"`
+	EffectiveDate   time.Time                     `json:"effective_date" gorm:"type:date;not null;comment:调薪统一生效日期"`
+	Status          CompensationReviewStatus      `json:"status" gorm:"size:20;default:draft;comment:状态"`
+	Description     string                        `json:"description" gorm:"type:text;comment:说明"`
+	CreatedBy       uint                          `json:"created_by" gorm:"comment:创建人ID"`
+	CompletedBy     *uint                         `json:"completed_by" gorm:"comment:完成人ID"`
+	CompletedAt     *time.Time                    `json:"completed_at" gorm:"comment:完成时间"`
+	Budgets         []CompensationReviewBudget    `json:"budgets,omitempty" gorm:"foreignKey:CycleID"`
+	Guidelines      []CompensationReviewGuideline `json:"guidelines,omitempty" gorm:"foreignKey:CycleID"`
+	CreatedAt       time.Time                     `json:"created_at"`
+	UpdatedAt       time.Time                     `json:"updated_at"`
+}
+
+// CompensationReviewBudget 部门调薪预算（月基本薪资增加总额）
+type CompensationReviewBudget struct {
+	ID           uint        `json:"id" gorm:"primaryKey"`
+	CycleID      uint        `json:"cycle_id" gorm:"not null;uniqueIndex:idx_review_budget_dept;comment:调薪周期ID"`
+	DepartmentID uint        `json:"department_id" gorm:"not null;uniqueIndex:idx_review_budget_dept;comment:部门ID"`
+	Department   *Department `json:"department,omitempty" gorm:"foreignKey:DepartmentID"`
+	Amount       Money       `json:"amount" gorm:"type:decimal(15,2);default:0;comment:预算金额"`
+}
+
+// CompensationReviewGuideline 调薪指导规则：按绩效得分与比较比率区间给出建议涨幅（百分比），区间上限为 0 表示不限
+type CompensationReviewGuideline struct {
+	ID            uint    `json:"id" gorm:"primaryKey"`
+	CycleID       uint    `json:"cycle_id" gorm:"not null;index;comment:调薪周期ID"`
+	MinScore      float64 `json:"min_score" gorm:"type:decimal(5,2);default:0;comment:绩效得分下限（含）"`
+	MaxScore      float64 `json:"max_score" gorm:"type:decimal(5,2);default:0;comment:绩效得分上限（不含）"`
+	MinCompaRatio float64 `json:"min_compa_ratio" gorm:"type:decimal(6,4);default:0;comment:比较比率下限（含）"`
+	MaxCompaRatio float64 `json:"max_compa_ratio" gorm:"type:decimal(6,4);default:0;comment:比较比率上限（不含）"`
+	MinPercent    float64 `json:"min_percent" gorm:"type:decimal(5,2);default:0;comment:建议涨幅下限%"`
+	TargetPercent float64 `json:"target_percent" gorm:"type:decimal(5,2);default:0;comment:建议涨幅%"`
+	MaxPercent    float64 `json:"max_percent" gorm:"type:decimal(5,2);default:0;comment:建议涨幅上限%"`
+	Sort          int     `json:"sort" gorm:"default:0;comment:匹配顺序"`
+}
+
+// CompensationProposalStatus 调薪建议状态
+type CompensationProposalStatus string
+
+const (
+	ProposalDraft     CompensationProposalStatus = "draft"     // 草稿
+	ProposalSubmitted CompensationProposalStatus = "submitted" // 已提交
+	ProposalApproved  CompensationProposalStatus = "approved"  // 已批准
+	ProposalRejected  CompensationProposalStatus = "rejected"  // 已驳回
+)
+
+// CompensationReviewProposal 员工调薪建议
+type CompensationReviewProposal struct {
+	ID               uint                       `json:"id" gorm:"primaryKey"`
+	CycleID          uint                       `json:"cycle_id" gorm:"not null;uniqueIndex:idx_review_proposal_employee;comment:调薪周期ID"`
+	EmployeeID       uint                       `json:"employee_id" gorm:"not null;uniqueIndex:idx_review_proposal_employee;comment:员工ID"`
+	Employee         *Employee                  `json:"employee,omitempty" gorm:"foreignKey:EmployeeID"`
+	DepartmentID     uint                       `json:"department_id" gorm:"not null;index;comment:预算归属部门ID"`
+	CurrentBase      Money                      `json:"current_base" gorm:"type:decimal(15,2);comment:调整前基本薪资"`
+	ProposedBase     Money                      `json:"proposed_base" gorm:"type:decimal(15,2);comment:建议基本薪资"`
+	IncreaseAmount   Money                      `json:"increase_amount" gorm:"type:decimal(15,2);comment:涨薪金额"`
+	IncreasePercent  float64                    `json:"increase_percent" gorm:"type:decimal(6,2);comment:涨幅%"`
+	PerformanceScore *float64                   `json:"performance_score" gorm:"type:decimal(5,2);comment:绩效得分"`
+	CompaRatio       *float64                   `json:"compa_ratio" gorm:"type:decimal(6,4);comment:调整前比较比率"`
+	GuidelineID      *uint                      `json:"guideline_id" gorm:"comment:匹配的指导规则ID"`
+	OutsideGuideline bool                       `json:"outside_guideline" gorm:"default:false;comment:是否超出指导范围"`
+	Justification    string                     `json:"justification" gorm:"type:text;comment:调薪理由"`
+	Status           CompensationProposalStatus `json:"status" gorm:"size:20;default:draft;comment:状态"`
+	ProposedBy       uint                       `json:"proposed_by" gorm:"comment:提交人用户ID"`
+	ReviewedBy       *uint                      `json:"reviewed_by" gorm:"comment:审批人用户ID"`
+	ReviewedAt       *time.Time                 `json:"reviewed_at" gorm:"comment:审批时间"`
+	ReviewNotes      string                     `json:"review_notes" gorm:"type:text;comment:审批意见"`
+	AdjustmentID     *uint                      `json:"adjustment_id" gorm:"comment:生成的调薪记录ID"`
+	CreatedAt        time.Time                  `json:"created_at"`
+	UpdatedAt        time.Time                  `json:"updated_at"`
+}
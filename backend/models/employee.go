@@ -124,9 +124,13 @@ type Employee struct {
 	ContractStartDate  *CustomDate            `json:"contract_start_date" gorm:"comment:合同开始日期"`
 	ContractEndDate    *CustomDate            `json:"contract_end_date" gorm:"comment:合同结束日期"`
 	ContractType       string                 `json:"contract_type" gorm:"size:20;comment:合同类型"`
+	TerminationDate    *CustomDate            `json:"termination_date" gorm:"comment:离职日期(最后工作日)"`
 	
 	// 薪资信息
-	BaseSalary         float64                `json:"base_salary" gorm:"type:decimal(10,2);comment:基本薪资"`
+	BaseSalary         Money                  `json:"base_salary" gorm:"type:decimal(10,2);comment:基本薪资"`
+	BankName           string                 `json:"bank_name" gorm:"size:100;comment:工资卡开户行"`
+	BankAccount        string                 `json:"bank_account" gorm:"size:50;comment:工资卡账号"`
+	BankCode           string                 `json:"bank_code" gorm:"size:20;comment:开户行联行号"`
 	
 	// 联系信息
 	Address            string                 `json:"address" gorm:"size:255;comment:地址"`
@@ -367,15 +371,15 @@ type Salary struct {
 	EmployeeID     uint                   `json:"employee_id" gorm:"not null;comment:员工ID"`
 	Employee       *Employee              `json:"employee,omitempty" gorm:"foreignKey:EmployeeID"`
 	Month          string                 `json:"month" gorm:"size:7;comment:薪资月份"`
-	BaseSalary     float64                `json:"base_salary" gorm:"type:decimal(10,2);comment:基本薪资"`
-	Bonus          float64                `json:"bonus" gorm:"type:decimal(10,2);comment:奖金"`
-	Allowance      float64                `json:"allowance" gorm:"type:decimal(10,2);comment:津贴"`
-	Deduction      float64                `json:"deduction" gorm:"type:decimal(10,2);comment:扣款"`
-	GrossSalary    float64                `json:"gross_salary" gorm:"type:decimal(10,2);comment:应发薪资"`
-	Tax            float64                `json:"tax" gorm:"type:decimal(10,2);comment:个人所得税"`
-	SocialSecurity float64                `json:"social_security" gorm:"type:decimal(10,2);comment:社保"`
-	HousingFund    float64                `json:"housing_fund" gorm:"type:decimal(10,2);comment:公积金"`
-	NetSalary      float64                `json:"net_salary" gorm:"type:decimal(10,2);comment:实发薪资"`
+	BaseSalary     Money                  `json:"base_salary" gorm:"type:decimal(10,2);comment:基本薪资"`
+	Bonus          Money                  `json:"bonus" gorm:"type:decimal(10,2);comment:奖金"`
+	Allowance      Money                  `json:"allowance" gorm:"type:decimal(10,2);comment:津贴"`
+	Deduction      Money                  `json:"deduction" gorm:"type:decimal(10,2);comment:扣款"`
+	GrossSalary    Money                  `json:"gross_salary" gorm:"type:decimal(10,2);comment:应发薪资"`
+	Tax            Money                  `json:"tax" gorm:"type:decimal(10,2);comment:个人所得税"`
+	SocialSecurity Money                  `json:"social_security" gorm:"type:decimal(10,2);comment:社保"`
+	HousingFund    Money                  `json:"housing_fund" gorm:"type:decimal(10,2);comment:公积金"`
+	NetSalary      Money                  `json:"net_salary" gorm:"type:decimal(10,2);comment:实发薪资"`
 	Status         string                 `json:"status" gorm:"size:20;default:draft;comment:状态"`
 	Remark         string                 `json:"remark" gorm:"type:text;comment:备注"`
 	PayrollRecords []PayrollRecord        `json:"payroll_records,omitempty" gorm:"foreignKey:SalaryID"`
@@ -391,7 +395,7 @@ type PayrollRecord struct {
 	PaymentDate   *time.Time             `json:"payment_date" gorm:"comment:发放日期"`
 	PaymentMethod string                 `json:"payment_method" gorm:"size:20;comment:发放方式"`
 	BankAccount   string                 `json:"bank_account" gorm:"size:50;comment:银行账户"`
-	PaymentAmount float64                `json:"payment_amount" gorm:"type:decimal(10,2);comment:发放金额"`
+	PaymentAmount Money                  `json:"payment_amount" gorm:"type:decimal(10,2);comment:发放金额"`
 	Status        string                 `json:"status" gorm:"size:20;default:pending;comment:发放状态"`
 	ProcessorID   *uint                  `json:"processor_id" gorm:"comment:处理人ID"`
 	Processor     *Employee              `json:"processor,omitempty" gorm:"foreignKey:ProcessorID"`
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultCurrency 未指定币种时的记账币种
+const DefaultCurrency = "CNY"
+
+// NormalizeCurrency 统一币种代码为大写，空值按人民币处理
+func NormalizeCurrency(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return DefaultCurrency
+	}
+	return code
+}
+
+// IsValidCurrency 判断是否为三位字母的 ISO 4217 币种代码
+func IsValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// ExchangeRate 汇率：1 单位源币种折合的目标币种金额，某日适用生效日期不晚于该日的最新汇率
+type ExchangeRate struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	FromCurrency  string    `json:"from_currency" gorm:"size:3;not null;uniqueIndex:idx_exchange_rate;comment:源币种"`
+	ToCurrency    string    `json:"to_currency" gorm:"size:3;not null;uniqueIndex:idx_exchange_rate;comment:目标币种"`
+	EffectiveDate time.Time `json:"effective_date" gorm:"type:date;not null;uniqueIndex:idx_exchange_rate;comment:生效日期"`
+	Rate          float64   `json:"rate" gorm:"type:decimal(18,8);not null;comment:汇率"`
+	Source        string    `json:"source" gorm:"size:100;comment:汇率来源(如中国人民银行中间价)"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// GLAccountMapping 薪资组件与总账科目的对应关系，每个组件入账一借一贷
+type GLAccountMapping struct {
+	ID                uint             `json:"id" gorm:"primaryKey"`
+	ComponentID       uint             `json:"component_id" gorm:"not null;uniqueIndex;comment:薪资组件ID"`
+	Component         *SalaryComponent `json:"component,omitempty" gorm:"foreignKey:ComponentID"`
+	DebitAccount      string           `json:"debit_account" gorm:"size:50;not null;comment:借方科目编码"`
+	DebitAccountName  string           `json:"debit_account_name" gorm:"size:100;comment:借方科目名称"`
+	CreditAccount     string           `json:"credit_account" gorm:"size:50;not null;comment:贷方科目编码"`
+	CreditAccountName string           `json:"credit_account_name" gorm:"size:100;comment:贷方科目名称"`
+	Description       string           `json:"description" gorm:"size:255;comment:说明"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+}
@@ -0,0 +1,235 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// RoundingRule 金额舍入规则
+type RoundingRule string
+
+const (
+	RoundHalfUp   RoundingRule = "half_up"   // 四舍五入到分
+	RoundHalfEven RoundingRule = "half_even" // 银行家舍入到分（四舍六入五成双）
+	RoundYuan     RoundingRule = "yuan"      // 四舍五入到元
+)
+
+// IsValid 判断舍入规则是否受支持，空值按四舍五入到分处理
+func (r RoundingRule) IsValid() bool {
+	switch r {
+	case "", RoundHalfUp, RoundHalfEven, RoundYuan:
+		return true
+	}
+	return false
+}
+
+// Money 以分为单位的定点金额，数据库中映射为 decimal(…,2)，JSON 中序列化为两位小数的数字。
+//
+// 以下 decimal 列仍为 float64，它们不参与应发、实发金额的累加：
+//   - 薪资范围（SalaryGrade、JobLevel、Recruitment 的 MinSalary/MaxSalary/MidSalary）与 Candidate.ExpectedSalary，
+//     仅用于区间比较和比较比率计算；
+//   - 社保公积金政策的缴费基数上下限与社平工资，属于政策参数，仅用于基数封顶保底和倍数计算。
+type Money struct {
+	cents int64
+}
+
+// MoneyFromCents 由分构造金额
+func MoneyFromCents(cents int64) Money {
+	return Money{cents: cents}
+}
+
+// NewMoney 由浮点金额构造，按四舍五入保留到分
+func NewMoney(amount float64) Money {
+	return RoundMoney(amount, RoundHalfUp)
+}
+
+// RoundMoney 按舍入规则将计算结果转换为金额；
+// 浮点数先取最短十进制表示再舍入，避免 2.675 之类的值因二进制误差被舍成 2.67
+func RoundMoney(amount float64, rule RoundingRule) Money {
+	value, ok := new(big.Rat).SetString(strconv.FormatFloat(amount, 'f', -1, 64))
+	if !ok {
+		return Money{}
+	}
+	return roundRat(value, rule)
+}
+
+// ParseMoney 解析十进制金额字符串，超过两位的小数按四舍五入处理
+func ParseMoney(text string) (Money, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return Money{}, nil
+	}
+	value, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return Money{}, fmt.Errorf("invalid amount: %s", text)
+	}
+	return roundRat(value, RoundHalfUp), nil
+}
+
+// SumMoney 金额求和
+func SumMoney(values ...Money) Money {
+	var total Money
+	for _, value := range values {
+		total.cents += value.cents
+	}
+	return total
+}
+
+// Cents 返回以分为单位的整数金额
+func (m Money) Cents() int64 {
+	return m.cents
+}
+
+// Float64 返回浮点金额，仅用于公式求值和比率计算，不应再参与金额累加
+func (m Money) Float64() float64 {
+	return float64(m.cents) / 100
+}
+
+// String 返回两位小数的金额文本，如 "-1234.50"
+func (m Money) String() string {
+	cents := m.cents
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+func (m Money) Add(other Money) Money {
+	return Money{cents: m.cents + other.cents}
+}
+
+func (m Money) Sub(other Money) Money {
+	return Money{cents: m.cents - other.cents}
+}
+
+func (m Money) Neg() Money {
+	return Money{cents: -m.cents}
+}
+
+func (m Money) Abs() Money {
+	if m.cents < 0 {
+		return m.Neg()
+	}
+	return m
+}
+
+func (m Money) IsZero() bool {
+	return m.cents == 0
+}
+
+// Sign 返回 -1、0 或 1
+func (m Money) Sign() int {
+	switch {
+	case m.cents < 0:
+		return -1
+	case m.cents > 0:
+		return 1
+	}
+	return 0
+}
+
+// Cmp 比较两个金额，返回 -1、0 或 1
+func (m Money) Cmp(other Money) int {
+	return m.Sub(other).Sign()
+}
+
+// Mul 金额乘以系数（如费率、折算比例），结果按舍入规则保留
+func (m Money) Mul(factor float64, rule RoundingRule) Money {
+	ratio, ok := new(big.Rat).SetString(strconv.FormatFloat(factor, 'f', -1, 64))
+	if !ok {
+		return Money{}
+	}
+	value := new(big.Rat).SetFrac64(m.cents, 100)
+	return roundRat(value.Mul(value, ratio), rule)
+}
+
+// Round 按舍入规则重新舍入金额（如按元取整）
+func (m Money) Round(rule RoundingRule) Money {
+	return roundRat(new(big.Rat).SetFrac64(m.cents, 100), rule)
+}
+
+// roundRat 将以元为单位的有理数按规则舍入为金额
+func roundRat(value *big.Rat, rule RoundingRule) Money {
+	unit := int64(100)
+	if rule == RoundYuan {
+		unit = 1
+	}
+
+	scaled := new(big.Rat).Mul(value, new(big.Rat).SetInt64(unit))
+	num := new(big.Int).Abs(scaled.Num())
+	den := scaled.Denom()
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	// 比较余数的两倍与分母，判断是否超过一半
+	switch new(big.Int).Lsh(remainder, 1).Cmp(den) {
+	case 1:
+		quotient.Add(quotient, big.NewInt(1))
+	case 0:
+		if rule != RoundHalfEven || quotient.Bit(0) == 1 {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+
+	amount := quotient.Int64()
+	if scaled.Sign() < 0 {
+		amount = -amount
+	}
+	return Money{cents: amount * (100 / unit)}
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface，兼容数字和字符串两种写法
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "null" {
+		*m = Money{}
+		return nil
+	}
+	parsed, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Value implements the driver.Valuer interface，以十进制字符串写入 decimal 列，避免经过浮点
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (m *Money) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = Money{}
+	case []byte:
+		parsed, err := ParseMoney(string(v))
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case string:
+		parsed, err := ParseMoney(v)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case float64:
+		*m = NewMoney(v)
+	case int64:
+		*m = Money{cents: v * 100}
+	default:
+		return fmt.Errorf("cannot scan %T into Money", value)
+	}
+	return nil
+}
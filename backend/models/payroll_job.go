@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+)
+
+// PayrollJobStatus 薪资计算任务状态
+type PayrollJobStatus string
+
+const (
+	PayrollJobPending    PayrollJobStatus = "pending"    // 等待执行
+	PayrollJobRunning    PayrollJobStatus = "running"    // 执行中
+	PayrollJobCancelling PayrollJobStatus = "cancelling" // 取消中（等待进行中的员工计算结束）
+	PayrollJobCancelled  PayrollJobStatus = "cancelled"  // 已取消，可恢复
+	PayrollJobCompleted  PayrollJobStatus = "completed"  // 已完成（可能存在失败员工）
+	PayrollJobFailed     PayrollJobStatus = "failed"     // 任务级失败
+)
+
+// IsActive 判断任务是否仍在排队或执行
+func (s PayrollJobStatus) IsActive() bool {
+	return s == PayrollJobPending || s == PayrollJobRunning || s == PayrollJobCancelling
+}
+
+// PayrollJob 后台薪资计算任务
+type PayrollJob struct {
+	ID              uint             `json:"id" gorm:"primaryKey"`
+	PayrollPeriodID uint             `json:"payroll_period_id" gorm:"not null;index;comment:薪资周期ID"`
+	PayrollPeriod   *PayrollPeriod   `json:"payroll_period,omitempty" gorm:"foreignKey:PayrollPeriodID"`
+	DepartmentID    *uint            `json:"department_id" gorm:"comment:部门筛选"`
+	Status          PayrollJobStatus `json:"status" gorm:"size:20;default:pending;index;comment:任务状态"`
+	Concurrency     int              `json:"concurrency" gorm:"default:4;comment:并发计算数"`
+	Total           int              `json:"total" gorm:"default:0;comment:员工总数"`
+	Processed       int              `json:"processed" gorm:"default:0;comment:已处理数"`
+	Succeeded       int              `json:"succeeded" gorm:"default:0;comment:成功数"`
+	Failed          int              `json:"failed" gorm:"default:0;comment:失败数"`
+	TotalAmount     Money            `json:"total_amount" gorm:"type:decimal(15,2);default:0;comment:实发合计"`
+	RequestedBy     uint             `json:"requested_by" gorm:"not null;comment:发起用户ID"`
+	Error           string           `json:"error" gorm:"type:text;comment:任务级错误"`
+	StartedAt       *time.Time       `json:"started_at" gorm:"comment:开始时间"`
+	FinishedAt      *time.Time       `json:"finished_at" gorm:"comment:结束时间"`
+	HeartbeatAt     *time.Time       `json:"heartbeat_at" gorm:"comment:执行心跳(用于崩溃恢复)"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+// PayrollJobItemStatus 任务中单个员工的计算状态
+type PayrollJobItemStatus string
+
+const (
+	PayrollJobItemPending   PayrollJobItemStatus = "pending"
+	PayrollJobItemRunning   PayrollJobItemStatus = "running"
+	PayrollJobItemSucceeded PayrollJobItemStatus = "succeeded"
+	PayrollJobItemFailed    PayrollJobItemStatus = "failed"
+)
+
+// PayrollJobItem 任务中单个员工的计算结果
+type PayrollJobItem struct {
+	ID         uint                 `json:"id" gorm:"primaryKey"`
+	JobID      uint                 `json:"job_id" gorm:"not null;index:idx_payroll_job_item_status;comment:任务ID"`
+	EmployeeID uint                 `json:"employee_id" gorm:"not null;comment:员工ID"`
+	Employee   *Employee            `json:"employee,omitempty" gorm:"foreignKey:EmployeeID"`
+	Status     PayrollJobItemStatus `json:"status" gorm:"size:20;default:pending;index:idx_payroll_job_item_status;comment:计算状态"`
+	SalaryID   *uint                `json:"salary_id" gorm:"comment:生成的薪资记录ID"`
+	NetAmount  Money                `json:"net_amount" gorm:"type:decimal(15,2);default:0;comment:实发薪资"`
+	Message    string               `json:"message" gorm:"type:text;comment:错误信息"`
+	Attempts   int                  `json:"attempts" gorm:"default:0;comment:尝试次数"`
+	CreatedAt  time.Time            `json:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+}
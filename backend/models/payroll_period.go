@@ -0,0 +1,114 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// periodTransitions 薪资周期关账流程允许的状态流转：
+// open → calculating → reviewed → approved → (paid) → closed，重新开放需走 Reopen 流程
+var periodTransitions = map[PayrollPeriodStatus][]PayrollPeriodStatus{
+	PeriodStatusDraft:       {PeriodStatusOpen},
+	PeriodStatusOpen:        {PeriodStatusCalculating},
+	PeriodStatusCalculating: {PeriodStatusOpen, PeriodStatusCalculated, PeriodStatusReviewed},
+	PeriodStatusCalculated:  {PeriodStatusCalculating, PeriodStatusReviewed},
+	PeriodStatusReviewed:    {PeriodStatusCalculating, PeriodStatusApproved},
+	PeriodStatusApproved:    {PeriodStatusPaid, PeriodStatusClosed},
+	PeriodStatusPaid:        {PeriodStatusClosed},
+}
+
+// CanTransitionTo 判断周期能否流转到目标状态
+func (s PayrollPeriodStatus) CanTransitionTo(target PayrollPeriodStatus) bool {
+	for _, next := range periodTransitions[s] {
+		if next == target {
+			return true
+		}
+	}
+	return false
+}
+
+// LocksInputs 审核及之后的状态冻结薪资、考勤与请假数据
+func (s PayrollPeriodStatus) LocksInputs() bool {
+	switch s {
+	case PeriodStatusReviewed, PeriodStatusApproved, PeriodStatusPaid, PeriodStatusClosed:
+		return true
+	}
+	return false
+}
+
+// InputsLocked 周期处于锁定状态或被手动锁定时不允许修改周期内数据
+func (p *PayrollPeriod) InputsLocked() bool {
+	return p.IsLocked || p.Status.LocksInputs()
+}
+
+// IsClosed 周期是否已关账
+func (p *PayrollPeriod) IsClosed() bool {
+	return p.Status == PeriodStatusClosed
+}
+
+// OffCycleReason 非周期发放原因
+type OffCycleReason string
+
+const (
+	OffCycleTermination   OffCycleReason = "termination"    // 离职结算
+	OffCycleCorrection    OffCycleReason = "correction"     // 更正补发
+	OffCycleReferralBonus OffCycleReason = "referral_bonus" // 推荐奖金
+	OffCycleOther         OffCycleReason = "other"          // 其他一次性发放
+)
+
+// IsValid 判断非周期发放原因是否受支持
+func (r OffCycleReason) IsValid() bool {
+	switch r {
+	case OffCycleTermination, OffCycleCorrection, OffCycleReferralBonus, OffCycleOther:
+		return true
+	}
+	return false
+}
+
+// SupplementalPeriodTypes 不含考勤、不按薪资结构计算的补充发放周期类型
+var SupplementalPeriodTypes = []PayrollPeriodType{PeriodTypeBonus, PeriodTypeOffCycle}
+
+// IsOffCycle 是否为挂靠常规周期的非周期发放
+func (p *PayrollPeriod) IsOffCycle() bool {
+	return p.PeriodType == PeriodTypeOffCycle
+}
+
+// IsSupplemental 是否为奖金或非周期发放等补充发放周期
+func (p *PayrollPeriod) IsSupplemental() bool {
+	return p.PeriodType == PeriodTypeBonus || p.PeriodType == PeriodTypeOffCycle
+}
+
+// AllowedComponentCodes 返回非周期发放允许使用的组件编码
+func (p *PayrollPeriod) AllowedComponentCodes() []string {
+	var codes []string
+	seen := make(map[string]bool)
+	for _, code := range strings.Split(p.AllowedComponents, ",") {
+		if code = strings.TrimSpace(code); code != "" && !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// PayrollPeriodAction 周期操作类型
+type PayrollPeriodAction string
+
+const (
+	PeriodActionTransition PayrollPeriodAction = "transition" // 状态流转
+	PeriodActionLock       PayrollPeriodAction = "lock"       // 手动锁定
+	PeriodActionUnlock     PayrollPeriodAction = "unlock"     // 解除手动锁定
+	PeriodActionReopen     PayrollPeriodAction = "reopen"     // 重新开放
+)
+
+// PayrollPeriodEvent 薪资周期状态变更审计记录
+type PayrollPeriodEvent struct {
+	ID              uint                `json:"id" gorm:"primaryKey"`
+	PayrollPeriodID uint                `json:"payroll_period_id" gorm:"not null;index;comment:薪资周期ID"`
+	Action          PayrollPeriodAction `json:"action" gorm:"size:20;not null;comment:操作类型"`
+	FromStatus      PayrollPeriodStatus `json:"from_status" gorm:"size:20;comment:原状态"`
+	ToStatus        PayrollPeriodStatus `json:"to_status" gorm:"size:20;comment:新状态"`
+	Reason          string              `json:"reason" gorm:"type:text;comment:操作原因"`
+	OperatorID      uint                `json:"operator_id" gorm:"not null;comment:操作人ID"`
+	CreatedAt       time.Time           `json:"created_at"`
+}
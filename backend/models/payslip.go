@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// PayslipPasswordRule 工资单 PDF 打开口令规则
+type PayslipPasswordRule string
+
+const (
+	PayslipPasswordNone         PayslipPasswordRule = "none"           // 不加密
+	PayslipPasswordIDCardSuffix PayslipPasswordRule = "id_card_suffix" // 身份证号后 N 位
+	PayslipPasswordEmployeeNo   PayslipPasswordRule = "employee_no"    // 员工工号
+	PayslipPasswordBirthday     PayslipPasswordRule = "birthday"       // 出生日期(YYYYMMDD)
+)
+
+// PayslipTemplate 工资单模板，按法人实体配置抬头、展示内容与加密规则
+type PayslipTemplate struct {
+	ID               uint                `json:"id" gorm:"primaryKey"`
+	Name             string              `json:"name" gorm:"size:100;not null;comment:模板名称"`
+	LegalEntityID    *uint               `json:"legal_entity_id" gorm:"uniqueIndex;comment:法人实体(公司类型部门)ID，为空表示默认模板"`
+	LegalEntity      *Department         `json:"legal_entity,omitempty" gorm:"foreignKey:LegalEntityID"`
+	CompanyName      string              `json:"company_name" gorm:"size:200;comment:抬头公司名称，为空时取法人实体名称"`
+	Title            string              `json:"title" gorm:"size:100;default:工资单;comment:标题"`
+	HeaderNote       string              `json:"header_note" gorm:"type:text;comment:页眉说明"`
+	FooterNote       string              `json:"footer_note" gorm:"type:text;comment:页脚说明"`
+	ShowEmployerCost bool                `json:"show_employer_cost" gorm:"comment:是否展示企业承担部分"`
+	ShowYTD          bool                `json:"show_ytd" gorm:"comment:是否展示年度累计"`
+	ShowTaxBreakdown bool                `json:"show_tax_breakdown" gorm:"comment:是否展示个税计算明细"`
+	PasswordRule     PayslipPasswordRule `json:"password_rule" gorm:"size:20;default:none;comment:打开口令规则"`
+	PasswordLength   int                 `json:"password_length" gorm:"default:6;comment:身份证后N位口令长度"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// RetroPayStatus 补发/补扣状态
+type RetroPayStatus string
+
+const (
+	RetroPayPending   RetroPayStatus = "pending"   // 待并入下一开放周期
+	RetroPayApplied   RetroPayStatus = "applied"   // 已并入薪资
+	RetroPayCancelled RetroPayStatus = "cancelled" // 已取消
+)
+
+// RetroPayItem 追溯调薪产生的补发（正数）或补扣（负数）明细
+type RetroPayItem struct {
+	ID                uint              `json:"id" gorm:"primaryKey"`
+	EmployeeID        uint              `json:"employee_id" gorm:"not null;index;comment:员工ID"`
+	Employee          *Employee         `json:"employee,omitempty" gorm:"foreignKey:EmployeeID"`
+	AdjustmentID      uint              `json:"adjustment_id" gorm:"not null;index;comment:触发的调薪记录ID"`
+	Adjustment        *SalaryAdjustment `json:"adjustment,omitempty" gorm:"foreignKey:AdjustmentID"`
+	SourcePeriodID    uint              `json:"source_period_id" gorm:"not null;comment:被追溯的已发放周期ID"`
+	SourcePeriod      *PayrollPeriod    `json:"source_period,omitempty" gorm:"foreignKey:SourcePeriodID"`
+	SourceSalaryID    uint              `json:"source_salary_id" gorm:"not null;index;comment:已发放薪资记录ID"`
+	SourceVersion     int               `json:"source_version" gorm:"comment:已发放薪资版本号"`
+	TargetPeriodID    *uint             `json:"target_period_id" gorm:"comment:并入的周期ID"`
+	TargetPeriod      *PayrollPeriod    `json:"target_period,omitempty" gorm:"foreignKey:TargetPeriodID"`
+	TargetSalaryID    *uint             `json:"target_salary_id" gorm:"comment:并入的薪资记录ID"`
+	PaidGross         Money             `json:"paid_gross" gorm:"type:decimal(15,2);comment:已发放应发薪资"`
+	RecalculatedGross Money             `json:"recalculated_gross" gorm:"type:decimal(15,2);comment:重算应发薪资"`
+	Amount            Money             `json:"amount" gorm:"type:decimal(15,2);comment:补发(+)/补扣(-)金额"`
+	Status            RetroPayStatus    `json:"status" gorm:"size:20;default:pending;comment:状态"`
+	Trace             string            `json:"trace" gorm:"type:text;comment:重算过程"`
+	AppliedAt         *time.Time        `json:"applied_at" gorm:"comment:并入时间"`
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 	"gorm.io/gorm"
 )
@@ -13,12 +14,14 @@ type SalaryComponent struct {
 	Category      SalaryComponentCategory `json:"category" gorm:"size:20;not null;comment:组件分类"`
 	Type          SalaryComponentType    `json:"type" gorm:"size:20;not null;comment:计算类型"`
 	Formula       string                 `json:"formula" gorm:"type:text;comment:计算公式"`
+	DependsOn     string                 `json:"depends_on" gorm:"size:500;comment:显式依赖的组件编码(逗号分隔)"`
 	IsFixed       bool                   `json:"is_fixed" gorm:"default:false;comment:是否固定金额"`
 	IsTaxable     bool                   `json:"is_taxable" gorm:"default:true;comment:是否计税"`
 	IsRequired    bool                   `json:"is_required" gorm:"default:false;comment:是否必选项"`
-	DefaultAmount float64                `json:"default_amount" gorm:"type:decimal(15,2);default:0;comment:默认金额"`
-	MinAmount     *float64               `json:"min_amount" gorm:"type:decimal(15,2);comment:最小金额"`
-	MaxAmount     *float64               `json:"max_amount" gorm:"type:decimal(15,2);comment:最大金额"`
+	DefaultAmount Money                  `json:"default_amount" gorm:"type:decimal(15,2);default:0;comment:默认金额"`
+	MinAmount     *Money                 `json:"min_amount" gorm:"type:decimal(15,2);comment:最小金额"`
+	MaxAmount     *Money                 `json:"max_amount" gorm:"type:decimal(15,2);comment:最大金额"`
+	RoundingRule  RoundingRule           `json:"rounding_rule" gorm:"size:20;default:half_up;comment:舍入规则"`
 	Sort          int                    `json:"sort" gorm:"default:0;comment:排序"`
 	Status        string                 `json:"status" gorm:"size:20;default:active;comment:状态"`
 	Description   string                 `json:"description" gorm:"type:text;comment:描述"`
@@ -27,6 +30,17 @@ type SalaryComponent struct {
 	DeletedAt     gorm.DeletedAt         `json:"deleted_at,omitempty" gorm:"index"`
 }
 
+// DependencyCodes 返回显式声明的依赖组件编码
+func (c *SalaryComponent) DependencyCodes() []string {
+	var codes []string
+	for _, code := range strings.Split(c.DependsOn, ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
 // SalaryComponentCategory 薪资组件分类
 type SalaryComponentCategory string
 
@@ -38,6 +52,7 @@ const (
 	ComponentCategoryTax        SalaryComponentCategory = "tax"         // 税费
 	ComponentCategoryBenefit    SalaryComponentCategory = "benefit"     // 福利
 	ComponentCategoryInsurance  SalaryComponentCategory = "insurance"   // 保险
+	ComponentCategoryEmployerCost SalaryComponentCategory = "employer_cost" // 企业承担（不计入员工应发/实发）
 )
 
 // SalaryComponentType 薪资组件计算类型
@@ -83,6 +98,8 @@ type SalaryStructure struct {
 	JobLevel     *JobLevel              `json:"job_level,omitempty" gorm:"foreignKey:JobLevelID"`
 	SalaryGradeID *uint                 `json:"salary_grade_id" gorm:"comment:薪资等级ID"`
 	SalaryGrade   *SalaryGrade          `json:"salary_grade,omitempty" gorm:"foreignKey:SalaryGradeID"`
+	ProrationMethod ProrationMethod     `json:"proration_method" gorm:"size:20;default:working_days;comment:月中折算方式"`
+	Currency      string                `json:"currency" gorm:"size:3;default:CNY;comment:发薪币种"`
 	Components    []SalaryStructureComponent `json:"components,omitempty" gorm:"foreignKey:StructureID"`
 	IsDefault     bool                  `json:"is_default" gorm:"default:false;comment:是否默认结构"`
 	Status        string                `json:"status" gorm:"size:20;default:active;comment:状态"`
@@ -91,6 +108,10 @@ type SalaryStructure struct {
 	CreatedAt     time.Time             `json:"created_at"`
 	UpdatedAt     time.Time             `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt        `json:"deleted_at,omitempty" gorm:"index"`
+
+	// 保存时生成的结构版本信息（不落库）
+	VersionEffectiveDate *CustomDate    `json:"version_effective_date,omitempty" gorm:"-"`
+	ChangeNote           string         `json:"change_note,omitempty" gorm:"-"`
 }
 
 // SalaryStructureComponent 薪资结构组件关联
@@ -100,7 +121,7 @@ type SalaryStructureComponent struct {
 	Structure         *SalaryStructure       `json:"structure,omitempty" gorm:"foreignKey:StructureID"`
 	ComponentID       uint                   `json:"component_id" gorm:"not null;comment:薪资组件ID"`
 	Component         *SalaryComponent       `json:"component,omitempty" gorm:"foreignKey:ComponentID"`
-	DefaultValue      float64                `json:"default_value" gorm:"type:decimal(15,2);default:0;comment:默认值"`
+	DefaultValue      Money                  `json:"default_value" gorm:"type:decimal(15,2);default:0;comment:默认值"`
 	IsRequired        bool                   `json:"is_required" gorm:"default:false;comment:是否必填"`
 	CanEdit           bool                   `json:"can_edit" gorm:"default:true;comment:是否可编辑"`
 	Sort              int                    `json:"sort" gorm:"default:0;comment:排序"`
@@ -120,6 +141,11 @@ type PayrollPeriod struct {
 	PayDate     *time.Time             `json:"pay_date" gorm:"comment:发薪日期"`
 	Status      PayrollPeriodStatus    `json:"status" gorm:"size:20;default:draft;comment:状态"`
 	IsLocked    bool                   `json:"is_locked" gorm:"default:false;comment:是否锁定"`
+	Currency    string                 `json:"currency" gorm:"size:3;default:CNY;comment:发薪币种"`
+	ParentPeriodID    *uint            `json:"parent_period_id" gorm:"index;comment:所属常规周期ID(仅非周期发放)"`
+	ParentPeriod      *PayrollPeriod   `json:"parent_period,omitempty" gorm:"foreignKey:ParentPeriodID"`
+	OffCycleReason    OffCycleReason   `json:"off_cycle_reason" gorm:"size:30;comment:非周期发放原因"`
+	AllowedComponents string           `json:"allowed_components" gorm:"size:500;comment:允许发放的组件编码(逗号分隔，仅非周期发放)"`
 	CreatedAt   time.Time              `json:"created_at"`
 	UpdatedAt   time.Time              `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt         `json:"deleted_at,omitempty" gorm:"index"`
@@ -133,6 +159,7 @@ const (
 	PeriodTypeQuarterly PayrollPeriodType = "quarterly"  // 季薪
 	PeriodTypeYearly    PayrollPeriodType = "yearly"     // 年薪
 	PeriodTypeBonus     PayrollPeriodType = "bonus"      // 奖金
+	PeriodTypeOffCycle  PayrollPeriodType = "off_cycle"  // 非周期发放（离职结算、更正补发、一次性奖励等）
 )
 
 // PayrollPeriodStatus 薪资周期状态
@@ -141,6 +168,7 @@ type PayrollPeriodStatus string
 const (
 	PeriodStatusDraft     PayrollPeriodStatus = "draft"      // 草稿
 	PeriodStatusOpen      PayrollPeriodStatus = "open"       // 开放
+	PeriodStatusCalculating PayrollPeriodStatus = "calculating" // 计算中
 	PeriodStatusCalculated PayrollPeriodStatus = "calculated" // 已计算
 	PeriodStatusReviewed  PayrollPeriodStatus = "reviewed"   // 已审核
 	PeriodStatusApproved  PayrollPeriodStatus = "approved"   // 已批准
@@ -157,11 +185,21 @@ type EnhancedSalary struct {
 	PayrollPeriod   *PayrollPeriod         `json:"payroll_period,omitempty" gorm:"foreignKey:PayrollPeriodID"`
 	StructureID     *uint                  `json:"structure_id" gorm:"comment:薪资结构ID"`
 	Structure       *SalaryStructure       `json:"structure,omitempty" gorm:"foreignKey:StructureID"`
+	StructureVersionID *uint               `json:"structure_version_id" gorm:"comment:计算所用薪资结构版本ID"`
+	StructureVersion   *SalaryStructureVersion `json:"structure_version,omitempty" gorm:"foreignKey:StructureVersionID"`
+	Currency        string                 `json:"currency" gorm:"size:3;default:CNY;comment:币种(取自薪资周期)"`
 	
 	// 薪资计算结果
-	GrossSalary     float64                `json:"gross_salary" gorm:"type:decimal(15,2);default:0;comment:应发薪资"`
-	TotalDeductions float64                `json:"total_deductions" gorm:"type:decimal(15,2);default:0;comment:总扣除"`
-	NetSalary       float64                `json:"net_salary" gorm:"type:decimal(15,2);default:0;comment:实发薪资"`
+	GrossSalary     Money                  `json:"gross_salary" gorm:"type:decimal(15,2);default:0;comment:应发薪资"`
+	TotalDeductions Money                  `json:"total_deductions" gorm:"type:decimal(15,2);default:0;comment:总扣除"`
+	NetSalary       Money                  `json:"net_salary" gorm:"type:decimal(15,2);default:0;comment:实发薪资"`
+	
+	// 个人所得税（累计预扣法）
+	TaxableIncome   Money                  `json:"taxable_income" gorm:"type:decimal(15,2);default:0;comment:本期计税收入"`
+	TaxDeductions   Money                  `json:"tax_deductions" gorm:"type:decimal(15,2);default:0;comment:本期专项扣除"`
+	IncomeTax       Money                  `json:"income_tax" gorm:"type:decimal(15,2);default:0;comment:本期预扣个税"`
+	EmployerCost    Money                  `json:"employer_cost" gorm:"type:decimal(15,2);default:0;comment:企业承担社保公积金"`
+	BonusTaxMethod  BonusTaxMethod         `json:"bonus_tax_method" gorm:"size:20;comment:奖金计税方式(仅奖金发放)"`
 	
 	// 详细组件记录
 	Components      []SalaryDetail         `json:"components,omitempty" gorm:"foreignKey:SalaryID"`
@@ -195,6 +233,15 @@ type EnhancedSalary struct {
 	DeletedAt       gorm.DeletedAt         `json:"deleted_at,omitempty" gorm:"index"`
 }
 
+// BonusTaxMethod 全年一次性奖金计税方式
+type BonusTaxMethod string
+
+const (
+	BonusTaxSeparate BonusTaxMethod = "separate" // 单独计税
+	BonusTaxCombined BonusTaxMethod = "combined" // 并入当年综合所得
+	BonusTaxOptimal  BonusTaxMethod = "optimal"  // 两种方式试算，取税额较低者
+)
+
 // SalaryStatus 薪资状态
 type SalaryStatus string
 
@@ -215,11 +262,12 @@ type SalaryDetail struct {
 	Salary            *EnhancedSalary        `json:"salary,omitempty" gorm:"foreignKey:SalaryID"`
 	ComponentID       uint                   `json:"component_id" gorm:"not null;comment:薪资组件ID"`
 	Component         *SalaryComponent       `json:"component,omitempty" gorm:"foreignKey:ComponentID"`
-	CalculatedValue   float64                `json:"calculated_value" gorm:"type:decimal(15,2);default:0;comment:计算值"`
-	ManualValue       *float64               `json:"manual_value" gorm:"type:decimal(15,2);comment:手动调整值"`
-	FinalValue        float64                `json:"final_value" gorm:"type:decimal(15,2);default:0;comment:最终值"`
+	CalculatedValue   Money                  `json:"calculated_value" gorm:"type:decimal(15,2);default:0;comment:计算值"`
+	ManualValue       *Money                 `json:"manual_value" gorm:"type:decimal(15,2);comment:手动调整值"`
+	FinalValue        Money                  `json:"final_value" gorm:"type:decimal(15,2);default:0;comment:最终值"`
 	CalculationFormula string                `json:"calculation_formula" gorm:"type:text;comment:计算公式"`
 	Notes             string                 `json:"notes" gorm:"type:text;comment:备注"`
+	Segments          []SalaryDetailSegment  `json:"segments,omitempty" gorm:"foreignKey:SalaryDetailID"`
 	CreatedAt         time.Time              `json:"created_at"`
 	UpdatedAt         time.Time              `json:"updated_at"`
 }
@@ -233,7 +281,7 @@ type EnhancedPayrollRecord struct {
 	PaymentBatch    *PaymentBatch          `json:"payment_batch,omitempty" gorm:"foreignKey:PaymentBatchID"`
 	
 	// 支付信息
-	PaymentAmount   float64                `json:"payment_amount" gorm:"type:decimal(15,2);not null;comment:支付金额"`
+	PaymentAmount   Money                  `json:"payment_amount" gorm:"type:decimal(15,2);not null;comment:支付金额"`
 	PaymentMethod   PaymentMethod          `json:"payment_method" gorm:"size:20;not null;comment:支付方式"`
 	BankAccount     string                 `json:"bank_account" gorm:"size:50;comment:银行账号"`
 	BankName        string                 `json:"bank_name" gorm:"size:100;comment:银行名称"`
@@ -252,6 +300,7 @@ type EnhancedPayrollRecord struct {
 	
 	// 审计
 	RetryCount      int                    `json:"retry_count" gorm:"default:0;comment:重试次数"`
+	ReissuedFromID  *uint                  `json:"reissued_from_id" gorm:"index;comment:重发来源发放记录ID"`
 	Notes           string                 `json:"notes" gorm:"type:text;comment:备注"`
 	
 	CreatedAt       time.Time              `json:"created_at"`
@@ -278,6 +327,7 @@ const (
 	PayrollStatusCompleted PayrollStatus = "completed"  // 已完成
 	PayrollStatusFailed    PayrollStatus = "failed"     // 失败
 	PayrollStatusCancelled PayrollStatus = "cancelled"  // 已取消
+	PayrollStatusReissued  PayrollStatus = "reissued"   // 失败后已转入重发批次
 )
 
 // PaymentBatch 支付批次
@@ -287,7 +337,8 @@ type PaymentBatch struct {
 	Name            string                 `json:"name" gorm:"size:100;not null;comment:批次名称"`
 	PayrollPeriodID uint                   `json:"payroll_period_id" gorm:"not null;comment:薪资周期ID"`
 	PayrollPeriod   *PayrollPeriod         `json:"payroll_period,omitempty" gorm:"foreignKey:PayrollPeriodID"`
-	TotalAmount     float64                `json:"total_amount" gorm:"type:decimal(15,2);default:0;comment:总金额"`
+	TotalAmount     Money                  `json:"total_amount" gorm:"type:decimal(15,2);default:0;comment:总金额"`
+	Currency        string                 `json:"currency" gorm:"size:3;default:CNY;comment:币种"`
 	TotalRecords    int                    `json:"total_records" gorm:"default:0;comment:总记录数"`
 	SuccessRecords  int                    `json:"success_records" gorm:"default:0;comment:成功记录数"`
 	FailedRecords   int                    `json:"failed_records" gorm:"default:0;comment:失败记录数"`
@@ -326,15 +377,16 @@ type SalaryAdjustment struct {
 	AdjustmentType  SalaryAdjustmentType   `json:"adjustment_type" gorm:"size:20;not null;comment:调整类型"`
 	Reason          string                 `json:"reason" gorm:"size:255;not null;comment:调整原因"`
 	EffectiveDate   time.Time              `json:"effective_date" gorm:"not null;comment:生效日期"`
-	OldBaseSalary   float64                `json:"old_base_salary" gorm:"type:decimal(15,2);comment:原基本薪资"`
-	NewBaseSalary   float64                `json:"new_base_salary" gorm:"type:decimal(15,2);comment:新基本薪资"`
-	AdjustmentAmount float64               `json:"adjustment_amount" gorm:"type:decimal(15,2);comment:调整金额"`
+	OldBaseSalary   Money                  `json:"old_base_salary" gorm:"type:decimal(15,2);comment:原基本薪资"`
+	NewBaseSalary   Money                  `json:"new_base_salary" gorm:"type:decimal(15,2);comment:新基本薪资"`
+	AdjustmentAmount Money                 `json:"adjustment_amount" gorm:"type:decimal(15,2);comment:调整金额"`
 	AdjustmentPercent float64              `json:"adjustment_percent" gorm:"type:decimal(5,2);comment:调整百分比"`
 	ApprovedBy      *uint                  `json:"approved_by" gorm:"comment:批准人ID"`
 	Approver        *Employee              `json:"approver,omitempty" gorm:"foreignKey:ApprovedBy"`
 	ApprovedAt      *time.Time             `json:"approved_at" gorm:"comment:批准时间"`
 	Status          string                 `json:"status" gorm:"size:20;default:pending;comment:状态"`
 	Notes           string                 `json:"notes" gorm:"type:text;comment:备注"`
+	BandWarning     string                 `json:"band_warning" gorm:"size:255;comment:薪酬带宽提示"`
 	CreatedAt       time.Time              `json:"created_at"`
 	UpdatedAt       time.Time              `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt         `json:"deleted_at,omitempty" gorm:"index"`
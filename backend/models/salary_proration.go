@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// ProrationMethod 月中入离职、调薪时的薪资折算方式
+type ProrationMethod string
+
+const (
+	ProrationCalendarDays  ProrationMethod = "calendar_days"  // 按自然日折算
+	ProrationWorkingDays   ProrationMethod = "working_days"   // 按当期应出勤天数折算
+	ProrationStatutoryDays ProrationMethod = "statutory_days" // 按月计薪天数 21.75 折算日薪
+)
+
+// IsValid 判断折算方式是否受支持
+func (m ProrationMethod) IsValid() bool {
+	switch m {
+	case ProrationCalendarDays, ProrationWorkingDays, ProrationStatutoryDays:
+		return true
+	}
+	return false
+}
+
+// 折算区段的起始原因
+const (
+	SegmentReasonPeriodStart = "period_start" // 周期开始
+	SegmentReasonHire        = "hire"         // 月中入职
+	SegmentReasonAdjustment  = "adjustment"   // 调薪生效
+)
+
+// SalaryDetailSegment 薪资明细的折算区段，记录每段的基数与折算天数供审计
+type SalaryDetailSegment struct {
+	ID             uint            `json:"id" gorm:"primaryKey"`
+	SalaryDetailID uint            `json:"salary_detail_id" gorm:"not null;index;comment:薪资明细ID"`
+	StartDate      time.Time       `json:"start_date" gorm:"type:date;not null;comment:区段开始日期"`
+	EndDate        time.Time       `json:"end_date" gorm:"type:date;not null;comment:区段结束日期"`
+	Reason         string          `json:"reason" gorm:"size:30;comment:区段起始原因"`
+	AdjustmentID   *uint           `json:"adjustment_id" gorm:"comment:调薪记录ID"`
+	BaseSalary     Money           `json:"base_salary" gorm:"type:decimal(15,2);comment:区段月基本薪资"`
+	Method         ProrationMethod `json:"method" gorm:"size:20;comment:折算方式"`
+	Days           float64         `json:"days" gorm:"type:decimal(6,2);comment:区段计薪天数"`
+	TotalDays      float64         `json:"total_days" gorm:"type:decimal(6,2);comment:周期计薪天数"`
+	Amount         Money           `json:"amount" gorm:"type:decimal(15,2);comment:区段折算金额"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"time"
+)
+
+// 二次验证失败锁定策略
+const (
+	StepUpMaxAttempts  = 5                // 连续失败次数上限
+	StepUpLockDuration = 15 * time.Minute // 达到上限后的锁定时长
+)
+
+// StepUpMethod 薪资自助查询的二次验证方式
+type StepUpMethod string
+
+const (
+	StepUpPIN      StepUpMethod = "pin"      // 工资单查询密码
+	StepUpPassword StepUpMethod = "password" // 重新输入登录密码
+)
+
+// IsValid 判断验证方式是否受支持
+func (m StepUpMethod) IsValid() bool {
+	return m == StepUpPIN || m == StepUpPassword
+}
+
+// SalaryAccessCredential 用户的工资单查询密码与二次验证失败计数
+type SalaryAccessCredential struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	UserID         uint       `json:"user_id" gorm:"not null;uniqueIndex;comment:用户ID"`
+	PINHash        string     `json:"-" gorm:"size:255;comment:工资单查询密码哈希"`
+	FailedAttempts int        `json:"failed_attempts" gorm:"default:0;comment:连续验证失败次数"`
+	LockedUntil    *time.Time `json:"locked_until" gorm:"comment:锁定截止时间"`
+	LastVerifiedAt *time.Time `json:"last_verified_at" gorm:"comment:最近验证通过时间"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// IsLocked 判断当前是否处于锁定期
+func (c *SalaryAccessCredential) IsLocked(now time.Time) bool {
+	return c.LockedUntil != nil && now.Before(*c.LockedUntil)
+}
+
+// RemainingAttempts 锁定前剩余的可尝试次数
+func (c *SalaryAccessCredential) RemainingAttempts() int {
+	if remaining := StepUpMaxAttempts - c.FailedAttempts; remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// RecordFailure 记录一次验证失败，达到上限时锁定并重新计数
+func (c *SalaryAccessCredential) RecordFailure(now time.Time) {
+	if c.LockedUntil != nil && !c.IsLocked(now) {
+		c.LockedUntil = nil
+	}
+	c.FailedAttempts++
+	if c.FailedAttempts >= StepUpMaxAttempts {
+		lockedUntil := now.Add(StepUpLockDuration)
+		c.LockedUntil = &lockedUntil
+		c.FailedAttempts = 0
+	}
+}
+
+// RecordSuccess 验证通过后清除失败计数
+func (c *SalaryAccessCredential) RecordSuccess(now time.Time) {
+	c.FailedAttempts = 0
+	c.LockedUntil = nil
+	c.LastVerifiedAt = &now
+}
+
+// PayslipAckStatus 员工对工资单的确认状态
+type PayslipAckStatus string
+
+const (
+	PayslipConfirmed PayslipAckStatus = "confirmed" // 已确认无误
+	PayslipDisputed  PayslipAckStatus = "disputed"  // 有异议
+)
+
+// PayslipAcknowledgement 员工对单条薪资记录的确认结果，每条薪资记录保留最新一次
+type PayslipAcknowledgement struct {
+	ID             uint             `json:"id" gorm:"primaryKey"`
+	SalaryID       uint             `json:"salary_id" gorm:"not null;uniqueIndex;comment:薪资记录ID"`
+	EmployeeID     uint             `json:"employee_id" gorm:"not null;index;comment:员工ID"`
+	Status         PayslipAckStatus `json:"status" gorm:"size:20;not null;comment:确认状态"`
+	AcknowledgedAt time.Time        `json:"acknowledged_at" gorm:"comment:确认时间"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+// PayslipDisputeCategory 工资单异议类别
+type PayslipDisputeCategory string
+
+const (
+	DisputeAmount     PayslipDisputeCategory = "amount"     // 金额有误
+	DisputeAttendance PayslipDisputeCategory = "attendance" // 考勤扣款
+	DisputeTax        PayslipDisputeCategory = "tax"        // 个税
+	DisputeInsurance  PayslipDisputeCategory = "insurance"  // 社保公积金
+	DisputeOther      PayslipDisputeCategory = "other"      // 其他
+)
+
+// IsValid 判断异议类别是否受支持
+func (c PayslipDisputeCategory) IsValid() bool {
+	switch c {
+	case DisputeAmount, DisputeAttendance, DisputeTax, DisputeInsurance, DisputeOther:
+		return true
+	}
+	return false
+}
+
+// PayslipDisputeStatus 工资单异议处理状态
+type PayslipDisputeStatus string
+
+const (
+	DisputeOpen     PayslipDisputeStatus = "open"      // 待处理
+	DisputeInReview PayslipDisputeStatus = "in_review" // 已指派处理人
+	DisputeResolved PayslipDisputeStatus = "resolved"  // 已解决
+	DisputeRejected PayslipDisputeStatus = "rejected"  // 已驳回
+)
+
+// IsClosed 判断异议是否已处理完毕
+func (s PayslipDisputeStatus) IsClosed() bool {
+	return s == DisputeResolved || s == DisputeRejected
+}
+
+// PayslipDispute 员工对工资单提出的异议，由薪资管理员跟进至解决
+type PayslipDispute struct {
+	ID          uint                   `json:"id" gorm:"primaryKey"`
+	SalaryID    uint                   `json:"salary_id" gorm:"not null;index;comment:薪资记录ID"`
+	Salary      *EnhancedSalary        `json:"salary,omitempty" gorm:"foreignKey:SalaryID"`
+	EmployeeID  uint                   `json:"employee_id" gorm:"not null;index;comment:员工ID"`
+	Employee    *Employee              `json:"employee,omitempty" gorm:"foreignKey:EmployeeID"`
+	RaisedBy    uint                   `json:"raised_by" gorm:"comment:提出人用户ID"`
+	Category    PayslipDisputeCategory `json:"category" gorm:"size:20;not null;comment:异议类别"`
+	Description string                 `json:"description" gorm:"type:text;not null;comment:异议说明"`
+	Status      PayslipDisputeStatus   `json:"status" gorm:"size:20;default:open;index;comment:处理状态"`
+	AssignedTo  *uint                  `json:"assigned_to" gorm:"index;comment:处理人用户ID"`
+	AssignedAt  *time.Time             `json:"assigned_at" gorm:"comment:指派时间"`
+	Resolution  string                 `json:"resolution" gorm:"type:text;comment:处理结果"`
+	ResolvedBy  *uint                  `json:"resolved_by" gorm:"comment:处理完结人用户ID"`
+	ResolvedAt  *time.Time             `json:"resolved_at" gorm:"comment:处理完结时间"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
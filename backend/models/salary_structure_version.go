@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+// SalaryStructureVersion 薪资结构版本快照，结构每次修改生成一个按生效日期区分的新版本
+type SalaryStructureVersion struct {
+	ID              uint                              `json:"id" gorm:"primaryKey"`
+	StructureID     uint                              `json:"structure_id" gorm:"not null;uniqueIndex:idx_structure_version;comment:薪资结构ID"`
+	Structure       *SalaryStructure                  `json:"structure,omitempty" gorm:"foreignKey:StructureID"`
+	Version         int                               `json:"version" gorm:"not null;uniqueIndex:idx_structure_version;comment:版本号"`
+	EffectiveDate   time.Time                         `json:"effective_date" gorm:"type:date;not null;comment:生效日期"`
+	ExpiryDate      *time.Time                        `json:"expiry_date" gorm:"type:date;comment:失效日期（下一版本生效前一天）"`
+	Name            string                            `json:"name" gorm:"size:100;not null;comment:结构名称"`
+	Description     string                            `json:"description" gorm:"type:text;comment:描述"`
+	SalaryGradeID   *uint                             `json:"salary_grade_id" gorm:"comment:薪资等级ID"`
+	ProrationMethod ProrationMethod                   `json:"proration_method" gorm:"size:20;comment:月中折算方式"`
+	Currency        string                            `json:"currency" gorm:"size:3;comment:发薪币种"`
+	ChangeNote      string                            `json:"change_note" gorm:"size:255;comment:变更说明"`
+	Components      []SalaryStructureVersionComponent `json:"components,omitempty" gorm:"foreignKey:VersionID"`
+	CreatedAt       time.Time                         `json:"created_at"`
+}
+
+// SalaryStructureVersionComponent 薪资结构版本中的组件快照
+type SalaryStructureVersionComponent struct {
+	ID           uint             `json:"id" gorm:"primaryKey"`
+	VersionID    uint             `json:"version_id" gorm:"not null;index;comment:结构版本ID"`
+	ComponentID  uint             `json:"component_id" gorm:"not null;comment:薪资组件ID"`
+	Component    *SalaryComponent `json:"component,omitempty" gorm:"foreignKey:ComponentID"`
+	DefaultValue Money            `json:"default_value" gorm:"type:decimal(15,2);default:0;comment:默认值"`
+	IsRequired   bool             `json:"is_required" gorm:"comment:是否必填"`
+	CanEdit      bool             `json:"can_edit" gorm:"comment:是否可编辑"`
+	Sort         int              `json:"sort" gorm:"default:0;comment:排序"`
+}
+
+// ActiveOn 判断版本在指定日期是否有效
+func (v *SalaryStructureVersion) ActiveOn(date time.Time) bool {
+	if date.Before(v.EffectiveDate) {
+		return false
+	}
+	return v.ExpiryDate == nil || !date.After(*v.ExpiryDate)
+}
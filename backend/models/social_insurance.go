@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SocialInsurancePolicy 城市社保公积金缴费政策
+type SocialInsurancePolicy struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	CityCode      string     `json:"city_code" gorm:"size:50;not null;index;comment:城市编码(对应地理位置部门编码)"`
+	CityName      string     `json:"city_name" gorm:"size:100;comment:城市名称"`
+	EffectiveDate time.Time  `json:"effective_date" gorm:"not null;comment:生效日期"`
+	ExpiryDate    *time.Time `json:"expiry_date" gorm:"comment:失效日期"`
+	IsDefault     bool       `json:"is_default" gorm:"default:false;comment:是否默认政策(无法确定城市时使用)"`
+
+	// 社保缴费基数上下限
+	BaseFloor   float64 `json:"base_floor" gorm:"type:decimal(15,2);default:0;comment:社保缴费基数下限"`
+	BaseCeiling float64 `json:"base_ceiling" gorm:"type:decimal(15,2);default:0;comment:社保缴费基数上限(0表示不限)"`
+
+	// 社保缴费比例（小数，如 0.08 表示 8%）
+	PensionEmployeeRate      float64 `json:"pension_employee_rate" gorm:"type:decimal(6,4);default:0;comment:养老保险个人比例"`
+	PensionEmployerRate      float64 `json:"pension_employer_rate" gorm:"type:decimal(6,4);default:0;comment:养老保险单位比例"`
+	MedicalEmployeeRate      float64 `json:"medical_employee_rate" gorm:"type:decimal(6,4);default:0;comment:医疗保险个人比例"`
+	MedicalEmployerRate      float64 `json:"medical_employer_rate" gorm:"type:decimal(6,4);default:0;comment:医疗保险单位比例"`
+	UnemploymentEmployeeRate float64 `json:"unemployment_employee_rate" gorm:"type:decimal(6,4);default:0;comment:失业保险个人比例"`
+	UnemploymentEmployerRate float64 `json:"unemployment_employer_rate" gorm:"type:decimal(6,4);default:0;comment:失业保险单位比例"`
+	InjuryEmployerRate       float64 `json:"injury_employer_rate" gorm:"type:decimal(6,4);default:0;comment:工伤保险单位比例"`
+	MaternityEmployerRate    float64 `json:"maternity_employer_rate" gorm:"type:decimal(6,4);default:0;comment:生育保险单位比例"`
+
+	// 住房公积金
+	HousingFundBaseFloor    float64 `json:"housing_fund_base_floor" gorm:"type:decimal(15,2);default:0;comment:公积金缴存基数下限"`
+	HousingFundBaseCeiling  float64 `json:"housing_fund_base_ceiling" gorm:"type:decimal(15,2);default:0;comment:公积金缴存基数上限(0表示不限)"`
+	HousingFundEmployeeRate float64 `json:"housing_fund_employee_rate" gorm:"type:decimal(6,4);default:0;comment:公积金个人比例"`
+	HousingFundEmployerRate float64 `json:"housing_fund_employer_rate" gorm:"type:decimal(6,4);default:0;comment:公积金单位比例"`
+
+	// 上年度职工月平均工资，用于经济补偿封顶及一次性补偿收入免税额度
+	AverageMonthlyWage float64 `json:"average_monthly_wage" gorm:"type:decimal(15,2);default:0;comment:上年度职工月平均工资"`
+
+	Status      string         `json:"status" gorm:"size:20;default:active;comment:状态"`
+	Description string         `json:"description" gorm:"type:text;comment:描述"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// ClampBase 按社保缴费基数上下限调整缴费基数
+func (p *SocialInsurancePolicy) ClampBase(base float64) float64 {
+	return clampContributionBase(base, p.BaseFloor, p.BaseCeiling)
+}
+
+// ClampHousingFundBase 按公积金缴存基数上下限调整缴存基数
+func (p *SocialInsurancePolicy) ClampHousingFundBase(base float64) float64 {
+	return clampContributionBase(base, p.HousingFundBaseFloor, p.HousingFundBaseCeiling)
+}
+
+func clampContributionBase(base, floor, ceiling float64) float64 {
+	if base < floor {
+		base = floor
+	}
+	if ceiling > 0 && base > ceiling {
+		base = ceiling
+	}
+	return base
+}
+
+func (SocialInsurancePolicy) TableName() string { return "social_insurance_policies" }
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SpecialDeduction 个人所得税专项附加扣除申报
+type SpecialDeduction struct {
+	ID             uint                 `json:"id" gorm:"primaryKey"`
+	EmployeeID     uint                 `json:"employee_id" gorm:"not null;index;comment:员工ID"`
+	Employee       *Employee            `json:"employee,omitempty" gorm:"foreignKey:EmployeeID"`
+	Type           SpecialDeductionType `json:"type" gorm:"size:30;not null;comment:扣除类型"`
+	TaxYear        int                  `json:"tax_year" gorm:"not null;index;comment:纳税年度"`
+	StartMonth     int                  `json:"start_month" gorm:"not null;default:1;comment:开始月份"`
+	EndMonth       int                  `json:"end_month" gorm:"not null;default:12;comment:结束月份"`
+	MonthlyAmount  Money                `json:"monthly_amount" gorm:"type:decimal(15,2);not null;comment:每月扣除金额"`
+	Beneficiary    string               `json:"beneficiary" gorm:"size:100;comment:扣除对象(子女/被赡养人等)"`
+	Status         string               `json:"status" gorm:"size:20;default:active;comment:状态"`
+	RolledOverFrom *uint                `json:"rolled_over_from" gorm:"comment:结转来源申报ID"`
+	Notes          string               `json:"notes" gorm:"type:text;comment:备注"`
+	CreatedAt      time.Time            `json:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt       `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// SpecialDeductionType 专项附加扣除类型
+type SpecialDeductionType string
+
+const (
+	DeductionChildEducation      SpecialDeductionType = "child_education"       // 子女教育
+	DeductionContinuingEducation SpecialDeductionType = "continuing_education"  // 继续教育
+	DeductionHousingLoanInterest SpecialDeductionType = "housing_loan_interest" // 住房贷款利息
+	DeductionHousingRent         SpecialDeductionType = "housing_rent"          // 住房租金
+	DeductionElderlySupport      SpecialDeductionType = "elderly_support"       // 赡养老人
+	DeductionInfantCare          SpecialDeductionType = "infant_care"           // 3岁以下婴幼儿照护
+	DeductionSeriousIllness      SpecialDeductionType = "serious_illness"       // 大病医疗（仅年度汇算扣除）
+)
+
+// MonthlyLimit 返回该类型每月可扣除的上限金额
+func (t SpecialDeductionType) MonthlyLimit() float64 {
+	switch t {
+	case DeductionChildEducation, DeductionInfantCare:
+		return 2000
+	case DeductionContinuingEducation:
+		return 400
+	case DeductionHousingLoanInterest:
+		return 1000
+	case DeductionHousingRent:
+		return 1500
+	case DeductionElderlySupport:
+		return 3000
+	case DeductionSeriousIllness:
+		return 80000
+	}
+	return 0
+}
+
+// WithheldMonthly 是否在每月预扣预缴时扣除（大病医疗只能在年度汇算时扣除）
+func (t SpecialDeductionType) WithheldMonthly() bool {
+	return t != DeductionSeriousIllness
+}
+
+func (SpecialDeduction) TableName() string { return "special_deductions" }
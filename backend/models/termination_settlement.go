@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+)
+
+// SettlementType 解除劳动合同的补偿方式
+type SettlementType string
+
+const (
+	SettlementN        SettlementType = "n"        // 协商解除等：经济补偿 N
+	SettlementNPlusOne SettlementType = "n_plus_1" // 未提前30日通知解除：经济补偿 N + 1个月代通知金
+	SettlementDoubleN  SettlementType = "2n"       // 违法解除：赔偿金 2N
+)
+
+// IsValid 判断补偿方式是否受支持
+func (t SettlementType) IsValid() bool {
+	switch t {
+	case SettlementN, SettlementNPlusOne, SettlementDoubleN:
+		return true
+	}
+	return false
+}
+
+// SettlementStatus 离职结算状态
+type SettlementStatus string
+
+const (
+	SettlementDraft     SettlementStatus = "draft"     // 草稿：待人事复核
+	SettlementApproved  SettlementStatus = "approved"  // 已复核：可生成非周期发放
+	SettlementIssued    SettlementStatus = "issued"    // 已生成非周期发放
+	SettlementCancelled SettlementStatus = "cancelled" // 已取消
+)
+
+// TerminationSettlement 离职结算单：经济补偿（或赔偿金）、代通知金与未休年假工资
+type TerminationSettlement struct {
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	EmployeeID      uint           `json:"employee_id" gorm:"not null;index;comment:员工ID"`
+	Employee        *Employee      `json:"employee,omitempty" gorm:"foreignKey:EmployeeID"`
+	Type            SettlementType `json:"type" gorm:"size:20;not null;comment:补偿方式"`
+	HireDate        time.Time      `json:"hire_date" gorm:"type:date;not null;comment:入职日期"`
+	TerminationDate time.Time      `json:"termination_date" gorm:"type:date;not null;comment:离职日期(最后工作日)"`
+
+	// 经济补偿
+	ServiceMonths      int     `json:"service_months" gorm:"comment:工作年限(满月数)"`
+	CompensationMonths float64 `json:"compensation_months" gorm:"type:decimal(5,1);comment:补偿月数N"`
+	WageMonths         int     `json:"wage_months" gorm:"comment:计算月平均工资的薪资记录数"`
+	AverageMonthlyWage Money   `json:"average_monthly_wage" gorm:"type:decimal(15,2);default:0;comment:离职前12个月平均工资"`
+	LocalAverageWage   Money   `json:"local_average_wage" gorm:"type:decimal(15,2);default:0;comment:当地上年度职工月平均工资"`
+	CompensationBase   Money   `json:"compensation_base" gorm:"type:decimal(15,2);default:0;comment:补偿基数(封顶后月工资)"`
+	WageCapped         bool    `json:"wage_capped" gorm:"default:false;comment:是否按当地平均工资3倍封顶"`
+	Compensation       Money   `json:"compensation" gorm:"type:decimal(15,2);default:0;comment:经济补偿或赔偿金"`
+
+	// 代通知金
+	LastMonthWage Money `json:"last_month_wage" gorm:"type:decimal(15,2);default:0;comment:上月工资"`
+	NoticePay     Money `json:"notice_pay" gorm:"type:decimal(15,2);default:0;comment:代通知金"`
+
+	// 未休年假
+	AnnualLeaveEntitled float64 `json:"annual_leave_entitled" gorm:"type:decimal(5,1);default:0;comment:折算后当年应休年假天数"`
+	AnnualLeaveUsed     float64 `json:"annual_leave_used" gorm:"type:decimal(5,1);default:0;comment:当年已休年假天数"`
+	UnusedLeaveDays     float64 `json:"unused_leave_days" gorm:"type:decimal(5,1);default:0;comment:应休未休天数"`
+	DailyWage           Money   `json:"daily_wage" gorm:"type:decimal(15,2);default:0;comment:日工资"`
+	LeavePayout         Money   `json:"leave_payout" gorm:"type:decimal(15,2);default:0;comment:未休年假工资报酬"`
+
+	// 个税：经济补偿在当地上年职工平均工资3倍以内免税，超过部分单独适用综合所得税率表
+	TaxExemptLimit      Money `json:"tax_exempt_limit" gorm:"type:decimal(15,2);default:0;comment:免税额度"`
+	TaxableCompensation Money `json:"taxable_compensation" gorm:"type:decimal(15,2);default:0;comment:超过免税额度部分"`
+	CompensationTax     Money `json:"compensation_tax" gorm:"type:decimal(15,2);default:0;comment:经济补偿单独计税税额"`
+
+	TotalAmount Money            `json:"total_amount" gorm:"type:decimal(15,2);default:0;comment:结算应发合计"`
+	Status      SettlementStatus `json:"status" gorm:"size:20;default:draft;index;comment:状态"`
+	Trace       string           `json:"trace" gorm:"type:text;comment:计算过程"`
+	Notes       string           `json:"notes" gorm:"type:text;comment:备注"`
+
+	OffCyclePeriodID *uint          `json:"off_cycle_period_id" gorm:"comment:生成的非周期发放ID"`
+	OffCyclePeriod   *PayrollPeriod `json:"off_cycle_period,omitempty" gorm:"foreignKey:OffCyclePeriodID"`
+	SalaryID         *uint          `json:"salary_id" gorm:"comment:生成的薪资记录ID"`
+
+	CreatedBy  uint       `json:"created_by" gorm:"comment:创建人ID"`
+	ApprovedBy *uint      `json:"approved_by" gorm:"comment:复核人ID"`
+	ApprovedAt *time.Time `json:"approved_at" gorm:"comment:复核时间"`
+	IssuedAt   *time.Time `json:"issued_at" gorm:"comment:生成发放时间"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// LeaveBalance 员工年度假期额度，已休天数按已批准的请假记录统计
+type LeaveBalance struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	EmployeeID      uint      `json:"employee_id" gorm:"not null;uniqueIndex:idx_leave_balance;comment:员工ID"`
+	Employee        *Employee `json:"employee,omitempty" gorm:"foreignKey:EmployeeID"`
+	Year            int       `json:"year" gorm:"not null;uniqueIndex:idx_leave_balance;comment:年度"`
+	LeaveType       string    `json:"leave_type" gorm:"size:20;not null;default:annual;uniqueIndex:idx_leave_balance;comment:假期类型"`
+	EntitledDays    float64   `json:"entitled_days" gorm:"type:decimal(5,1);default:0;comment:全年应休天数"`
+	CarriedOverDays float64   `json:"carried_over_days" gorm:"type:decimal(5,1);default:0;comment:上年结转天数"`
+	Notes           string    `json:"notes" gorm:"type:text;comment:备注"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
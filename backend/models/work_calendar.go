@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+)
+
+// CalendarDayType 工作日历日期类型
+type CalendarDayType string
+
+const (
+	CalendarDayHoliday CalendarDayType = "holiday" // 法定节假日（含调休放假）
+	CalendarDayWorkday CalendarDayType = "workday" // 调休上班的周末
+)
+
+// WorkCalendarDay 工作日历中的例外日期，未登记的日期按周一至周五上班处理
+type WorkCalendarDay struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	Date      time.Time       `json:"date" gorm:"type:date;not null;uniqueIndex;comment:日期"`
+	Type      CalendarDayType `json:"type" gorm:"size:20;not null;comment:日期类型"`
+	Name      string          `json:"name" gorm:"size:100;comment:名称(如春节、国庆调休)"`
+	Statutory bool            `json:"statutory" gorm:"default:false;comment:是否法定节假日(加班按三倍计薪)"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// 请假类型
+const (
+	LeaveTypeAnnual      = "annual"
+	LeaveTypeSick        = "sick"
+	LeaveTypePersonal    = "personal"
+	LeaveTypeMaternity   = "maternity"
+	LeaveTypePaternity   = "paternity"
+	LeaveTypeMarriage    = "marriage"
+	LeaveTypeBereavement = "bereavement"
+	LeaveTypeEmergency   = "emergency"
+	LeaveTypeUnpaid      = "unpaid"
+)
+
+// LeavePayCategory 请假的计薪类别
+type LeavePayCategory string
+
+const (
+	LeavePaid   LeavePayCategory = "paid"   // 带薪假，视同出勤
+	LeaveUnpaid LeavePayCategory = "unpaid" // 无薪假，计入缺勤
+	LeaveSick   LeavePayCategory = "sick"   // 病假，单独统计由公式决定扣减比例
+)
+
+// LeavePayCategoryOf 根据请假类型判断计薪类别，未知类型按无薪假处理
+func LeavePayCategoryOf(leaveType string) LeavePayCategory {
+	switch leaveType {
+	case LeaveTypeAnnual, LeaveTypeMaternity, LeaveTypePaternity, LeaveTypeMarriage, LeaveTypeBereavement:
+		return LeavePaid
+	case LeaveTypeSick:
+		return LeaveSick
+	default:
+		return LeaveUnpaid
+	}
+}
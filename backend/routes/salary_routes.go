@@ -65,29 +65,34 @@ func SetupSalaryRoutes(router *gin.RouterGroup, container *utils.Container) {
 			middleware.RequireAnyRole("admin", "hr"),
 			utils.CreateHandlerFunc[controllers.SalaryController](container, "ExportSalaryReport"))
 
-		// 个人薪资查询
+		// 个人薪资查询：需携带 /salary/self-service/verify 签发的二次验证令牌
 		salaries.GET("/my-salary",
 			middleware.RequireAnyRole("admin", "hr", "user"),
+			middleware.RequireStepUp(utils.ScopeSalarySelfService),
 			utils.CreateHandlerFunc[controllers.SalaryController](container, "GetMySalary"))
 
 		// 个人增强版薪资查询
 		salaries.GET("/my-enhanced-salary",
 			middleware.RequireAnyRole("admin", "hr", "user"),
+			middleware.RequireStepUp(utils.ScopeSalarySelfService),
 			utils.CreateHandlerFunc[controllers.SalaryController](container, "GetMyEnhancedSalary"))
 
 		// 个人薪资历史
 		salaries.GET("/my-salary-history",
 			middleware.RequireAnyRole("admin", "hr", "user"),
+			middleware.RequireStepUp(utils.ScopeSalarySelfService),
 			utils.CreateHandlerFunc[controllers.SalaryController](container, "GetMySalaryHistory"))
 
 		// 个人发放记录
 		salaries.GET("/my-payroll-records",
 			middleware.RequireAnyRole("admin", "hr", "user"),
+			middleware.RequireStepUp(utils.ScopeSalarySelfService),
 			utils.CreateHandlerFunc[controllers.SalaryController](container, "GetMyPayrollRecords"))
 
 		// 个人薪资仪表板
 		salaries.GET("/my-dashboard",
 			middleware.RequireAnyRole("admin", "hr", "user"),
+			middleware.RequireStepUp(utils.ScopeSalarySelfService),
 			utils.CreateHandlerFunc[controllers.SalaryController](container, "GetMySalaryDashboard"))
 
 		// 薪资发放
@@ -159,6 +164,180 @@ func SetupSalaryRoutes(router *gin.RouterGroup, container *utils.Container) {
 		structures.GET("",
 			middleware.RequireAnyRole("admin", "hr"),
 			utils.CreateHandlerFunc[controllers.SalaryController](container, "GetSalaryStructures"))
+
+		structures.GET("/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.SalaryController](container, "GetSalaryStructure"))
+
+		structures.PUT("/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.SalaryController](container, "UpdateSalaryStructure"))
+
+		// 结构版本历史与版本对比
+		structures.GET("/:id/versions",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.SalaryStructureVersionController](container, "GetVersions"))
+
+		structures.GET("/:id/versions/diff",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.SalaryStructureVersionController](container, "CompareVersions"))
+
+		structures.GET("/:id/versions/:version",
+			middleware.ValidateNumericID(),
+			middleware.ValidateNumericParam("version"),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.SalaryStructureVersionController](container, "GetVersion"))
+	}
+
+	// ========================= Special Additional Deductions =========================
+	deductions := router.Group("/salary/tax-deductions")
+	deductions.Use(middleware.JWTAuth())
+	{
+		deductions.GET("",
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.TaxDeductionController](container, "GetDeductions"))
+
+		deductions.GET("/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.TaxDeductionController](container, "GetDeduction"))
+
+		deductions.POST("",
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.TaxDeductionController](container, "CreateDeduction"))
+
+		deductions.PUT("/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.TaxDeductionController](container, "UpdateDeduction"))
+
+		deductions.DELETE("/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.TaxDeductionController](container, "DeleteDeduction"))
+
+		// 年度结转
+		deductions.POST("/rollover",
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.TaxDeductionController](container, "RollOverDeductions"))
+	}
+
+	// ========================= Social Insurance Policies =========================
+	policies := router.Group("/salary/insurance-policies")
+	policies.Use(middleware.JWTAuth())
+	{
+		policies.GET("",
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.SocialInsuranceController](container, "GetPolicies"))
+
+		policies.GET("/preview",
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.SocialInsuranceController](container, "PreviewContributions"))
+
+		policies.GET("/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.SocialInsuranceController](container, "GetPolicy"))
+
+		policies.POST("",
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.SocialInsuranceController](container, "CreatePolicy"))
+
+		policies.PUT("/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.SocialInsuranceController](container, "UpdatePolicy"))
+
+		policies.DELETE("/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireRole("admin"),
+			utils.CreateHandlerFunc[controllers.SocialInsuranceController](container, "DeletePolicy"))
+	}
+
+	// ========================= Work Calendar =========================
+	calendar := router.Group("/salary/calendar")
+	calendar.Use(middleware.JWTAuth())
+	{
+		calendar.GET("",
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.WorkCalendarController](container, "GetCalendarDays"))
+
+		calendar.GET("/working-days",
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.WorkCalendarController](container, "GetWorkingDays"))
+
+		calendar.POST("",
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.WorkCalendarController](container, "SaveCalendarDays"))
+
+		calendar.DELETE("/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireRole("admin"),
+			utils.CreateHandlerFunc[controllers.WorkCalendarController](container, "DeleteCalendarDay"))
+	}
+
+	// ========================= Retro Pay =========================
+	retro := router.Group("/salary/retro-pay")
+	retro.Use(middleware.JWTAuth())
+	{
+		retro.GET("",
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.RetroPayController](container, "GetRetroPayReport"))
+
+		retro.POST("/adjustments/:id/process",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.RetroPayController](container, "ProcessAdjustment"))
+
+		retro.POST("/:id/cancel",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.RetroPayController](container, "CancelRetroPayItem"))
+	}
+
+	// ========================= Payroll Calculation Jobs =========================
+	jobs := router.Group("/payroll/jobs")
+	jobs.Use(middleware.JWTAuth())
+	{
+		jobs.POST("",
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.PayrollJobController](container, "StartJob"))
+
+		jobs.GET("",
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.PayrollJobController](container, "GetJobs"))
+
+		jobs.GET("/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.PayrollJobController](container, "GetJob"))
+
+		jobs.GET("/:id/items",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.PayrollJobController](container, "GetJobItems"))
+
+		jobs.POST("/:id/cancel",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.PayrollJobController](container, "CancelJob"))
+
+		jobs.POST("/:id/resume",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.PayrollJobController](container, "ResumeJob"))
 	}
 
 	// ========================= Payroll Period Management =========================
@@ -181,8 +360,61 @@ func SetupSalaryRoutes(router *gin.RouterGroup, container *utils.Container) {
 
 		periods.PUT("/:id/unlock",
 			middleware.ValidateNumericID(),
-			middleware.RequireAnyRole("admin", "hr"),
+			middleware.RequireRole("admin"),
+			middleware.ValidateAndBindJSON(),
 			utils.CreateHandlerFunc[controllers.SalaryController](container, "UnlockPayrollPeriod"))
+
+		// 关账流程
+		periods.PUT("/:id/status",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.SalaryController](container, "TransitionPayrollPeriod"))
+
+		periods.POST("/:id/reopen",
+			middleware.ValidateNumericID(),
+			middleware.RequireRole("admin"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.SalaryController](container, "ReopenPayrollPeriod"))
+
+		// 非周期发放（离职结算、更正补发、一次性奖励等）
+		periods.POST("/:id/off-cycle",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.SalaryController](container, "CreateOffCycleRun"))
+
+		periods.GET("/:id/off-cycle",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.SalaryController](container, "GetOffCycleRuns"))
+
+		periods.GET("/:id/events",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.SalaryController](container, "GetPayrollPeriodEvents"))
+
+		// 与上一周期的薪资差异分析
+		periods.GET("/:id/variance",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.PayrollVarianceController](container, "GetVarianceReport"))
+
+		periods.GET("/:id/variance/export",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.PayrollVarianceController](container, "ExportVarianceReport"))
+
+		periods.GET("/:id/payslips",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.PayslipController](container, "DownloadPeriodPayslips"))
+
+		// 总账凭证（format=json|csv）
+		periods.GET("/:id/gl-journal",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.GLJournalController](container, "GetPeriodJournal"))
 	}
 
 	// ========================= Enhanced Salary Management =========================
@@ -200,6 +432,16 @@ func SetupSalaryRoutes(router *gin.RouterGroup, container *utils.Container) {
 			middleware.ValidateAndBindJSON(),
 			utils.CreateHandlerFunc[controllers.SalaryController](container, "BatchCalculateSalaries"))
 
+		enhanced.POST("/bonus",
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.SalaryController](container, "CalculateBonusPayments"))
+
+		enhanced.POST("/off-cycle",
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.SalaryController](container, "CalculateOffCyclePayments"))
+
 		// 薪资记录管理
 		enhanced.GET("",
 			middleware.RequireAnyRole("admin", "hr"),
@@ -235,6 +477,223 @@ func SetupSalaryRoutes(router *gin.RouterGroup, container *utils.Container) {
 			utils.CreateHandlerFunc[controllers.SalaryController](container, "BulkApproveSalaries"))
 	}
 
+	// ========================= Payslips =========================
+	payslips := router.Group("/salary/payslips")
+	payslips.Use(middleware.JWTAuth())
+	{
+		payslips.GET("/templates",
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.PayslipController](container, "GetTemplates"))
+
+		payslips.POST("/templates",
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.PayslipController](container, "CreateTemplate"))
+
+		payslips.PUT("/templates/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.PayslipController](container, "UpdateTemplate"))
+
+		payslips.DELETE("/templates/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireRole("admin"),
+			utils.CreateHandlerFunc[controllers.PayslipController](container, "DeleteTemplate"))
+
+		// 员工本人经 /salary/self-service/payslips/:id 查看、下载
+		payslips.GET("/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.PayslipController](container, "GetPayslip"))
+
+		payslips.GET("/:id/pdf",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.PayslipController](container, "DownloadPayslip"))
+	}
+
+	// ========================= General Ledger =========================
+	glMappings := router.Group("/payroll/gl-mappings")
+	glMappings.Use(middleware.JWTAuth())
+	{
+		glMappings.GET("",
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.GLJournalController](container, "GetAccountMappings"))
+
+		glMappings.POST("",
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.GLJournalController](container, "CreateAccountMapping"))
+
+		glMappings.PUT("/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.GLJournalController](container, "UpdateAccountMapping"))
+
+		glMappings.DELETE("/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireRole("admin"),
+			utils.CreateHandlerFunc[controllers.GLJournalController](container, "DeleteAccountMapping"))
+	}
+
+	// ========================= Exchange Rates =========================
+	exchangeRates := router.Group("/payroll/exchange-rates")
+	exchangeRates.Use(middleware.JWTAuth())
+	{
+		exchangeRates.GET("",
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.ExchangeRateController](container, "GetExchangeRates"))
+
+		// 折算为集团报告币种（amount、currency、date）
+		exchangeRates.GET("/convert",
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.ExchangeRateController](container, "ConvertAmount"))
+
+		exchangeRates.POST("",
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.ExchangeRateController](container, "CreateExchangeRate"))
+
+		exchangeRates.PUT("/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.ExchangeRateController](container, "UpdateExchangeRate"))
+
+		exchangeRates.DELETE("/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireRole("admin"),
+			utils.CreateHandlerFunc[controllers.ExchangeRateController](container, "DeleteExchangeRate"))
+	}
+
+	// ========================= Termination Settlements =========================
+	settlements := router.Group("/payroll/settlements")
+	settlements.Use(middleware.JWTAuth())
+	{
+		settlements.GET("",
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.TerminationSettlementController](container, "GetSettlements"))
+
+		// 试算经济补偿（N / N+1 / 2N）、代通知金与未休年假工资
+		settlements.POST("/preview",
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.TerminationSettlementController](container, "PreviewSettlement"))
+
+		settlements.POST("",
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.TerminationSettlementController](container, "CreateSettlement"))
+
+		settlements.GET("/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.TerminationSettlementController](container, "GetSettlement"))
+
+		settlements.POST("/:id/approve",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.TerminationSettlementController](container, "ApproveSettlement"))
+
+		settlements.POST("/:id/cancel",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.TerminationSettlementController](container, "CancelSettlement"))
+
+		// 生成离职结算类型的非周期发放，之后按薪资审批与支付批次流程发放
+		settlements.POST("/:id/issue",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.TerminationSettlementController](container, "IssueSettlement"))
+	}
+
+	leaveBalances := router.Group("/payroll/leave-balances")
+	leaveBalances.Use(middleware.JWTAuth())
+	{
+		leaveBalances.GET("",
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.TerminationSettlementController](container, "GetLeaveBalances"))
+
+		leaveBalances.PUT("",
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.TerminationSettlementController](container, "SaveLeaveBalance"))
+	}
+
+	// ========================= Salary Self-Service =========================
+	selfService := router.Group("/salary/self-service")
+	selfService.Use(middleware.JWTAuth())
+	{
+		// 二次验证：工资单查询密码或登录密码，通过后签发短期令牌
+		selfService.POST("/verify",
+			middleware.RequireAnyRole("admin", "hr", "user"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.SalarySelfServiceController](container, "VerifyStepUp"))
+
+		selfService.PUT("/pin",
+			middleware.RequireAnyRole("admin", "hr", "user"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.SalarySelfServiceController](container, "SetPayslipPIN"))
+
+		selfService.GET("/payslips/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr", "user"),
+			middleware.RequireStepUp(utils.ScopeSalarySelfService),
+			utils.CreateHandlerFunc[controllers.PayslipController](container, "GetMyPayslip"))
+
+		selfService.GET("/payslips/:id/pdf",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr", "user"),
+			middleware.RequireStepUp(utils.ScopeSalarySelfService),
+			utils.CreateHandlerFunc[controllers.PayslipController](container, "DownloadMyPayslip"))
+
+		selfService.POST("/payslips/:id/confirm",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr", "user"),
+			middleware.RequireStepUp(utils.ScopeSalarySelfService),
+			utils.CreateHandlerFunc[controllers.SalarySelfServiceController](container, "ConfirmPayslip"))
+
+		selfService.POST("/payslips/:id/dispute",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr", "user"),
+			middleware.RequireStepUp(utils.ScopeSalarySelfService),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.SalarySelfServiceController](container, "DisputePayslip"))
+
+		selfService.GET("/disputes",
+			middleware.RequireAnyRole("admin", "hr", "user"),
+			middleware.RequireStepUp(utils.ScopeSalarySelfService),
+			utils.CreateHandlerFunc[controllers.SalarySelfServiceController](container, "GetMyDisputes"))
+	}
+
+	disputes := router.Group("/payroll/payslip-disputes")
+	disputes.Use(middleware.JWTAuth())
+	{
+		disputes.GET("",
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.SalarySelfServiceController](container, "GetDisputes"))
+
+		disputes.GET("/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.SalarySelfServiceController](container, "GetDispute"))
+
+		disputes.POST("/:id/assign",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.SalarySelfServiceController](container, "AssignDispute"))
+
+		disputes.POST("/:id/resolve",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.SalarySelfServiceController](container, "ResolveDispute"))
+	}
+
 	// ========================= Payment Processing =========================
 	payments := router.Group("/payroll/payments")
 	payments.Use(middleware.JWTAuth())
@@ -253,6 +712,107 @@ func SetupSalaryRoutes(router *gin.RouterGroup, container *utils.Container) {
 			middleware.RequireAnyRole("admin", "hr"),
 			middleware.ValidateAndBindJSON(),
 			utils.CreateHandlerFunc[controllers.SalaryController](container, "ProcessPaymentBatch"))
+
+		// 银行代发文件
+		payments.GET("/bank-formats",
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.BankFileController](container, "GetBankFileFormats"))
+
+		payments.GET("/batches/:id/bank-file",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.BankFileController](container, "DownloadBankFile"))
+
+		payments.GET("/batches/:id/bank-files",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.BankFileController](container, "GetBankFiles"))
+
+		// 银行回盘对账
+		payments.POST("/batches/:id/bank-returns",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.BankReconciliationController](container, "ImportReturnFile"))
+
+		payments.GET("/batches/:id/bank-returns",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.BankReconciliationController](container, "GetReturnImports"))
+
+		payments.GET("/bank-returns/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.BankReconciliationController](container, "GetReturnImport"))
+	}
+
+	// ========================= Compensation Review Cycles =========================
+	reviews := router.Group("/salary/reviews")
+	reviews.Use(middleware.JWTAuth())
+	{
+		reviews.GET("",
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.CompensationReviewController](container, "GetCycles"))
+
+		reviews.POST("",
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.CompensationReviewController](container, "CreateCycle"))
+
+		reviews.GET("/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.CompensationReviewController](container, "GetCycle"))
+
+		reviews.PUT("/:id",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.CompensationReviewController](container, "UpdateCycle"))
+
+		reviews.POST("/:id/open",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.CompensationReviewController](container, "OpenCycle"))
+
+		reviews.POST("/:id/close",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.CompensationReviewController](container, "CloseProposals"))
+
+		reviews.POST("/:id/cancel",
+			middleware.ValidateNumericID(),
+			middleware.RequireRole("admin"),
+			utils.CreateHandlerFunc[controllers.CompensationReviewController](container, "CancelCycle"))
+
+		reviews.POST("/:id/finalize",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.CompensationReviewController](container, "FinalizeCycle"))
+
+		reviews.GET("/:id/summary",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.CompensationReviewController](container, "GetSummary"))
+
+		reviews.POST("/:id/proposals/review",
+			middleware.ValidateNumericID(),
+			middleware.RequireAnyRole("admin", "hr"),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.CompensationReviewController](container, "ReviewProposals"))
+
+		// 经理为直接下属提交调薪建议（范围在服务内按汇报关系校验）
+		reviews.GET("/:id/worksheet",
+			middleware.ValidateNumericID(),
+			utils.CreateHandlerFunc[controllers.CompensationReviewController](container, "GetWorksheet"))
+
+		reviews.POST("/:id/proposals",
+			middleware.ValidateNumericID(),
+			middleware.ValidateAndBindJSON(),
+			utils.CreateHandlerFunc[controllers.CompensationReviewController](container, "SaveProposals"))
+
+		reviews.POST("/:id/proposals/submit",
+			middleware.ValidateNumericID(),
+			utils.CreateHandlerFunc[controllers.CompensationReviewController](container, "SubmitProposals"))
 	}
 
 	// ========================= Analytics and Reporting =========================
@@ -269,7 +829,12 @@ func SetupSalaryRoutes(router *gin.RouterGroup, container *utils.Container) {
 
 		analytics.GET("/export",
 			middleware.RequireAnyRole("admin", "hr"),
-			utils.CreateHandlerFunc[controllers.SalaryController](container, "ExportSalaryReport"))
+			utils.CreateHandlerFunc[controllers.SalaryController](container, "ExportEnhancedSalaryReport"))
+
+		// 薪酬带宽：比较比率、渗透率、超带宽员工与带宽重叠
+		analytics.GET("/compa-ratio",
+			middleware.RequireAnyRole("admin", "hr"),
+			utils.CreateHandlerFunc[controllers.CompensationBandController](container, "GetCompaRatioReport"))
 	}
 
 	// ========================= Utilities =========================
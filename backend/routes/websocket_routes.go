@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"reflect"
+
 	"gin-project/controllers"
 	"gin-project/services"
 	"gin-project/utils"
@@ -9,8 +11,11 @@ import (
 )
 
 func SetupWebSocketRoutes(router *gin.RouterGroup, container *utils.Container) {
-	// 创建WebSocket服务实例
-	wsService := services.NewWebSocketService()
+	// 使用容器中的WebSocket服务单例，保证业务服务推送的消息能送达已连接的客户端
+	wsService, ok := resolveWebSocketService(container)
+	if !ok {
+		wsService = services.NewWebSocketService()
+	}
 	wsService.Run() // 启动WebSocket服务
 
 	// 创建WebSocket控制器
@@ -36,3 +41,12 @@ func SetupWebSocketRoutes(router *gin.RouterGroup, container *utils.Container) {
 		wsGroup.POST("/notify", wsController.NotifyEvent)
 	}
 }
+
+func resolveWebSocketService(container *utils.Container) (*services.WebSocketService, bool) {
+	instance, err := container.Resolve(reflect.TypeOf((*services.WebSocketService)(nil)))
+	if err != nil {
+		return nil, false
+	}
+	wsService, ok := instance.(*services.WebSocketService)
+	return wsService, ok
+}
@@ -92,6 +92,10 @@ func (as *AttendanceService) CheckIn(employeeID uint, location, remark string) (
 		return nil, err
 	}
 
+	if err := ensureDatesEditable(as.db, now, now); err != nil {
+		return nil, err
+	}
+
 	// 创建签到记录
 	attendance := &models.Attendance{
 		EmployeeID:  employeeID,
@@ -133,6 +137,10 @@ func (as *AttendanceService) CheckOut(employeeID uint, location, remark string)
 		return nil, fmt.Errorf("今日已签退")
 	}
 
+	if err := ensureDatesEditable(as.db, attendance.Date, attendance.Date); err != nil {
+		return nil, err
+	}
+
 	// 更新签退时间
 	attendance.CheckOutTime = &now
 	if attendance.Remark != "" {
@@ -266,6 +274,10 @@ func (as *AttendanceService) GetAttendanceStatistics(employeeID uint, month stri
 
 // CreateLeave 创建请假申请
 func (as *AttendanceService) CreateLeave(leave *models.Leave) (*models.Leave, error) {
+	if err := ensureDatesEditable(as.db, leave.StartDate, leave.EndDate); err != nil {
+		return nil, err
+	}
+
 	// 计算请假天数
 	duration := leave.EndDate.Sub(leave.StartDate)
 	leave.Days = duration.Hours() / 24
@@ -331,6 +343,11 @@ func (as *AttendanceService) ApproveLeave(leaveID, approverID uint, status, note
 		return nil, fmt.Errorf("该请假已被处理")
 	}
 
+	// 审批结果会改变周期内的缺勤与请假天数
+	if err := ensureDatesEditable(as.db, leave.StartDate, leave.EndDate); err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
 	leave.Status = status
 	leave.ApproverID = &approverID
@@ -98,7 +98,7 @@ func (s *AuthService) generateJWT(userID, role string) (string, error) {
 // ValidateToken validates a JWT token and returns user information
 func (s *AuthService) ValidateToken(tokenString string) (*models.User, error) {
 	// 验证JWT token
-	claims, err := utils.DefaultJWTService.ValidateToken(tokenString)
+	claims, err := utils.DefaultJWTService.ValidateSessionToken(tokenString)
 	if err != nil {
 		return nil, err
 	}
@@ -134,7 +134,7 @@ func (s *AuthService) ValidateToken(tokenString string) (*models.User, error) {
 // RefreshToken refreshes an existing JWT token
 func (s *AuthService) RefreshToken(tokenString string) (*models.LoginResponse, error) {
 	// 验证当前token
-	claims, err := utils.DefaultJWTService.ValidateToken(tokenString)
+	claims, err := utils.DefaultJWTService.ValidateSessionToken(tokenString)
 	if err != nil {
 		return nil, err
 	}
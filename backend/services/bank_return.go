@@ -0,0 +1,714 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-project/models"
+	"gin-project/utils"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"gorm.io/gorm"
+)
+
+// ========================= Bank Return Files =========================
+
+// BankReturnStatus 回盘明细的处理结果
+type BankReturnStatus string
+
+const (
+	BankReturnSucceeded BankReturnStatus = "succeeded" // 入账成功
+	BankReturnFailed    BankReturnStatus = "failed"    // 退票/拒绝
+	BankReturnPending   BankReturnStatus = "pending"   // 银行仍在处理
+)
+
+// BankReturnLine 回盘文件解析出的明细
+type BankReturnLine struct {
+	LineNumber     int              `json:"line_number"`
+	Reference      string           `json:"reference"`
+	Account        string           `json:"account"`
+	Name           string           `json:"name"`
+	Amount         models.Money     `json:"amount"`
+	Status         BankReturnStatus `json:"status"`
+	TransactionRef string           `json:"transaction_ref"`
+	Message        string           `json:"message"`
+	Raw            string           `json:"raw"`
+}
+
+// BankReturnParser 银行回盘文件解析器，格式标识与代发文件导出器一致
+type BankReturnParser interface {
+	Format() BankFileFormat
+	Parse(content []byte) ([]BankReturnLine, error)
+}
+
+var bankReturnParsers = map[BankFileFormat]BankReturnParser{}
+
+// RegisterBankReturnParser 注册回盘文件解析器，同一格式以后注册者为准
+func RegisterBankReturnParser(parser BankReturnParser) {
+	bankReturnParsers[parser.Format()] = parser
+}
+
+// BankReturnParserFor 按格式获取已注册的回盘解析器
+func BankReturnParserFor(format BankFileFormat) (BankReturnParser, bool) {
+	parser, ok := bankReturnParsers[format]
+	return parser, ok
+}
+
+func init() {
+	RegisterBankReturnParser(csvBankReturnParser{})
+	RegisterBankReturnParser(pain002BankReturnParser{})
+	RegisterBankReturnParser(icbcBankReturnParser{})
+}
+
+// recordIDFromReference 从代发参考号解析发放记录ID
+func recordIDFromReference(reference string) (uint, bool) {
+	reference = strings.TrimSpace(reference)
+	if !strings.HasPrefix(reference, "PR") {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(reference, "PR"), 10, 32)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+type BankReconciliationServiceInterface interface {
+	ImportReturnFile(batchID uint, format BankFileFormat, fileName string, content []byte, userID uint) (*models.BankReturnImport, error)
+	GetReturnImports(batchID uint) ([]models.BankReturnImport, error)
+	GetReturnImport(id uint) (*models.BankReturnImport, error)
+}
+
+type BankReconciliationService struct {
+	db *gorm.DB
+}
+
+func NewBankReconciliationService(db *gorm.DB) BankReconciliationServiceInterface {
+	return &BankReconciliationService{db: db}
+}
+
+// ImportReturnFile 导入银行回盘文件：按参考号（其次账号+金额）匹配发放记录并更新状态，
+// 重算批次成功/失败笔数，记录无法对账的明细，并为新失败的记录生成重发批次
+func (s *BankReconciliationService) ImportReturnFile(batchID uint, format BankFileFormat, fileName string, content []byte, userID uint) (*models.BankReturnImport, error) {
+	parser, ok := BankReturnParserFor(format)
+	if !ok {
+		return nil, &utils.ValidationError{Message: fmt.Sprintf("不支持的回盘文件格式: %s", format)}
+	}
+	if userID == 0 {
+		return nil, &utils.ValidationError{Message: "无法识别操作人，不能导入回盘文件"}
+	}
+	lines, err := parser.Parse(content)
+	if err != nil {
+		return nil, &utils.ValidationError{Message: "回盘文件解析失败: " + err.Error()}
+	}
+	if len(lines) == 0 {
+		return nil, &utils.ValidationError{Message: "回盘文件没有明细"}
+	}
+
+	hash := sha256.Sum256(content)
+	returnImport := &models.BankReturnImport{
+		PaymentBatchID: batchID,
+		Format:         string(format),
+		FileName:       fileName,
+		FileHash:       hex.EncodeToString(hash[:]),
+		TotalLines:     len(lines),
+		ImportedBy:     userID,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var batch models.PaymentBatch
+		if err := tx.Preload("Records").First(&batch, batchID).Error; err != nil {
+			return &utils.ValidationError{Message: "支付批次不存在"}
+		}
+		if batch.Status == models.BatchStatusCancelled {
+			return utils.NewConflictError("支付批次已取消")
+		}
+
+		var imported int64
+		if err := tx.Model(&models.BankReturnImport{}).
+			Where("payment_batch_id = ? AND file_hash = ?", batchID, returnImport.FileHash).
+			Count(&imported).Error; err != nil {
+			return err
+		}
+		if imported > 0 {
+			return utils.NewConflictError("该回盘文件已导入")
+		}
+		if err := tx.Create(returnImport).Error; err != nil {
+			return err
+		}
+
+		reconciliation := ReconcileBankReturn(batch.Records, lines)
+		returnImport.SucceededLines = reconciliation.Succeeded
+		returnImport.FailedLines = reconciliation.Failed
+		returnImport.PendingLines = reconciliation.Pending
+		returnImport.ExceptionLines = len(reconciliation.Exceptions)
+
+		now := time.Now()
+		for _, record := range reconciliation.Changed {
+			updates := map[string]interface{}{
+				"status":          record.Status,
+				"failure_reason":  record.FailureReason,
+				"transaction_ref": record.TransactionRef,
+			}
+			if record.Status == models.PayrollStatusCompleted {
+				updates["completed_date"] = now
+			}
+			if err := tx.Model(&models.EnhancedPayrollRecord{}).Where("id = ?", record.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+			if record.Status == models.PayrollStatusCompleted {
+				if err := tx.Model(&models.EnhancedSalary{}).Where("id = ?", record.SalaryID).
+					Update("status", "paid").Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		for i := range reconciliation.Exceptions {
+			reconciliation.Exceptions[i].ImportID = returnImport.ID
+		}
+		if len(reconciliation.Exceptions) > 0 {
+			if err := tx.Create(&reconciliation.Exceptions).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := refreshBatchResults(tx, &batch); err != nil {
+			return err
+		}
+
+		if reconciliation.Failed > 0 {
+			reissue, err := createReissueBatch(tx, &batch, returnImport.ID, userID)
+			if err != nil {
+				return err
+			}
+			if reissue != nil {
+				returnImport.ReissueBatchID = &reissue.ID
+			}
+		}
+		return tx.Save(returnImport).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetReturnImport(returnImport.ID)
+}
+
+// GetReturnImports 获取支付批次的回盘导入记录
+func (s *BankReconciliationService) GetReturnImports(batchID uint) ([]models.BankReturnImport, error) {
+	var imports []models.BankReturnImport
+	if err := s.db.Where("payment_batch_id = ?", batchID).Order("created_at DESC").Find(&imports).Error; err != nil {
+		return nil, err
+	}
+	return imports, nil
+}
+
+// GetReturnImport 获取回盘导入详情及异常明细
+func (s *BankReconciliationService) GetReturnImport(id uint) (*models.BankReturnImport, error) {
+	var returnImport models.BankReturnImport
+	if err := s.db.Preload("Exceptions").First(&returnImport, id).Error; err != nil {
+		return nil, err
+	}
+	return &returnImport, nil
+}
+
+// BankReconciliation 回盘明细与发放记录的匹配结果
+type BankReconciliation struct {
+	Changed    []*models.EnhancedPayrollRecord // 状态发生变化的发放记录
+	Exceptions []models.BankReturnException
+	Succeeded  int
+	Failed     int
+	Pending    int
+}
+
+// ReconcileBankReturn 在内存中将回盘明细与批次发放记录逐条匹配，不修改传入的记录
+func ReconcileBankReturn(records []models.EnhancedPayrollRecord, lines []BankReturnLine) *BankReconciliation {
+	r := &bankReconciler{
+		records: make(map[uint]*models.EnhancedPayrollRecord, len(records)),
+		seen:    make(map[uint]bool),
+		result:  &BankReconciliation{},
+	}
+	for i := range records {
+		record := records[i]
+		r.records[record.ID] = &record
+		r.order = append(r.order, record.ID)
+	}
+	for _, line := range lines {
+		r.apply(line)
+	}
+	return r.result
+}
+
+type bankReconciler struct {
+	records map[uint]*models.EnhancedPayrollRecord
+	order   []uint
+	seen    map[uint]bool
+	result  *BankReconciliation
+}
+
+// match 优先按参考号匹配；参考号缺失时按账号与金额匹配，且仅在唯一命中时采用
+func (r *bankReconciler) match(line BankReturnLine) *models.EnhancedPayrollRecord {
+	if id, ok := recordIDFromReference(line.Reference); ok {
+		return r.records[id]
+	}
+	if line.Account == "" {
+		return nil
+	}
+	var found *models.EnhancedPayrollRecord
+	for _, id := range r.order {
+		record := r.records[id]
+		if record.BankAccount != line.Account || record.PaymentAmount != line.Amount || r.seen[record.ID] {
+			continue
+		}
+		if found != nil {
+			return nil
+		}
+		found = record
+	}
+	return found
+}
+
+func (r *bankReconciler) apply(line BankReturnLine) {
+	record := r.match(line)
+	if record == nil {
+		r.exception(line, nil, models.ReturnExceptionUnmatched, "未找到对应的发放记录")
+		return
+	}
+	if r.seen[record.ID] {
+		r.exception(line, record, models.ReturnExceptionDuplicate, "同一发放记录在文件中出现多次")
+		return
+	}
+	r.seen[record.ID] = true
+
+	if line.Amount.Sign() > 0 && line.Amount != record.PaymentAmount {
+		r.exception(line, record, models.ReturnExceptionAmountMismatch,
+			fmt.Sprintf("回盘金额 %s 与发放金额 %s 不一致", line.Amount, record.PaymentAmount))
+		return
+	}
+
+	if record.Status == models.PayrollStatusReissued {
+		if line.Status == BankReturnSucceeded {
+			r.exception(line, record, models.ReturnExceptionConflict, "发放记录已转入重发批次，回盘却为成功，请核查是否重复入账")
+			return
+		}
+		if line.Status == BankReturnFailed {
+			r.result.Failed++
+		} else {
+			r.result.Pending++
+		}
+		return
+	}
+
+	switch line.Status {
+	case BankReturnSucceeded:
+		if record.Status == models.PayrollStatusCompleted {
+			r.result.Succeeded++
+			return
+		}
+		record.Status = models.PayrollStatusCompleted
+		record.FailureReason = ""
+	case BankReturnFailed:
+		if record.Status == models.PayrollStatusCompleted {
+			r.exception(line, record, models.ReturnExceptionConflict, "发放记录已确认入账，回盘却为失败")
+			return
+		}
+		record.Status = models.PayrollStatusFailed
+		record.FailureReason = line.Message
+		if record.FailureReason == "" {
+			record.FailureReason = "银行退回"
+		}
+	default:
+		r.result.Pending++
+		return
+	}
+	if line.TransactionRef != "" {
+		record.TransactionRef = line.TransactionRef
+	}
+	r.result.Changed = append(r.result.Changed, record)
+	if line.Status == BankReturnSucceeded {
+		r.result.Succeeded++
+	} else {
+		r.result.Failed++
+	}
+}
+
+func (r *bankReconciler) exception(line BankReturnLine, record *models.EnhancedPayrollRecord, reason models.BankReturnExceptionReason, message string) {
+	exception := models.BankReturnException{
+		LineNumber: line.LineNumber,
+		Reference:  line.Reference,
+		Account:    line.Account,
+		Amount:     line.Amount,
+		Reason:     reason,
+		Message:    message,
+		RawLine:    line.Raw,
+	}
+	if record != nil {
+		id := record.ID
+		exception.RecordID = &id
+	}
+	r.result.Exceptions = append(r.result.Exceptions, exception)
+}
+
+// refreshBatchResults 按发放记录状态重算批次成功/失败笔数，全部记录有结果时批次完成
+func refreshBatchResults(tx *gorm.DB, batch *models.PaymentBatch) error {
+	type statusCount struct {
+		Status string
+		Count  int
+	}
+	var counts []statusCount
+	if err := tx.Model(&models.EnhancedPayrollRecord{}).
+		Select("status, COUNT(*) AS count").
+		Where("payment_batch_id = ?", batch.ID).
+		Group("status").Scan(&counts).Error; err != nil {
+		return err
+	}
+
+	var total, succeeded, failed int
+	for _, c := range counts {
+		total += c.Count
+		switch models.PayrollStatus(c.Status) {
+		case models.PayrollStatusCompleted:
+			succeeded += c.Count
+		case models.PayrollStatusFailed, models.PayrollStatusReissued:
+			failed += c.Count
+		}
+	}
+
+	updates := map[string]interface{}{
+		"success_records": succeeded,
+		"failed_records":  failed,
+	}
+	if total > 0 && succeeded+failed == total {
+		now := time.Now()
+		updates["completed_date"] = now
+		if succeeded > 0 {
+			updates["status"] = models.BatchStatusCompleted
+		} else {
+			updates["status"] = models.BatchStatusFailed
+		}
+	}
+	return tx.Model(batch).Updates(updates).Error
+}
+
+// createReissueBatch 为批次内尚未重发的失败记录生成新的支付批次，收款账号取员工当前登记的工资卡；
+// 原记录标记为已重发，避免原批次再次导出时重复付款
+func createReissueBatch(tx *gorm.DB, batch *models.PaymentBatch, importID uint, userID uint) (*models.PaymentBatch, error) {
+	var failed []models.EnhancedPayrollRecord
+	if err := tx.Preload("Salary.Employee").
+		Where("payment_batch_id = ? AND status = ?", batch.ID, models.PayrollStatusFailed).
+		Where("NOT EXISTS (SELECT 1 FROM enhanced_payroll_records r WHERE r.reissued_from_id = enhanced_payroll_records.id AND r.deleted_at IS NULL)").
+		Order("id ASC").Find(&failed).Error; err != nil {
+		return nil, err
+	}
+	if len(failed) == 0 {
+		return nil, nil
+	}
+
+	reissue := &models.PaymentBatch{
+		BatchNumber:     fmt.Sprintf("%s-R%d", truncateRunes(batch.BatchNumber, 40), importID),
+		Name:            truncateRunes(batch.Name, 90) + "（重发）",
+		PayrollPeriodID: batch.PayrollPeriodID,
+		Currency:        batch.Currency,
+		Status:          models.BatchStatusDraft,
+		CreatedBy:       &userID,
+		Notes:           fmt.Sprintf("由批次 %s 回盘失败记录生成", batch.BatchNumber),
+	}
+	if err := tx.Create(reissue).Error; err != nil {
+		return nil, fmt.Errorf("failed to create reissue batch: %w", err)
+	}
+
+	records := make([]models.EnhancedPayrollRecord, 0, len(failed))
+	var total models.Money
+	for _, original := range failed {
+		originalID := original.ID
+		record := models.EnhancedPayrollRecord{
+			SalaryID:       original.SalaryID,
+			PaymentBatchID: &reissue.ID,
+			PaymentAmount:  original.PaymentAmount,
+			PaymentMethod:  original.PaymentMethod,
+			BankAccount:    original.BankAccount,
+			BankName:       original.BankName,
+			Status:         models.PayrollStatusPending,
+			RetryCount:     original.RetryCount + 1,
+			ReissuedFromID: &originalID,
+			Notes:          "原失败原因: " + original.FailureReason,
+		}
+		if original.Salary != nil && original.Salary.Employee != nil && original.Salary.Employee.BankAccount != "" {
+			record.BankAccount = original.Salary.Employee.BankAccount
+			record.BankName = original.Salary.Employee.BankName
+		}
+		records = append(records, record)
+		total = total.Add(record.PaymentAmount)
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to create reissue records: %w", err)
+	}
+	originalIDs := make([]uint, 0, len(failed))
+	for _, original := range failed {
+		originalIDs = append(originalIDs, original.ID)
+	}
+	if err := tx.Model(&models.EnhancedPayrollRecord{}).Where("id IN ?", originalIDs).
+		Update("status", models.PayrollStatusReissued).Error; err != nil {
+		return nil, fmt.Errorf("failed to mark reissued records: %w", err)
+	}
+
+	reissue.TotalRecords = len(records)
+	reissue.TotalAmount = total
+	if err := tx.Model(reissue).Updates(map[string]interface{}{
+		"total_records": reissue.TotalRecords,
+		"total_amount":  reissue.TotalAmount,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return reissue, nil
+}
+
+// ========================= Generic CSV Return =========================
+
+// csvBankReturnParser 解析通用 CSV 回盘：按表头识别列，支持中英文列名
+type csvBankReturnParser struct{}
+
+func (csvBankReturnParser) Format() BankFileFormat { return BankFileCSV }
+
+var csvReturnColumns = map[string][]string{
+	"reference":       {"参考号", "reference", "end_to_end_id"},
+	"account":         {"收款账号", "账号", "account"},
+	"name":            {"姓名", "户名", "name"},
+	"amount":          {"金额", "amount"},
+	"status":          {"状态", "处理结果", "status"},
+	"transaction_ref": {"银行流水号", "交易参考号", "transaction_ref"},
+	"message":         {"失败原因", "备注", "message"},
+}
+
+func (csvBankReturnParser) Parse(content []byte) ([]BankReturnLine, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(content, []byte("\xEF\xBB\xBF"))))
+	reader.FieldsPerRecord = -1
+
+	var columns map[string]int
+	var lines []BankReturnLine
+	for lineNumber := 1; ; lineNumber++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if columns == nil {
+			columns = csvReturnHeader(row)
+			continue
+		}
+		field := func(name string) string {
+			if index, ok := columns[name]; ok && index < len(row) {
+				return strings.TrimSpace(row[index])
+			}
+			return ""
+		}
+		if field("reference") == "" && field("account") == "" {
+			continue
+		}
+
+		amount, err := models.ParseMoney(strings.ReplaceAll(field("amount"), ",", ""))
+		if err != nil || field("amount") == "" {
+			return nil, fmt.Errorf("第 %d 行金额无效", lineNumber)
+		}
+		lines = append(lines, BankReturnLine{
+			LineNumber:     lineNumber,
+			Reference:      field("reference"),
+			Account:        field("account"),
+			Name:           field("name"),
+			Amount:         amount,
+			Status:         parseBankReturnStatus(field("status")),
+			TransactionRef: field("transaction_ref"),
+			Message:        field("message"),
+			Raw:            strings.Join(row, ","),
+		})
+	}
+	if columns == nil {
+		return nil, fmt.Errorf("未找到表头")
+	}
+	return lines, nil
+}
+
+// csvReturnHeader 表头需至少包含参考号或账号列，以及金额与状态列，否则视为非表头行
+func csvReturnHeader(row []string) map[string]int {
+	columns := make(map[string]int)
+	for i, cell := range row {
+		cell = strings.ToLower(strings.TrimSpace(cell))
+		for name, aliases := range csvReturnColumns {
+			for _, alias := range aliases {
+				if cell == alias {
+					columns[name] = i
+				}
+			}
+		}
+	}
+	_, hasReference := columns["reference"]
+	_, hasAccount := columns["account"]
+	_, hasAmount := columns["amount"]
+	_, hasStatus := columns["status"]
+	if (hasReference || hasAccount) && hasAmount && hasStatus {
+		return columns
+	}
+	return nil
+}
+
+// parseBankReturnStatus 识别常见的成功/失败/处理中标识，无法识别时按处理中处理
+func parseBankReturnStatus(value string) BankReturnStatus {
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "成功", "SUCCESS", "SUCCEEDED", "ACSC", "ACCC", "0":
+		return BankReturnSucceeded
+	case "失败", "退票", "FAILED", "FAIL", "REJECTED", "RJCT", "1":
+		return BankReturnFailed
+	}
+	return BankReturnPending
+}
+
+// ========================= ISO 20022 pain.002 =========================
+
+// pain002BankReturnParser 解析 pain.001 代发文件对应的 pain.002 状态报告，与导出器共用 pain001 格式标识
+type pain002BankReturnParser struct{}
+
+func (pain002BankReturnParser) Format() BankFileFormat { return BankFilePain001 }
+
+type pain002Document struct {
+	Transactions []pain002Transaction `xml:"CstmrPmtStsRpt>OrgnlPmtInfAndSts>TxInfAndSts"`
+}
+
+type pain002Transaction struct {
+	EndToEndID  string   `xml:"OrgnlEndToEndId"`
+	Status      string   `xml:"TxSts"`
+	ReasonCode  string   `xml:"StsRsnInf>Rsn>Cd"`
+	Additional  []string `xml:"StsRsnInf>AddtlInf"`
+	ServicerRef string   `xml:"AcctSvcrRef"`
+	Amount      string   `xml:"OrgnlTxRef>Amt>InstdAmt"`
+	Account     string   `xml:"OrgnlTxRef>CdtrAcct>Id>Othr>Id"`
+	IBAN        string   `xml:"OrgnlTxRef>CdtrAcct>Id>IBAN"`
+	Name        string   `xml:"OrgnlTxRef>Cdtr>Nm"`
+}
+
+func (pain002BankReturnParser) Parse(content []byte) ([]BankReturnLine, error) {
+	var doc pain002Document
+	if err := xml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+
+	lines := make([]BankReturnLine, 0, len(doc.Transactions))
+	for i, tx := range doc.Transactions {
+		line := BankReturnLine{
+			LineNumber:     i + 1,
+			Reference:      strings.TrimSpace(tx.EndToEndID),
+			Account:        strings.TrimSpace(tx.Account),
+			Name:           strings.TrimSpace(tx.Name),
+			Status:         parseBankReturnStatus(tx.Status),
+			TransactionRef: strings.TrimSpace(tx.ServicerRef),
+			Raw:            fmt.Sprintf("%s %s", tx.EndToEndID, tx.Status),
+		}
+		if line.Account == "" {
+			line.Account = strings.TrimSpace(tx.IBAN)
+		}
+		if tx.Amount != "" {
+			amount, err := models.ParseMoney(tx.Amount)
+			if err != nil {
+				return nil, fmt.Errorf("交易 %s 金额无效", tx.EndToEndID)
+			}
+			line.Amount = amount
+		}
+		if line.Status == BankReturnFailed {
+			line.Message = strings.TrimSpace(strings.Join(append([]string{tx.ReasonCode}, tx.Additional...), " "))
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// ========================= ICBC Fixed Width Return =========================
+
+// icbcBankReturnParser 工商银行代发回盘定长文件，GBK 编码，仅解析明细行：
+//
+//	D 序号(6) 收款账号(32) 户名(40) 金额分(15) 参考号(20) 处理结果(1，0成功1失败，其余为处理中) 银行流水号(30) 失败原因(60)
+type icbcBankReturnParser struct{}
+
+func (icbcBankReturnParser) Format() BankFileFormat { return BankFileICBC }
+
+func (icbcBankReturnParser) Parse(content []byte) ([]BankReturnLine, error) {
+	decoder := simplifiedchinese.GBK.NewDecoder()
+	var lines []BankReturnLine
+	for i, raw := range bytes.Split(content, []byte("\n")) {
+		raw = bytes.TrimRight(raw, "\r")
+		if len(raw) == 0 || raw[0] != 'D' {
+			continue
+		}
+
+		reader := &fixedWidthReader{data: raw[1:], decoder: func(b []byte) string {
+			decoded, err := decoder.Bytes(b)
+			if err != nil {
+				return string(b)
+			}
+			return string(decoded)
+		}}
+		reader.field(6)
+		account := reader.field(32)
+		name := reader.field(40)
+		cents, err := strconv.ParseInt(reader.field(15), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行金额无效", i+1)
+		}
+		reference := reader.field(20)
+		status := BankReturnPending
+		switch reader.field(1) {
+		case "0":
+			status = BankReturnSucceeded
+		case "1":
+			status = BankReturnFailed
+		}
+
+		line := BankReturnLine{
+			LineNumber:     i + 1,
+			Reference:      reference,
+			Account:        account,
+			Name:           name,
+			Amount:         models.MoneyFromCents(cents),
+			Status:         status,
+			TransactionRef: reader.field(30),
+			Message:        reader.field(60),
+			Raw:            reader.decode(raw),
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// fixedWidthReader 按字节宽度顺序读取定长字段并去除填充空格
+type fixedWidthReader struct {
+	data    []byte
+	offset  int
+	decoder func([]byte) string
+}
+
+func (r *fixedWidthReader) field(width int) string {
+	if r.offset >= len(r.data) {
+		return ""
+	}
+	end := r.offset + width
+	if end > len(r.data) {
+		end = len(r.data)
+	}
+	value := r.data[r.offset:end]
+	r.offset = end
+	return strings.TrimSpace(r.decode(value))
+}
+
+func (r *fixedWidthReader) decode(b []byte) string {
+	return r.decoder(b)
+}
@@ -0,0 +1,437 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"gin-project/models"
+	"gin-project/utils"
+
+	"gorm.io/gorm"
+)
+
+// ========================= Salary Bands & Compa-Ratio =========================
+
+type CompensationBandServiceInterface interface {
+	GetCompaRatioReport(params CompaRatioParams) (*CompaRatioReport, error)
+}
+
+type CompensationBandService struct {
+	db *gorm.DB
+}
+
+func NewCompensationBandService(db *gorm.DB) CompensationBandServiceInterface {
+	return &CompensationBandService{db: db}
+}
+
+// SalaryBand 薪酬带宽，来源为薪资等级（grade）或职级（job_level）
+type SalaryBand struct {
+	Source string  `json:"source"`
+	ID     uint    `json:"id"`
+	Code   string  `json:"code"`
+	Name   string  `json:"name"`
+	Level  int     `json:"level"`
+	Min    float64 `json:"min"`
+	Mid    float64 `json:"mid"`
+	Max    float64 `json:"max"`
+}
+
+// Valid 带宽已配置（上限大于 0 且不小于下限）
+func (b *SalaryBand) Valid() bool {
+	return b != nil && b.Max > 0 && b.Max >= b.Min
+}
+
+// BandPosition 薪资相对带宽的位置
+type BandPosition string
+
+const (
+	BandBelow  BandPosition = "below"  // 低于下限
+	BandWithin BandPosition = "within" // 带宽内
+	BandAbove  BandPosition = "above"  // 高于上限
+)
+
+// BandPlacement 薪资在带宽中的位置：比较比率 = 薪资 / 中位值，渗透率 = (薪资 - 下限) / (上限 - 下限)
+type BandPlacement struct {
+	CompaRatio       float64      `json:"compa_ratio"`
+	RangePenetration float64      `json:"range_penetration"`
+	Position         BandPosition `json:"position"`
+}
+
+// PlaceInBand 计算薪资在带宽中的位置，比率保留 4 位小数
+func PlaceInBand(salary float64, band *SalaryBand) BandPlacement {
+	placement := BandPlacement{Position: BandWithin}
+	if band.Mid > 0 {
+		placement.CompaRatio = roundRatio(salary / band.Mid)
+	}
+	if band.Max > band.Min {
+		placement.RangePenetration = roundRatio((salary - band.Min) / (band.Max - band.Min))
+	}
+	switch {
+	case salary < band.Min:
+		placement.Position = BandBelow
+	case salary > band.Max:
+		placement.Position = BandAbove
+	}
+	return placement
+}
+
+func roundRatio(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
+
+// gradeBand 薪资等级带宽，未设置中位值时取上下限中点
+func gradeBand(grade *models.SalaryGrade) *SalaryBand {
+	mid := grade.MidSalary
+	if mid <= 0 {
+		mid = (grade.MinSalary + grade.MaxSalary) / 2
+	}
+	return &SalaryBand{Source: "grade", ID: grade.ID, Code: grade.Code, Name: grade.Name, Level: grade.Level,
+		Min: grade.MinSalary, Mid: mid, Max: grade.MaxSalary}
+}
+
+func jobLevelBand(level *models.JobLevel) *SalaryBand {
+	return &SalaryBand{Source: "job_level", ID: level.ID, Code: level.Code, Name: level.Name, Level: level.Level,
+		Min: level.MinSalary, Mid: (level.MinSalary + level.MaxSalary) / 2, Max: level.MaxSalary}
+}
+
+// bandForJobLevel 员工适用带宽：优先取与职级同层次的有效薪资等级，否则取职级自身的薪资范围
+func bandForJobLevel(level *models.JobLevel, grades map[int]*models.SalaryGrade) *SalaryBand {
+	if level == nil {
+		return nil
+	}
+	if grade, ok := grades[level.Level]; ok {
+		if band := gradeBand(grade); band.Valid() {
+			return band
+		}
+	}
+	if band := jobLevelBand(level); band.Valid() {
+		return band
+	}
+	return nil
+}
+
+func activeGradesByLevel(db *gorm.DB) (map[int]*models.SalaryGrade, []models.SalaryGrade, error) {
+	var grades []models.SalaryGrade
+	if err := db.Where("status = ?", "active").Order("level ASC, id ASC").Find(&grades).Error; err != nil {
+		return nil, nil, err
+	}
+	byLevel := make(map[int]*models.SalaryGrade, len(grades))
+	for i := range grades {
+		if _, ok := byLevel[grades[i].Level]; !ok {
+			byLevel[grades[i].Level] = &grades[i]
+		}
+	}
+	return byLevel, grades, nil
+}
+
+// ========================= Band Validation =========================
+
+// SalaryBandPolicy 薪资超出带宽时的处理策略
+type SalaryBandPolicy string
+
+const (
+	SalaryBandWarn  SalaryBandPolicy = "warn"  // 允许保存并记录提示
+	SalaryBandBlock SalaryBandPolicy = "block" // 拒绝保存
+)
+
+// CurrentSalaryBandPolicy 由环境变量 SALARY_BAND_POLICY 配置，默认仅提示
+func CurrentSalaryBandPolicy() SalaryBandPolicy {
+	if SalaryBandPolicy(os.Getenv("SALARY_BAND_POLICY")) == SalaryBandBlock {
+		return SalaryBandBlock
+	}
+	return SalaryBandWarn
+}
+
+// SalaryBandCheck 薪资带宽校验结果，未配置带宽时 Band 为 nil 且视为通过
+type SalaryBandCheck struct {
+	Salary    float64        `json:"salary"`
+	Band      *SalaryBand    `json:"band"`
+	Placement *BandPlacement `json:"placement,omitempty"`
+	Message   string         `json:"message,omitempty"`
+}
+
+// InBand 薪资是否在带宽内
+func (c *SalaryBandCheck) InBand() bool {
+	return c.Placement == nil || c.Placement.Position == BandWithin
+}
+
+// checkSalaryBand 按职级适用带宽校验薪资
+func checkSalaryBand(db *gorm.DB, jobLevelID uint, salary float64) (*SalaryBandCheck, error) {
+	var level models.JobLevel
+	if err := db.First(&level, jobLevelID).Error; err != nil {
+		return nil, err
+	}
+	grades, _, err := activeGradesByLevel(db)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &SalaryBandCheck{Salary: salary, Band: bandForJobLevel(&level, grades)}
+	if check.Band == nil {
+		return check, nil
+	}
+	placement := PlaceInBand(salary, check.Band)
+	check.Placement = &placement
+	if !check.InBand() {
+		direction := "低于"
+		if placement.Position == BandAbove {
+			direction = "高于"
+		}
+		check.Message = fmt.Sprintf("薪资 %s %s「%s」薪酬带宽 %s - %s", formatAmount(salary), direction,
+			check.Band.Name, formatAmount(check.Band.Min), formatAmount(check.Band.Max))
+	}
+	return check, nil
+}
+
+// applyAdjustmentBandPolicy 校验调薪后的基本薪资是否在带宽内：阻止策略下返回校验错误，提示策略下写入 BandWarning
+func applyAdjustmentBandPolicy(db *gorm.DB, adjustment *models.SalaryAdjustment) error {
+	var employee models.Employee
+	if err := db.Select("id", "job_level_id").First(&employee, adjustment.EmployeeID).Error; err != nil {
+		return &utils.ValidationError{Message: "员工不存在"}
+	}
+	if employee.JobLevelID == 0 {
+		return nil
+	}
+
+	check, err := checkSalaryBand(db, employee.JobLevelID, adjustment.NewBaseSalary.Float64())
+	if err != nil {
+		return err
+	}
+	if check.InBand() {
+		adjustment.BandWarning = ""
+		return nil
+	}
+	if CurrentSalaryBandPolicy() == SalaryBandBlock {
+		return &utils.ValidationError{Message: check.Message}
+	}
+	adjustment.BandWarning = check.Message
+	return nil
+}
+
+// ========================= Compa-Ratio Report =========================
+
+// CompaRatioParams 比较比率分析参数
+type CompaRatioParams struct {
+	DepartmentID *uint `json:"department_id"`
+}
+
+// EmployeeBandPosition 员工在带宽中的位置
+type EmployeeBandPosition struct {
+	EmployeeID     uint         `json:"employee_id"`
+	EmployeeNo     string       `json:"employee_no"`
+	EmployeeName   string       `json:"employee_name"`
+	DepartmentID   uint         `json:"department_id"`
+	DepartmentName string       `json:"department_name"`
+	JobLevelName   string       `json:"job_level_name"`
+	BaseSalary     models.Money `json:"base_salary"`
+	Band           *SalaryBand  `json:"band"`
+	BandPlacement
+}
+
+// BandOverlap 相邻层次带宽的重叠，重叠比例 = 重叠金额 / 较低带宽宽度
+type BandOverlap struct {
+	Source         string  `json:"source"`
+	Lower          string  `json:"lower"`
+	Upper          string  `json:"upper"`
+	OverlapAmount  float64 `json:"overlap_amount"`
+	OverlapPercent float64 `json:"overlap_percent"`
+	Inverted       bool    `json:"inverted"`
+}
+
+// DepartmentCompaRatio 部门比较比率汇总
+type DepartmentCompaRatio struct {
+	DepartmentID      uint    `json:"department_id"`
+	DepartmentName    string  `json:"department_name"`
+	Employees         int     `json:"employees"`
+	AverageCompaRatio float64 `json:"average_compa_ratio"`
+	BelowBand         int     `json:"below_band"`
+	AboveBand         int     `json:"above_band"`
+}
+
+// CompaRatioSummary 汇总
+type CompaRatioSummary struct {
+	Employees         int     `json:"employees"`
+	WithoutBand       int     `json:"without_band"`
+	BelowBand         int     `json:"below_band"`
+	WithinBand        int     `json:"within_band"`
+	AboveBand         int     `json:"above_band"`
+	AverageCompaRatio float64 `json:"average_compa_ratio"`
+	MedianCompaRatio  float64 `json:"median_compa_ratio"`
+}
+
+// CompaRatioReport 比较比率与带宽分析报告
+type CompaRatioReport struct {
+	Summary          CompaRatioSummary      `json:"summary"`
+	Employees        []EmployeeBandPosition `json:"employees"`
+	OutOfBand        []EmployeeBandPosition `json:"out_of_band"`
+	Unbanded         []EmployeeBandPosition `json:"unbanded"`
+	Departments      []DepartmentCompaRatio `json:"departments"`
+	GradeOverlaps    []BandOverlap          `json:"grade_overlaps"`
+	JobLevelOverlaps []BandOverlap          `json:"job_level_overlaps"`
+}
+
+func (s *CompensationBandService) GetCompaRatioReport(params CompaRatioParams) (*CompaRatioReport, error) {
+	query := s.db.Preload("Department").Preload("JobLevel").Where("status = ?", "active")
+	if params.DepartmentID != nil {
+		query = query.Where("department_id = ?", *params.DepartmentID)
+	}
+	var employees []models.Employee
+	if err := query.Order("department_id ASC, employee_id ASC").Find(&employees).Error; err != nil {
+		return nil, err
+	}
+
+	gradesByLevel, grades, err := activeGradesByLevel(s.db)
+	if err != nil {
+		return nil, err
+	}
+	var levels []models.JobLevel
+	if err := s.db.Where("status = ?", "active").Order("level ASC, id ASC").Find(&levels).Error; err != nil {
+		return nil, err
+	}
+
+	positions := make([]EmployeeBandPosition, 0, len(employees))
+	for i := range employees {
+		employee := &employees[i]
+		position := EmployeeBandPosition{
+			EmployeeID:   employee.ID,
+			EmployeeNo:   employee.EmployeeID,
+			EmployeeName: employee.Name,
+			DepartmentID: employee.DepartmentID,
+			BaseSalary:   employee.BaseSalary,
+			Band:         bandForJobLevel(employee.JobLevel, gradesByLevel),
+		}
+		if employee.Department != nil {
+			position.DepartmentName = employee.Department.Name
+		}
+		if employee.JobLevel != nil {
+			position.JobLevelName = employee.JobLevel.Name
+		}
+		if position.Band != nil {
+			position.BandPlacement = PlaceInBand(employee.BaseSalary.Float64(), position.Band)
+		}
+		positions = append(positions, position)
+	}
+
+	report := BuildCompaRatioReport(positions)
+
+	gradeBands := make([]SalaryBand, 0, len(grades))
+	for i := range grades {
+		if band := gradeBand(&grades[i]); band.Valid() {
+			gradeBands = append(gradeBands, *band)
+		}
+	}
+	levelBands := make([]SalaryBand, 0, len(levels))
+	for i := range levels {
+		if band := jobLevelBand(&levels[i]); band.Valid() {
+			levelBands = append(levelBands, *band)
+		}
+	}
+	report.GradeOverlaps = BandOverlaps(gradeBands)
+	report.JobLevelOverlaps = BandOverlaps(levelBands)
+	return report, nil
+}
+
+// BuildCompaRatioReport 汇总员工带宽位置；未匹配带宽的员工单独列出，不计入比率统计
+func BuildCompaRatioReport(positions []EmployeeBandPosition) *CompaRatioReport {
+	report := &CompaRatioReport{
+		Employees:        positions,
+		OutOfBand:        []EmployeeBandPosition{},
+		Unbanded:         []EmployeeBandPosition{},
+		Departments:      []DepartmentCompaRatio{},
+		GradeOverlaps:    []BandOverlap{},
+		JobLevelOverlaps: []BandOverlap{},
+	}
+	report.Summary.Employees = len(positions)
+
+	type departmentTotals struct {
+		summary DepartmentCompaRatio
+		total   float64
+	}
+	departments := make(map[uint]*departmentTotals)
+	var order []uint
+	var ratios []float64
+
+	for _, position := range positions {
+		if position.Band == nil {
+			report.Summary.WithoutBand++
+			report.Unbanded = append(report.Unbanded, position)
+			continue
+		}
+		ratios = append(ratios, position.CompaRatio)
+
+		dept, ok := departments[position.DepartmentID]
+		if !ok {
+			dept = &departmentTotals{summary: DepartmentCompaRatio{DepartmentID: position.DepartmentID, DepartmentName: position.DepartmentName}}
+			departments[position.DepartmentID] = dept
+			order = append(order, position.DepartmentID)
+		}
+		dept.summary.Employees++
+		dept.total += position.CompaRatio
+
+		switch position.Position {
+		case BandBelow:
+			report.Summary.BelowBand++
+			dept.summary.BelowBand++
+			report.OutOfBand = append(report.OutOfBand, position)
+		case BandAbove:
+			report.Summary.AboveBand++
+			dept.summary.AboveBand++
+			report.OutOfBand = append(report.OutOfBand, position)
+		default:
+			report.Summary.WithinBand++
+		}
+	}
+
+	if len(ratios) > 0 {
+		var total float64
+		for _, ratio := range ratios {
+			total += ratio
+		}
+		report.Summary.AverageCompaRatio = roundRatio(total / float64(len(ratios)))
+
+		sort.Float64s(ratios)
+		middle := len(ratios) / 2
+		if len(ratios)%2 == 1 {
+			report.Summary.MedianCompaRatio = ratios[middle]
+		} else {
+			report.Summary.MedianCompaRatio = roundRatio((ratios[middle-1] + ratios[middle]) / 2)
+		}
+	}
+
+	for _, id := range order {
+		dept := departments[id]
+		dept.summary.AverageCompaRatio = roundRatio(dept.total / float64(dept.summary.Employees))
+		report.Departments = append(report.Departments, dept.summary)
+	}
+	return report
+}
+
+// BandOverlaps 按层次排序后计算相邻带宽的重叠；较高层次的下限或上限低于较低层次时标记为倒挂
+func BandOverlaps(bands []SalaryBand) []BandOverlap {
+	sorted := make([]SalaryBand, len(bands))
+	copy(sorted, bands)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Level < sorted[j].Level })
+
+	overlaps := []BandOverlap{}
+	for i := 1; i < len(sorted); i++ {
+		lower, upper := sorted[i-1], sorted[i]
+		amount := math.Min(lower.Max, upper.Max) - math.Max(lower.Min, upper.Min)
+		if amount <= 0 {
+			continue
+		}
+		overlap := BandOverlap{
+			Source:        lower.Source,
+			Lower:         lower.Name,
+			Upper:         upper.Name,
+			OverlapAmount: roundAmount(amount),
+			Inverted:      upper.Min < lower.Min || upper.Max < lower.Max,
+		}
+		if width := lower.Max - lower.Min; width > 0 {
+			overlap.OverlapPercent = roundAmount(amount / width * 100)
+		}
+		overlaps = append(overlaps, overlap)
+	}
+	return overlaps
+}
@@ -0,0 +1,953 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gin-project/models"
+	"gin-project/utils"
+
+	"gorm.io/gorm"
+)
+
+// ========================= Compensation Review Cycles =========================
+
+type CompensationReviewServiceInterface interface {
+	GetCycles() ([]models.CompensationReviewCycle, error)
+	GetCycle(id uint) (*models.CompensationReviewCycle, error)
+	CreateCycle(cycle *models.CompensationReviewCycle, userID uint) (*models.CompensationReviewCycle, error)
+	UpdateCycle(id uint, cycle *models.CompensationReviewCycle) (*models.CompensationReviewCycle, error)
+	TransitionCycle(id uint, status models.CompensationReviewStatus) (*models.CompensationReviewCycle, error)
+
+	// 经理调薪工作表与建议
+	GetWorksheet(id, userID uint, role string, departmentID *uint) (*ReviewWorksheet, error)
+	SaveProposals(id, userID uint, role string, inputs []ProposalInput) ([]models.CompensationReviewProposal, error)
+	SubmitProposals(id, userID uint, role string, proposalIDs []uint) (int, error)
+
+	// 汇总审批与生成调薪
+	GetSummary(id uint) (*ReviewSummary, error)
+	ReviewProposals(id, userID uint, decision ProposalDecision) (int, error)
+	FinalizeCycle(id, userID uint) (*ReviewFinalizeResult, error)
+}
+
+type CompensationReviewService struct {
+	db *gorm.DB
+}
+
+func NewCompensationReviewService(db *gorm.DB) CompensationReviewServiceInterface {
+	return &CompensationReviewService{db: db}
+}
+
+// ErrReviewScope 经理只能为直接下属提交调薪建议
+var ErrReviewScope = errors.New("只能为直接下属提交调薪建议")
+
+// ProposalInput 批量调薪建议，ProposedBase 与 IncreasePercent 二选一
+type ProposalInput struct {
+	EmployeeID      uint          `json:"employee_id" binding:"required"`
+	ProposedBase    *models.Money `json:"proposed_base"`
+	IncreasePercent *float64      `json:"increase_percent"`
+	Justification   string        `json:"justification"`
+}
+
+// ProposalDecision 审批决定，可按建议ID或部门批量处理已提交的建议
+type ProposalDecision struct {
+	ProposalIDs  []uint `json:"proposal_ids"`
+	DepartmentID *uint  `json:"department_id"`
+	Approve      bool   `json:"approve"`
+	Notes        string `json:"notes"`
+}
+
+// ReviewWorksheetRow 调薪工作表行
+type ReviewWorksheetRow struct {
+	EmployeeID       uint                                `json:"employee_id"`
+	EmployeeNo       string                              `json:"employee_no"`
+	EmployeeName     string                              `json:"employee_name"`
+	DepartmentID     uint                                `json:"department_id"`
+	DepartmentName   string                              `json:"department_name"`
+	JobLevelName     string                              `json:"job_level_name"`
+	CurrentBase      models.Money                        `json:"current_base"`
+	PerformanceScore *float64                            `json:"performance_score"`
+	CompaRatio       *float64                            `json:"compa_ratio"`
+	Band             *SalaryBand                         `json:"band"`
+	Guideline        *models.CompensationReviewGuideline `json:"guideline"`
+	SuggestedBase    models.Money                        `json:"suggested_base"`
+	Proposal         *models.CompensationReviewProposal  `json:"proposal"`
+}
+
+// ReviewWorksheet 经理调薪工作表
+type ReviewWorksheet struct {
+	Cycle       *models.CompensationReviewCycle `json:"cycle"`
+	Rows        []ReviewWorksheetRow            `json:"rows"`
+	Departments []ReviewDepartmentSummary       `json:"departments"`
+}
+
+// ReviewDepartmentSummary 部门预算执行汇总；已占用 = 草稿 + 已提交 + 已批准
+type ReviewDepartmentSummary struct {
+	DepartmentID     uint         `json:"department_id"`
+	DepartmentName   string       `json:"department_name"`
+	Budget           models.Money `json:"budget"`
+	Committed        models.Money `json:"committed"`
+	Approved         models.Money `json:"approved"`
+	Remaining        models.Money `json:"remaining"`
+	Proposals        int          `json:"proposals"`
+	Pending          int          `json:"pending"`
+	ApprovedCount    int          `json:"approved_count"`
+	OutsideGuideline int          `json:"outside_guideline"`
+	AveragePercent   float64      `json:"average_percent"`
+}
+
+// ReviewSummary 调薪周期汇总
+type ReviewSummary struct {
+	Cycle       *models.CompensationReviewCycle `json:"cycle"`
+	Departments []ReviewDepartmentSummary       `json:"departments"`
+	Total       ReviewDepartmentSummary         `json:"total"`
+}
+
+// ReviewFinalizeResult 生成调薪记录结果
+type ReviewFinalizeResult struct {
+	Cycle         *models.CompensationReviewCycle `json:"cycle"`
+	AdjustmentIDs []uint                          `json:"adjustment_ids"`
+	BandWarnings  []string                        `json:"band_warnings"`
+}
+
+// reviewTransitions 周期状态流转；完成只能通过生成调薪记录
+var reviewTransitions = map[models.CompensationReviewStatus][]models.CompensationReviewStatus{
+	models.ReviewCycleDraft:  {models.ReviewCycleOpen, models.ReviewCycleCancelled},
+	models.ReviewCycleOpen:   {models.ReviewCycleReview, models.ReviewCycleCancelled},
+	models.ReviewCycleReview: {models.ReviewCycleOpen, models.ReviewCycleCancelled},
+}
+
+var reviewCycleLabels = map[models.CompensationReviewStatus]string{
+	models.ReviewCycleDraft:     "草稿",
+	models.ReviewCycleOpen:      "开放提交",
+	models.ReviewCycleReview:    "审批中",
+	models.ReviewCycleCompleted: "已完成",
+	models.ReviewCycleCancelled: "已取消",
+}
+
+// ========================= Cycle Management =========================
+
+func (s *CompensationReviewService) GetCycles() ([]models.CompensationReviewCycle, error) {
+	var cycles []models.CompensationReviewCycle
+	if err := s.db.Order("effective_date DESC, id DESC").Find(&cycles).Error; err != nil {
+		return nil, err
+	}
+	return cycles, nil
+}
+
+func (s *CompensationReviewService) GetCycle(id uint) (*models.CompensationReviewCycle, error) {
+	var cycle models.CompensationReviewCycle
+	if err := s.db.Preload("Budgets.Department").
+		Preload("Guidelines", func(db *gorm.DB) *gorm.DB { return db.Order("sort ASC, id ASC") }).
+		First(&cycle, id).Error; err != nil {
+		return nil, &utils.ValidationError{Message: "调薪周期不存在"}
+	}
+	return &cycle, nil
+}
+
+func (s *CompensationReviewService) CreateCycle(cycle *models.CompensationReviewCycle, userID uint) (*models.CompensationReviewCycle, error) {
+	if err := validateReviewCycle(cycle); err != nil {
+		return nil, err
+	}
+	cycle.ID = 0
+	cycle.Status = models.ReviewCycleDraft
+	cycle.CreatedBy = userID
+	cycle.EffectiveDate = truncateDate(cycle.EffectiveDate)
+	if err := s.db.Create(cycle).Error; err != nil {
+		return nil, err
+	}
+	return s.GetCycle(cycle.ID)
+}
+
+// UpdateCycle 草稿或开放状态下可调整预算与指导规则，预算不能低于已占用金额
+func (s *CompensationReviewService) UpdateCycle(id uint, cycle *models.CompensationReviewCycle) (*models.CompensationReviewCycle, error) {
+	existing, err := s.GetCycle(id)
+	if err != nil {
+		return nil, err
+	}
+	if existing.Status != models.ReviewCycleDraft && existing.Status != models.ReviewCycleOpen {
+		return nil, utils.NewConflictError(fmt.Sprintf("调薪周期%s，不能修改", reviewCycleLabels[existing.Status]))
+	}
+	if err := validateReviewCycle(cycle); err != nil {
+		return nil, err
+	}
+
+	var proposals []models.CompensationReviewProposal
+	if err := s.db.Where("cycle_id = ?", id).Find(&proposals).Error; err != nil {
+		return nil, err
+	}
+	for _, summary := range SummarizeReviewBudgets(cycle.Budgets, proposals) {
+		if summary.Remaining.Sign() < 0 {
+			return nil, &utils.ValidationError{Message: fmt.Sprintf("部门 %d 的预算 %s 低于已占用金额 %s",
+				summary.DepartmentID, summary.Budget, summary.Committed)}
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.CompensationReviewCycle{ID: id}).Updates(map[string]interface{}{
+			"name":             cycle.Name,
+			"performance_year": cycle.PerformanceYear,
+			"effective_date":   truncateDate(cycle.EffectiveDate),
+			"description":      cycle.Description,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("cycle_id = ?", id).Delete(&models.CompensationReviewBudget{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("cycle_id = ?", id).Delete(&models.CompensationReviewGuideline{}).Error; err != nil {
+			return err
+		}
+		for i := range cycle.Budgets {
+			cycle.Budgets[i].ID = 0
+			cycle.Budgets[i].CycleID = id
+		}
+		for i := range cycle.Guidelines {
+			cycle.Guidelines[i].ID = 0
+			cycle.Guidelines[i].CycleID = id
+		}
+		if len(cycle.Budgets) > 0 {
+			if err := tx.Omit("Department").Create(&cycle.Budgets).Error; err != nil {
+				return err
+			}
+		}
+		if len(cycle.Guidelines) > 0 {
+			if err := tx.Create(&cycle.Guidelines).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetCycle(id)
+}
+
+func (s *CompensationReviewService) TransitionCycle(id uint, status models.CompensationReviewStatus) (*models.CompensationReviewCycle, error) {
+	cycle, err := s.GetCycle(id)
+	if err != nil {
+		return nil, err
+	}
+	allowed := false
+	for _, next := range reviewTransitions[cycle.Status] {
+		allowed = allowed || next == status
+	}
+	if !allowed {
+		return nil, utils.NewConflictError(fmt.Sprintf("调薪周期%s，不能变更为%s",
+			reviewCycleLabels[cycle.Status], reviewCycleLabels[status]))
+	}
+	if status == models.ReviewCycleOpen && cycle.Status == models.ReviewCycleDraft && len(cycle.Budgets) == 0 {
+		return nil, &utils.ValidationError{Message: "开放调薪周期前请先设置部门预算"}
+	}
+
+	if err := s.db.Model(&models.CompensationReviewCycle{ID: id}).Update("status", status).Error; err != nil {
+		return nil, err
+	}
+	cycle.Status = status
+	return cycle, nil
+}
+
+func validateReviewCycle(cycle *models.CompensationReviewCycle) error {
+	cycle.Name = strings.TrimSpace(cycle.Name)
+	if cycle.Name == "" {
+		return &utils.ValidationError{Message: "周期名称不能为空"}
+	}
+	if cycle.EffectiveDate.IsZero() {
+		return &utils.ValidationError{Message: "请设置调薪生效日期"}
+	}
+	if cycle.PerformanceYear == 0 {
+		cycle.PerformanceYear = cycle.EffectiveDate.Year() - 1
+	}
+
+	seen := make(map[uint]bool, len(cycle.Budgets))
+	for _, budget := range cycle.Budgets {
+		if budget.DepartmentID == 0 || budget.Amount.Sign() < 0 {
+			return &utils.ValidationError{Message: "部门预算配置无效"}
+		}
+		if seen[budget.DepartmentID] {
+			return &utils.ValidationError{Message: fmt.Sprintf("部门 %d 的预算重复设置", budget.DepartmentID)}
+		}
+		seen[budget.DepartmentID] = true
+	}
+	for i, guideline := range cycle.Guidelines {
+		if guideline.MinPercent > guideline.MaxPercent ||
+			guideline.TargetPercent < guideline.MinPercent || guideline.TargetPercent > guideline.MaxPercent {
+			return &utils.ValidationError{Message: fmt.Sprintf("第 %d 条指导规则的涨幅区间无效", i+1)}
+		}
+	}
+	return nil
+}
+
+// ========================= Guidelines =========================
+
+// MatchReviewGuideline 按排序返回第一条同时匹配绩效得分与比较比率区间的规则；
+// 缺少得分或比率时只匹配不限定该维度的规则
+func MatchReviewGuideline(guidelines []models.CompensationReviewGuideline, score, compaRatio *float64) *models.CompensationReviewGuideline {
+	sorted := make([]models.CompensationReviewGuideline, len(guidelines))
+	copy(sorted, guidelines)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Sort < sorted[j].Sort })
+
+	for i := range sorted {
+		guideline := &sorted[i]
+		if !inGuidelineRange(score, guideline.MinScore, guideline.MaxScore) ||
+			!inGuidelineRange(compaRatio, guideline.MinCompaRatio, guideline.MaxCompaRatio) {
+			continue
+		}
+		return guideline
+	}
+	return nil
+}
+
+func inGuidelineRange(value *float64, min, max float64) bool {
+	if min == 0 && max == 0 {
+		return true
+	}
+	if value == nil {
+		return false
+	}
+	return *value >= min && (max == 0 || *value < max)
+}
+
+// EvaluateProposal 计算涨薪金额与涨幅，并判断是否超出指导涨幅区间
+func EvaluateProposal(proposal *models.CompensationReviewProposal, guideline *models.CompensationReviewGuideline) {
+	proposal.IncreaseAmount = proposal.ProposedBase.Sub(proposal.CurrentBase)
+	proposal.IncreasePercent = 0
+	if proposal.CurrentBase.Sign() > 0 {
+		proposal.IncreasePercent = roundAmount(proposal.IncreaseAmount.Float64() / proposal.CurrentBase.Float64() * 100)
+	}
+
+	proposal.GuidelineID = nil
+	proposal.OutsideGuideline = false
+	if guideline != nil {
+		id := guideline.ID
+		proposal.GuidelineID = &id
+		proposal.OutsideGuideline = proposal.IncreasePercent < guideline.MinPercent || proposal.IncreasePercent > guideline.MaxPercent
+	}
+}
+
+// SummarizeReviewBudgets 按部门汇总预算占用，驳回的建议不占用预算
+func SummarizeReviewBudgets(budgets []models.CompensationReviewBudget, proposals []models.CompensationReviewProposal) []ReviewDepartmentSummary {
+	byDepartment := make(map[uint]*ReviewDepartmentSummary)
+	var order []uint
+	get := func(departmentID uint) *ReviewDepartmentSummary {
+		summary, ok := byDepartment[departmentID]
+		if !ok {
+			summary = &ReviewDepartmentSummary{DepartmentID: departmentID}
+			byDepartment[departmentID] = summary
+			order = append(order, departmentID)
+		}
+		return summary
+	}
+
+	for _, budget := range budgets {
+		summary := get(budget.DepartmentID)
+		summary.Budget = budget.Amount
+		if budget.Department != nil {
+			summary.DepartmentName = budget.Department.Name
+		}
+	}
+
+	percentTotals := make(map[uint]float64)
+	for _, proposal := range proposals {
+		if proposal.Status == models.ProposalRejected {
+			continue
+		}
+		summary := get(proposal.DepartmentID)
+		summary.Proposals++
+		summary.Committed = summary.Committed.Add(proposal.IncreaseAmount)
+		percentTotals[proposal.DepartmentID] += proposal.IncreasePercent
+		if proposal.OutsideGuideline {
+			summary.OutsideGuideline++
+		}
+		switch proposal.Status {
+		case models.ProposalSubmitted:
+			summary.Pending++
+		case models.ProposalApproved:
+			summary.ApprovedCount++
+			summary.Approved = summary.Approved.Add(proposal.IncreaseAmount)
+		}
+	}
+
+	result := make([]ReviewDepartmentSummary, 0, len(order))
+	for _, id := range order {
+		summary := byDepartment[id]
+		summary.Remaining = summary.Budget.Sub(summary.Committed)
+		if summary.Proposals > 0 {
+			summary.AveragePercent = roundAmount(percentTotals[id] / float64(summary.Proposals))
+		}
+		result = append(result, *summary)
+	}
+	return result
+}
+
+// ========================= Worksheet & Proposals =========================
+
+// reviewEmployees 工作表范围：人事与管理员可查看全部在职员工（可按部门过滤），其他用户仅查看直接下属
+func (s *CompensationReviewService) reviewEmployees(userID uint, role string, departmentID *uint) ([]models.Employee, error) {
+	query := s.db.Preload("Department").Preload("JobLevel").Where("status = ?", "active")
+	if role != "admin" && role != "hr" {
+		var user models.User
+		if userID == 0 || s.db.First(&user, userID).Error != nil {
+			return nil, ErrReviewScope
+		}
+		managerID, err := ReviewManagerScope(role, &user)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("manager_id = ?", *managerID)
+	}
+	if departmentID != nil {
+		query = query.Where("department_id = ?", *departmentID)
+	}
+
+	var employees []models.Employee
+	if err := query.Order("department_id ASC, employee_id ASC").Find(&employees).Error; err != nil {
+		return nil, err
+	}
+	return employees, nil
+}
+
+// ReviewManagerScope 返回经理可查看的直接下属范围（其员工ID）；管理员和人事不受限，返回 nil
+func ReviewManagerScope(role string, user *models.User) (*uint, error) {
+	if role == "admin" || role == "hr" {
+		return nil, nil
+	}
+	if user == nil || user.EmployeeID == nil || *user.EmployeeID == 0 {
+		return nil, ErrReviewScope
+	}
+	managerID := *user.EmployeeID
+	return &managerID, nil
+}
+
+// SelectReviewEmployees 按提交的员工ID从可见范围中取出员工，任一不在范围内即拒绝
+func SelectReviewEmployees(scope []models.Employee, employeeIDs []uint) ([]models.Employee, error) {
+	inScope := make(map[uint]*models.Employee, len(scope))
+	for i := range scope {
+		inScope[scope[i].ID] = &scope[i]
+	}
+	selected := make([]models.Employee, 0, len(employeeIDs))
+	for _, id := range employeeIDs {
+		employee, ok := inScope[id]
+		if !ok {
+			return nil, ErrReviewScope
+		}
+		selected = append(selected, *employee)
+	}
+	return selected, nil
+}
+
+// reviewContext 员工调薪参考信息：生效日前的基本薪资、绩效得分、带宽与比较比率
+type reviewContext struct {
+	currentBase models.Money
+	score       *float64
+	band        *SalaryBand
+	compaRatio  *float64
+}
+
+func (s *CompensationReviewService) loadReviewContext(cycle *models.CompensationReviewCycle, employees []models.Employee) (map[uint]*reviewContext, error) {
+	contexts := make(map[uint]*reviewContext, len(employees))
+	if len(employees) == 0 {
+		return contexts, nil
+	}
+	ids := make([]uint, len(employees))
+	for i, employee := range employees {
+		ids[i] = employee.ID
+	}
+
+	var adjustments []models.SalaryAdjustment
+	if err := s.db.Where("employee_id IN ? AND status = ? AND effective_date < ?", ids, "approved", cycle.EffectiveDate).
+		Order("effective_date ASC, id ASC").Find(&adjustments).Error; err != nil {
+		return nil, err
+	}
+	adjustmentsByEmployee := make(map[uint][]models.SalaryAdjustment)
+	for _, adjustment := range adjustments {
+		adjustmentsByEmployee[adjustment.EmployeeID] = append(adjustmentsByEmployee[adjustment.EmployeeID], adjustment)
+	}
+
+	type scoreRow struct {
+		EmployeeID uint
+		Score      float64
+	}
+	var scores []scoreRow
+	if err := s.db.Model(&models.Performance{}).Select("employee_id, AVG(score) AS score").
+		Where("employee_id IN ? AND year = ? AND status <> ?", ids, cycle.PerformanceYear, "draft").
+		Group("employee_id").Scan(&scores).Error; err != nil {
+		return nil, err
+	}
+	scoreByEmployee := make(map[uint]float64, len(scores))
+	for _, row := range scores {
+		scoreByEmployee[row.EmployeeID] = roundAmount(row.Score)
+	}
+
+	grades, _, err := activeGradesByLevel(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range employees {
+		employee := &employees[i]
+		ctx := &reviewContext{
+			currentBase: baseSalaryAt(adjustmentsByEmployee[employee.ID], employee.BaseSalary, cycle.EffectiveDate.AddDate(0, 0, -1)),
+			band:        bandForJobLevel(employee.JobLevel, grades),
+		}
+		if score, ok := scoreByEmployee[employee.ID]; ok {
+			ctx.score = &score
+		}
+		if ctx.band != nil && ctx.band.Mid > 0 {
+			ratio := PlaceInBand(ctx.currentBase.Float64(), ctx.band).CompaRatio
+			ctx.compaRatio = &ratio
+		}
+		contexts[employee.ID] = ctx
+	}
+	return contexts, nil
+}
+
+func (s *CompensationReviewService) GetWorksheet(id, userID uint, role string, departmentID *uint) (*ReviewWorksheet, error) {
+	cycle, err := s.GetCycle(id)
+	if err != nil {
+		return nil, err
+	}
+	employees, err := s.reviewEmployees(userID, role, departmentID)
+	if err != nil {
+		return nil, err
+	}
+	contexts, err := s.loadReviewContext(cycle, employees)
+	if err != nil {
+		return nil, err
+	}
+
+	var proposals []models.CompensationReviewProposal
+	if err := s.db.Where("cycle_id = ?", id).Find(&proposals).Error; err != nil {
+		return nil, err
+	}
+	proposalByEmployee := make(map[uint]*models.CompensationReviewProposal, len(proposals))
+	for i := range proposals {
+		proposalByEmployee[proposals[i].EmployeeID] = &proposals[i]
+	}
+
+	worksheet := &ReviewWorksheet{Cycle: cycle, Rows: make([]ReviewWorksheetRow, 0, len(employees))}
+	departments := make(map[uint]bool)
+	for i := range employees {
+		employee := &employees[i]
+		ctx := contexts[employee.ID]
+		row := ReviewWorksheetRow{
+			EmployeeID:       employee.ID,
+			EmployeeNo:       employee.EmployeeID,
+			EmployeeName:     employee.Name,
+			DepartmentID:     employee.DepartmentID,
+			CurrentBase:      ctx.currentBase,
+			PerformanceScore: ctx.score,
+			CompaRatio:       ctx.compaRatio,
+			Band:             ctx.band,
+			Guideline:        MatchReviewGuideline(cycle.Guidelines, ctx.score, ctx.compaRatio),
+			SuggestedBase:    ctx.currentBase,
+			Proposal:         proposalByEmployee[employee.ID],
+		}
+		if employee.Department != nil {
+			row.DepartmentName = employee.Department.Name
+		}
+		if employee.JobLevel != nil {
+			row.JobLevelName = employee.JobLevel.Name
+		}
+		if row.Guideline != nil {
+			row.SuggestedBase = ctx.currentBase.Mul(1+row.Guideline.TargetPercent/100, models.RoundHalfUp)
+		}
+		departments[employee.DepartmentID] = true
+		worksheet.Rows = append(worksheet.Rows, row)
+	}
+
+	for _, summary := range SummarizeReviewBudgets(cycle.Budgets, proposals) {
+		if departments[summary.DepartmentID] {
+			worksheet.Departments = append(worksheet.Departments, summary)
+		}
+	}
+	return worksheet, nil
+}
+
+// SaveProposals 批量保存调薪建议（草稿），超出指导区间须填写理由，部门占用不得超过预算
+func (s *CompensationReviewService) SaveProposals(id, userID uint, role string, inputs []ProposalInput) ([]models.CompensationReviewProposal, error) {
+	cycle, err := s.GetCycle(id)
+	if err != nil {
+		return nil, err
+	}
+	if cycle.Status != models.ReviewCycleOpen {
+		return nil, utils.NewConflictError(fmt.Sprintf("调薪周期%s，不能提交调薪建议", reviewCycleLabels[cycle.Status]))
+	}
+	if len(inputs) == 0 {
+		return nil, &utils.ValidationError{Message: "请至少提交一条调薪建议"}
+	}
+
+	employees, err := s.reviewEmployees(userID, role, nil)
+	if err != nil {
+		return nil, err
+	}
+	employeeIDs := make([]uint, len(inputs))
+	for i, input := range inputs {
+		employeeIDs[i] = input.EmployeeID
+	}
+	selected, err := SelectReviewEmployees(employees, employeeIDs)
+	if err != nil {
+		return nil, err
+	}
+	inScope := make(map[uint]*models.Employee, len(selected))
+	for i := range selected {
+		inScope[selected[i].ID] = &selected[i]
+	}
+	contexts, err := s.loadReviewContext(cycle, selected)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing []models.CompensationReviewProposal
+	if err := s.db.Where("cycle_id = ?", id).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	existingByEmployee := make(map[uint]models.CompensationReviewProposal, len(existing))
+	for _, proposal := range existing {
+		existingByEmployee[proposal.EmployeeID] = proposal
+	}
+
+	saved := make([]models.CompensationReviewProposal, 0, len(inputs))
+	for _, input := range inputs {
+		employee := inScope[input.EmployeeID]
+		ctx := contexts[input.EmployeeID]
+
+		proposal, ok := existingByEmployee[input.EmployeeID]
+		if ok && proposal.Status != models.ProposalDraft && proposal.Status != models.ProposalRejected {
+			return nil, utils.NewConflictError(fmt.Sprintf("员工 %s 的调薪建议已提交，不能修改", employee.Name))
+		}
+		proposal.CycleID = id
+		proposal.EmployeeID = employee.ID
+		proposal.DepartmentID = employee.DepartmentID
+		proposal.CurrentBase = ctx.currentBase
+		proposal.PerformanceScore = ctx.score
+		proposal.CompaRatio = ctx.compaRatio
+		proposal.Justification = strings.TrimSpace(input.Justification)
+		proposal.Status = models.ProposalDraft
+		proposal.ProposedBy = userID
+		proposal.ReviewedBy, proposal.ReviewedAt, proposal.ReviewNotes = nil, nil, ""
+
+		switch {
+		case input.ProposedBase != nil:
+			proposal.ProposedBase = *input.ProposedBase
+		case input.IncreasePercent != nil:
+			if math.IsNaN(*input.IncreasePercent) || math.IsInf(*input.IncreasePercent, 0) {
+				return nil, &utils.ValidationError{Message: fmt.Sprintf("员工 %s 的涨幅无效", employee.Name)}
+			}
+			proposal.ProposedBase = ctx.currentBase.Mul(1+*input.IncreasePercent/100, models.RoundHalfUp)
+		default:
+			return nil, &utils.ValidationError{Message: fmt.Sprintf("请填写员工 %s 的建议薪资或涨幅", employee.Name)}
+		}
+		if proposal.ProposedBase.Sign() < 0 {
+			return nil, &utils.ValidationError{Message: fmt.Sprintf("员工 %s 的建议薪资无效", employee.Name)}
+		}
+
+		EvaluateProposal(&proposal, MatchReviewGuideline(cycle.Guidelines, ctx.score, ctx.compaRatio))
+		if proposal.OutsideGuideline && proposal.Justification == "" {
+			return nil, &utils.ValidationError{Message: fmt.Sprintf("员工 %s 的涨幅 %.2f%% 超出指导范围，请填写理由",
+				employee.Name, proposal.IncreasePercent)}
+		}
+		existingByEmployee[input.EmployeeID] = proposal
+		saved = append(saved, proposal)
+	}
+
+	all := make([]models.CompensationReviewProposal, 0, len(existingByEmployee))
+	for _, proposal := range existingByEmployee {
+		all = append(all, proposal)
+	}
+	if err := ensureWithinReviewBudget(cycle.Budgets, all, saved); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for i := range saved {
+			if err := tx.Omit("Employee").Save(&saved[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// ensureWithinReviewBudget 本次变更涉及的部门占用不得超过预算，未设置预算的部门不能涨薪
+func ensureWithinReviewBudget(budgets []models.CompensationReviewBudget, all, changed []models.CompensationReviewProposal) error {
+	touched := make(map[uint]bool, len(changed))
+	for _, proposal := range changed {
+		touched[proposal.DepartmentID] = true
+	}
+	budgeted := make(map[uint]bool, len(budgets))
+	for _, budget := range budgets {
+		budgeted[budget.DepartmentID] = true
+	}
+
+	for _, summary := range SummarizeReviewBudgets(budgets, all) {
+		if !touched[summary.DepartmentID] || summary.Committed.Sign() <= 0 {
+			continue
+		}
+		if !budgeted[summary.DepartmentID] {
+			return &utils.ValidationError{Message: fmt.Sprintf("部门 %d 未设置本次调薪预算", summary.DepartmentID)}
+		}
+		if summary.Remaining.Sign() < 0 {
+			return &utils.ValidationError{Message: fmt.Sprintf("超出部门调薪预算：预算 %s，已占用 %s",
+				summary.Budget, summary.Committed)}
+		}
+	}
+	return nil
+}
+
+// SubmitProposals 提交草稿建议进入审批，未指定ID时提交本人范围内的全部草稿
+func (s *CompensationReviewService) SubmitProposals(id, userID uint, role string, proposalIDs []uint) (int, error) {
+	cycle, err := s.GetCycle(id)
+	if err != nil {
+		return 0, err
+	}
+	if cycle.Status != models.ReviewCycleOpen {
+		return 0, utils.NewConflictError(fmt.Sprintf("调薪周期%s，不能提交调薪建议", reviewCycleLabels[cycle.Status]))
+	}
+
+	employees, err := s.reviewEmployees(userID, role, nil)
+	if err != nil {
+		return 0, err
+	}
+	employeeIDs := make([]uint, len(employees))
+	for i, employee := range employees {
+		employeeIDs[i] = employee.ID
+	}
+
+	query := s.db.Model(&models.CompensationReviewProposal{}).
+		Where("cycle_id = ? AND status = ?", id, models.ProposalDraft)
+	if len(proposalIDs) > 0 {
+		var outOfScope int64
+		if err := s.db.Model(&models.CompensationReviewProposal{}).
+			Where("cycle_id = ? AND id IN ? AND employee_id NOT IN ?", id, proposalIDs, append(employeeIDs, 0)).
+			Count(&outOfScope).Error; err != nil {
+			return 0, err
+		}
+		if outOfScope > 0 {
+			return 0, ErrReviewScope
+		}
+		query = query.Where("id IN ?", proposalIDs)
+	} else {
+		query = query.Where("employee_id IN ?", append(employeeIDs, 0))
+	}
+
+	result := query.Update("status", models.ProposalSubmitted)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return int(result.RowsAffected), nil
+}
+
+// ========================= Approval & Finalization =========================
+
+func (s *CompensationReviewService) GetSummary(id uint) (*ReviewSummary, error) {
+	cycle, err := s.GetCycle(id)
+	if err != nil {
+		return nil, err
+	}
+	var proposals []models.CompensationReviewProposal
+	if err := s.db.Where("cycle_id = ?", id).Find(&proposals).Error; err != nil {
+		return nil, err
+	}
+
+	summary := &ReviewSummary{Cycle: cycle, Departments: SummarizeReviewBudgets(cycle.Budgets, proposals)}
+	departmentNames := make(map[uint]string)
+	var missing []uint
+	for _, department := range summary.Departments {
+		if department.DepartmentName == "" {
+			missing = append(missing, department.DepartmentID)
+		}
+	}
+	if len(missing) > 0 {
+		var departments []models.Department
+		if err := s.db.Where("id IN ?", missing).Find(&departments).Error; err != nil {
+			return nil, err
+		}
+		for _, department := range departments {
+			departmentNames[department.ID] = department.Name
+		}
+	}
+
+	var percentTotal float64
+	for i := range summary.Departments {
+		department := &summary.Departments[i]
+		if department.DepartmentName == "" {
+			department.DepartmentName = departmentNames[department.DepartmentID]
+		}
+		summary.Total.Budget = summary.Total.Budget.Add(department.Budget)
+		summary.Total.Committed = summary.Total.Committed.Add(department.Committed)
+		summary.Total.Approved = summary.Total.Approved.Add(department.Approved)
+		summary.Total.Proposals += department.Proposals
+		summary.Total.Pending += department.Pending
+		summary.Total.ApprovedCount += department.ApprovedCount
+		summary.Total.OutsideGuideline += department.OutsideGuideline
+		percentTotal += department.AveragePercent * float64(department.Proposals)
+	}
+	summary.Total.DepartmentName = "合计"
+	summary.Total.Remaining = summary.Total.Budget.Sub(summary.Total.Committed)
+	if summary.Total.Proposals > 0 {
+		summary.Total.AveragePercent = roundAmount(percentTotal / float64(summary.Total.Proposals))
+	}
+	return summary, nil
+}
+
+// ReviewProposals 审批已提交的建议；批准后部门已批准金额不得超过预算
+func (s *CompensationReviewService) ReviewProposals(id, userID uint, decision ProposalDecision) (int, error) {
+	cycle, err := s.GetCycle(id)
+	if err != nil {
+		return 0, err
+	}
+	if cycle.Status != models.ReviewCycleOpen && cycle.Status != models.ReviewCycleReview {
+		return 0, utils.NewConflictError(fmt.Sprintf("调薪周期%s，不能审批", reviewCycleLabels[cycle.Status]))
+	}
+	if len(decision.ProposalIDs) == 0 && decision.DepartmentID == nil {
+		return 0, &utils.ValidationError{Message: "请指定要审批的建议或部门"}
+	}
+	if !decision.Approve && strings.TrimSpace(decision.Notes) == "" {
+		return 0, &utils.ValidationError{Message: "驳回时请填写审批意见"}
+	}
+
+	query := s.db.Where("cycle_id = ? AND status = ?", id, models.ProposalSubmitted)
+	if len(decision.ProposalIDs) > 0 {
+		query = query.Where("id IN ?", decision.ProposalIDs)
+	}
+	if decision.DepartmentID != nil {
+		query = query.Where("department_id = ?", *decision.DepartmentID)
+	}
+	var targets []models.CompensationReviewProposal
+	if err := query.Find(&targets).Error; err != nil {
+		return 0, err
+	}
+	if len(targets) == 0 {
+		return 0, &utils.ValidationError{Message: "没有待审批的调薪建议"}
+	}
+
+	status := models.ProposalRejected
+	if decision.Approve {
+		status = models.ProposalApproved
+		var approved []models.CompensationReviewProposal
+		if err := s.db.Where("cycle_id = ? AND status = ?", id, models.ProposalApproved).Find(&approved).Error; err != nil {
+			return 0, err
+		}
+		for i := range targets {
+			target := targets[i]
+			target.Status = models.ProposalApproved
+			approved = append(approved, target)
+		}
+		for _, summary := range SummarizeReviewBudgets(cycle.Budgets, approved) {
+			if summary.Approved.Cmp(summary.Budget) > 0 {
+				return 0, utils.NewConflictError(fmt.Sprintf("批准后部门 %d 的调薪金额 %s 超出预算 %s",
+					summary.DepartmentID, summary.Approved, summary.Budget))
+			}
+		}
+	}
+
+	ids := make([]uint, len(targets))
+	for i, target := range targets {
+		ids[i] = target.ID
+	}
+	now := time.Now()
+	result := s.db.Model(&models.CompensationReviewProposal{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"status":       status,
+		"reviewed_by":  userID,
+		"reviewed_at":  now,
+		"review_notes": strings.TrimSpace(decision.Notes),
+	})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return int(result.RowsAffected), nil
+}
+
+// FinalizeCycle 为已批准的建议生成统一生效日期的调薪记录（已批准状态），生效日期落在已发放周期时同步生成补发
+func (s *CompensationReviewService) FinalizeCycle(id, userID uint) (*ReviewFinalizeResult, error) {
+	cycle, err := s.GetCycle(id)
+	if err != nil {
+		return nil, err
+	}
+	if cycle.Status != models.ReviewCycleReview {
+		return nil, utils.NewConflictError("请先停止提交并完成审批后再生成调薪记录")
+	}
+
+	var pending int64
+	if err := s.db.Model(&models.CompensationReviewProposal{}).
+		Where("cycle_id = ? AND status = ?", id, models.ProposalSubmitted).Count(&pending).Error; err != nil {
+		return nil, err
+	}
+	if pending > 0 {
+		return nil, utils.NewConflictError(fmt.Sprintf("仍有 %d 条调薪建议待审批", pending))
+	}
+	if err := ensureAdjustmentDateAllowed(s.db, cycle.EffectiveDate); err != nil {
+		return nil, err
+	}
+
+	var approved []models.CompensationReviewProposal
+	if err := s.db.Where("cycle_id = ? AND status = ? AND adjustment_id IS NULL", id, models.ProposalApproved).
+		Order("department_id ASC, employee_id ASC").Find(&approved).Error; err != nil {
+		return nil, err
+	}
+
+	result := &ReviewFinalizeResult{AdjustmentIDs: []uint{}, BandWarnings: []string{}}
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for i := range approved {
+			proposal := &approved[i]
+			if proposal.IncreaseAmount.IsZero() {
+				continue
+			}
+			adjustment := models.SalaryAdjustment{
+				EmployeeID:        proposal.EmployeeID,
+				AdjustmentType:    models.AdjustmentTypeAnnual,
+				Reason:            truncateRunes(cycle.Name, 255),
+				EffectiveDate:     cycle.EffectiveDate,
+				OldBaseSalary:     proposal.CurrentBase,
+				NewBaseSalary:     proposal.ProposedBase,
+				AdjustmentAmount:  proposal.IncreaseAmount,
+				AdjustmentPercent: proposal.IncreasePercent,
+				ApprovedBy:        &userID,
+				ApprovedAt:        &now,
+				Status:            "approved",
+				Notes:             proposal.Justification,
+			}
+			if err := applyAdjustmentBandPolicy(tx, &adjustment); err != nil {
+				return err
+			}
+			if err := tx.Create(&adjustment).Error; err != nil {
+				return err
+			}
+			if _, err := newRetroPayEngine(tx).process(&adjustment); err != nil {
+				return err
+			}
+			if err := tx.Model(proposal).Update("adjustment_id", adjustment.ID).Error; err != nil {
+				return err
+			}
+			result.AdjustmentIDs = append(result.AdjustmentIDs, adjustment.ID)
+			if adjustment.BandWarning != "" {
+				result.BandWarnings = append(result.BandWarnings, fmt.Sprintf("员工 %d：%s", adjustment.EmployeeID, adjustment.BandWarning))
+			}
+		}
+		return tx.Model(&models.CompensationReviewCycle{ID: id}).Updates(map[string]interface{}{
+			"status":       models.ReviewCycleCompleted,
+			"completed_by": userID,
+			"completed_at": now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cycle.Status = models.ReviewCycleCompleted
+	cycle.CompletedBy = &userID
+	cycle.CompletedAt = &now
+	result.Cycle = cycle
+	return result, nil
+}
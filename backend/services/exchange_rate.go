@@ -0,0 +1,253 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"gin-project/models"
+	"gin-project/utils"
+
+	"gorm.io/gorm"
+)
+
+// ========================= Exchange Rates =========================
+
+type ExchangeRateServiceInterface interface {
+	GetExchangeRates(params ExchangeRateQueryParams) ([]models.ExchangeRate, error)
+	CreateExchangeRate(rate *models.ExchangeRate) (*models.ExchangeRate, error)
+	UpdateExchangeRate(id uint, rate *models.ExchangeRate) (*models.ExchangeRate, error)
+	DeleteExchangeRate(id uint) error
+
+	// 按指定日期有效的汇率折算为集团报告币种
+	ConvertToReporting(amount models.Money, currency string, on time.Time) (*CurrencyConversion, error)
+}
+
+type ExchangeRateService struct {
+	db *gorm.DB
+}
+
+func NewExchangeRateService(db *gorm.DB) ExchangeRateServiceInterface {
+	return &ExchangeRateService{db: db}
+}
+
+type ExchangeRateQueryParams struct {
+	FromCurrency string
+	ToCurrency   string
+}
+
+// CurrencyConversion 金额折算结果
+type CurrencyConversion struct {
+	Amount          models.Money `json:"amount"`
+	Currency        string       `json:"currency"`
+	Rate            float64      `json:"rate"`
+	ConvertedAmount models.Money `json:"converted_amount"`
+	TargetCurrency  string       `json:"target_currency"`
+	Date            time.Time    `json:"date"`
+}
+
+// ReportingCurrency 集团报告币种，由 PAYROLL_REPORTING_CURRENCY 配置，默认人民币
+func ReportingCurrency() string {
+	return models.NormalizeCurrency(os.Getenv("PAYROLL_REPORTING_CURRENCY"))
+}
+
+func (s *ExchangeRateService) GetExchangeRates(params ExchangeRateQueryParams) ([]models.ExchangeRate, error) {
+	query := s.db.Model(&models.ExchangeRate{})
+	if params.FromCurrency != "" {
+		query = query.Where("from_currency = ?", models.NormalizeCurrency(params.FromCurrency))
+	}
+	if params.ToCurrency != "" {
+		query = query.Where("to_currency = ?", models.NormalizeCurrency(params.ToCurrency))
+	}
+
+	var rates []models.ExchangeRate
+	if err := query.Order("from_currency ASC, to_currency ASC, effective_date DESC").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func (s *ExchangeRateService) CreateExchangeRate(rate *models.ExchangeRate) (*models.ExchangeRate, error) {
+	if err := s.validateRate(rate, 0); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(rate).Error; err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+func (s *ExchangeRateService) UpdateExchangeRate(id uint, rate *models.ExchangeRate) (*models.ExchangeRate, error) {
+	var existing models.ExchangeRate
+	if err := s.db.First(&existing, id).Error; err != nil {
+		return nil, &utils.ValidationError{Message: "汇率不存在"}
+	}
+
+	rate.ID = id
+	rate.CreatedAt = existing.CreatedAt
+	if err := s.validateRate(rate, id); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(rate).Error; err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+func (s *ExchangeRateService) DeleteExchangeRate(id uint) error {
+	return s.db.Delete(&models.ExchangeRate{}, id).Error
+}
+
+func (s *ExchangeRateService) validateRate(rate *models.ExchangeRate, id uint) error {
+	rate.FromCurrency = models.NormalizeCurrency(rate.FromCurrency)
+	rate.ToCurrency = models.NormalizeCurrency(rate.ToCurrency)
+	if !models.IsValidCurrency(rate.FromCurrency) || !models.IsValidCurrency(rate.ToCurrency) {
+		return &utils.ValidationError{Message: "币种代码须为三位字母"}
+	}
+	if rate.FromCurrency == rate.ToCurrency {
+		return &utils.ValidationError{Message: "源币种和目标币种不能相同"}
+	}
+	if rate.Rate <= 0 {
+		return &utils.ValidationError{Message: "汇率必须大于 0"}
+	}
+	if rate.EffectiveDate.IsZero() {
+		return &utils.ValidationError{Message: "生效日期不能为空"}
+	}
+	rate.EffectiveDate = truncateDate(rate.EffectiveDate)
+
+	var count int64
+	if err := s.db.Model(&models.ExchangeRate{}).
+		Where("from_currency = ? AND to_currency = ? AND effective_date = ? AND id <> ?",
+			rate.FromCurrency, rate.ToCurrency, rate.EffectiveDate, id).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return &utils.ValidationError{Message: fmt.Sprintf("%s→%s 在 %s 已有汇率",
+			rate.FromCurrency, rate.ToCurrency, rate.EffectiveDate.Format("2006-01-02"))}
+	}
+	return nil
+}
+
+func (s *ExchangeRateService) ConvertToReporting(amount models.Money, currency string, on time.Time) (*CurrencyConversion, error) {
+	converter, err := loadCurrencyConverter(s.db, ReportingCurrency())
+	if err != nil {
+		return nil, err
+	}
+	return converter.Convert(amount, currency, on)
+}
+
+// ========================= Currency Conversion =========================
+
+// CurrencyConverter 按生效日期查找汇率并折算到目标币种；
+// 优先使用源币种→目标币种的汇率，没有时使用反向汇率的倒数
+type CurrencyConverter struct {
+	target string
+	rates  []models.ExchangeRate // 按生效日期倒序
+}
+
+func NewCurrencyConverter(rates []models.ExchangeRate, target string) *CurrencyConverter {
+	sorted := make([]models.ExchangeRate, len(rates))
+	copy(sorted, rates)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].EffectiveDate.After(sorted[j].EffectiveDate) })
+	return &CurrencyConverter{target: models.NormalizeCurrency(target), rates: sorted}
+}
+
+// loadCurrencyConverter 加载与目标币种相关的全部汇率
+func loadCurrencyConverter(db *gorm.DB, target string) (*CurrencyConverter, error) {
+	target = models.NormalizeCurrency(target)
+	var rates []models.ExchangeRate
+	if err := db.Where("to_currency = ? OR from_currency = ?", target, target).Find(&rates).Error; err != nil {
+		return nil, fmt.Errorf("failed to load exchange rates: %w", err)
+	}
+	return NewCurrencyConverter(rates, target), nil
+}
+
+// Target 折算目标币种
+func (c *CurrencyConverter) Target() string {
+	return c.target
+}
+
+// Rate 返回指定日期 1 单位源币种折合目标币种的汇率
+func (c *CurrencyConverter) Rate(currency string, on time.Time) (float64, bool) {
+	currency = models.NormalizeCurrency(currency)
+	if currency == c.target {
+		return 1, true
+	}
+	on = truncateDate(on)
+	for _, rate := range c.rates {
+		if rate.FromCurrency == currency && rate.ToCurrency == c.target && !rate.EffectiveDate.After(on) {
+			return rate.Rate, true
+		}
+	}
+	for _, rate := range c.rates {
+		if rate.FromCurrency == c.target && rate.ToCurrency == currency && !rate.EffectiveDate.After(on) && rate.Rate > 0 {
+			return 1 / rate.Rate, true
+		}
+	}
+	return 0, false
+}
+
+// Convert 按指定日期有效的汇率折算金额，结果四舍五入到分
+func (c *CurrencyConverter) Convert(amount models.Money, currency string, on time.Time) (*CurrencyConversion, error) {
+	currency = models.NormalizeCurrency(currency)
+	rate, ok := c.Rate(currency, on)
+	if !ok {
+		return nil, &utils.ValidationError{Message: fmt.Sprintf("缺少 %s→%s 在 %s 有效的汇率",
+			currency, c.target, on.Format("2006-01-02"))}
+	}
+	return &CurrencyConversion{
+		Amount:          amount,
+		Currency:        currency,
+		Rate:            rate,
+		ConvertedAmount: amount.Mul(rate, models.RoundHalfUp),
+		TargetCurrency:  c.target,
+		Date:            on,
+	}, nil
+}
+
+// CurrencyPeriodTotal 某币种在某薪资周期的原币薪资汇总，按周期结束日的汇率折算
+type CurrencyPeriodTotal struct {
+	Currency   string       `gorm:"column:currency"`
+	PeriodEnd  time.Time    `gorm:"column:period_end"`
+	Count      int          `gorm:"column:count"`
+	TotalGross models.Money `gorm:"column:total_gross"`
+	TotalNet   models.Money `gorm:"column:total_net"`
+}
+
+// SummarizeCurrencies 按币种汇总原币金额并折算为目标币种，不同币种的原币金额不会相加
+func (c *CurrencyConverter) SummarizeCurrencies(totals []CurrencyPeriodTotal) ([]CurrencyAnalytics, error) {
+	byCurrency := make(map[string]*CurrencyAnalytics)
+	var order []string
+	for _, total := range totals {
+		currency := models.NormalizeCurrency(total.Currency)
+		summary, ok := byCurrency[currency]
+		if !ok {
+			summary = &CurrencyAnalytics{Currency: currency}
+			byCurrency[currency] = summary
+			order = append(order, currency)
+		}
+
+		gross, err := c.Convert(total.TotalGross, currency, total.PeriodEnd)
+		if err != nil {
+			return nil, err
+		}
+		net, err := c.Convert(total.TotalNet, currency, total.PeriodEnd)
+		if err != nil {
+			return nil, err
+		}
+		summary.EmployeeCount += total.Count
+		summary.TotalGross = summary.TotalGross.Add(total.TotalGross)
+		summary.TotalNet = summary.TotalNet.Add(total.TotalNet)
+		summary.ReportingGross = summary.ReportingGross.Add(gross.ConvertedAmount)
+		summary.ReportingNet = summary.ReportingNet.Add(net.ConvertedAmount)
+	}
+
+	sort.Strings(order)
+	result := make([]CurrencyAnalytics, 0, len(order))
+	for _, currency := range order {
+		result = append(result, *byCurrency[currency])
+	}
+	return result, nil
+}
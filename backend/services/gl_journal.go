@@ -0,0 +1,427 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gin-project/models"
+	"gin-project/utils"
+
+	"gorm.io/gorm"
+)
+
+// ========================= General Ledger Journal =========================
+
+type GLJournalServiceInterface interface {
+	// 科目映射
+	GetAccountMappings() ([]models.GLAccountMapping, error)
+	CreateAccountMapping(mapping *models.GLAccountMapping) (*models.GLAccountMapping, error)
+	UpdateAccountMapping(id uint, mapping *models.GLAccountMapping) (*models.GLAccountMapping, error)
+	DeleteAccountMapping(id uint) error
+
+	// 凭证生成
+	GeneratePeriodJournal(periodID uint) (*PayrollJournal, error)
+}
+
+type GLJournalService struct {
+	db *gorm.DB
+}
+
+func NewGLJournalService(db *gorm.DB) GLJournalServiceInterface {
+	return &GLJournalService{db: db}
+}
+
+// CostAllocation 员工成本分摊到成本中心的比例（0-1）
+type CostAllocation struct {
+	CostCenter string  `json:"cost_center"`
+	Ratio      float64 `json:"ratio"`
+}
+
+// JournalLine 凭证分录行
+type JournalLine struct {
+	LineNo        int          `json:"line_no"`
+	Account       string       `json:"account"`
+	AccountName   string       `json:"account_name"`
+	CostCenter    string       `json:"cost_center"`
+	Debit         models.Money `json:"debit"`
+	Credit        models.Money `json:"credit"`
+	ComponentCode string       `json:"component_code"`
+	Description   string       `json:"description"`
+}
+
+// PayrollJournal 薪资周期的总账凭证
+type PayrollJournal struct {
+	Reference     string        `json:"reference"`
+	PeriodID      uint          `json:"period_id"`
+	PeriodName    string        `json:"period_name"`
+	JournalDate   time.Time     `json:"journal_date"`
+	Currency      string        `json:"currency"`
+	EmployeeCount int           `json:"employee_count"`
+	Lines         []JournalLine `json:"lines"`
+	TotalDebit    models.Money  `json:"total_debit"`
+	TotalCredit   models.Money  `json:"total_credit"`
+}
+
+// journalStatuses 仅已批准或已发放的薪资入账
+var journalStatuses = []models.SalaryStatus{models.SalaryStatusApproved, models.SalaryStatusPaid}
+
+// ========================= Account Mapping Management =========================
+
+func (s *GLJournalService) GetAccountMappings() ([]models.GLAccountMapping, error) {
+	var mappings []models.GLAccountMapping
+	if err := s.db.Preload("Component").Order("component_id ASC").Find(&mappings).Error; err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
+
+func (s *GLJournalService) CreateAccountMapping(mapping *models.GLAccountMapping) (*models.GLAccountMapping, error) {
+	if err := s.validateMapping(mapping, 0); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(mapping).Error; err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+func (s *GLJournalService) UpdateAccountMapping(id uint, mapping *models.GLAccountMapping) (*models.GLAccountMapping, error) {
+	var existing models.GLAccountMapping
+	if err := s.db.First(&existing, id).Error; err != nil {
+		return nil, &utils.ValidationError{Message: "科目映射不存在"}
+	}
+
+	mapping.ID = id
+	mapping.CreatedAt = existing.CreatedAt
+	if err := s.validateMapping(mapping, id); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(mapping).Error; err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+func (s *GLJournalService) DeleteAccountMapping(id uint) error {
+	return s.db.Delete(&models.GLAccountMapping{}, id).Error
+}
+
+func (s *GLJournalService) validateMapping(mapping *models.GLAccountMapping, id uint) error {
+	mapping.DebitAccount = strings.TrimSpace(mapping.DebitAccount)
+	mapping.CreditAccount = strings.TrimSpace(mapping.CreditAccount)
+	if mapping.DebitAccount == "" || mapping.CreditAccount == "" {
+		return &utils.ValidationError{Message: "借方科目和贷方科目不能为空"}
+	}
+	if mapping.DebitAccount == mapping.CreditAccount {
+		return &utils.ValidationError{Message: "借方科目和贷方科目不能相同"}
+	}
+
+	var component models.SalaryComponent
+	if err := s.db.First(&component, mapping.ComponentID).Error; err != nil {
+		return &utils.ValidationError{Message: "薪资组件不存在"}
+	}
+	var count int64
+	if err := s.db.Model(&models.GLAccountMapping{}).
+		Where("component_id = ? AND id <> ?", mapping.ComponentID, id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return &utils.ValidationError{Message: fmt.Sprintf("薪资组件「%s」已配置科目映射", component.Name)}
+	}
+	return nil
+}
+
+// ========================= Journal Generation =========================
+
+// GeneratePeriodJournal 生成薪资周期的总账凭证，存在未映射组件或借贷不平衡时拒绝导出
+func (s *GLJournalService) GeneratePeriodJournal(periodID uint) (*PayrollJournal, error) {
+	var period models.PayrollPeriod
+	if err := s.db.First(&period, periodID).Error; err != nil {
+		return nil, &utils.ValidationError{Message: "薪资周期不存在"}
+	}
+
+	var salaries []models.EnhancedSalary
+	if err := s.db.Preload("Employee.Department").Preload("Components.Component").
+		Where("payroll_period_id = ? AND status IN ?", periodID, journalStatuses).
+		Order("employee_id ASC, version ASC").Find(&salaries).Error; err != nil {
+		return nil, err
+	}
+	latest := latestEmployeeSalaries(salaries)
+	if len(latest) == 0 {
+		return nil, &utils.ValidationError{Message: "该薪资周期没有已批准的薪资记录"}
+	}
+	selected := make([]models.EnhancedSalary, 0, len(latest))
+	for _, salary := range latest {
+		selected = append(selected, *salary)
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].EmployeeID < selected[j].EmployeeID })
+
+	var mappings []models.GLAccountMapping
+	if err := s.db.Find(&mappings).Error; err != nil {
+		return nil, err
+	}
+	mappingByComponent := make(map[uint]models.GLAccountMapping, len(mappings))
+	for _, mapping := range mappings {
+		mappingByComponent[mapping.ComponentID] = mapping
+	}
+
+	allocations, err := s.costAllocations(&period, selected)
+	if err != nil {
+		return nil, err
+	}
+	return BuildPayrollJournal(&period, selected, mappingByComponent, allocations)
+}
+
+// costAllocations 按周期内有效的组织分配工作占比分摊成本，无分配时全部计入主部门成本中心
+func (s *GLJournalService) costAllocations(period *models.PayrollPeriod, salaries []models.EnhancedSalary) (map[uint][]CostAllocation, error) {
+	employeeIDs := make([]uint, len(salaries))
+	for i, salary := range salaries {
+		employeeIDs[i] = salary.EmployeeID
+	}
+
+	var assignments []models.EmployeeAssignment
+	if err := s.db.Preload("OrganizationUnit").
+		Where("employee_id IN ? AND status = ? AND work_percentage > 0", employeeIDs, "active").
+		Where("effective_date IS NULL OR effective_date <= ?", period.EndDate).
+		Where("expiration_date IS NULL OR expiration_date >= ?", period.StartDate).
+		Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	byEmployee := make(map[uint][]models.EmployeeAssignment)
+	for _, assignment := range assignments {
+		byEmployee[assignment.EmployeeID] = append(byEmployee[assignment.EmployeeID], assignment)
+	}
+
+	allocations := make(map[uint][]CostAllocation, len(salaries))
+	for _, salary := range salaries {
+		fallback := ""
+		if salary.Employee != nil && salary.Employee.Department != nil {
+			fallback = salary.Employee.Department.CostCenter
+		}
+		allocations[salary.EmployeeID] = AllocateByWorkPercentage(byEmployee[salary.EmployeeID], fallback)
+	}
+	return allocations, nil
+}
+
+// AllocateByWorkPercentage 按工作占比换算各成本中心的分摊比例，同一成本中心合并；
+// 组织单元未设置成本中心时使用主部门成本中心
+func AllocateByWorkPercentage(assignments []models.EmployeeAssignment, fallback string) []CostAllocation {
+	var total float64
+	weights := make(map[string]float64)
+	var order []string
+	for _, assignment := range assignments {
+		costCenter := fallback
+		if assignment.OrganizationUnit != nil && assignment.OrganizationUnit.CostCenter != "" {
+			costCenter = assignment.OrganizationUnit.CostCenter
+		}
+		if _, ok := weights[costCenter]; !ok {
+			order = append(order, costCenter)
+		}
+		weights[costCenter] += assignment.WorkPercentage
+		total += assignment.WorkPercentage
+	}
+	if total <= 0 {
+		return []CostAllocation{{CostCenter: fallback, Ratio: 1}}
+	}
+
+	allocations := make([]CostAllocation, len(order))
+	for i, costCenter := range order {
+		allocations[i] = CostAllocation{CostCenter: costCenter, Ratio: weights[costCenter] / total}
+	}
+	return allocations
+}
+
+// journalKey 分录汇总维度：科目、成本中心、借贷方向、组件
+type journalKey struct {
+	account    string
+	costCenter string
+	debit      bool
+	component  string
+}
+
+// BuildPayrollJournal 按组件科目映射生成凭证：费用类组件（应发项目、企业承担）的借方按成本中心分摊，
+// 金额以分为单位计算并用最大余数法分摊，保证借贷严格平衡；负数金额借贷方向互换
+func BuildPayrollJournal(period *models.PayrollPeriod, salaries []models.EnhancedSalary, mappings map[uint]models.GLAccountMapping, allocations map[uint][]CostAllocation) (*PayrollJournal, error) {
+	amounts := make(map[journalKey]int64)
+	names := make(map[journalKey]string)
+	descriptions := make(map[string]string)
+	var missing []string
+	missingSeen := make(map[uint]bool)
+
+	post := func(key journalKey, name string, cents int64) {
+		amounts[key] += cents
+		names[key] = name
+	}
+
+	for _, salary := range salaries {
+		for _, detail := range salary.Components {
+			cents := detail.FinalValue.Cents()
+			if cents == 0 || detail.Component == nil {
+				continue
+			}
+			component := detail.Component
+			mapping, ok := mappings[detail.ComponentID]
+			if !ok {
+				if !missingSeen[detail.ComponentID] {
+					missingSeen[detail.ComponentID] = true
+					missing = append(missing, fmt.Sprintf("%s(%s)", component.Name, component.Code))
+				}
+				continue
+			}
+			descriptions[component.Code] = component.Name
+
+			debitAccount, debitName := mapping.DebitAccount, mapping.DebitAccountName
+			creditAccount, creditName := mapping.CreditAccount, mapping.CreditAccountName
+			if cents < 0 {
+				cents = -cents
+				debitAccount, creditAccount = creditAccount, debitAccount
+				debitName, creditName = creditName, debitName
+			}
+
+			post(journalKey{account: creditAccount, component: component.Code}, creditName, cents)
+			if !allocatesCost(component.Category) {
+				post(journalKey{account: debitAccount, debit: true, component: component.Code}, debitName, cents)
+				continue
+			}
+			shares := allocations[salary.EmployeeID]
+			if len(shares) == 0 {
+				shares = []CostAllocation{{Ratio: 1}}
+			}
+			for i, split := range splitCents(cents, shares) {
+				post(journalKey{account: debitAccount, costCenter: shares[i].CostCenter, debit: true, component: component.Code}, debitName, split)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, &utils.ValidationError{Message: "以下薪资组件未配置总账科目: " + strings.Join(missing, "、")}
+	}
+
+	keys := make([]journalKey, 0, len(amounts))
+	for key := range amounts {
+		keys = append(keys, key)
+	}
+	// 借方在前，其次按科目、成本中心、组件排序
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.debit != b.debit {
+			return a.debit
+		}
+		if a.account != b.account {
+			return a.account < b.account
+		}
+		if a.costCenter != b.costCenter {
+			return a.costCenter < b.costCenter
+		}
+		return a.component < b.component
+	})
+
+	journal := &PayrollJournal{
+		Reference:     fmt.Sprintf("PAY-%s-%d", period.StartDate.Format("200601"), period.ID),
+		PeriodID:      period.ID,
+		PeriodName:    period.Name,
+		JournalDate:   period.EndDate,
+		Currency:      models.NormalizeCurrency(period.Currency),
+		EmployeeCount: len(salaries),
+		Lines:         make([]JournalLine, 0, len(keys)),
+	}
+	if period.PayDate != nil {
+		journal.JournalDate = *period.PayDate
+	}
+
+	var debitCents, creditCents int64
+	for i, key := range keys {
+		line := JournalLine{
+			LineNo:        i + 1,
+			Account:       key.account,
+			AccountName:   names[key],
+			CostCenter:    key.costCenter,
+			ComponentCode: key.component,
+			Description:   fmt.Sprintf("%s %s", period.Name, descriptions[key.component]),
+		}
+		if key.debit {
+			line.Debit = models.MoneyFromCents(amounts[key])
+			debitCents += amounts[key]
+		} else {
+			line.Credit = models.MoneyFromCents(amounts[key])
+			creditCents += amounts[key]
+		}
+		journal.Lines = append(journal.Lines, line)
+	}
+	journal.TotalDebit = models.MoneyFromCents(debitCents)
+	journal.TotalCredit = models.MoneyFromCents(creditCents)
+
+	if debitCents != creditCents {
+		return nil, utils.NewConflictError(fmt.Sprintf("凭证借贷不平衡：借方 %s，贷方 %s",
+			journal.TotalDebit, journal.TotalCredit))
+	}
+	return journal, nil
+}
+
+// allocatesCost 应发项目与企业承担部分为人工成本，借方按成本中心分摊；代扣项目仅在负债科目间结转
+func allocatesCost(category models.SalaryComponentCategory) bool {
+	switch category {
+	case models.ComponentCategoryDeduction, models.ComponentCategoryTax, models.ComponentCategoryInsurance:
+		return false
+	default:
+		return true
+	}
+}
+
+// splitCents 按比例拆分金额（分），舍入差额按余数从大到小补齐，拆分结果之和等于原金额
+func splitCents(total int64, shares []CostAllocation) []int64 {
+	result := make([]int64, len(shares))
+	remainders := make([]float64, len(shares))
+	var allocated int64
+	for i, share := range shares {
+		exact := float64(total) * share.Ratio
+		result[i] = int64(math.Floor(exact))
+		remainders[i] = exact - float64(result[i])
+		allocated += result[i]
+	}
+
+	order := make([]int, len(shares))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for i := 0; allocated < total; i++ {
+		result[order[i%len(order)]]++
+		allocated++
+	}
+	return result
+}
+
+// WriteJournalCSV 以通用 CSV 格式输出凭证
+func WriteJournalCSV(journal *PayrollJournal) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\xEF\xBB\xBF")
+	writer := csv.NewWriter(&buf)
+	records := [][]string{{"凭证号", "凭证日期", "行号", "科目编码", "科目名称", "成本中心", "借方金额", "贷方金额", "币种", "组件编码", "摘要"}}
+	for _, line := range journal.Lines {
+		records = append(records, []string{
+			journal.Reference,
+			journal.JournalDate.Format("2006-01-02"),
+			fmt.Sprintf("%d", line.LineNo),
+			line.Account,
+			line.AccountName,
+			line.CostCenter,
+			line.Debit.String(),
+			line.Credit.String(),
+			journal.Currency,
+			line.ComponentCode,
+			line.Description,
+		})
+	}
+	records = append(records, []string{journal.Reference, "", "", "", "合计", "",
+		journal.TotalDebit.String(), journal.TotalCredit.String(), journal.Currency, "", ""})
+	if err := writer.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
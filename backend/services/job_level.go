@@ -16,6 +16,7 @@ type JobLevelServiceInterface interface {
 	GetJobLevelsByLevel() ([]*models.JobLevel, error)
 	GetJobLevelStatistics() (*JobLevelStatistics, error)
 	ValidateSalaryRange(jobLevelID uint, salary float64) error
+	CheckSalaryBand(jobLevelID uint, salary float64) (*SalaryBandCheck, error)
 	GetSalaryRangeByLevel(level int) (*SalaryRange, error)
 }
 
@@ -230,20 +231,25 @@ func (jls *JobLevelService) GetJobLevelStatistics() (*JobLevelStatistics, error)
 	}, nil
 }
 
+// ValidateSalaryRange 按职级适用的薪酬带宽（同层次薪资等级优先）校验薪资，未配置带宽时不限制
 func (jls *JobLevelService) ValidateSalaryRange(jobLevelID uint, salary float64) error {
-	var jobLevel models.JobLevel
-	err := jls.db.First(&jobLevel, jobLevelID).Error
+	check, err := jls.CheckSalaryBand(jobLevelID, salary)
 	if err != nil {
 		return err
 	}
 
-	if salary < jobLevel.MinSalary || salary > jobLevel.MaxSalary {
-		return utils.NewValidationError("薪资超出职级范围")
+	if !check.InBand() {
+		return utils.NewValidationError("薪资超出职级范围: " + check.Message)
 	}
 
 	return nil
 }
 
+// CheckSalaryBand 返回薪资在职级带宽中的位置
+func (jls *JobLevelService) CheckSalaryBand(jobLevelID uint, salary float64) (*SalaryBandCheck, error) {
+	return checkSalaryBand(jls.db, jobLevelID, salary)
+}
+
 func (jls *JobLevelService) GetSalaryRangeByLevel(level int) (*SalaryRange, error) {
 	var jobLevel models.JobLevel
 	err := jls.db.Where("level = ? AND status = ?", level, "active").First(&jobLevel).Error
@@ -0,0 +1,639 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"gin-project/models"
+	"gin-project/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPayrollJobConcurrency = 4
+	maxPayrollJobConcurrency     = 16
+	// payrollJobTick 心跳与进度推送间隔
+	payrollJobTick = 2 * time.Second
+	// payrollJobStaleAfter 心跳超过该时长未更新的任务视为执行进程已中断
+	payrollJobStaleAfter = time.Minute
+	// payrollJobSweepInterval 巡检心跳过期任务的间隔
+	payrollJobSweepInterval = 30 * time.Second
+)
+
+type PayrollJobServiceInterface interface {
+	StartJob(req PayrollJobRequest, userID uint) (*models.PayrollJob, error)
+	GetJobs(params PayrollJobQueryParams) (*utils.PaginationResponse, error)
+	GetJob(id uint) (*models.PayrollJob, error)
+	GetJobItems(id uint, status string, page, pageSize int) (*utils.PaginationResponse, error)
+	CancelJob(id uint) (*models.PayrollJob, error)
+	ResumeJob(id uint) (*models.PayrollJob, error)
+	ResumeInterruptedJobs() (int, error)
+	RunSweeper(ctx context.Context)
+}
+
+// PayrollJobService 后台薪资计算任务：持久化任务与员工明细，由有界并发的工作池执行，
+// 进程崩溃后可依据心跳恢复未完成的员工
+type PayrollJobService struct {
+	db            *gorm.DB
+	salaryService SalaryServiceInterface
+	wsService     *WebSocketService
+
+	mu      sync.Mutex
+	running map[uint]context.CancelFunc
+}
+
+// PayrollJobRequest 创建薪资计算任务请求
+type PayrollJobRequest struct {
+	PeriodID     uint   `json:"period_id" binding:"required"`
+	DepartmentID *uint  `json:"department_id"`
+	EmployeeIDs  []uint `json:"employee_ids"`
+	Concurrency  int    `json:"concurrency"`
+}
+
+type PayrollJobQueryParams struct {
+	PeriodID uint
+	Status   string
+	Page     int
+	PageSize int
+}
+
+// PayrollJobProgress 通过 WebSocket 推送的任务进度
+type PayrollJobProgress struct {
+	JobID       uint                    `json:"job_id"`
+	PeriodID    uint                    `json:"period_id"`
+	Status      models.PayrollJobStatus `json:"status"`
+	Total       int                     `json:"total"`
+	Processed   int                     `json:"processed"`
+	Succeeded   int                     `json:"succeeded"`
+	Failed      int                     `json:"failed"`
+	Percent     float64                 `json:"percent"`
+	TotalAmount models.Money            `json:"total_amount"`
+}
+
+func NewPayrollJobService(db *gorm.DB, salaryService SalaryServiceInterface, wsService *WebSocketService) PayrollJobServiceInterface {
+	return &PayrollJobService{
+		db:            db,
+		salaryService: salaryService,
+		wsService:     wsService,
+		running:       make(map[uint]context.CancelFunc),
+	}
+}
+
+// StartJob 创建薪资计算任务并在后台执行
+func (s *PayrollJobService) StartJob(req PayrollJobRequest, userID uint) (*models.PayrollJob, error) {
+	if userID == 0 {
+		return nil, &utils.ValidationError{Message: "无法识别任务发起人"}
+	}
+
+	var period models.PayrollPeriod
+	if err := s.db.First(&period, req.PeriodID).Error; err != nil {
+		return nil, &utils.ValidationError{Message: "薪资周期不存在"}
+	}
+	switch period.PeriodType {
+	case models.PeriodTypeBonus:
+		return nil, &utils.ValidationError{Message: "奖金周期请使用奖金发放计算"}
+	case models.PeriodTypeOffCycle:
+		return nil, &utils.ValidationError{Message: "非周期发放请逐笔录入发放项目"}
+	}
+	if err := ensurePeriodEditable(&period); err != nil {
+		return nil, err
+	}
+
+	var active int64
+	if err := s.db.Model(&models.PayrollJob{}).
+		Where("payroll_period_id = ? AND status IN ?", period.ID, activePayrollJobStatuses).
+		Count(&active).Error; err != nil {
+		return nil, err
+	}
+	if active > 0 {
+		return nil, &utils.ValidationError{Message: "该薪资周期已有进行中的计算任务"}
+	}
+
+	var employeeIDs []uint
+	query := s.db.Model(&models.Employee{}).Where("status = ?", "active")
+	if req.DepartmentID != nil {
+		query = query.Where("department_id = ?", *req.DepartmentID)
+	}
+	if len(req.EmployeeIDs) > 0 {
+		query = query.Where("id IN ?", req.EmployeeIDs)
+	}
+	if err := query.Order("id ASC").Pluck("id", &employeeIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(employeeIDs) == 0 {
+		return nil, &utils.ValidationError{Message: "没有需要计算的员工"}
+	}
+
+	job := &models.PayrollJob{
+		PayrollPeriodID: period.ID,
+		DepartmentID:    req.DepartmentID,
+		Status:          models.PayrollJobPending,
+		Concurrency:     NormalizeJobConcurrency(req.Concurrency),
+		Total:           len(employeeIDs),
+		RequestedBy:     userID,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+		items := make([]models.PayrollJobItem, 0, len(employeeIDs))
+		for _, employeeID := range employeeIDs {
+			items = append(items, models.PayrollJobItem{
+				JobID:      job.ID,
+				EmployeeID: employeeID,
+				Status:     models.PayrollJobItemPending,
+			})
+		}
+		return tx.CreateInBatches(items, 500).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payroll job: %w", err)
+	}
+
+	s.launch(job.ID)
+	return s.GetJob(job.ID)
+}
+
+func (s *PayrollJobService) GetJobs(params PayrollJobQueryParams) (*utils.PaginationResponse, error) {
+	var jobs []models.PayrollJob
+	var total int64
+
+	query := s.db.Model(&models.PayrollJob{}).Preload("PayrollPeriod")
+	if params.PeriodID > 0 {
+		query = query.Where("payroll_period_id = ?", params.PeriodID)
+	}
+	if params.Status != "" {
+		query = query.Where("status = ?", params.Status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	offset := (params.Page - 1) * params.PageSize
+	if err := query.Offset(offset).Limit(params.PageSize).Order("id DESC").Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+
+	response := utils.CreatePaginationResponse(jobs, params.Page, params.PageSize, total)
+	return &response, nil
+}
+
+func (s *PayrollJobService) GetJob(id uint) (*models.PayrollJob, error) {
+	var job models.PayrollJob
+	if err := s.db.Preload("PayrollPeriod").First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetJobItems 获取任务的员工明细，可按状态筛选（如仅查看失败员工）
+func (s *PayrollJobService) GetJobItems(id uint, status string, page, pageSize int) (*utils.PaginationResponse, error) {
+	var items []models.PayrollJobItem
+	var total int64
+
+	query := s.db.Model(&models.PayrollJobItem{}).Preload("Employee").Where("job_id = ?", id)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Offset(offset).Limit(pageSize).Order("id ASC").Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	response := utils.CreatePaginationResponse(items, page, pageSize, total)
+	return &response, nil
+}
+
+// CancelJob 取消任务：正在计算的员工完成后停止，未处理的员工保留为待处理，可通过恢复继续。
+// 执行进程已中断但心跳尚未过期的任务先标记为取消中，由巡检在心跳过期后收尾
+func (s *PayrollJobService) CancelJob(id uint) (*models.PayrollJob, error) {
+	job, err := s.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+	if !job.Status.IsActive() {
+		return nil, &utils.ValidationError{Message: "任务未在执行，无法取消"}
+	}
+
+	if err := s.db.Model(&models.PayrollJob{}).Where("id = ?", id).
+		Update("status", models.PayrollJobCancelling).Error; err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	cancel, ok := s.running[id]
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	} else if PayrollJobStale(job, time.Now()) {
+		// 没有进程在执行该任务，直接标记为已取消
+		if err := s.finishCancelled(job); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetJob(id)
+}
+
+// ResumeJob 恢复已取消、失败或存在失败员工的任务，重新计算所有未成功的员工；
+// 心跳已过期的进行中任务视为执行进程已中断，同样可以恢复
+func (s *PayrollJobService) ResumeJob(id uint) (*models.PayrollJob, error) {
+	job, err := s.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status.IsActive() && !PayrollJobStale(job, time.Now()) {
+		return nil, &utils.ValidationError{Message: "任务正在执行"}
+	}
+	var period models.PayrollPeriod
+	if err := s.db.First(&period, job.PayrollPeriodID).Error; err != nil {
+		return nil, fmt.Errorf("payroll period not found: %w", err)
+	}
+	if err := ensurePeriodEditable(&period); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PayrollJobItem{}).
+			Where("job_id = ? AND status <> ?", id, models.PayrollJobItemSucceeded).
+			Update("status", models.PayrollJobItemPending).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.PayrollJob{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"status":       models.PayrollJobPending,
+			"error":        "",
+			"finished_at":  nil,
+			"heartbeat_at": nil,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.launch(id)
+	return s.GetJob(id)
+}
+
+// ResumeInterruptedJobs 恢复因进程中断而心跳过期的任务，完结无人执行的取消请求；服务启动时及巡检时调用
+func (s *PayrollJobService) ResumeInterruptedJobs() (int, error) {
+	var jobs []models.PayrollJob
+	if err := s.db.Where("status IN ? AND (heartbeat_at IS NULL OR heartbeat_at < ?)",
+		activePayrollJobStatuses, time.Now().Add(-payrollJobStaleAfter)).Find(&jobs).Error; err != nil {
+		return 0, err
+	}
+
+	resumed := 0
+	for i := range jobs {
+		if jobs[i].Status == models.PayrollJobCancelling {
+			if err := s.finishCancelled(&jobs[i]); err != nil {
+				return resumed, err
+			}
+			continue
+		}
+		if s.launch(jobs[i].ID) {
+			resumed++
+		}
+	}
+	return resumed, nil
+}
+
+// RunSweeper 定期巡检心跳过期的任务，直到 ctx 结束。
+// 进程在心跳过期前重启时，启动时的恢复认领不到这些任务，由巡检在过期后接管
+func (s *PayrollJobService) RunSweeper(ctx context.Context) {
+	ticker := time.NewTicker(payrollJobSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if resumed, err := s.ResumeInterruptedJobs(); err != nil {
+				log.Printf("failed to sweep payroll jobs: %v", err)
+			} else if resumed > 0 {
+				log.Printf("resumed %d stale payroll jobs", resumed)
+			}
+		}
+	}
+}
+
+// PayrollJobStale 心跳为空或超过 payrollJobStaleAfter 未更新，说明没有进程在执行该任务
+func PayrollJobStale(job *models.PayrollJob, now time.Time) bool {
+	return job.HeartbeatAt == nil || now.Sub(*job.HeartbeatAt) > payrollJobStaleAfter
+}
+
+// NormalizeJobConcurrency 未指定时使用默认并发数，且不超过上限
+func NormalizeJobConcurrency(concurrency int) int {
+	if concurrency <= 0 {
+		return defaultPayrollJobConcurrency
+	}
+	if concurrency > maxPayrollJobConcurrency {
+		return maxPayrollJobConcurrency
+	}
+	return concurrency
+}
+
+// ========================= Worker Pool =========================
+
+var activePayrollJobStatuses = []models.PayrollJobStatus{
+	models.PayrollJobPending, models.PayrollJobRunning, models.PayrollJobCancelling,
+}
+
+// launch 认领任务（心跳为空或已过期）并在后台执行，认领失败说明任务已由其他进程执行
+func (s *PayrollJobService) launch(jobID uint) bool {
+	now := time.Now()
+	result := s.db.Model(&models.PayrollJob{}).
+		Where("id = ? AND status IN ? AND (heartbeat_at IS NULL OR heartbeat_at < ?)",
+			jobID, []models.PayrollJobStatus{models.PayrollJobPending, models.PayrollJobRunning}, now.Add(-payrollJobStaleAfter)).
+		Updates(map[string]interface{}{
+			"status":       models.PayrollJobRunning,
+			"heartbeat_at": now,
+			"started_at":   gorm.Expr("COALESCE(started_at, ?)", now),
+		})
+	if result.Error != nil {
+		log.Printf("failed to claim payroll job %d: %v", jobID, result.Error)
+		return false
+	}
+	if result.RowsAffected == 0 {
+		return false
+	}
+
+	job, err := s.GetJob(jobID)
+	if err != nil {
+		log.Printf("failed to load payroll job %d: %v", jobID, err)
+		return false
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.running[jobID] = cancel
+	s.mu.Unlock()
+
+	go s.run(ctx, job)
+	return true
+}
+
+func (s *PayrollJobService) run(ctx context.Context, job *models.PayrollJob) {
+	defer func() {
+		s.mu.Lock()
+		if cancel, ok := s.running[job.ID]; ok {
+			cancel()
+			delete(s.running, job.ID)
+		}
+		s.mu.Unlock()
+	}()
+
+	// 上次中断时正在计算的员工重新排队
+	if err := s.db.Model(&models.PayrollJobItem{}).
+		Where("job_id = ? AND status = ?", job.ID, models.PayrollJobItemRunning).
+		Update("status", models.PayrollJobItemPending).Error; err != nil {
+		s.fail(job, err)
+		return
+	}
+
+	var items []models.PayrollJobItem
+	if err := s.db.Where("job_id = ? AND status = ?", job.ID, models.PayrollJobItemPending).
+		Order("id ASC").Find(&items).Error; err != nil {
+		s.fail(job, err)
+		return
+	}
+
+	done := make(chan struct{})
+	go s.watch(ctx, job, done)
+
+	DispatchJobItems(ctx, items, job.Concurrency, func(item models.PayrollJobItem) {
+		s.processItem(job, item)
+	})
+	close(done)
+
+	status := models.PayrollJobCompleted
+	if ctx.Err() != nil {
+		status = models.PayrollJobCancelled
+	}
+	now := time.Now()
+	if err := s.db.Model(&models.PayrollJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"status":       status,
+		"finished_at":  now,
+		"heartbeat_at": nil,
+	}).Error; err != nil {
+		log.Printf("failed to finish payroll job %d: %v", job.ID, err)
+	}
+	job.Status = status
+	s.publish(job)
+}
+
+// DispatchJobItems 以有界并发逐个处理员工明细，ctx 取消后停止派发，
+// 等待已派发的员工处理完毕后返回派发数量
+func DispatchJobItems(ctx context.Context, items []models.PayrollJobItem, concurrency int, process func(models.PayrollJobItem)) int {
+	queue := make(chan models.PayrollJobItem)
+	var wg sync.WaitGroup
+	for i := 0; i < NormalizeJobConcurrency(concurrency); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range queue {
+				process(item)
+			}
+		}()
+	}
+
+	dispatched := 0
+feed:
+	for _, item := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			break feed
+		case queue <- item:
+			dispatched++
+		}
+	}
+	close(queue)
+	wg.Wait()
+	return dispatched
+}
+
+// watch 定期更新心跳并推送进度；发现任务被（其他进程）标记为取消时停止派发
+func (s *PayrollJobService) watch(ctx context.Context, job *models.PayrollJob, done <-chan struct{}) {
+	ticker := time.NewTicker(payrollJobTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			var current models.PayrollJob
+			if err := s.db.Select("id", "status").First(&current, job.ID).Error; err == nil &&
+				current.Status == models.PayrollJobCancelling {
+				s.mu.Lock()
+				if cancel, ok := s.running[job.ID]; ok {
+					cancel()
+				}
+				s.mu.Unlock()
+			}
+			if ctx.Err() == nil {
+				s.db.Model(&models.PayrollJob{}).Where("id = ?", job.ID).Update("heartbeat_at", time.Now())
+			}
+			s.publish(job)
+		}
+	}
+}
+
+// processItem 计算单个员工，失败原因记录在明细上，不影响其他员工
+func (s *PayrollJobService) processItem(job *models.PayrollJob, item models.PayrollJobItem) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.finishItem(&item, nil, fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	if err := s.db.Model(&item).Updates(map[string]interface{}{
+		"status":   models.PayrollJobItemRunning,
+		"attempts": gorm.Expr("attempts + 1"),
+	}).Error; err != nil {
+		s.finishItem(&item, nil, err)
+		return
+	}
+
+	// 上次执行在薪资保存后中断：直接记录已生成的薪资，避免重复计算
+	if item.Attempts > 0 {
+		var existing models.EnhancedSalary
+		if err := s.db.Where("employee_id = ? AND payroll_period_id = ? AND calculated_at >= ?",
+			item.EmployeeID, job.PayrollPeriodID, item.CreatedAt).First(&existing).Error; err == nil {
+			s.finishItem(&item, &existing, nil)
+			return
+		}
+	}
+
+	salary, err := s.salaryService.CalculateEmployeeSalary(item.EmployeeID, job.PayrollPeriodID, job.RequestedBy)
+	s.finishItem(&item, salary, err)
+}
+
+func (s *PayrollJobService) finishItem(item *models.PayrollJobItem, salary *models.EnhancedSalary, err error) {
+	updates := map[string]interface{}{}
+	if err != nil {
+		updates["status"] = models.PayrollJobItemFailed
+		updates["message"] = err.Error()
+	} else {
+		updates["status"] = models.PayrollJobItemSucceeded
+		updates["message"] = ""
+		updates["salary_id"] = salary.ID
+		updates["net_amount"] = salary.NetSalary
+	}
+	if dbErr := s.db.Model(&models.PayrollJobItem{}).Where("id = ?", item.ID).Updates(updates).Error; dbErr != nil {
+		log.Printf("failed to update payroll job item %d: %v", item.ID, dbErr)
+	}
+}
+
+// refreshCounts 根据员工明细重新汇总任务进度（恢复执行时不会重复计数）
+func (s *PayrollJobService) refreshCounts(job *models.PayrollJob) error {
+	var rows []struct {
+		Status models.PayrollJobItemStatus
+		Count  int
+		Amount models.Money
+	}
+	if err := s.db.Model(&models.PayrollJobItem{}).
+		Select("status, COUNT(*) AS count, COALESCE(SUM(net_amount), 0) AS amount").
+		Where("job_id = ?", job.ID).Group("status").Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	job.Total, job.Succeeded, job.Failed, job.TotalAmount = 0, 0, 0, models.Money{}
+	for _, row := range rows {
+		job.Total += row.Count
+		switch row.Status {
+		case models.PayrollJobItemSucceeded:
+			job.Succeeded = row.Count
+			job.TotalAmount = row.Amount
+		case models.PayrollJobItemFailed:
+			job.Failed = row.Count
+		}
+	}
+	job.Processed = job.Succeeded + job.Failed
+
+	return s.db.Model(&models.PayrollJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"total":        job.Total,
+		"processed":    job.Processed,
+		"succeeded":    job.Succeeded,
+		"failed":       job.Failed,
+		"total_amount": job.TotalAmount,
+	}).Error
+}
+
+// publish 汇总进度并推送给发起任务的用户
+func (s *PayrollJobService) publish(job *models.PayrollJob) {
+	if err := s.refreshCounts(job); err != nil {
+		log.Printf("failed to refresh payroll job %d progress: %v", job.ID, err)
+		return
+	}
+
+	var current models.PayrollJob
+	if err := s.db.Select("id", "status").First(&current, job.ID).Error; err == nil {
+		job.Status = current.Status
+	}
+
+	if s.wsService == nil {
+		return
+	}
+	progress := PayrollJobProgress{
+		JobID:       job.ID,
+		PeriodID:    job.PayrollPeriodID,
+		Status:      job.Status,
+		Total:       job.Total,
+		Processed:   job.Processed,
+		Succeeded:   job.Succeeded,
+		Failed:      job.Failed,
+		TotalAmount: job.TotalAmount,
+	}
+	if job.Total > 0 {
+		progress.Percent = roundAmount(float64(job.Processed) * 100 / float64(job.Total))
+	}
+	// 用户未在线时忽略推送失败，进度可通过任务查询接口获取
+	_ = s.wsService.SendToUser(strconv.FormatUint(uint64(job.RequestedBy), 10), MessageTypePayrollJob, progress)
+}
+
+func (s *PayrollJobService) fail(job *models.PayrollJob, err error) {
+	log.Printf("payroll job %d failed: %v", job.ID, err)
+	now := time.Now()
+	s.db.Model(&models.PayrollJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"status":       models.PayrollJobFailed,
+		"error":        err.Error(),
+		"finished_at":  now,
+		"heartbeat_at": nil,
+	})
+	job.Status = models.PayrollJobFailed
+	s.publish(job)
+}
+
+func (s *PayrollJobService) finishCancelled(job *models.PayrollJob) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PayrollJobItem{}).
+			Where("job_id = ? AND status = ?", job.ID, models.PayrollJobItemRunning).
+			Update("status", models.PayrollJobItemPending).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.PayrollJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+			"status":       models.PayrollJobCancelled,
+			"finished_at":  time.Now(),
+			"heartbeat_at": nil,
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cancel payroll job: %w", err)
+	}
+	return nil
+}
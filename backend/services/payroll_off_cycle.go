@@ -0,0 +1,296 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gin-project/models"
+	"gin-project/utils"
+
+	"gorm.io/gorm"
+)
+
+// ========================= Off-cycle Payroll Runs =========================
+
+var offCycleReasonLabels = map[models.OffCycleReason]string{
+	models.OffCycleTermination:   "离职结算",
+	models.OffCycleCorrection:    "更正补发",
+	models.OffCycleReferralBonus: "推荐奖金",
+	models.OffCycleOther:         "一次性发放",
+}
+
+// OffCyclePaymentLine 一次性发放中单个组件的金额
+type OffCyclePaymentLine struct {
+	ComponentCode string       `json:"component_code" binding:"required"`
+	Amount        models.Money `json:"amount" binding:"required"`
+	Notes         string       `json:"notes"`
+}
+
+// OffCyclePaymentItem 单个员工的非周期发放明细
+type OffCyclePaymentItem struct {
+	EmployeeID uint                  `json:"employee_id" binding:"required"`
+	Lines      []OffCyclePaymentLine `json:"lines" binding:"required,min=1,dive"`
+}
+
+// CreateOffCycleRun 在常规周期下创建非周期发放；沿用父周期的年月、起止日期与币种，
+// 使其与父周期同属一个计税月份，并拥有独立的审批、关账与支付批次
+func (s *SalaryService) CreateOffCycleRun(parentID uint, run *models.PayrollPeriod) (*models.PayrollPeriod, error) {
+	var parent models.PayrollPeriod
+	if err := s.db.First(&parent, parentID).Error; err != nil {
+		return nil, &utils.ValidationError{Message: "薪资周期不存在"}
+	}
+	if parent.IsSupplemental() {
+		return nil, &utils.ValidationError{Message: "非周期发放只能挂靠常规薪资周期"}
+	}
+	if !run.OffCycleReason.IsValid() {
+		return nil, &utils.ValidationError{Message: fmt.Sprintf("不支持的非周期发放原因: %s", run.OffCycleReason)}
+	}
+
+	codes := run.AllowedComponentCodes()
+	if _, err := loadOffCycleComponents(s.db, codes); err != nil {
+		return nil, err
+	}
+	run.AllowedComponents = strings.Join(codes, ",")
+
+	if err := createOffCycleRun(s.db, &parent, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// createOffCycleRun 按父周期补全非周期发放的计税月份与币种后保存
+func createOffCycleRun(db *gorm.DB, parent *models.PayrollPeriod, run *models.PayrollPeriod) error {
+	run.ID = 0
+	run.PeriodType = models.PeriodTypeOffCycle
+	run.ParentPeriodID = &parent.ID
+	run.Year = parent.Year
+	run.Month = parent.Month
+	run.Quarter = parent.Quarter
+	run.StartDate = parent.StartDate
+	run.EndDate = parent.EndDate
+	run.Currency = models.NormalizeCurrency(parent.Currency)
+	run.Status = models.PeriodStatusDraft
+	run.IsLocked = false
+	run.Name = strings.TrimSpace(run.Name)
+	if run.Name == "" {
+		run.Name = fmt.Sprintf("%s %s", parent.Name, offCycleReasonLabels[run.OffCycleReason])
+	}
+	return db.Create(run).Error
+}
+
+// GetOffCycleRuns 获取常规周期下的非周期发放
+func (s *SalaryService) GetOffCycleRuns(parentID uint) ([]models.PayrollPeriod, error) {
+	var runs []models.PayrollPeriod
+	if err := s.db.Where("parent_period_id = ? AND period_type = ?", parentID, models.PeriodTypeOffCycle).
+		Order("created_at ASC").Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// CalculateOffCyclePayments 计算非周期发放：仅允许使用该次发放登记的组件，
+// 个税按累计预扣法并入本纳税年度收入，社保公积金已在常规周期扣缴不再重复计算
+func (s *SalaryService) CalculateOffCyclePayments(periodID uint, items []OffCyclePaymentItem, userID uint) (*EnhancedBatchResult, error) {
+	var period models.PayrollPeriod
+	if err := s.db.First(&period, periodID).Error; err != nil {
+		return nil, fmt.Errorf("payroll period not found: %w", err)
+	}
+	if !period.IsOffCycle() {
+		return nil, errors.New("payroll period is not an off-cycle run")
+	}
+	if err := ensurePeriodEditable(&period); err != nil {
+		return nil, err
+	}
+
+	components, err := loadOffCycleComponents(s.db, period.AllowedComponentCodes())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &EnhancedBatchResult{
+		Total:   len(items),
+		Results: make([]EnhancedCalculateItem, 0, len(items)),
+	}
+
+	for _, item := range items {
+		resultItem := EnhancedCalculateItem{EmployeeID: item.EmployeeID}
+
+		salary, err := s.calculateOffCyclePayment(&period, components, item, userID)
+		if err != nil {
+			resultItem.Success = false
+			resultItem.Message = err.Error()
+			result.Failed++
+		} else {
+			resultItem.Success = true
+			resultItem.Message = "计算成功"
+			resultItem.Salary = salary
+			resultItem.GrossAmount = salary.GrossSalary
+			resultItem.NetAmount = salary.NetSalary
+			if salary.Employee != nil {
+				resultItem.EmployeeName = salary.Employee.Name
+			}
+			result.Success++
+			result.TotalAmount = result.TotalAmount.Add(salary.NetSalary)
+		}
+
+		result.Results = append(result.Results, resultItem)
+	}
+
+	return result, nil
+}
+
+func (s *SalaryService) calculateOffCyclePayment(period *models.PayrollPeriod, components map[string]*models.SalaryComponent, item OffCyclePaymentItem, userID uint) (*models.EnhancedSalary, error) {
+	details, err := OffCyclePaymentDetails(components, item.Lines)
+	if err != nil {
+		return nil, err
+	}
+
+	return createOffCycleSalary(s.db, period, item.EmployeeID, details, userID)
+}
+
+// createOffCycleSalary 生成非周期发放薪资记录；extra 为不参与累计预扣的单独计税明细
+func createOffCycleSalary(db *gorm.DB, period *models.PayrollPeriod, employeeID uint, details []models.SalaryDetail, userID uint, extra ...models.SalaryDetail) (*models.EnhancedSalary, error) {
+	var employee models.Employee
+	if err := db.First(&employee, employeeID).Error; err != nil {
+		return nil, fmt.Errorf("employee not found: %w", err)
+	}
+
+	var existing models.EnhancedSalary
+	if err := db.Where("employee_id = ? AND payroll_period_id = ?", employee.ID, period.ID).First(&existing).Error; err == nil {
+		return nil, errors.New("off-cycle payment for this employee already exists")
+	}
+
+	calculation := &structureCalculation{Currency: models.NormalizeCurrency(period.Currency)}
+	for _, detail := range details {
+		calculation.addDetail(detail)
+	}
+	if calculation.Currency == models.DefaultCurrency {
+		if err := newIncomeTaxWithholder(db).withhold(&employee, period, calculation); err != nil {
+			return nil, err
+		}
+	}
+	for _, detail := range extra {
+		calculation.addDetail(detail)
+	}
+	if err := calculation.roundNetPay(db); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	salary := &models.EnhancedSalary{
+		EmployeeID:      employee.ID,
+		PayrollPeriodID: period.ID,
+		Status:          models.SalaryStatusCalculated,
+		CalculatedBy:    &userID,
+		CalculatedAt:    &now,
+		Version:         1,
+	}
+	calculation.applyTo(salary)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(salary).Error; err != nil {
+			return fmt.Errorf("failed to create off-cycle salary: %w", err)
+		}
+		details := calculation.Details
+		for i := range details {
+			details[i].SalaryID = salary.ID
+			details[i].Component = nil
+		}
+		if err := tx.Create(&details).Error; err != nil {
+			return fmt.Errorf("failed to create off-cycle details: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Preload("Employee").Preload("PayrollPeriod").
+		Preload("Components.Component").First(salary, salary.ID).Error; err != nil {
+		return nil, err
+	}
+	return salary, nil
+}
+
+// OffCyclePaymentDetails 将手工录入的发放行转换为薪资明细，组件须在允许范围内且金额为正，
+// 扣款通过扣款类组件录入；金额按组件舍入规则保留
+func OffCyclePaymentDetails(components map[string]*models.SalaryComponent, lines []OffCyclePaymentLine) ([]models.SalaryDetail, error) {
+	if len(lines) == 0 {
+		return nil, &utils.ValidationError{Message: "非周期发放至少需要一个发放项目"}
+	}
+
+	details := make([]models.SalaryDetail, 0, len(lines))
+	seen := make(map[string]bool, len(lines))
+	for _, line := range lines {
+		code := strings.TrimSpace(line.ComponentCode)
+		component, ok := components[code]
+		if !ok {
+			return nil, &utils.ValidationError{Message: fmt.Sprintf("薪资组件 %s 不在本次非周期发放允许的范围内", code)}
+		}
+		if seen[code] {
+			return nil, &utils.ValidationError{Message: fmt.Sprintf("薪资组件 %s 重复录入", code)}
+		}
+		seen[code] = true
+		if line.Amount.Sign() <= 0 {
+			return nil, &utils.ValidationError{Message: fmt.Sprintf("薪资组件 %s 的金额必须大于 0", code)}
+		}
+
+		amount := line.Amount.Round(component.RoundingRule)
+		details = append(details, models.SalaryDetail{
+			ComponentID:        component.ID,
+			Component:          component,
+			CalculatedValue:    amount,
+			FinalValue:         amount,
+			CalculationFormula: fmt.Sprintf("off-cycle payment = %s", amount),
+			Notes:              line.Notes,
+		})
+	}
+	return details, nil
+}
+
+// CheckOffCycleComponent 个税、社保公积金等系统计算项目、全年一次性奖金及企业成本项目不能手工发放
+func CheckOffCycleComponent(component *models.SalaryComponent) error {
+	if isSystemComponent(component.Code) {
+		return &utils.ValidationError{Message: fmt.Sprintf("薪资组件「%s」由系统计算，不能用于非周期发放", component.Name)}
+	}
+	if component.Code == AnnualBonusComponentCode {
+		return &utils.ValidationError{Message: "全年一次性奖金请通过奖金周期发放"}
+	}
+	switch component.Category {
+	case models.ComponentCategoryTax, models.ComponentCategoryInsurance, models.ComponentCategoryEmployerCost:
+		return &utils.ValidationError{Message: fmt.Sprintf("薪资组件「%s」不能用于非周期发放", component.Name)}
+	}
+	return nil
+}
+
+// loadOffCycleComponents 按编码加载非周期发放允许的组件
+func loadOffCycleComponents(db *gorm.DB, codes []string) (map[string]*models.SalaryComponent, error) {
+	if len(codes) == 0 {
+		return nil, &utils.ValidationError{Message: "请指定非周期发放允许使用的薪资组件"}
+	}
+
+	var list []models.SalaryComponent
+	if err := db.Where("code IN ?", codes).Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("failed to load salary components: %w", err)
+	}
+	components := make(map[string]*models.SalaryComponent, len(list))
+	for i := range list {
+		if err := CheckOffCycleComponent(&list[i]); err != nil {
+			return nil, err
+		}
+		components[list[i].Code] = &list[i]
+	}
+
+	var missing []string
+	for _, code := range codes {
+		if _, ok := components[code]; !ok {
+			missing = append(missing, code)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, &utils.ValidationError{Message: "以下薪资组件不存在: " + strings.Join(missing, "、")}
+	}
+	return components, nil
+}
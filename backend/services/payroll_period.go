@@ -0,0 +1,266 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gin-project/models"
+	"gin-project/utils"
+
+	"gorm.io/gorm"
+)
+
+// ========================= Payroll Period Close Workflow =========================
+
+var periodStatusLabels = map[models.PayrollPeriodStatus]string{
+	models.PeriodStatusDraft:       "草稿",
+	models.PeriodStatusOpen:        "开放",
+	models.PeriodStatusCalculating: "计算中",
+	models.PeriodStatusCalculated:  "已计算",
+	models.PeriodStatusReviewed:    "已审核",
+	models.PeriodStatusApproved:    "已批准",
+	models.PeriodStatusPaid:        "已发放",
+	models.PeriodStatusClosed:      "已关闭",
+}
+
+func periodStatusLabel(status models.PayrollPeriodStatus) string {
+	if label, ok := periodStatusLabels[status]; ok {
+		return label
+	}
+	return string(status)
+}
+
+// TransitionPayrollPeriod 按关账流程推进薪资周期状态
+func (s *SalaryService) TransitionPayrollPeriod(id uint, status models.PayrollPeriodStatus, userID uint) (*models.PayrollPeriod, error) {
+	if err := validatePeriodOperator(userID); err != nil {
+		return nil, err
+	}
+
+	var period models.PayrollPeriod
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&period, id).Error; err != nil {
+			return &utils.ValidationError{Message: "薪资周期不存在"}
+		}
+		from := period.Status
+		if !from.CanTransitionTo(status) {
+			return utils.NewConflictError(fmt.Sprintf("薪资周期不能从%s变更为%s",
+				periodStatusLabel(from), periodStatusLabel(status)))
+		}
+		if err := checkPeriodTransition(tx, &period, status); err != nil {
+			return err
+		}
+
+		// 以原状态为条件更新，避免并发流转相互覆盖
+		result := tx.Model(&models.PayrollPeriod{}).
+			Where("id = ? AND status = ?", id, from).
+			Update("status", status)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return utils.NewConflictError("薪资周期状态已被其他操作修改，请刷新后重试")
+		}
+		period.Status = status
+		return recordPeriodEvent(tx, &period, models.PeriodActionTransition, from, "", userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+// checkPeriodTransition 校验流转前置条件：计算任务完成后才能离开计算中状态，薪资全部批准后才能批准或关账
+func checkPeriodTransition(tx *gorm.DB, period *models.PayrollPeriod, target models.PayrollPeriodStatus) error {
+	if period.Status == models.PeriodStatusCalculating {
+		var active int64
+		if err := tx.Model(&models.PayrollJob{}).
+			Where("payroll_period_id = ? AND status IN ?", period.ID, activePayrollJobStatuses).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return utils.NewConflictError("该薪资周期仍有进行中的计算任务")
+		}
+	}
+
+	switch target {
+	case models.PeriodStatusReviewed:
+		var count int64
+		if err := tx.Model(&models.EnhancedSalary{}).
+			Where("payroll_period_id = ?", period.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return utils.NewConflictError("薪资周期尚未计算任何薪资")
+		}
+	case models.PeriodStatusApproved, models.PeriodStatusClosed:
+		var pending int64
+		if err := tx.Model(&models.EnhancedSalary{}).
+			Where("payroll_period_id = ? AND status NOT IN ?", period.ID, []string{"approved", "paid"}).
+			Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending > 0 {
+			return utils.NewConflictError(fmt.Sprintf("薪资周期仍有 %d 条薪资未批准", pending))
+		}
+	}
+	return nil
+}
+
+// ReopenPayrollPeriod 重新开放已锁定的薪资周期，必须填写原因并记录审计日志
+func (s *SalaryService) ReopenPayrollPeriod(id uint, reason string, userID uint) (*models.PayrollPeriod, error) {
+	if err := validatePeriodOperator(userID); err != nil {
+		return nil, err
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, &utils.ValidationError{Message: "重新开放薪资周期必须填写原因"}
+	}
+
+	var period models.PayrollPeriod
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&period, id).Error; err != nil {
+			return &utils.ValidationError{Message: "薪资周期不存在"}
+		}
+		if !period.InputsLocked() {
+			return utils.NewConflictError("薪资周期未锁定，无需重新开放")
+		}
+
+		from := period.Status
+		result := tx.Model(&models.PayrollPeriod{}).
+			Where("id = ? AND status = ?", id, from).
+			Updates(map[string]interface{}{"status": models.PeriodStatusOpen, "is_locked": false})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return utils.NewConflictError("薪资周期状态已被其他操作修改，请刷新后重试")
+		}
+		period.Status, period.IsLocked = models.PeriodStatusOpen, false
+		return recordPeriodEvent(tx, &period, models.PeriodActionReopen, from, reason, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+// GetPayrollPeriodEvents 获取薪资周期的状态变更审计记录
+func (s *SalaryService) GetPayrollPeriodEvents(id uint) ([]models.PayrollPeriodEvent, error) {
+	var events []models.PayrollPeriodEvent
+	if err := s.db.Where("payroll_period_id = ?", id).Order("created_at DESC, id DESC").Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// validatePeriodOperator 周期状态变更必须记录操作人
+func validatePeriodOperator(userID uint) error {
+	if userID == 0 {
+		return &utils.ValidationError{Message: "无法识别操作人，不能变更薪资周期"}
+	}
+	return nil
+}
+
+func recordPeriodEvent(tx *gorm.DB, period *models.PayrollPeriod, action models.PayrollPeriodAction, from models.PayrollPeriodStatus, reason string, userID uint) error {
+	event := models.PayrollPeriodEvent{
+		PayrollPeriodID: period.ID,
+		Action:          action,
+		FromStatus:      from,
+		ToStatus:        period.Status,
+		Reason:          reason,
+		OperatorID:      userID,
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		return fmt.Errorf("failed to record payroll period event: %w", err)
+	}
+	return nil
+}
+
+// ========================= Period Lock Guards =========================
+
+// ensurePeriodEditable 周期锁定时拒绝计算或修改其中的薪资
+func ensurePeriodEditable(period *models.PayrollPeriod) error {
+	if period.InputsLocked() {
+		return periodLockedError(period)
+	}
+	return nil
+}
+
+// ensurePeriodNotClosed 已关账周期拒绝审批、发放等薪资状态变更
+func ensurePeriodNotClosed(period *models.PayrollPeriod) error {
+	if period.IsClosed() {
+		return periodLockedError(period)
+	}
+	return nil
+}
+
+func periodLockedError(period *models.PayrollPeriod) error {
+	state := periodStatusLabel(period.Status)
+	if period.IsLocked && !period.Status.LocksInputs() {
+		state = "手动锁定"
+	}
+	return utils.NewConflictError(fmt.Sprintf("薪资周期「%s」处于%s状态，数据已锁定，如需修改请先重新开放周期", period.Name, state))
+}
+
+// validatePeriodCurrency 规范化周期币种，未指定时按人民币发薪
+func validatePeriodCurrency(period *models.PayrollPeriod) error {
+	period.Currency = models.NormalizeCurrency(period.Currency)
+	if !models.IsValidCurrency(period.Currency) {
+		return &utils.ValidationError{Message: "币种代码须为三位字母"}
+	}
+	return nil
+}
+
+// loadSalaryPeriod 读取薪资记录所属的周期
+func loadSalaryPeriod(db *gorm.DB, salary *models.EnhancedSalary) (*models.PayrollPeriod, error) {
+	var period models.PayrollPeriod
+	if err := db.First(&period, salary.PayrollPeriodID).Error; err != nil {
+		return nil, fmt.Errorf("payroll period not found: %w", err)
+	}
+	return &period, nil
+}
+
+// overlappingPeriods 查找与日期区间重叠的常规薪资周期（奖金与非周期发放不含考勤数据）
+func overlappingPeriods(db *gorm.DB, start, end time.Time) ([]models.PayrollPeriod, error) {
+	var periods []models.PayrollPeriod
+	if err := db.Where("period_type NOT IN ? AND start_date <= ? AND end_date >= ?",
+		models.SupplementalPeriodTypes, end, truncateDate(start)).
+		Find(&periods).Error; err != nil {
+		return nil, err
+	}
+	return periods, nil
+}
+
+// ensureDatesEditable 日期区间落在已锁定的周期内时拒绝修改考勤与请假
+func ensureDatesEditable(db *gorm.DB, start, end time.Time) error {
+	periods, err := overlappingPeriods(db, start, end)
+	if err != nil {
+		return err
+	}
+	for i := range periods {
+		if periods[i].InputsLocked() {
+			return periodLockedError(&periods[i])
+		}
+	}
+	return nil
+}
+
+// ensureAdjustmentDateAllowed 生效日期之后存在审核中或已批准但尚未发放的周期时拒绝调薪，避免已审核薪资失效；
+// 已发放或已关账的周期不受影响，由追溯补发并入下一开放周期
+func ensureAdjustmentDateAllowed(db *gorm.DB, effectiveDate time.Time) error {
+	var periods []models.PayrollPeriod
+	if err := db.Where("period_type NOT IN ? AND end_date >= ? AND status NOT IN ?",
+		models.SupplementalPeriodTypes, truncateDate(effectiveDate),
+		[]models.PayrollPeriodStatus{models.PeriodStatusPaid, models.PeriodStatusClosed}).
+		Order("start_date ASC").Find(&periods).Error; err != nil {
+		return err
+	}
+	for i := range periods {
+		if periods[i].InputsLocked() {
+			return periodLockedError(&periods[i])
+		}
+	}
+	return nil
+}
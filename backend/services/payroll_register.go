@@ -0,0 +1,552 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"gin-project/models"
+	"gin-project/utils"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// ========================= Payroll Register Export =========================
+
+// PayrollRegisterFormat 薪资登记表导出格式
+type PayrollRegisterFormat string
+
+const (
+	PayrollRegisterExcel PayrollRegisterFormat = "excel"
+	PayrollRegisterCSV   PayrollRegisterFormat = "csv"
+)
+
+// registerBatchSize 每批加载明细的薪资条数，控制大批量导出时的内存占用
+const registerBatchSize = 500
+
+// PayrollRegisterColumn 薪资登记表中的薪资项目列
+type PayrollRegisterColumn struct {
+	ComponentID uint
+	Code        string
+	Name        string
+	Category    models.SalaryComponentCategory
+}
+
+// PayrollRegisterRow 薪资登记表中的员工行
+type PayrollRegisterRow struct {
+	EmployeeNo     string
+	EmployeeName   string
+	DepartmentID   uint
+	DepartmentName string
+	Status         string
+	Amounts        map[uint]models.Money
+	GrossSalary    models.Money
+	Deductions     models.Money
+	NetSalary      models.Money
+	EmployerCost   models.Money
+}
+
+// registerTotals 部门小计与总计累加器
+type registerTotals struct {
+	count   int
+	amounts map[uint]models.Money
+	gross   models.Money
+	deduct  models.Money
+	net     models.Money
+	cost    models.Money
+}
+
+func newRegisterTotals() *registerTotals {
+	return &registerTotals{amounts: make(map[uint]models.Money)}
+}
+
+func (t *registerTotals) add(row *PayrollRegisterRow) {
+	t.count++
+	for id, amount := range row.Amounts {
+		t.amounts[id] = t.amounts[id].Add(amount)
+	}
+	t.gross = t.gross.Add(row.GrossSalary)
+	t.deduct = t.deduct.Add(row.Deductions)
+	t.net = t.net.Add(row.NetSalary)
+	t.cost = t.cost.Add(row.EmployerCost)
+}
+
+// registerSink 登记表输出目标
+type registerSink interface {
+	header(values []string) error
+	row(values []interface{}, total bool) error
+	close() error
+}
+
+// PayrollRegisterWriter 逐行写出薪资登记表，部门变化时插入部门小计，关闭时写出总计；
+// 行须按部门排序传入
+type PayrollRegisterWriter struct {
+	sink       registerSink
+	columns    []PayrollRegisterColumn
+	department *PayrollRegisterRow
+	subtotal   *registerTotals
+	grand      *registerTotals
+}
+
+// NewPayrollRegisterWriter 创建登记表写出器并写入表头
+func NewPayrollRegisterWriter(w io.Writer, format PayrollRegisterFormat, columns []PayrollRegisterColumn) (*PayrollRegisterWriter, error) {
+	var sink registerSink
+	var err error
+	switch format {
+	case PayrollRegisterExcel:
+		sink, err = newExcelRegisterSink(w, len(columns)+8)
+	case PayrollRegisterCSV:
+		sink, err = newCSVRegisterSink(w)
+	default:
+		return nil, &utils.ValidationError{Message: "不支持的导出格式: " + string(format)}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	header := []string{"工号", "姓名", "部门", "状态"}
+	for _, column := range columns {
+		header = append(header, column.Name)
+	}
+	header = append(header, "应发合计", "扣除合计", "实发工资", "企业承担")
+	if err := sink.header(header); err != nil {
+		return nil, err
+	}
+	return &PayrollRegisterWriter{sink: sink, columns: columns, grand: newRegisterTotals()}, nil
+}
+
+// WriteRow 写出员工行
+func (w *PayrollRegisterWriter) WriteRow(row *PayrollRegisterRow) error {
+	if w.department != nil && w.department.DepartmentID != row.DepartmentID {
+		if err := w.writeSubtotal(); err != nil {
+			return err
+		}
+	}
+	if w.department == nil {
+		w.department = &PayrollRegisterRow{DepartmentID: row.DepartmentID, DepartmentName: row.DepartmentName}
+		w.subtotal = newRegisterTotals()
+	}
+
+	values := []interface{}{row.EmployeeNo, row.EmployeeName, row.DepartmentName, row.Status}
+	for _, column := range w.columns {
+		values = append(values, row.Amounts[column.ComponentID])
+	}
+	values = append(values, row.GrossSalary, row.Deductions, row.NetSalary, row.EmployerCost)
+	if err := w.sink.row(values, false); err != nil {
+		return err
+	}
+	w.subtotal.add(row)
+	w.grand.add(row)
+	return nil
+}
+
+// Close 写出最后一个部门小计及总计并结束输出
+func (w *PayrollRegisterWriter) Close() error {
+	if w.department != nil {
+		if err := w.writeSubtotal(); err != nil {
+			return err
+		}
+	}
+	if err := w.writeTotals("总计", fmt.Sprintf("%d 人", w.grand.count), w.grand); err != nil {
+		return err
+	}
+	return w.sink.close()
+}
+
+func (w *PayrollRegisterWriter) writeSubtotal() error {
+	name := w.department.DepartmentName
+	if name == "" {
+		name = "未分配部门"
+	}
+	err := w.writeTotals("小计", fmt.Sprintf("%s（%d 人）", name, w.subtotal.count), w.subtotal)
+	w.department, w.subtotal = nil, nil
+	return err
+}
+
+func (w *PayrollRegisterWriter) writeTotals(label, description string, totals *registerTotals) error {
+	values := []interface{}{label, "", description, ""}
+	for _, column := range w.columns {
+		values = append(values, totals.amounts[column.ComponentID])
+	}
+	values = append(values, totals.gross, totals.deduct, totals.net, totals.cost)
+	return w.sink.row(values, true)
+}
+
+// csvRegisterSink CSV 输出，带 UTF-8 BOM 以便 Excel 正确识别中文
+type csvRegisterSink struct {
+	writer *csv.Writer
+}
+
+func newCSVRegisterSink(w io.Writer) (*csvRegisterSink, error) {
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return nil, err
+	}
+	return &csvRegisterSink{writer: csv.NewWriter(w)}, nil
+}
+
+func (s *csvRegisterSink) header(values []string) error {
+	return s.writer.Write(values)
+}
+
+func (s *csvRegisterSink) row(values []interface{}, total bool) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		if amount, ok := value.(models.Money); ok {
+			record[i] = amount.String()
+		} else {
+			record[i] = fmt.Sprint(value)
+		}
+	}
+	return s.writer.Write(record)
+}
+
+func (s *csvRegisterSink) close() error {
+	s.writer.Flush()
+	return s.writer.Error()
+}
+
+// excelRegisterSink 基于 excelize 流式写入的 XLSX 输出：冻结表头、金额使用千分位两位小数格式
+type excelRegisterSink struct {
+	out         io.Writer
+	file        *excelize.File
+	stream      *excelize.StreamWriter
+	rowNum      int
+	headerStyle int
+	amountStyle int
+	totalStyle  int
+	totalAmount int
+}
+
+func newExcelRegisterSink(w io.Writer, columns int) (*excelRegisterSink, error) {
+	file := excelize.NewFile()
+	sink := &excelRegisterSink{out: w, file: file}
+	sheet := "薪资登记表"
+	if err := file.SetSheetName("Sheet1", sheet); err != nil {
+		return nil, err
+	}
+
+	amountFormat := "#,##0.00"
+	styles := []struct {
+		target *int
+		style  *excelize.Style
+	}{
+		{&sink.headerStyle, &excelize.Style{Font: &excelize.Font{Bold: true},
+			Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"D9E1F2"}}}},
+		{&sink.amountStyle, &excelize.Style{CustomNumFmt: &amountFormat}},
+		{&sink.totalStyle, &excelize.Style{Font: &excelize.Font{Bold: true}}},
+		{&sink.totalAmount, &excelize.Style{Font: &excelize.Font{Bold: true}, CustomNumFmt: &amountFormat}},
+	}
+	for _, item := range styles {
+		id, err := file.NewStyle(item.style)
+		if err != nil {
+			return nil, err
+		}
+		*item.target = id
+	}
+
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SetPanes(&excelize.Panes{Freeze: true, XSplit: 2, YSplit: 1,
+		TopLeftCell: "C2", ActivePane: "bottomRight"}); err != nil {
+		return nil, err
+	}
+	if err := stream.SetColWidth(1, 3, 14); err != nil {
+		return nil, err
+	}
+	if err := stream.SetColWidth(4, columns, 12); err != nil {
+		return nil, err
+	}
+	sink.stream = stream
+	return sink, nil
+}
+
+func (s *excelRegisterSink) header(values []string) error {
+	cells := make([]interface{}, len(values))
+	for i, value := range values {
+		cells[i] = excelize.Cell{StyleID: s.headerStyle, Value: value}
+	}
+	return s.setRow(cells)
+}
+
+func (s *excelRegisterSink) row(values []interface{}, total bool) error {
+	cells := make([]interface{}, len(values))
+	for i, value := range values {
+		style := 0
+		amount, isAmount := value.(models.Money)
+		if isAmount {
+			value = amount.Float64()
+		}
+		switch {
+		case total && isAmount:
+			style = s.totalAmount
+		case total:
+			style = s.totalStyle
+		case isAmount:
+			style = s.amountStyle
+		}
+		cells[i] = excelize.Cell{StyleID: style, Value: value}
+	}
+	return s.setRow(cells)
+}
+
+func (s *excelRegisterSink) setRow(cells []interface{}) error {
+	s.rowNum++
+	cell, err := excelize.CoordinatesToCellName(1, s.rowNum)
+	if err != nil {
+		return err
+	}
+	return s.stream.SetRow(cell, cells)
+}
+
+func (s *excelRegisterSink) close() error {
+	defer s.file.Close()
+	if err := s.stream.Flush(); err != nil {
+		return err
+	}
+	return s.file.Write(s.out)
+}
+
+// ========================= Register Query =========================
+
+// PayrollRegisterExport 已校验参数的登记表导出任务，Stream 时才查询并流式写出数据
+type PayrollRegisterExport struct {
+	FileName    string
+	ContentType string
+
+	db      *gorm.DB
+	format  PayrollRegisterFormat
+	params  ExportParams
+	columns []PayrollRegisterColumn
+}
+
+// registerHeader 登记表查询的薪资行
+type registerHeader struct {
+	ID              uint
+	EmployeeNo      string
+	EmployeeName    string
+	DepartmentID    uint
+	DepartmentName  string
+	Status          string
+	GrossSalary     models.Money
+	TotalDeductions models.Money
+	NetSalary       models.Money
+	EmployerCost    models.Money
+}
+
+// newPayrollRegisterExport 校验导出参数并确定薪资项目列
+func newPayrollRegisterExport(db *gorm.DB, params ExportParams) (*PayrollRegisterExport, error) {
+	if params.PeriodID == nil {
+		return nil, &utils.ValidationError{Message: "请指定薪资周期"}
+	}
+	var period models.PayrollPeriod
+	if err := db.First(&period, *params.PeriodID).Error; err != nil {
+		return nil, &utils.ValidationError{Message: "薪资周期不存在"}
+	}
+
+	format := PayrollRegisterFormat(strings.ToLower(params.Format))
+	contentType := ""
+	extension := ""
+	switch format {
+	case "", "xlsx", PayrollRegisterExcel:
+		format = PayrollRegisterExcel
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		extension = "xlsx"
+	case PayrollRegisterCSV:
+		contentType = "text/csv; charset=utf-8"
+		extension = "csv"
+	default:
+		return nil, &utils.ValidationError{Message: "不支持的导出格式: " + params.Format}
+	}
+	if params.Status != "" {
+		if _, ok := salaryStatusLabels[models.SalaryStatus(params.Status)]; !ok {
+			return nil, &utils.ValidationError{Message: "无效的薪资状态: " + params.Status}
+		}
+	}
+
+	export := &PayrollRegisterExport{
+		FileName:    fmt.Sprintf("payroll_register_%s_%s.%s", period.StartDate.Format("200601"), time.Now().Format("20060102150405"), extension),
+		ContentType: contentType,
+		db:          db,
+		format:      format,
+		params:      params,
+	}
+
+	var components []models.SalaryComponent
+	if err := db.Model(&models.SalaryComponent{}).Unscoped().
+		Where("id IN (?)", db.Model(&models.SalaryDetail{}).Select("DISTINCT salary_details.component_id").
+			Where("salary_details.salary_id IN (?)", export.salaryQuery().Select("enhanced_salaries.id"))).
+		Find(&components).Error; err != nil {
+		return nil, err
+	}
+	export.columns = registerColumns(components)
+	return export, nil
+}
+
+// registerColumns 列顺序：应发项目、扣除项目、企业承担，同类按排序号和编码
+func registerColumns(components []models.SalaryComponent) []PayrollRegisterColumn {
+	group := func(category models.SalaryComponentCategory) int {
+		switch category {
+		case models.ComponentCategoryDeduction, models.ComponentCategoryInsurance, models.ComponentCategoryTax:
+			return 1
+		case models.ComponentCategoryEmployerCost:
+			return 2
+		default:
+			return 0
+		}
+	}
+	sort.SliceStable(components, func(i, j int) bool {
+		a, b := components[i], components[j]
+		if group(a.Category) != group(b.Category) {
+			return group(a.Category) < group(b.Category)
+		}
+		if a.Sort != b.Sort {
+			return a.Sort < b.Sort
+		}
+		return a.Code < b.Code
+	})
+	columns := make([]PayrollRegisterColumn, len(components))
+	for i, component := range components {
+		columns[i] = PayrollRegisterColumn{ComponentID: component.ID, Code: component.Code, Name: component.Name, Category: component.Category}
+	}
+	return columns
+}
+
+// salaryQuery 周期内每位员工最新版本的薪资，按部门与状态筛选
+func (e *PayrollRegisterExport) salaryQuery() *gorm.DB {
+	query := e.db.Model(&models.EnhancedSalary{}).
+		Joins("JOIN employees ON employees.id = enhanced_salaries.employee_id").
+		Where("enhanced_salaries.payroll_period_id = ?", *e.params.PeriodID).
+		Where("NOT EXISTS (SELECT 1 FROM enhanced_salaries newer WHERE newer.employee_id = enhanced_salaries.employee_id " +
+			"AND newer.payroll_period_id = enhanced_salaries.payroll_period_id AND newer.version > enhanced_salaries.version " +
+			"AND newer.deleted_at IS NULL)")
+	if e.params.DepartmentID != nil {
+		query = query.Where("employees.department_id = ?", *e.params.DepartmentID)
+	}
+	if e.params.Status != "" {
+		query = query.Where("enhanced_salaries.status = ?", e.params.Status)
+	} else {
+		query = query.Where("enhanced_salaries.status NOT IN ?", excludedVarianceStatuses)
+	}
+	return query
+}
+
+// Stream 以游标逐行读取薪资，按批加载明细后写出，内存占用与总人数无关
+func (e *PayrollRegisterExport) Stream(w io.Writer) error {
+	writer, err := NewPayrollRegisterWriter(w, e.format, e.columns)
+	if err != nil {
+		return err
+	}
+
+	rows, err := e.salaryQuery().
+		Joins("LEFT JOIN departments ON departments.id = employees.department_id").
+		Select("enhanced_salaries.id, employees.employee_id AS employee_no, employees.name AS employee_name, " +
+			"employees.department_id, departments.name AS department_name, enhanced_salaries.status, " +
+			"enhanced_salaries.gross_salary, enhanced_salaries.total_deductions, enhanced_salaries.net_salary, enhanced_salaries.employer_cost").
+		Order("departments.name ASC, employees.department_id ASC, employees.employee_id ASC").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	batch := make([]registerHeader, 0, registerBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := e.writeBatch(writer, batch); err != nil {
+			return err
+		}
+		batch = batch[:0]
+		return nil
+	}
+	for rows.Next() {
+		var header registerHeader
+		if err := e.db.ScanRows(rows, &header); err != nil {
+			return err
+		}
+		batch = append(batch, header)
+		if len(batch) == registerBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+func (e *PayrollRegisterExport) writeBatch(writer *PayrollRegisterWriter, batch []registerHeader) error {
+	ids := make([]uint, len(batch))
+	for i, header := range batch {
+		ids[i] = header.ID
+	}
+	var details []models.SalaryDetail
+	if err := e.db.Select("salary_id, component_id, final_value").
+		Where("salary_id IN ?", ids).Find(&details).Error; err != nil {
+		return err
+	}
+	amounts := make(map[uint]map[uint]models.Money, len(batch))
+	for _, detail := range details {
+		if amounts[detail.SalaryID] == nil {
+			amounts[detail.SalaryID] = make(map[uint]models.Money)
+		}
+		amounts[detail.SalaryID][detail.ComponentID] = amounts[detail.SalaryID][detail.ComponentID].Add(detail.FinalValue)
+	}
+
+	for _, header := range batch {
+		if err := writer.WriteRow(&PayrollRegisterRow{
+			EmployeeNo:     header.EmployeeNo,
+			EmployeeName:   header.EmployeeName,
+			DepartmentID:   header.DepartmentID,
+			DepartmentName: header.DepartmentName,
+			Status:         salaryStatusLabel(models.SalaryStatus(header.Status)),
+			Amounts:        amounts[header.ID],
+			GrossSalary:    header.GrossSalary,
+			Deductions:     header.TotalDeductions,
+			NetSalary:      header.NetSalary,
+			EmployerCost:   header.EmployerCost,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Bytes 将登记表完整写入内存，供不支持流式输出的调用方使用
+func (e *PayrollRegisterExport) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := e.Stream(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var salaryStatusLabels = map[models.SalaryStatus]string{
+	models.SalaryStatusDraft:      "草稿",
+	models.SalaryStatusCalculated: "已计算",
+	models.SalaryStatusReviewed:   "已审核",
+	models.SalaryStatusApproved:   "已批准",
+	models.SalaryStatusPaid:       "已发放",
+	models.SalaryStatusCancelled:  "已取消",
+	models.SalaryStatusRejected:   "已拒绝",
+}
+
+func salaryStatusLabel(status models.SalaryStatus) string {
+	if label, ok := salaryStatusLabels[status]; ok {
+		return label
+	}
+	return string(status)
+}
@@ -0,0 +1,721 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gin-project/models"
+	"gin-project/utils"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// ========================= Payroll Variance =========================
+
+type PayrollVarianceServiceInterface interface {
+	ComparePeriods(params PayrollVarianceParams) (*PayrollVarianceReport, error)
+	ExportVarianceReport(params PayrollVarianceParams) ([]byte, string, error)
+}
+
+type PayrollVarianceService struct {
+	db *gorm.DB
+}
+
+func NewPayrollVarianceService(db *gorm.DB) PayrollVarianceServiceInterface {
+	return &PayrollVarianceService{db: db}
+}
+
+// VarianceThresholds 差异预警阈值：变动金额与变动比例均达到阈值时标记，阈值为 0 表示不限制该条件
+type VarianceThresholds struct {
+	Amount  float64 `json:"amount"`
+	Percent float64 `json:"percent"`
+}
+
+// DefaultVarianceThresholds 默认变动 500 元且 10% 以上时标记
+var DefaultVarianceThresholds = VarianceThresholds{Amount: 500, Percent: 10}
+
+// Exceeded 判断变动是否达到阈值；上期为 0 时比例视为无穷大
+func (t VarianceThresholds) Exceeded(previous, current models.Money) bool {
+	change := current.Sub(previous).Abs()
+	if change.IsZero() {
+		return false
+	}
+	if t.Amount > 0 && change.Cmp(models.NewMoney(t.Amount)) < 0 {
+		return false
+	}
+	if t.Percent > 0 && !previous.IsZero() && change.Float64()/previous.Abs().Float64()*100 < t.Percent {
+		return false
+	}
+	return true
+}
+
+// PayrollVarianceParams 差异分析参数，未指定上期时取同类型的前一个周期
+type PayrollVarianceParams struct {
+	CurrentPeriodID  uint
+	PreviousPeriodID *uint
+	Thresholds       VarianceThresholds
+	FlaggedOnly      bool
+}
+
+// VarianceExplanation 差异原因
+type VarianceExplanation struct {
+	Type        string       `json:"type"` // salary_adjustment, retro_pay, attendance, new_component, removed_component
+	Description string       `json:"description"`
+	Amount      models.Money `json:"amount,omitempty"`
+	ReferenceID *uint        `json:"reference_id,omitempty"`
+}
+
+// ComponentVariance 薪资项目差异
+type ComponentVariance struct {
+	ComponentID   uint         `json:"component_id"`
+	Code          string       `json:"code"`
+	Name          string       `json:"name"`
+	Category      string       `json:"category"`
+	Previous      models.Money `json:"previous"`
+	Current       models.Money `json:"current"`
+	Change        models.Money `json:"change"`
+	ChangePercent float64      `json:"change_percent"`
+	IsNew         bool         `json:"is_new"`
+	IsRemoved     bool         `json:"is_removed"`
+	Flagged       bool         `json:"flagged"`
+
+	inPrevious bool
+	inCurrent  bool
+}
+
+// EmployeeVariance 员工薪资差异
+type EmployeeVariance struct {
+	EmployeeID         uint                  `json:"employee_id"`
+	EmployeeNo         string                `json:"employee_no"`
+	EmployeeName       string                `json:"employee_name"`
+	DepartmentName     string                `json:"department_name"`
+	PreviousSalaryID   uint                  `json:"previous_salary_id"`
+	CurrentSalaryID    uint                  `json:"current_salary_id"`
+	PreviousGross      models.Money          `json:"previous_gross"`
+	CurrentGross       models.Money          `json:"current_gross"`
+	GrossChange        models.Money          `json:"gross_change"`
+	GrossChangePercent float64               `json:"gross_change_percent"`
+	PreviousNet        models.Money          `json:"previous_net"`
+	CurrentNet         models.Money          `json:"current_net"`
+	NetChange          models.Money          `json:"net_change"`
+	NetChangePercent   float64               `json:"net_change_percent"`
+	Flagged            bool                  `json:"flagged"`
+	Components         []ComponentVariance   `json:"components"`
+	Explanations       []VarianceExplanation `json:"explanations"`
+}
+
+// HeadcountChange 入职（本期新增）或离职（本期减少）人员
+type HeadcountChange struct {
+	EmployeeID     uint         `json:"employee_id"`
+	EmployeeNo     string       `json:"employee_no"`
+	EmployeeName   string       `json:"employee_name"`
+	DepartmentName string       `json:"department_name"`
+	Date           *time.Time   `json:"date"`
+	Reason         string       `json:"reason"`
+	GrossSalary    models.Money `json:"gross_salary"`
+	NetSalary      models.Money `json:"net_salary"`
+}
+
+// VarianceSummary 两期汇总对比
+type VarianceSummary struct {
+	PreviousHeadcount    int          `json:"previous_headcount"`
+	CurrentHeadcount     int          `json:"current_headcount"`
+	PreviousGross        models.Money `json:"previous_gross"`
+	CurrentGross         models.Money `json:"current_gross"`
+	GrossChange          models.Money `json:"gross_change"`
+	PreviousNet          models.Money `json:"previous_net"`
+	CurrentNet           models.Money `json:"current_net"`
+	NetChange            models.Money `json:"net_change"`
+	PreviousEmployerCost models.Money `json:"previous_employer_cost"`
+	CurrentEmployerCost  models.Money `json:"current_employer_cost"`
+	EmployerCostChange   models.Money `json:"employer_cost_change"`
+	FlaggedEmployees     int          `json:"flagged_employees"`
+	Joiners              int          `json:"joiners"`
+	Leavers              int          `json:"leavers"`
+}
+
+// PayrollVarianceReport 两个薪资周期的差异分析报告
+type PayrollVarianceReport struct {
+	PreviousPeriod *models.PayrollPeriod `json:"previous_period"`
+	CurrentPeriod  *models.PayrollPeriod `json:"current_period"`
+	Thresholds     VarianceThresholds    `json:"thresholds"`
+	Summary        VarianceSummary       `json:"summary"`
+	Employees      []EmployeeVariance    `json:"employees"`
+	Components     []ComponentVariance   `json:"components"`
+	Joiners        []HeadcountChange     `json:"joiners"`
+	Leavers        []HeadcountChange     `json:"leavers"`
+}
+
+// excludedVarianceStatuses 已取消或已拒绝的薪资不参与对比
+var excludedVarianceStatuses = []models.SalaryStatus{models.SalaryStatusCancelled, models.SalaryStatusRejected}
+
+func (s *PayrollVarianceService) ComparePeriods(params PayrollVarianceParams) (*PayrollVarianceReport, error) {
+	current, previous, err := s.loadPeriods(params)
+	if err != nil {
+		return nil, err
+	}
+	currentSalaries, err := s.loadSalaries(current.ID)
+	if err != nil {
+		return nil, err
+	}
+	previousSalaries, err := s.loadSalaries(previous.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := ComparePayroll(previous, current, previousSalaries, currentSalaries, params.Thresholds)
+	if err := s.explain(report, currentSalaries); err != nil {
+		return nil, err
+	}
+	if params.FlaggedOnly {
+		flagged := report.Employees[:0]
+		for _, employee := range report.Employees {
+			if employee.Flagged {
+				flagged = append(flagged, employee)
+			}
+		}
+		report.Employees = flagged
+	}
+	return report, nil
+}
+
+// loadPeriods 读取本期与对比期，未指定对比期时取同类型、同币种、开始日期早于本期的最近一个周期；
+// 不同币种的周期金额不可直接比较
+func (s *PayrollVarianceService) loadPeriods(params PayrollVarianceParams) (*models.PayrollPeriod, *models.PayrollPeriod, error) {
+	var current models.PayrollPeriod
+	if err := s.db.First(&current, params.CurrentPeriodID).Error; err != nil {
+		return nil, nil, &utils.ValidationError{Message: "薪资周期不存在"}
+	}
+
+	var previous models.PayrollPeriod
+	if params.PreviousPeriodID != nil {
+		if err := s.db.First(&previous, *params.PreviousPeriodID).Error; err != nil {
+			return nil, nil, &utils.ValidationError{Message: "对比薪资周期不存在"}
+		}
+		if previous.ID == current.ID {
+			return nil, nil, &utils.ValidationError{Message: "对比周期不能与本期相同"}
+		}
+		if models.NormalizeCurrency(previous.Currency) != models.NormalizeCurrency(current.Currency) {
+			return nil, nil, &utils.ValidationError{Message: "对比周期与本期币种不同，无法比较"}
+		}
+	} else if err := s.db.Where("period_type = ? AND start_date < ?", current.PeriodType, current.StartDate).
+		Where("COALESCE(currency, ?) = ?", models.DefaultCurrency, models.NormalizeCurrency(current.Currency)).
+		Order("start_date DESC").First(&previous).Error; err != nil {
+		return nil, nil, &utils.ValidationError{Message: "未找到可对比的上一薪资周期"}
+	}
+	return &current, &previous, nil
+}
+
+// loadSalaries 读取周期内每位员工最新版本的薪资及明细
+func (s *PayrollVarianceService) loadSalaries(periodID uint) ([]models.EnhancedSalary, error) {
+	var salaries []models.EnhancedSalary
+	if err := s.db.Preload("Employee.Department").Preload("Components.Component").
+		Where("payroll_period_id = ? AND status NOT IN ?", periodID, excludedVarianceStatuses).
+		Find(&salaries).Error; err != nil {
+		return nil, err
+	}
+	return salaries, nil
+}
+
+// explain 为标记的员工补充调薪、追溯补发及考勤差异原因
+func (s *PayrollVarianceService) explain(report *PayrollVarianceReport, currentSalaries []models.EnhancedSalary) error {
+	employees := make(map[uint]*models.Employee)
+	for i := range currentSalaries {
+		employees[currentSalaries[i].EmployeeID] = currentSalaries[i].Employee
+	}
+
+	for i := range report.Employees {
+		variance := &report.Employees[i]
+		if !variance.Flagged {
+			continue
+		}
+
+		var adjustments []models.SalaryAdjustment
+		if err := s.db.Where("employee_id = ? AND status = ? AND effective_date > ? AND effective_date <= ?",
+			variance.EmployeeID, "approved", report.PreviousPeriod.StartDate, report.CurrentPeriod.EndDate).
+			Order("effective_date ASC").Find(&adjustments).Error; err != nil {
+			return err
+		}
+		for _, adjustment := range adjustments {
+			id := adjustment.ID
+			variance.Explanations = append(variance.Explanations, VarianceExplanation{
+				Type: "salary_adjustment",
+				Description: fmt.Sprintf("%s起调薪：基本薪资 %s → %s（%s）", adjustment.EffectiveDate.Format("2006-01-02"),
+					adjustment.OldBaseSalary, adjustment.NewBaseSalary, adjustment.Reason),
+				Amount:      adjustment.NewBaseSalary.Sub(adjustment.OldBaseSalary),
+				ReferenceID: &id,
+			})
+		}
+
+		var retroItems []models.RetroPayItem
+		if err := s.db.Preload("SourcePeriod").
+			Where("target_salary_id = ? AND status = ?", variance.CurrentSalaryID, models.RetroPayApplied).
+			Find(&retroItems).Error; err != nil {
+			return err
+		}
+		for _, item := range retroItems {
+			id := item.ID
+			source := ""
+			if item.SourcePeriod != nil {
+				source = item.SourcePeriod.Name
+			}
+			variance.Explanations = append(variance.Explanations, VarianceExplanation{
+				Type:        "retro_pay",
+				Description: fmt.Sprintf("并入%s追溯补发/补扣 %s", source, item.Amount),
+				Amount:      item.Amount,
+				ReferenceID: &id,
+			})
+		}
+
+		if variance.PreviousSalaryID == 0 || employees[variance.EmployeeID] == nil {
+			continue
+		}
+		employee := employees[variance.EmployeeID]
+		previous, err := loadAttendanceInputs(s.db, employee, report.PreviousPeriod.StartDate, report.PreviousPeriod.EndDate)
+		if err != nil {
+			return err
+		}
+		current, err := loadAttendanceInputs(s.db, employee, report.CurrentPeriod.StartDate, report.CurrentPeriod.EndDate)
+		if err != nil {
+			return err
+		}
+		variance.Explanations = append(variance.Explanations, ExplainAttendanceChange(*previous, *current)...)
+	}
+	return nil
+}
+
+// ComparePayroll 按员工和薪资项目对比两期薪资，识别入离职人员与新增/取消的薪资项目
+func ComparePayroll(previousPeriod, currentPeriod *models.PayrollPeriod, previous, current []models.EnhancedSalary, thresholds VarianceThresholds) *PayrollVarianceReport {
+	report := &PayrollVarianceReport{
+		PreviousPeriod: previousPeriod,
+		CurrentPeriod:  currentPeriod,
+		Thresholds:     thresholds,
+		Employees:      []EmployeeVariance{},
+		Components:     []ComponentVariance{},
+		Joiners:        []HeadcountChange{},
+		Leavers:        []HeadcountChange{},
+	}
+
+	previousByEmployee := latestEmployeeSalaries(previous)
+	currentByEmployee := latestEmployeeSalaries(current)
+	totals := make(map[uint]*ComponentVariance)
+
+	for employeeID, salary := range previousByEmployee {
+		report.Summary.PreviousHeadcount++
+		report.Summary.PreviousGross = report.Summary.PreviousGross.Add(salary.GrossSalary)
+		report.Summary.PreviousNet = report.Summary.PreviousNet.Add(salary.NetSalary)
+		report.Summary.PreviousEmployerCost = report.Summary.PreviousEmployerCost.Add(salary.EmployerCost)
+		accumulateComponents(totals, salary, true)
+		if _, ok := currentByEmployee[employeeID]; !ok {
+			report.Leavers = append(report.Leavers, leaverChange(salary, currentPeriod))
+		}
+	}
+
+	for employeeID, salary := range currentByEmployee {
+		report.Summary.CurrentHeadcount++
+		report.Summary.CurrentGross = report.Summary.CurrentGross.Add(salary.GrossSalary)
+		report.Summary.CurrentNet = report.Summary.CurrentNet.Add(salary.NetSalary)
+		report.Summary.CurrentEmployerCost = report.Summary.CurrentEmployerCost.Add(salary.EmployerCost)
+		accumulateComponents(totals, salary, false)
+
+		previousSalary, ok := previousByEmployee[employeeID]
+		if !ok {
+			report.Joiners = append(report.Joiners, joinerChange(salary, currentPeriod))
+			continue
+		}
+		report.Employees = append(report.Employees, compareEmployee(previousSalary, salary, thresholds))
+	}
+
+	for _, variance := range report.Employees {
+		if variance.Flagged {
+			report.Summary.FlaggedEmployees++
+		}
+	}
+	report.Summary.GrossChange = report.Summary.CurrentGross.Sub(report.Summary.PreviousGross)
+	report.Summary.NetChange = report.Summary.CurrentNet.Sub(report.Summary.PreviousNet)
+	report.Summary.EmployerCostChange = report.Summary.CurrentEmployerCost.Sub(report.Summary.PreviousEmployerCost)
+	report.Summary.Joiners = len(report.Joiners)
+	report.Summary.Leavers = len(report.Leavers)
+
+	for _, total := range totals {
+		finishComponentVariance(total, thresholds)
+		report.Components = append(report.Components, *total)
+	}
+	sortComponentVariances(report.Components)
+
+	// 标记的员工排在前面，其次按应发变动幅度降序
+	sort.SliceStable(report.Employees, func(i, j int) bool {
+		a, b := report.Employees[i], report.Employees[j]
+		if a.Flagged != b.Flagged {
+			return a.Flagged
+		}
+		if cmp := a.GrossChange.Abs().Cmp(b.GrossChange.Abs()); cmp != 0 {
+			return cmp > 0
+		}
+		return a.EmployeeNo < b.EmployeeNo
+	})
+	sortHeadcountChanges(report.Joiners)
+	sortHeadcountChanges(report.Leavers)
+	return report
+}
+
+// latestEmployeeSalaries 同一周期内每位员工仅保留版本号最大的薪资记录
+func latestEmployeeSalaries(salaries []models.EnhancedSalary) map[uint]*models.EnhancedSalary {
+	latest := make(map[uint]*models.EnhancedSalary)
+	for i := range salaries {
+		salary := &salaries[i]
+		if existing, ok := latest[salary.EmployeeID]; !ok || salary.Version > existing.Version {
+			latest[salary.EmployeeID] = salary
+		}
+	}
+	return latest
+}
+
+func compareEmployee(previous, current *models.EnhancedSalary, thresholds VarianceThresholds) EmployeeVariance {
+	variance := EmployeeVariance{
+		EmployeeID:         current.EmployeeID,
+		PreviousSalaryID:   previous.ID,
+		CurrentSalaryID:    current.ID,
+		PreviousGross:      previous.GrossSalary,
+		CurrentGross:       current.GrossSalary,
+		GrossChange:        current.GrossSalary.Sub(previous.GrossSalary),
+		GrossChangePercent: changePercent(previous.GrossSalary, current.GrossSalary),
+		PreviousNet:        previous.NetSalary,
+		CurrentNet:         current.NetSalary,
+		NetChange:          current.NetSalary.Sub(previous.NetSalary),
+		NetChangePercent:   changePercent(previous.NetSalary, current.NetSalary),
+		Components:         []ComponentVariance{},
+		Explanations:       []VarianceExplanation{},
+	}
+	fillEmployeeInfo(current.Employee, &variance.EmployeeNo, &variance.EmployeeName, &variance.DepartmentName)
+
+	components := make(map[uint]*ComponentVariance)
+	accumulateComponents(components, previous, true)
+	accumulateComponents(components, current, false)
+	for _, component := range components {
+		finishComponentVariance(component, thresholds)
+		if component.Change.IsZero() && !component.IsNew && !component.IsRemoved {
+			continue
+		}
+		variance.Components = append(variance.Components, *component)
+		if component.Flagged {
+			variance.Flagged = true
+		}
+		switch {
+		case component.IsNew:
+			variance.Explanations = append(variance.Explanations, VarianceExplanation{
+				Type:        "new_component",
+				Description: fmt.Sprintf("新增薪资项目「%s」%s", component.Name, component.Current),
+				Amount:      component.Change,
+			})
+		case component.IsRemoved:
+			variance.Explanations = append(variance.Explanations, VarianceExplanation{
+				Type:        "removed_component",
+				Description: fmt.Sprintf("本期无薪资项目「%s」（上期 %s）", component.Name, component.Previous),
+				Amount:      component.Change,
+			})
+		}
+	}
+	sortComponentVariances(variance.Components)
+
+	if thresholds.Exceeded(previous.GrossSalary, current.GrossSalary) || thresholds.Exceeded(previous.NetSalary, current.NetSalary) {
+		variance.Flagged = true
+	}
+	return variance
+}
+
+func accumulateComponents(totals map[uint]*ComponentVariance, salary *models.EnhancedSalary, previous bool) {
+	for _, detail := range salary.Components {
+		total, ok := totals[detail.ComponentID]
+		if !ok {
+			total = &ComponentVariance{ComponentID: detail.ComponentID}
+			if detail.Component != nil {
+				total.Code = detail.Component.Code
+				total.Name = detail.Component.Name
+				total.Category = string(detail.Component.Category)
+			}
+			totals[detail.ComponentID] = total
+		}
+		if previous {
+			total.Previous = total.Previous.Add(detail.FinalValue)
+			total.inPrevious = true
+		} else {
+			total.Current = total.Current.Add(detail.FinalValue)
+			total.inCurrent = true
+		}
+	}
+}
+
+// finishComponentVariance 计算变动额并判定新增/取消：仅本期出现为新增，仅上期出现为取消
+func finishComponentVariance(component *ComponentVariance, thresholds VarianceThresholds) {
+	component.IsNew = component.inCurrent && !component.inPrevious
+	component.IsRemoved = component.inPrevious && !component.inCurrent
+	component.Change = component.Current.Sub(component.Previous)
+	component.ChangePercent = changePercent(component.Previous, component.Current)
+	component.Flagged = thresholds.Exceeded(component.Previous, component.Current)
+}
+
+// changePercent 变动百分比，上期为 0 时返回 0
+func changePercent(previous, current models.Money) float64 {
+	if previous.IsZero() {
+		return 0
+	}
+	return math.Round(float64(current.Cents()-previous.Cents())/float64(previous.Abs().Cents())*10000) / 100
+}
+
+func sortComponentVariances(components []ComponentVariance) {
+	sort.Slice(components, func(i, j int) bool {
+		if components[i].Category != components[j].Category {
+			return components[i].Category < components[j].Category
+		}
+		return components[i].Code < components[j].Code
+	})
+}
+
+func sortHeadcountChanges(changes []HeadcountChange) {
+	sort.Slice(changes, func(i, j int) bool { return changes[i].EmployeeNo < changes[j].EmployeeNo })
+}
+
+func fillEmployeeInfo(employee *models.Employee, no, name, department *string) {
+	if employee == nil {
+		return
+	}
+	*no, *name = employee.EmployeeID, employee.Name
+	if employee.Department != nil {
+		*department = employee.Department.Name
+	}
+}
+
+// joinerChange 本期新增人员：入职日期在本期内为新入职，否则为上期未发薪
+func joinerChange(salary *models.EnhancedSalary, period *models.PayrollPeriod) HeadcountChange {
+	change := HeadcountChange{EmployeeID: salary.EmployeeID, GrossSalary: salary.GrossSalary, NetSalary: salary.NetSalary, Reason: "上期无薪资记录"}
+	fillEmployeeInfo(salary.Employee, &change.EmployeeNo, &change.EmployeeName, &change.DepartmentName)
+	if salary.Employee != nil && salary.Employee.HireDate != nil && !salary.Employee.HireDate.IsZero() {
+		hireDate := salary.Employee.HireDate.Time
+		change.Date = &hireDate
+		if !hireDate.Before(truncateDate(period.StartDate)) {
+			change.Reason = "本期入职"
+		}
+	}
+	return change
+}
+
+// leaverChange 本期减少人员：离职日期早于本期结束为离职，否则为本期未发薪
+func leaverChange(salary *models.EnhancedSalary, period *models.PayrollPeriod) HeadcountChange {
+	change := HeadcountChange{EmployeeID: salary.EmployeeID, GrossSalary: salary.GrossSalary, NetSalary: salary.NetSalary, Reason: "本期无薪资记录"}
+	fillEmployeeInfo(salary.Employee, &change.EmployeeNo, &change.EmployeeName, &change.DepartmentName)
+	if salary.Employee != nil && salary.Employee.TerminationDate != nil && !salary.Employee.TerminationDate.IsZero() {
+		terminationDate := salary.Employee.TerminationDate.Time
+		change.Date = &terminationDate
+		if terminationDate.Before(period.EndDate) {
+			change.Reason = "已离职"
+		}
+	}
+	return change
+}
+
+// ExplainAttendanceChange 对比两期考勤汇总，列出影响薪资的考勤变化
+func ExplainAttendanceChange(previous, current AttendanceInputs) []VarianceExplanation {
+	items := []struct {
+		label    string
+		unit     string
+		previous float64
+		current  float64
+	}{
+		{"应出勤天数", "天", previous.WorkingDays, current.WorkingDays},
+		{"缺勤天数", "天", previous.AbsenceDays, current.AbsenceDays},
+		{"无薪假天数", "天", previous.UnpaidLeaveDays, current.UnpaidLeaveDays},
+		{"病假天数", "天", previous.SickLeaveDays, current.SickLeaveDays},
+		{"迟到早退次数", "次", previous.LateCount + previous.EarlyCount, current.LateCount + current.EarlyCount},
+		{"加班小时数", "小时", previous.OvertimeHours + previous.RestDayOvertimeHours + previous.HolidayOvertimeHours,
+			current.OvertimeHours + current.RestDayOvertimeHours + current.HolidayOvertimeHours},
+	}
+
+	var explanations []VarianceExplanation
+	for _, item := range items {
+		if item.previous == item.current {
+			continue
+		}
+		explanations = append(explanations, VarianceExplanation{
+			Type: "attendance",
+			Description: fmt.Sprintf("%s %s%s → %s%s", item.label,
+				formatQuantity(item.previous), item.unit, formatQuantity(item.current), item.unit),
+		})
+	}
+	return explanations
+}
+
+func formatQuantity(value float64) string {
+	return fmt.Sprintf("%g", math.Round(value*100)/100)
+}
+
+// ========================= Variance Excel Export =========================
+
+func (s *PayrollVarianceService) ExportVarianceReport(params PayrollVarianceParams) ([]byte, string, error) {
+	report, err := s.ComparePeriods(params)
+	if err != nil {
+		return nil, "", err
+	}
+	content, err := WriteVarianceWorkbook(report)
+	if err != nil {
+		return nil, "", err
+	}
+	filename := fmt.Sprintf("payroll_variance_%s_%s.xlsx",
+		report.PreviousPeriod.StartDate.Format("200601"), report.CurrentPeriod.StartDate.Format("200601"))
+	return content, filename, nil
+}
+
+// WriteVarianceWorkbook 将差异报告写入 Excel：汇总、员工差异、项目差异、人员变动四个工作表，超阈值行高亮
+func WriteVarianceWorkbook(report *PayrollVarianceReport) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	highlight, err := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FFF2CC"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return nil, err
+	}
+
+	writeRow := func(sheet string, row int, values []interface{}, style int) error {
+		cell, err := excelize.CoordinatesToCellName(1, row)
+		if err != nil {
+			return err
+		}
+		for i, value := range values {
+			if amount, ok := value.(models.Money); ok {
+				values[i] = amount.Float64()
+			}
+		}
+		if err := f.SetSheetRow(sheet, cell, &values); err != nil {
+			return err
+		}
+		if style == 0 {
+			return nil
+		}
+		last, err := excelize.CoordinatesToCellName(len(values), row)
+		if err != nil {
+			return err
+		}
+		return f.SetCellStyle(sheet, cell, last, style)
+	}
+
+	// 汇总
+	summarySheet := "汇总"
+	if err := f.SetSheetName("Sheet1", summarySheet); err != nil {
+		return nil, err
+	}
+	summary := report.Summary
+	summaryRows := [][]interface{}{
+		{"项目", report.PreviousPeriod.Name, report.CurrentPeriod.Name, "变动"},
+		{"人数", summary.PreviousHeadcount, summary.CurrentHeadcount, summary.CurrentHeadcount - summary.PreviousHeadcount},
+		{"应发合计", summary.PreviousGross, summary.CurrentGross, summary.GrossChange},
+		{"实发合计", summary.PreviousNet, summary.CurrentNet, summary.NetChange},
+		{"企业承担合计", summary.PreviousEmployerCost, summary.CurrentEmployerCost, summary.EmployerCostChange},
+		{"入职人数", "", summary.Joiners, ""},
+		{"离职人数", "", summary.Leavers, ""},
+		{"超阈值人数", "", summary.FlaggedEmployees, ""},
+		{"预警阈值", fmt.Sprintf("变动≥%s元且≥%s%%", formatAmount(report.Thresholds.Amount), formatQuantity(report.Thresholds.Percent)), "", ""},
+	}
+	for i, values := range summaryRows {
+		style := 0
+		if i == 0 {
+			style = bold
+		}
+		if err := writeRow(summarySheet, i+1, values, style); err != nil {
+			return nil, err
+		}
+	}
+
+	// 员工差异
+	employeeSheet := "员工差异"
+	if _, err := f.NewSheet(employeeSheet); err != nil {
+		return nil, err
+	}
+	if err := writeRow(employeeSheet, 1, []interface{}{"工号", "姓名", "部门", "上期应发", "本期应发", "应发变动", "应发变动%",
+		"上期实发", "本期实发", "实发变动", "实发变动%", "变动项目", "差异说明"}, bold); err != nil {
+		return nil, err
+	}
+	for i, employee := range report.Employees {
+		var changes, reasons string
+		for _, component := range employee.Components {
+			changes += fmt.Sprintf("%s %+.2f; ", component.Name, component.Change.Float64())
+		}
+		for _, explanation := range employee.Explanations {
+			reasons += explanation.Description + "; "
+		}
+		style := 0
+		if employee.Flagged {
+			style = highlight
+		}
+		if err := writeRow(employeeSheet, i+2, []interface{}{employee.EmployeeNo, employee.EmployeeName, employee.DepartmentName,
+			employee.PreviousGross, employee.CurrentGross, employee.GrossChange, employee.GrossChangePercent,
+			employee.PreviousNet, employee.CurrentNet, employee.NetChange, employee.NetChangePercent, changes, reasons}, style); err != nil {
+			return nil, err
+		}
+	}
+
+	// 项目差异
+	componentSheet := "项目差异"
+	if _, err := f.NewSheet(componentSheet); err != nil {
+		return nil, err
+	}
+	if err := writeRow(componentSheet, 1, []interface{}{"项目编码", "项目名称", "分类", "上期合计", "本期合计", "变动", "变动%", "说明"}, bold); err != nil {
+		return nil, err
+	}
+	for i, component := range report.Components {
+		note := ""
+		if component.IsNew {
+			note = "本期新增"
+		} else if component.IsRemoved {
+			note = "本期取消"
+		}
+		style := 0
+		if component.Flagged {
+			style = highlight
+		}
+		if err := writeRow(componentSheet, i+2, []interface{}{component.Code, component.Name, component.Category,
+			component.Previous, component.Current, component.Change, component.ChangePercent, note}, style); err != nil {
+			return nil, err
+		}
+	}
+
+	// 人员变动
+	headcountSheet := "人员变动"
+	if _, err := f.NewSheet(headcountSheet); err != nil {
+		return nil, err
+	}
+	if err := writeRow(headcountSheet, 1, []interface{}{"变动类型", "工号", "姓名", "部门", "日期", "说明", "应发", "实发"}, bold); err != nil {
+		return nil, err
+	}
+	row := 2
+	for _, group := range []struct {
+		label   string
+		changes []HeadcountChange
+	}{{"入职", report.Joiners}, {"离职", report.Leavers}} {
+		for _, change := range group.changes {
+			date := ""
+			if change.Date != nil {
+				date = change.Date.Format("2006-01-02")
+			}
+			if err := writeRow(headcountSheet, row, []interface{}{group.label, change.EmployeeNo, change.EmployeeName,
+				change.DepartmentName, date, change.Reason, change.GrossSalary, change.NetSalary}, 0); err != nil {
+				return nil, err
+			}
+			row++
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,693 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gin-project/models"
+	"gin-project/utils"
+
+	"gorm.io/gorm"
+)
+
+// ========================= Payslip =========================
+
+type PayslipServiceInterface interface {
+	// 模板管理
+	GetTemplates() ([]models.PayslipTemplate, error)
+	CreateTemplate(template *models.PayslipTemplate) (*models.PayslipTemplate, error)
+	UpdateTemplate(id uint, template *models.PayslipTemplate) (*models.PayslipTemplate, error)
+	DeleteTemplate(id uint) error
+
+	// 工资单生成
+	GetPayslip(salaryID uint) (*Payslip, error)
+	RenderPayslip(salaryID uint) (*PayslipFile, error)
+	RenderPeriodPayslips(periodID uint) (*PayslipFile, error)
+	CanEmployeeViewPayslip(salaryID, employeeID uint) (bool, error)
+}
+
+type PayslipService struct {
+	db *gorm.DB
+}
+
+func NewPayslipService(db *gorm.DB) PayslipServiceInterface {
+	return &PayslipService{db: db}
+}
+
+// PayslipLine 工资单明细行
+type PayslipLine struct {
+	Code   string       `json:"code"`
+	Name   string       `json:"name"`
+	Amount models.Money `json:"amount"`
+	YTD    models.Money `json:"ytd"`
+}
+
+// PayslipSection 工资单分组（应发、扣除、企业承担）
+type PayslipSection struct {
+	Title    string        `json:"title"`
+	Lines    []PayslipLine `json:"lines"`
+	Total    models.Money  `json:"total"`
+	TotalYTD models.Money  `json:"total_ytd"`
+}
+
+// PayslipTaxBreakdown 个税计算明细（本期及本纳税年度累计）
+type PayslipTaxBreakdown struct {
+	TaxableIncome    models.Money `json:"taxable_income"`
+	TaxDeductions    models.Money `json:"tax_deductions"`
+	IncomeTax        models.Money `json:"income_tax"`
+	YTDTaxableIncome models.Money `json:"ytd_taxable_income"`
+	YTDTaxDeductions models.Money `json:"ytd_tax_deductions"`
+	YTDIncomeTax     models.Money `json:"ytd_income_tax"`
+	Formula          string       `json:"formula"`
+}
+
+// PayslipYTD 员工本年度截至当期（含当期）的累计数据
+type PayslipYTD struct {
+	GrossSalary     models.Money
+	TotalDeductions models.Money
+	NetSalary       models.Money
+	TaxableIncome   models.Money
+	TaxDeductions   models.Money
+	IncomeTax       models.Money
+	EmployerCost    models.Money
+	Components      map[uint]models.Money
+}
+
+// Payslip 渲染工资单所需的完整数据
+type Payslip struct {
+	SalaryID       uint                 `json:"salary_id"`
+	CompanyName    string               `json:"company_name"`
+	Title          string               `json:"title"`
+	HeaderNote     string               `json:"header_note"`
+	FooterNote     string               `json:"footer_note"`
+	EmployeeNo     string               `json:"employee_no"`
+	EmployeeName   string               `json:"employee_name"`
+	DepartmentName string               `json:"department_name"`
+	PeriodName     string               `json:"period_name"`
+	StartDate      time.Time            `json:"start_date"`
+	EndDate        time.Time            `json:"end_date"`
+	PayDate        *time.Time           `json:"pay_date"`
+	Earnings       PayslipSection       `json:"earnings"`
+	Deductions     PayslipSection       `json:"deductions"`
+	EmployerCosts  *PayslipSection      `json:"employer_costs,omitempty"`
+	NetSalary      models.Money         `json:"net_salary"`
+	YTDNetSalary   models.Money         `json:"ytd_net_salary"`
+	Tax            *PayslipTaxBreakdown `json:"tax,omitempty"`
+	ShowYTD        bool                 `json:"show_ytd"`
+}
+
+// PayslipFile 生成的工资单文件（单份 PDF 或批量 ZIP）
+type PayslipFile struct {
+	FileName    string
+	ContentType string
+	Content     []byte
+	Count       int
+}
+
+// payslipVisibleStatuses 员工本人只能查看已批准或已发放的工资单
+var payslipVisibleStatuses = []models.SalaryStatus{models.SalaryStatusApproved, models.SalaryStatusPaid}
+
+// ========================= Template Management =========================
+
+func (s *PayslipService) GetTemplates() ([]models.PayslipTemplate, error) {
+	var templates []models.PayslipTemplate
+	if err := s.db.Preload("LegalEntity").Order("legal_entity_id ASC, id ASC").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (s *PayslipService) CreateTemplate(template *models.PayslipTemplate) (*models.PayslipTemplate, error) {
+	if err := s.validateTemplate(template, 0); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(template).Error; err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (s *PayslipService) UpdateTemplate(id uint, template *models.PayslipTemplate) (*models.PayslipTemplate, error) {
+	var existing models.PayslipTemplate
+	if err := s.db.First(&existing, id).Error; err != nil {
+		return nil, &utils.ValidationError{Message: "工资单模板不存在"}
+	}
+
+	template.ID = id
+	template.CreatedAt = existing.CreatedAt
+	if err := s.validateTemplate(template, id); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(template).Error; err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (s *PayslipService) DeleteTemplate(id uint) error {
+	return s.db.Delete(&models.PayslipTemplate{}, id).Error
+}
+
+// validateTemplate 校验模板配置，每个法人实体（及默认模板）只能有一个模板
+func (s *PayslipService) validateTemplate(template *models.PayslipTemplate, id uint) error {
+	template.Name = strings.TrimSpace(template.Name)
+	if template.Name == "" {
+		return &utils.ValidationError{Message: "模板名称不能为空"}
+	}
+	if template.Title == "" {
+		template.Title = "工资单"
+	}
+	switch template.PasswordRule {
+	case "":
+		template.PasswordRule = models.PayslipPasswordNone
+	case models.PayslipPasswordNone, models.PayslipPasswordIDCardSuffix,
+		models.PayslipPasswordEmployeeNo, models.PayslipPasswordBirthday:
+	default:
+		return &utils.ValidationError{Message: "不支持的口令规则: " + string(template.PasswordRule)}
+	}
+	if template.PasswordLength == 0 {
+		template.PasswordLength = 6
+	}
+	if template.PasswordLength < 4 || template.PasswordLength > 18 {
+		return &utils.ValidationError{Message: "身份证后N位口令长度须在4到18之间"}
+	}
+
+	query := s.db.Model(&models.PayslipTemplate{}).Where("id <> ?", id)
+	if template.LegalEntityID != nil {
+		var entity models.Department
+		if err := s.db.First(&entity, *template.LegalEntityID).Error; err != nil {
+			return &utils.ValidationError{Message: "法人实体不存在"}
+		}
+		if entity.Type != models.CompanyDept {
+			return &utils.ValidationError{Message: "模板只能关联公司类型的组织"}
+		}
+		query = query.Where("legal_entity_id = ?", *template.LegalEntityID)
+	} else {
+		query = query.Where("legal_entity_id IS NULL")
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return &utils.ValidationError{Message: "该法人实体已配置工资单模板"}
+	}
+	return nil
+}
+
+// ========================= Payslip Generation =========================
+
+// GetPayslip 组装工资单数据（不生成文件）
+func (s *PayslipService) GetPayslip(salaryID uint) (*Payslip, error) {
+	payslip, _, _, err := s.buildPayslip(salaryID)
+	return payslip, err
+}
+
+// RenderPayslip 生成单个员工的 PDF 工资单，按模板规则加密
+func (s *PayslipService) RenderPayslip(salaryID uint) (*PayslipFile, error) {
+	payslip, content, err := s.renderPDF(salaryID)
+	if err != nil {
+		return nil, err
+	}
+	return &PayslipFile{
+		FileName:    payslipFileName(payslip),
+		ContentType: "application/pdf",
+		Content:     content,
+		Count:       1,
+	}, nil
+}
+
+// RenderPeriodPayslips 批量生成薪资周期内已批准或已发放薪资的工资单，打包为 ZIP
+func (s *PayslipService) RenderPeriodPayslips(periodID uint) (*PayslipFile, error) {
+	var period models.PayrollPeriod
+	if err := s.db.First(&period, periodID).Error; err != nil {
+		return nil, &utils.ValidationError{Message: "薪资周期不存在"}
+	}
+
+	var salaryIDs []uint
+	if err := s.db.Model(&models.EnhancedSalary{}).
+		Where("payroll_period_id = ? AND status IN ?", periodID, payslipVisibleStatuses).
+		Order("employee_id ASC").Pluck("id", &salaryIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(salaryIDs) == 0 {
+		return nil, &utils.ValidationError{Message: "该薪资周期没有已批准的薪资记录"}
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, salaryID := range salaryIDs {
+		payslip, content, err := s.renderPDF(salaryID)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := archive.Create(payslipFileName(payslip))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := entry.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return &PayslipFile{
+		FileName:    fmt.Sprintf("payslips_%s.zip", period.StartDate.Format("200601")),
+		ContentType: "application/zip",
+		Content:     buf.Bytes(),
+		Count:       len(salaryIDs),
+	}, nil
+}
+
+// CanEmployeeViewPayslip 判断员工本人能否查看该工资单，员工ID取自二次验证令牌
+func (s *PayslipService) CanEmployeeViewPayslip(salaryID, employeeID uint) (bool, error) {
+	var salary models.EnhancedSalary
+	if err := s.db.First(&salary, salaryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return PayslipVisibleToEmployee(&salary, employeeID), nil
+}
+
+// PayslipVisibleToEmployee 员工只能查看本人已批准或已发放的工资单
+func PayslipVisibleToEmployee(salary *models.EnhancedSalary, employeeID uint) bool {
+	if employeeID == 0 || salary.EmployeeID != employeeID {
+		return false
+	}
+	for _, status := range payslipVisibleStatuses {
+		if salary.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// renderPDF 生成工资单 PDF，按模板口令规则加密
+func (s *PayslipService) renderPDF(salaryID uint) (*Payslip, []byte, error) {
+	payslip, template, employee, err := s.buildPayslip(salaryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	password, err := PayslipPassword(template, employee)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := RenderPayslipPDF(payslip, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	return payslip, content, nil
+}
+
+// buildPayslip 读取薪资、适用模板与年度累计数据组装工资单
+func (s *PayslipService) buildPayslip(salaryID uint) (*Payslip, *models.PayslipTemplate, *models.Employee, error) {
+	var salary models.EnhancedSalary
+	if err := s.db.Preload("Employee.Department").Preload("PayrollPeriod").
+		Preload("Components.Component").First(&salary, salaryID).Error; err != nil {
+		return nil, nil, nil, &utils.ValidationError{Message: "薪资记录不存在"}
+	}
+	if salary.Employee == nil || salary.PayrollPeriod == nil {
+		return nil, nil, nil, fmt.Errorf("salary %d is missing employee or payroll period", salaryID)
+	}
+
+	entity, err := s.legalEntity(salary.Employee)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	template, err := s.templateFor(entity)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ytd, err := s.yearToDate(&salary)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	payslip := BuildPayslip(&salary, template, ytd)
+	if payslip.CompanyName == "" && entity != nil {
+		payslip.CompanyName = entity.Name
+	}
+	if payslip.CompanyName == "" {
+		payslip.CompanyName = os.Getenv("COMPANY_NAME")
+	}
+	return payslip, template, salary.Employee, nil
+}
+
+// legalEntity 沿部门层级向上查找员工所属的法人实体（公司类型部门）
+func (s *PayslipService) legalEntity(employee *models.Employee) (*models.Department, error) {
+	departmentID := employee.DepartmentID
+	for depth := 0; departmentID != 0 && depth < 20; depth++ {
+		var department models.Department
+		if err := s.db.First(&department, departmentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		if department.Type == models.CompanyDept {
+			return &department, nil
+		}
+		if department.ParentID == nil {
+			break
+		}
+		departmentID = *department.ParentID
+	}
+	return nil, nil
+}
+
+// templateFor 优先使用法人实体模板，其次默认模板，均未配置时使用内置模板
+func (s *PayslipService) templateFor(entity *models.Department) (*models.PayslipTemplate, error) {
+	var template models.PayslipTemplate
+	if entity != nil {
+		err := s.db.Where("legal_entity_id = ?", entity.ID).First(&template).Error
+		if err == nil {
+			return &template, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	err := s.db.Where("legal_entity_id IS NULL").First(&template).Error
+	if err == nil {
+		return &template, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &models.PayslipTemplate{
+		Title:            "工资单",
+		ShowYTD:          true,
+		ShowTaxBreakdown: true,
+		PasswordRule:     models.PayslipPasswordNone,
+	}, nil
+}
+
+// yearToDate 汇总员工本年度截至当期（含当期）的薪资，已取消或已拒绝的薪资不计入
+func (s *PayslipService) yearToDate(salary *models.EnhancedSalary) (*PayslipYTD, error) {
+	period := salary.PayrollPeriod
+	yearStart := time.Date(period.StartDate.Year(), 1, 1, 0, 0, 0, 0, period.StartDate.Location())
+	salaries := s.db.Model(&models.EnhancedSalary{}).
+		Joins("JOIN payroll_periods ON payroll_periods.id = enhanced_salaries.payroll_period_id").
+		Where("enhanced_salaries.employee_id = ? AND payroll_periods.start_date >= ? AND payroll_periods.start_date <= ?",
+			salary.EmployeeID, yearStart, period.StartDate).
+		Where("enhanced_salaries.status NOT IN ?", []models.SalaryStatus{models.SalaryStatusCancelled, models.SalaryStatusRejected})
+
+	ytd := &PayslipYTD{Components: make(map[uint]models.Money)}
+	if err := salaries.Session(&gorm.Session{}).Select(
+		"COALESCE(SUM(gross_salary), 0) AS gross_salary, COALESCE(SUM(total_deductions), 0) AS total_deductions, " +
+			"COALESCE(SUM(net_salary), 0) AS net_salary, COALESCE(SUM(taxable_income), 0) AS taxable_income, " +
+			"COALESCE(SUM(tax_deductions), 0) AS tax_deductions, COALESCE(SUM(income_tax), 0) AS income_tax, " +
+			"COALESCE(SUM(employer_cost), 0) AS employer_cost").
+		Scan(ytd).Error; err != nil {
+		return nil, err
+	}
+
+	var salaryIDs []uint
+	if err := salaries.Session(&gorm.Session{}).Pluck("enhanced_salaries.id", &salaryIDs).Error; err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		ComponentID uint
+		Total       models.Money
+	}
+	if err := s.db.Model(&models.SalaryDetail{}).
+		Select("component_id, SUM(final_value) AS total").
+		Where("salary_id IN ?", salaryIDs).
+		Group("component_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		ytd.Components[row.ComponentID] = row.Total
+	}
+	return ytd, nil
+}
+
+// ========================= Payslip Rendering =========================
+
+// PayslipPassword 按模板口令规则生成员工的 PDF 打开口令，不加密时返回空字符串
+func PayslipPassword(template *models.PayslipTemplate, employee *models.Employee) (string, error) {
+	switch template.PasswordRule {
+	case models.PayslipPasswordIDCardSuffix:
+		idCard := strings.ToUpper(strings.TrimSpace(employee.IDCard))
+		length := template.PasswordLength
+		if length <= 0 {
+			length = 6
+		}
+		if len(idCard) < length {
+			return "", &utils.ValidationError{Message: fmt.Sprintf("员工%s未登记有效身份证号，无法生成加密工资单", employee.Name)}
+		}
+		return idCard[len(idCard)-length:], nil
+	case models.PayslipPasswordEmployeeNo:
+		if employee.EmployeeID == "" {
+			return "", &utils.ValidationError{Message: fmt.Sprintf("员工%s未登记工号，无法生成加密工资单", employee.Name)}
+		}
+		return employee.EmployeeID, nil
+	case models.PayslipPasswordBirthday:
+		if employee.Birthday == nil || employee.Birthday.IsZero() {
+			return "", &utils.ValidationError{Message: fmt.Sprintf("员工%s未登记出生日期，无法生成加密工资单", employee.Name)}
+		}
+		return employee.Birthday.Format("20060102"), nil
+	default:
+		return "", nil
+	}
+}
+
+// BuildPayslip 按组件分类将薪资明细归入应发、扣除和企业承担三部分
+func BuildPayslip(salary *models.EnhancedSalary, template *models.PayslipTemplate, ytd *PayslipYTD) *Payslip {
+	if ytd == nil {
+		ytd = &PayslipYTD{}
+	}
+	period := salary.PayrollPeriod
+	employee := salary.Employee
+
+	payslip := &Payslip{
+		SalaryID:     salary.ID,
+		CompanyName:  template.CompanyName,
+		Title:        template.Title,
+		HeaderNote:   template.HeaderNote,
+		FooterNote:   template.FooterNote,
+		EmployeeNo:   employee.EmployeeID,
+		EmployeeName: employee.Name,
+		PeriodName:   period.Name,
+		StartDate:    period.StartDate,
+		EndDate:      period.EndDate,
+		PayDate:      period.PayDate,
+		Earnings:     PayslipSection{Title: "应发项目", Total: salary.GrossSalary, TotalYTD: ytd.GrossSalary},
+		Deductions:   PayslipSection{Title: "扣除项目", Total: salary.TotalDeductions, TotalYTD: ytd.TotalDeductions},
+		NetSalary:    salary.NetSalary,
+		YTDNetSalary: ytd.NetSalary,
+		ShowYTD:      template.ShowYTD,
+	}
+	if employee.Department != nil {
+		payslip.DepartmentName = employee.Department.Name
+	}
+	employerCosts := PayslipSection{Title: "企业承担", Total: salary.EmployerCost, TotalYTD: ytd.EmployerCost}
+
+	details := append([]models.SalaryDetail(nil), salary.Components...)
+	sort.SliceStable(details, func(i, j int) bool {
+		return componentSort(details[i].Component) < componentSort(details[j].Component)
+	})
+	for _, detail := range details {
+		if detail.Component == nil {
+			continue
+		}
+		line := PayslipLine{
+			Code:   detail.Component.Code,
+			Name:   detail.Component.Name,
+			Amount: detail.FinalValue,
+			YTD:    ytd.Components[detail.ComponentID],
+		}
+		switch detail.Component.Category {
+		case models.ComponentCategoryDeduction, models.ComponentCategoryTax, models.ComponentCategoryInsurance:
+			payslip.Deductions.Lines = append(payslip.Deductions.Lines, line)
+		case models.ComponentCategoryEmployerCost:
+			employerCosts.Lines = append(employerCosts.Lines, line)
+		default:
+			payslip.Earnings.Lines = append(payslip.Earnings.Lines, line)
+		}
+	}
+
+	if template.ShowEmployerCost {
+		payslip.EmployerCosts = &employerCosts
+	}
+	if template.ShowTaxBreakdown {
+		payslip.Tax = &PayslipTaxBreakdown{
+			TaxableIncome:    salary.TaxableIncome,
+			TaxDeductions:    salary.TaxDeductions,
+			IncomeTax:        salary.IncomeTax,
+			YTDTaxableIncome: ytd.TaxableIncome,
+			YTDTaxDeductions: ytd.TaxDeductions,
+			YTDIncomeTax:     ytd.IncomeTax,
+		}
+		for _, detail := range details {
+			if detail.Component != nil && detail.Component.Code == IncomeTaxComponentCode {
+				payslip.Tax.Formula = detail.CalculationFormula
+			}
+		}
+	}
+	return payslip
+}
+
+func componentSort(component *models.SalaryComponent) int {
+	if component == nil {
+		return 0
+	}
+	return component.Sort
+}
+
+// 工资单版式（单位：磅，左上角为原点）
+const (
+	payslipMarginLeft   = 50.0
+	payslipAmountRight  = 400.0
+	payslipYTDRight     = 545.0
+	payslipLineHeight   = 18.0
+	payslipPageBottom   = 790.0
+	payslipBodyFontSize = 10.0
+	payslipRightEdge    = utils.PDFPageWidth - payslipMarginLeft
+)
+
+// payslipWriter 逐行输出工资单内容，超出页面底部时自动换页
+type payslipWriter struct {
+	doc *utils.PDFDocument
+	y   float64
+}
+
+func (w *payslipWriter) ensureSpace(lines int) {
+	if w.y+float64(lines)*payslipLineHeight > payslipPageBottom {
+		w.doc.AddPage()
+		w.y = 60
+	}
+}
+
+func (w *payslipWriter) rule() {
+	w.doc.Line(payslipMarginLeft, w.y-12, payslipRightEdge, w.y-12, 0.5)
+}
+
+func (w *payslipWriter) row(label, amount, ytd string) {
+	w.ensureSpace(1)
+	w.doc.Text(payslipMarginLeft+10, w.y, payslipBodyFontSize, label)
+	w.doc.TextRight(payslipAmountRight, w.y, payslipBodyFontSize, amount)
+	if ytd != "" {
+		w.doc.TextRight(payslipYTDRight, w.y, payslipBodyFontSize, ytd)
+	}
+	w.y += payslipLineHeight
+}
+
+func (w *payslipWriter) heading(title string, showYTD bool) {
+	w.ensureSpace(3)
+	w.y += 6
+	w.doc.Text(payslipMarginLeft, w.y, 12, title)
+	w.doc.TextRight(payslipAmountRight, w.y, payslipBodyFontSize, "本期")
+	if showYTD {
+		w.doc.TextRight(payslipYTDRight, w.y, payslipBodyFontSize, "本年累计")
+	}
+	w.y += payslipLineHeight
+	w.rule()
+}
+
+func (w *payslipWriter) section(section *PayslipSection, showYTD bool) {
+	w.heading(section.Title, showYTD)
+	for _, line := range section.Lines {
+		w.row(line.Name, line.Amount.String(), ytdAmount(line.YTD, showYTD))
+	}
+	w.rule()
+	w.row("合计", section.Total.String(), ytdAmount(section.TotalYTD, showYTD))
+}
+
+func ytdAmount(value models.Money, show bool) string {
+	if !show {
+		return ""
+	}
+	return value.String()
+}
+
+// RenderPayslipPDF 将工资单渲染为 PDF，password 非空时加密
+func RenderPayslipPDF(payslip *Payslip, password string) ([]byte, error) {
+	doc := utils.NewPDFDocument(fmt.Sprintf("%s %s %s", payslip.Title, payslip.PeriodName, payslip.EmployeeName))
+	if password != "" {
+		doc.SetPassword(password, "")
+	}
+	doc.AddPage()
+	w := &payslipWriter{doc: doc, y: 60}
+
+	if payslip.CompanyName != "" {
+		doc.TextCenter(w.y, 16, payslip.CompanyName)
+		w.y += 26
+	}
+	doc.TextCenter(w.y, 14, fmt.Sprintf("%s（%s）", payslip.Title, payslip.PeriodName))
+	w.y += 24
+	if payslip.HeaderNote != "" {
+		for _, note := range strings.Split(payslip.HeaderNote, "\n") {
+			doc.Text(payslipMarginLeft, w.y, 9, note)
+			w.y += 14
+		}
+		w.y += 4
+	}
+
+	doc.Text(payslipMarginLeft, w.y, payslipBodyFontSize, fmt.Sprintf("姓名：%s", payslip.EmployeeName))
+	doc.Text(220, w.y, payslipBodyFontSize, fmt.Sprintf("工号：%s", payslip.EmployeeNo))
+	doc.Text(380, w.y, payslipBodyFontSize, fmt.Sprintf("部门：%s", payslip.DepartmentName))
+	w.y += payslipLineHeight
+	payDate := "-"
+	if payslip.PayDate != nil {
+		payDate = payslip.PayDate.Format("2006-01-02")
+	}
+	doc.Text(payslipMarginLeft, w.y, payslipBodyFontSize, fmt.Sprintf("薪资期间：%s 至 %s",
+		payslip.StartDate.Format("2006-01-02"), payslip.EndDate.Format("2006-01-02")))
+	doc.Text(380, w.y, payslipBodyFontSize, fmt.Sprintf("发薪日期：%s", payDate))
+	w.y += payslipLineHeight + 6
+
+	w.section(&payslip.Earnings, payslip.ShowYTD)
+	w.section(&payslip.Deductions, payslip.ShowYTD)
+
+	w.ensureSpace(2)
+	w.y += 6
+	w.rule()
+	doc.Text(payslipMarginLeft, w.y, 12, "实发工资")
+	doc.TextRight(payslipAmountRight, w.y, 12, payslip.NetSalary.String())
+	if payslip.ShowYTD {
+		doc.TextRight(payslipYTDRight, w.y, 12, payslip.YTDNetSalary.String())
+	}
+	w.y += payslipLineHeight
+
+	if payslip.Tax != nil {
+		tax := payslip.Tax
+		w.heading("个人所得税计算", true)
+		w.row("计税收入", tax.TaxableIncome.String(), tax.YTDTaxableIncome.String())
+		w.row("专项扣除（社保公积金）", tax.TaxDeductions.String(), tax.YTDTaxDeductions.String())
+		w.row("预扣个人所得税", tax.IncomeTax.String(), tax.YTDIncomeTax.String())
+		if tax.Formula != "" {
+			w.ensureSpace(1)
+			doc.Text(payslipMarginLeft+10, w.y, 8, "累计预扣法："+tax.Formula)
+			w.y += payslipLineHeight
+		}
+	}
+	if payslip.EmployerCosts != nil {
+		w.section(payslip.EmployerCosts, payslip.ShowYTD)
+	}
+
+	if payslip.FooterNote != "" {
+		w.y += 12
+		for _, note := range strings.Split(payslip.FooterNote, "\n") {
+			w.ensureSpace(1)
+			doc.Text(payslipMarginLeft, w.y, 9, note)
+			w.y += 14
+		}
+	}
+	return doc.Bytes()
+}
+
+// payslipFileName 工资单文件名：工号_姓名_年月.pdf
+func payslipFileName(payslip *Payslip) string {
+	return fmt.Sprintf("%s_%s_%s.pdf", payslip.EmployeeNo, payslip.EmployeeName, payslip.StartDate.Format("200601"))
+}
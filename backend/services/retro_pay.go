@@ -0,0 +1,367 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gin-project/models"
+
+	"gorm.io/gorm"
+)
+
+// RetroPayComponentCode 追溯补发/补扣组件编码
+const RetroPayComponentCode = "RETRO_PAY"
+
+type RetroPayServiceInterface interface {
+	GetRetroPayReport(params RetroPayQueryParams) (*RetroPayReport, error)
+	ProcessAdjustment(adjustmentID uint) ([]models.RetroPayItem, error)
+	CancelRetroPayItem(id uint) error
+}
+
+type RetroPayService struct {
+	db *gorm.DB
+}
+
+type RetroPayQueryParams struct {
+	EmployeeID     uint
+	AdjustmentID   uint
+	SourcePeriodID uint
+	TargetPeriodID uint
+	Status         string
+}
+
+// RetroPayReport 追溯补发报表，按员工汇总补发/补扣明细
+type RetroPayReport struct {
+	Employees   []EmployeeRetroPay `json:"employees"`
+	TotalAmount models.Money       `json:"total_amount"`
+	ItemCount   int                `json:"item_count"`
+}
+
+// EmployeeRetroPay 单个员工的追溯补发明细
+type EmployeeRetroPay struct {
+	EmployeeID   uint                  `json:"employee_id"`
+	EmployeeName string                `json:"employee_name"`
+	Lines        []models.RetroPayItem `json:"lines"`
+	TotalAmount  models.Money          `json:"total_amount"`
+}
+
+func NewRetroPayService(db *gorm.DB) RetroPayServiceInterface {
+	return &RetroPayService{
+		db: db,
+	}
+}
+
+// GetRetroPayReport 获取追溯补发报表
+func (s *RetroPayService) GetRetroPayReport(params RetroPayQueryParams) (*RetroPayReport, error) {
+	query := s.db.Model(&models.RetroPayItem{}).
+		Preload("Employee").Preload("SourcePeriod").Preload("TargetPeriod")
+
+	if params.EmployeeID > 0 {
+		query = query.Where("employee_id = ?", params.EmployeeID)
+	}
+	if params.AdjustmentID > 0 {
+		query = query.Where("adjustment_id = ?", params.AdjustmentID)
+	}
+	if params.SourcePeriodID > 0 {
+		query = query.Where("source_period_id = ?", params.SourcePeriodID)
+	}
+	if params.TargetPeriodID > 0 {
+		query = query.Where("target_period_id = ?", params.TargetPeriodID)
+	}
+	if params.Status != "" {
+		query = query.Where("status = ?", params.Status)
+	}
+
+	var items []models.RetroPayItem
+	if err := query.Order("employee_id ASC, source_period_id ASC, id ASC").Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	report := &RetroPayReport{Employees: []EmployeeRetroPay{}, ItemCount: len(items)}
+	index := make(map[uint]int)
+	for _, item := range items {
+		i, ok := index[item.EmployeeID]
+		if !ok {
+			entry := EmployeeRetroPay{EmployeeID: item.EmployeeID}
+			if item.Employee != nil {
+				entry.EmployeeName = item.Employee.Name
+			}
+			report.Employees = append(report.Employees, entry)
+			i = len(report.Employees) - 1
+			index[item.EmployeeID] = i
+		}
+		item.Employee = nil
+		report.Employees[i].Lines = append(report.Employees[i].Lines, item)
+		if item.Status != models.RetroPayCancelled {
+			report.Employees[i].TotalAmount = report.Employees[i].TotalAmount.Add(item.Amount)
+			report.TotalAmount = report.TotalAmount.Add(item.Amount)
+		}
+	}
+	return report, nil
+}
+
+// ProcessAdjustment 对已批准的调薪重新执行追溯重算（已生成的补发会被扣除，重复执行不会重复补发）
+func (s *RetroPayService) ProcessAdjustment(adjustmentID uint) ([]models.RetroPayItem, error) {
+	var adjustment models.SalaryAdjustment
+	if err := s.db.First(&adjustment, adjustmentID).Error; err != nil {
+		return nil, errors.New("salary adjustment not found")
+	}
+
+	var items []models.RetroPayItem
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		items, err = newRetroPayEngine(tx).process(&adjustment)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// CancelRetroPayItem 取消尚未并入薪资的补发明细
+func (s *RetroPayService) CancelRetroPayItem(id uint) error {
+	var item models.RetroPayItem
+	if err := s.db.First(&item, id).Error; err != nil {
+		return errors.New("retro pay item not found")
+	}
+	if item.Status != models.RetroPayPending {
+		return errors.New("only pending retro pay items can be cancelled")
+	}
+	return s.db.Model(&item).Update("status", models.RetroPayCancelled).Error
+}
+
+// ========================= Retro Pay Engine =========================
+
+// retroPayEngine 对已发放周期进行影子重算，与已发放版本比对后生成补发/补扣明细
+type retroPayEngine struct {
+	db *gorm.DB
+}
+
+func newRetroPayEngine(db *gorm.DB) *retroPayEngine {
+	return &retroPayEngine{db: db}
+}
+
+// paidPeriodStatuses 视为已发放、需要追溯的周期状态
+var paidPeriodStatuses = []models.PayrollPeriodStatus{models.PeriodStatusPaid, models.PeriodStatusClosed}
+
+// process 重算调薪生效日之后已发放的周期，生成待并入下一开放周期的补发/补扣明细
+func (e *retroPayEngine) process(adjustment *models.SalaryAdjustment) ([]models.RetroPayItem, error) {
+	if adjustment.Status != "approved" {
+		return nil, errors.New("salary adjustment is not approved")
+	}
+
+	var salaries []models.EnhancedSalary
+	if err := e.db.Joins("JOIN payroll_periods ON payroll_periods.id = enhanced_salaries.payroll_period_id").
+		Where("enhanced_salaries.employee_id = ? AND payroll_periods.end_date >= ? AND payroll_periods.status IN ? AND payroll_periods.period_type NOT IN ?",
+			adjustment.EmployeeID, adjustment.EffectiveDate, paidPeriodStatuses, models.SupplementalPeriodTypes).
+		Where("enhanced_salaries.status NOT IN ?", []models.SalaryStatus{models.SalaryStatusCancelled, models.SalaryStatusRejected}).
+		Preload("PayrollPeriod").Preload("Components.Component").
+		Find(&salaries).Error; err != nil {
+		return nil, fmt.Errorf("failed to load paid salaries: %w", err)
+	}
+	salaries = LatestSalaryVersions(salaries)
+	if len(salaries) == 0 {
+		return []models.RetroPayItem{}, nil
+	}
+
+	var employee models.Employee
+	if err := e.db.Preload("Department").Preload("Position").First(&employee, adjustment.EmployeeID).Error; err != nil {
+		return nil, fmt.Errorf("employee not found: %w", err)
+	}
+
+	target, err := e.nextOpenPeriod(salaries[len(salaries)-1].PayrollPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.RetroPayItem, 0, len(salaries))
+	for i := range salaries {
+		item, err := e.recalculate(&employee, &salaries[i], adjustment)
+		if err != nil {
+			return nil, err
+		}
+		if item == nil {
+			continue
+		}
+		if target != nil {
+			item.TargetPeriodID = &target.ID
+		}
+		if err := e.db.Create(item).Error; err != nil {
+			return nil, fmt.Errorf("failed to create retro pay item: %w", err)
+		}
+		items = append(items, *item)
+	}
+	return items, nil
+}
+
+// recalculate 影子重算单个已发放周期，差额不足 0.01 时返回 nil
+func (e *retroPayEngine) recalculate(employee *models.Employee, salary *models.EnhancedSalary, adjustment *models.SalaryAdjustment) (*models.RetroPayItem, error) {
+	if salary.StructureID == nil {
+		return nil, nil
+	}
+
+	// 按原薪资计算时绑定的结构版本重算；早于版本化的记录取周期内生效版本
+	var structure *models.SalaryStructure
+	if salary.StructureVersionID != nil {
+		resolved, err := structureForVersion(e.db, *salary.StructureVersionID)
+		if err != nil {
+			return nil, err
+		}
+		structure = resolved
+	} else {
+		var current models.SalaryStructure
+		if err := e.db.Preload("Components").First(&current, *salary.StructureID).Error; err != nil {
+			return nil, fmt.Errorf("failed to load salary structure: %w", err)
+		}
+		resolved, _, err := structureForPeriod(e.db, &current, salary.PayrollPeriod)
+		if err != nil {
+			return nil, err
+		}
+		structure = resolved
+	}
+
+	shadow, err := newShadowSalaryCalculator(e.db).calculate(employee, structure, salary.PayrollPeriod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recalculate period %s: %w", salary.PayrollPeriod.Name, err)
+	}
+
+	var issued models.Money
+	if err := e.db.Model(&models.RetroPayItem{}).
+		Where("source_salary_id = ? AND status <> ?", salary.ID, models.RetroPayCancelled).
+		Select("COALESCE(SUM(amount), 0)").Scan(&issued).Error; err != nil {
+		return nil, fmt.Errorf("failed to load issued retro pay: %w", err)
+	}
+	return RetroPayDiff(employee.ID, adjustment, salary, shadow.GrossSalary, issued), nil
+}
+
+// RetroPayDiff 比对影子重算的应发与已发放版本，已发放薪资中包含的补发及此前已生成的补发不重复计入，差额为 0 时返回 nil
+func RetroPayDiff(employeeID uint, adjustment *models.SalaryAdjustment, salary *models.EnhancedSalary, recalculatedGross, issued models.Money) *models.RetroPayItem {
+	paidGross := salary.GrossSalary
+	for _, detail := range salary.Components {
+		if detail.Component != nil && detail.Component.Code == RetroPayComponentCode {
+			paidGross = paidGross.Sub(detail.FinalValue)
+		}
+	}
+
+	amount := recalculatedGross.Sub(paidGross).Sub(issued)
+	if amount.IsZero() {
+		return nil
+	}
+
+	return &models.RetroPayItem{
+		EmployeeID:        employeeID,
+		AdjustmentID:      adjustment.ID,
+		SourcePeriodID:    salary.PayrollPeriodID,
+		SourceSalaryID:    salary.ID,
+		SourceVersion:     salary.Version,
+		PaidGross:         paidGross,
+		RecalculatedGross: recalculatedGross,
+		Amount:            amount,
+		Status:            models.RetroPayPending,
+		Trace: fmt.Sprintf("recalculated %s - paid %s - issued %s = %s",
+			recalculatedGross, paidGross, issued, amount),
+	}
+}
+
+// nextOpenPeriod 查找最近一次已发放周期之后的第一个开放周期，未建立时返回 nil（计算时再并入）
+func (e *retroPayEngine) nextOpenPeriod(after *models.PayrollPeriod) (*models.PayrollPeriod, error) {
+	var period models.PayrollPeriod
+	err := e.db.Where("status IN ? AND period_type NOT IN ? AND start_date > ?",
+		[]models.PayrollPeriodStatus{models.PeriodStatusDraft, models.PeriodStatusOpen}, models.SupplementalPeriodTypes, after.StartDate).
+		Order("start_date ASC").First(&period).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+// LatestSalaryVersions 每个周期仅保留版本号最大的薪资记录，并按周期开始日期排序
+func LatestSalaryVersions(salaries []models.EnhancedSalary) []models.EnhancedSalary {
+	latest := make(map[uint]int)
+	for i, salary := range salaries {
+		if j, ok := latest[salary.PayrollPeriodID]; !ok || salary.Version > salaries[j].Version {
+			latest[salary.PayrollPeriodID] = i
+		}
+	}
+
+	result := make([]models.EnhancedSalary, 0, len(latest))
+	for _, i := range latest {
+		result = append(result, salaries[i])
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PayrollPeriod.StartDate.Before(result[j].PayrollPeriod.StartDate)
+	})
+	return result
+}
+
+// applyRetroPay 将周期开始前已发放周期的待处理补发/补扣并入本期应发
+func (c *salaryCalculator) applyRetroPay(employee *models.Employee, period *models.PayrollPeriod, result *structureCalculation) error {
+	var items []models.RetroPayItem
+	if err := c.db.Preload("SourcePeriod").
+		Where("employee_id = ? AND status = ?", employee.ID, models.RetroPayPending).
+		Where("source_period_id IN (?)", c.db.Model(&models.PayrollPeriod{}).Select("id").Where("start_date < ?", period.StartDate)).
+		Order("source_period_id ASC, id ASC").Find(&items).Error; err != nil {
+		return fmt.Errorf("failed to load retro pay: %w", err)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	component, err := ensureSystemComponent(c.db, models.SalaryComponent{
+		Code:        RetroPayComponentCode,
+		Name:        "追溯补发",
+		Category:    models.ComponentCategoryBase,
+		IsTaxable:   true,
+		Sort:        90,
+		Description: "追溯调薪时由系统生成（负数为补扣）",
+	})
+	if err != nil {
+		return err
+	}
+
+	var total models.Money
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		total = total.Add(item.Amount)
+		name := fmt.Sprintf("period %d", item.SourcePeriodID)
+		if item.SourcePeriod != nil {
+			name = item.SourcePeriod.Name
+		}
+		lines = append(lines, fmt.Sprintf("%s %s", name, item.Amount))
+	}
+
+	result.addDetail(models.SalaryDetail{
+		ComponentID:        component.ID,
+		Component:          component,
+		CalculatedValue:    total,
+		FinalValue:         total,
+		CalculationFormula: fmt.Sprintf("retro = %s = %s", strings.Join(lines, " + "), total),
+	})
+	result.RetroPay = items
+	return nil
+}
+
+// markRetroPayApplied 将已并入薪资的补发明细标记为已处理
+func markRetroPayApplied(tx *gorm.DB, items []models.RetroPayItem, salary *models.EnhancedSalary) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	now := time.Now()
+	return tx.Model(&models.RetroPayItem{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"status":           models.RetroPayApplied,
+		"target_period_id": salary.PayrollPeriodID,
+		"target_salary_id": salary.ID,
+		"applied_at":       &now,
+	}).Error
+}
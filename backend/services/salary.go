@@ -23,7 +23,7 @@ type SalaryServiceInterface interface {
 	BatchCalculateSalary(month string, departmentID uint, employeeIDs []uint) (*BatchCalculateResult, error)
 	ApproveSalary(id uint, status, remark string) (*models.Salary, error)
 	GetSalaryStatistics(month string, departmentID uint) (*SalaryStatistics, error)
-	ExportSalaryReport(month string, departmentID uint, format string) ([]byte, string, error)
+	ExportSalaryReport(month string, departmentID uint, format string) (*PayrollRegisterExport, error)
 	GetEmployeeSalary(userID uint, month string) (*models.Salary, error)
 	CreatePayrollRecord(payroll *models.PayrollRecord) (*models.PayrollRecord, error)
 	ProcessPayroll(salaryID uint, paymentMethod, bankAccount string) (*models.PayrollRecord, error)
@@ -57,8 +57,11 @@ type SalaryServiceInterface interface {
 	UpdatePayrollPeriod(id uint, period *models.PayrollPeriod) (*models.PayrollPeriod, error)
 	GetPayrollPeriods(params PeriodQueryParams) (*utils.PaginationResponse, error)
 	GetPayrollPeriodByID(id uint) (*models.PayrollPeriod, error)
-	LockPayrollPeriod(id uint) error
-	UnlockPayrollPeriod(id uint) error
+	LockPayrollPeriod(id uint, userID uint) error
+	UnlockPayrollPeriod(id uint, reason string, userID uint) error
+	TransitionPayrollPeriod(id uint, status models.PayrollPeriodStatus, userID uint) (*models.PayrollPeriod, error)
+	ReopenPayrollPeriod(id uint, reason string, userID uint) (*models.PayrollPeriod, error)
+	GetPayrollPeriodEvents(id uint) ([]models.PayrollPeriodEvent, error)
 
 	// Enhanced Salary Management
 	CalculateEmployeeSalary(employeeID, periodID uint, userID uint) (*models.EnhancedSalary, error)
@@ -66,6 +69,12 @@ type SalaryServiceInterface interface {
 	GetEnhancedSalaries(params EnhancedSalaryQueryParams) (*utils.PaginationResponse, error)
 	GetEnhancedSalaryByID(id uint) (*models.EnhancedSalary, error)
 	UpdateSalaryDetails(salaryID uint, details []SalaryDetailUpdate, userID uint) (*models.EnhancedSalary, error)
+	CalculateBonusPayments(periodID uint, items []BonusPaymentItem, method models.BonusTaxMethod, userID uint) (*EnhancedBatchResult, error)
+
+	// Off-cycle Runs
+	CreateOffCycleRun(parentID uint, run *models.PayrollPeriod) (*models.PayrollPeriod, error)
+	GetOffCycleRuns(parentID uint) ([]models.PayrollPeriod, error)
+	CalculateOffCyclePayments(periodID uint, items []OffCyclePaymentItem, userID uint) (*EnhancedBatchResult, error)
 
 	// Enhanced Approval Workflow
 	ReviewSalary(id uint, reviewerID uint, notes string, approve bool) (*models.EnhancedSalary, error)
@@ -87,7 +96,7 @@ type SalaryServiceInterface interface {
 	// Enhanced Analytics and Reporting
 	GetSalaryAnalytics(params AnalyticsParams) (*SalaryAnalytics, error)
 	GetDepartmentSalaryReport(departmentID uint, periodID uint) (*DepartmentSalaryReport, error)
-	ExportEnhancedSalaryReport(params ExportParams) ([]byte, string, error)
+	ExportEnhancedSalaryReport(params ExportParams) (*PayrollRegisterExport, error)
 
 	// Personal Salary Management
 	GetPersonalSalaryDetail(params PersonalSalaryParams) (*PersonalSalaryDetail, error)
@@ -237,7 +246,18 @@ func (s *SalaryService) CalculateSalary(employeeID uint, month string) (*models.
 		return nil, errors.New("该月份薪资已存在")
 	}
 
-	attendance, err := s.getEmployeeAttendance(employeeID, month)
+	monthStart, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil, errors.New("薪资月份格式错误")
+	}
+
+	attendance, err := s.getEmployeeAttendance(&employee, monthStart)
+	if err != nil {
+		return nil, err
+	}
+
+	// 月中入离职或调薪时按应出勤天数折算基本薪资
+	proration, err := loadProration(s.db, &employee, monthStart, monthStart.AddDate(0, 1, -1), models.ProrationWorkingDays)
 	if err != nil {
 		return nil, err
 	}
@@ -245,18 +265,26 @@ func (s *SalaryService) CalculateSalary(employeeID uint, month string) (*models.
 	salary := &models.Salary{
 		EmployeeID:  employeeID,
 		Month:       month,
-		BaseSalary:  employee.BaseSalary,
+		BaseSalary:  proration.ProratedBase,
 		Bonus:       s.calculateBonus(employee, attendance),
-		Allowance:   s.calculateAllowance(employee),
+		Allowance:   models.NewMoney(s.calculateAllowance(employee)),
 		Deduction:   s.calculateDeduction(employee, attendance),
 		Status:      "calculated",
 	}
 
-	salary.GrossSalary = salary.BaseSalary + salary.Bonus + salary.Allowance - salary.Deduction
-	salary.Tax = s.calculateTax(salary.GrossSalary)
-	salary.SocialSecurity = s.calculateSocialSecurity(salary.BaseSalary)
-	salary.HousingFund = s.calculateHousingFund(salary.BaseSalary)
-	salary.NetSalary = salary.GrossSalary - salary.Tax - salary.SocialSecurity - salary.HousingFund
+	salary.GrossSalary = models.SumMoney(salary.BaseSalary, salary.Bonus, salary.Allowance).Sub(salary.Deduction)
+	policy, err := findInsurancePolicy(s.db, &employee, monthStart)
+	if err != nil {
+		return nil, err
+	}
+	salary.SocialSecurity = models.NewMoney(s.calculateSocialSecurity(proration.FullBase.Float64(), policy))
+	salary.HousingFund = models.NewMoney(s.calculateHousingFund(proration.FullBase.Float64(), policy))
+	tax, err := s.calculateTax(&employee, salary)
+	if err != nil {
+		return nil, err
+	}
+	salary.Tax = models.NewMoney(tax)
+	salary.NetSalary = salary.GrossSalary.Sub(models.SumMoney(salary.Tax, salary.SocialSecurity, salary.HousingFund))
 
 	if err := s.db.Create(salary).Error; err != nil {
 		return nil, err
@@ -380,8 +408,23 @@ func (s *SalaryService) GetSalaryStatistics(month string, departmentID uint) (*S
 	return &stats, nil
 }
 
-func (s *SalaryService) ExportSalaryReport(month string, departmentID uint, format string) ([]byte, string, error) {
-	return nil, "", errors.New("导出功能暂未实现")
+// ExportSalaryReport 按月份（YYYY-MM）导出月度薪资周期的薪资登记表
+func (s *SalaryService) ExportSalaryReport(month string, departmentID uint, format string) (*PayrollRegisterExport, error) {
+	monthStart, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil, &utils.ValidationError{Message: "月份格式应为 YYYY-MM"}
+	}
+	var period models.PayrollPeriod
+	if err := s.db.Where("period_type = ? AND year = ? AND month = ?", models.PeriodTypeMonthly,
+		monthStart.Year(), int(monthStart.Month())).First(&period).Error; err != nil {
+		return nil, &utils.ValidationError{Message: "该月份没有薪资周期"}
+	}
+
+	params := ExportParams{PeriodID: &period.ID, Format: format}
+	if departmentID != 0 {
+		params.DepartmentID = &departmentID
+	}
+	return s.ExportEnhancedSalaryReport(params)
 }
 
 func (s *SalaryService) GetEmployeeSalary(userID uint, month string) (*models.Salary, error) {
@@ -413,85 +456,97 @@ func (s *SalaryService) validateSalary(salary *models.Salary) error {
 	return nil
 }
 
-func (s *SalaryService) getEmployeeAttendance(employeeID uint, month string) ([]models.Attendance, error) {
-	var attendances []models.Attendance
-	startDate := month + "-01"
-	endDate := month + "-31"
-
-	if err := s.db.Where("employee_id = ? AND date BETWEEN ? AND ?", employeeID, startDate, endDate).
-		Find(&attendances).Error; err != nil {
-		return nil, err
-	}
-
-	return attendances, nil
+// getEmployeeAttendance 按自然月起止日期和工作日历汇总员工考勤
+func (s *SalaryService) getEmployeeAttendance(employee *models.Employee, monthStart time.Time) (*AttendanceInputs, error) {
+	monthEnd := monthStart.AddDate(0, 1, -1)
+	return loadAttendanceInputs(s.db, employee, monthStart, monthEnd)
 }
 
-func (s *SalaryService) calculateBonus(employee models.Employee, attendances []models.Attendance) float64 {
-	if len(attendances) == 0 {
-		return 0
+// calculateBonus 全勤奖：应出勤日均已出勤或休带薪假时发放基本薪资的 10%
+func (s *SalaryService) calculateBonus(employee models.Employee, attendance *AttendanceInputs) models.Money {
+	if attendance.WorkingDays == 0 || attendance.AttendanceDays == 0 {
+		return models.Money{}
 	}
 
-	totalWorkDays := len(attendances)
-	normalWorkDays := 22
-
-	if totalWorkDays >= normalWorkDays {
-		return employee.BaseSalary * 0.1
+	if attendance.AbsenceDays == 0 && attendance.AttendanceDays+attendance.PaidLeaveDays >= attendance.WorkingDays {
+		return employee.BaseSalary.Mul(0.1, models.RoundHalfUp)
 	}
 
-	return 0
+	return models.Money{}
 }
 
 func (s *SalaryService) calculateAllowance(employee models.Employee) float64 {
 	return 500
 }
 
-func (s *SalaryService) calculateDeduction(employee models.Employee, attendances []models.Attendance) float64 {
-	deduction := 0.0
-	normalWorkDays := 22
-	actualWorkDays := len(attendances)
-
-	if actualWorkDays < normalWorkDays {
-		missedDays := normalWorkDays - actualWorkDays
-		dailySalary := employee.BaseSalary / 30
-		deduction = float64(missedDays) * dailySalary
+// calculateDeduction 缺勤扣款：缺勤天数（旷工 + 无薪假）按月计薪天数折算日薪扣减
+func (s *SalaryService) calculateDeduction(employee models.Employee, attendance *AttendanceInputs) models.Money {
+	if attendance.AbsenceDays <= 0 {
+		return models.Money{}
 	}
 
-	return deduction
+	return employee.BaseSalary.Mul(attendance.AbsenceDays/monthlyPayDays, models.RoundHalfUp)
 }
 
-func (s *SalaryService) calculateTax(grossSalary float64) float64 {
-	taxableIncome := grossSalary - 5000
+// calculateTax 按累计预扣法计算当月个税，累计数据取自同一年度此前月份的薪资记录
+func (s *SalaryService) calculateTax(employee *models.Employee, salary *models.Salary) (float64, error) {
+	month, err := time.Parse("2006-01", salary.Month)
+	if err != nil {
+		return 0, errors.New("薪资月份格式错误")
+	}
 
-	if taxableIncome <= 0 {
-		return 0
+	var ytd struct {
+		Income     models.Money
+		Deductions models.Money
+		Tax        models.Money
+	}
+	err = s.db.Model(&models.Salary{}).
+		Select("COALESCE(SUM(gross_salary), 0) AS income, "+
+			"COALESCE(SUM(social_security + housing_fund), 0) AS deductions, "+
+			"COALESCE(SUM(tax), 0) AS tax").
+		Where("employee_id = ? AND month >= ? AND month < ?", employee.ID, month.Format("2006")+"-01", salary.Month).
+		Scan(&ytd).Error
+	if err != nil {
+		return 0, err
 	}
 
-	var tax float64
-	if taxableIncome <= 3000 {
-		tax = taxableIncome * 0.03
-	} else if taxableIncome <= 12000 {
-		tax = 3000*0.03 + (taxableIncome-3000)*0.1
-	} else if taxableIncome <= 25000 {
-		tax = 3000*0.03 + 9000*0.1 + (taxableIncome-12000)*0.2
-	} else if taxableIncome <= 35000 {
-		tax = 3000*0.03 + 9000*0.1 + 13000*0.2 + (taxableIncome-25000)*0.25
-	} else if taxableIncome <= 55000 {
-		tax = 3000*0.03 + 9000*0.1 + 13000*0.2 + 10000*0.25 + (taxableIncome-35000)*0.3
-	} else if taxableIncome <= 80000 {
-		tax = 3000*0.03 + 9000*0.1 + 13000*0.2 + 10000*0.25 + 20000*0.3 + (taxableIncome-55000)*0.35
-	} else {
-		tax = 3000*0.03 + 9000*0.1 + 13000*0.2 + 10000*0.25 + 20000*0.3 + 25000*0.35 + (taxableIncome-80000)*0.45
+	months := taxableMonths(employee.HireDate, month.Year(), month.Month())
+	additional, err := cumulativeSpecialDeductions(s.db, employee.ID, month.Year(), int(month.Month())-months+1, int(month.Month()))
+	if err != nil {
+		return 0, err
 	}
 
-	return tax
+	result := CalculateCumulativeTax(CumulativeTaxInput{
+		Months:               months,
+		Income:               ytd.Income.Add(salary.GrossSalary).Float64(),
+		SpecialDeductions:    models.SumMoney(ytd.Deductions, salary.SocialSecurity, salary.HousingFund).Float64(),
+		AdditionalDeductions: additional.Float64(),
+		WithheldTax:          ytd.Tax.Float64(),
+	})
+	return result.CurrentTax, nil
 }
 
-func (s *SalaryService) calculateSocialSecurity(baseSalary float64) float64 {
-	return baseSalary * 0.08
+// calculateSocialSecurity 按参保城市政策计算个人社保缴费，未配置政策时按8%计算
+func (s *SalaryService) calculateSocialSecurity(baseSalary float64, policy *models.SocialInsurancePolicy) float64 {
+	if policy == nil {
+		return baseSalary * 0.08
+	}
+
+	total := 0.0
+	for _, contribution := range CalculateContributions(policy, baseSalary) {
+		if !contribution.Employer && contribution.Code != HousingFundEmployeeCode {
+			total += contribution.Amount
+		}
+	}
+	return roundAmount(total)
 }
 
-func (s *SalaryService) calculateHousingFund(baseSalary float64) float64 {
-	return baseSalary * 0.12
+// calculateHousingFund 按参保城市政策计算个人公积金缴存，未配置政策时按12%计算
+func (s *SalaryService) calculateHousingFund(baseSalary float64, policy *models.SocialInsurancePolicy) float64 {
+	if policy == nil {
+		return baseSalary * 0.12
+	}
+	return roundAmount(policy.ClampHousingFundBase(baseSalary) * policy.HousingFundEmployeeRate)
 }
 
 func (s *SalaryService) CreatePayrollRecord(payroll *models.PayrollRecord) (*models.PayrollRecord, error) {
@@ -559,7 +614,7 @@ func (s *SalaryService) validatePayrollRecord(payroll *models.PayrollRecord) err
 	if payroll.SalaryID == 0 {
 		return errors.New("薪资记录ID不能为空")
 	}
-	if payroll.PaymentAmount <= 0 {
+	if payroll.PaymentAmount.Sign() <= 0 {
 		return errors.New("发放金额必须大于0")
 	}
 	return nil
@@ -694,18 +749,17 @@ func (s *SalaryService) GetSalaryGradeByID(id uint) (*models.SalaryGrade, error)
 // ========================= Enhanced Salary Structure Management =========================
 
 func (s *SalaryService) CreateSalaryStructure(structure *models.SalaryStructure) (*models.SalaryStructure, error) {
-	if err := s.db.Create(structure).Error; err != nil {
+	if err := validateStructureDependencies(s.db, structure); err != nil {
 		return nil, err
 	}
-	return structure, nil
+	return createVersionedStructure(s.db, structure)
 }
 
 func (s *SalaryService) UpdateSalaryStructure(id uint, structure *models.SalaryStructure) (*models.SalaryStructure, error) {
-	structure.ID = id
-	if err := s.db.Save(structure).Error; err != nil {
+	if err := validateStructureDependencies(s.db, structure); err != nil {
 		return nil, err
 	}
-	return structure, nil
+	return updateVersionedStructure(s.db, id, structure)
 }
 
 func (s *SalaryService) DeleteSalaryStructure(id uint) error {
@@ -759,7 +813,7 @@ func (s *SalaryService) GetApplicableStructure(employeeID uint) (*models.SalaryS
 	}
 
 	var structure models.SalaryStructure
-	query := s.db.Preload("Components").Where("status = ?", "active")
+	query := s.db.Preload("Components.Component").Where("status = ?", "active")
 
 	// Try to find structure by department first
 	if employee.DepartmentID > 0 {
@@ -780,6 +834,12 @@ func (s *SalaryService) GetApplicableStructure(employeeID uint) (*models.SalaryS
 // ========================= Enhanced Payroll Period Management =========================
 
 func (s *SalaryService) CreatePayrollPeriod(period *models.PayrollPeriod) (*models.PayrollPeriod, error) {
+	if period.IsOffCycle() || period.ParentPeriodID != nil {
+		return nil, &utils.ValidationError{Message: "非周期发放须在所属常规周期下创建"}
+	}
+	if err := validatePeriodCurrency(period); err != nil {
+		return nil, err
+	}
 	if err := s.db.Create(period).Error; err != nil {
 		return nil, err
 	}
@@ -787,7 +847,31 @@ func (s *SalaryService) CreatePayrollPeriod(period *models.PayrollPeriod) (*mode
 }
 
 func (s *SalaryService) UpdatePayrollPeriod(id uint, period *models.PayrollPeriod) (*models.PayrollPeriod, error) {
+	existing, err := s.GetPayrollPeriodByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensurePeriodEditable(existing); err != nil {
+		return nil, err
+	}
+	if err := validatePeriodCurrency(period); err != nil {
+		return nil, err
+	}
+	if period.Currency != models.NormalizeCurrency(existing.Currency) {
+		var calculated int64
+		if err := s.db.Model(&models.EnhancedSalary{}).Where("payroll_period_id = ?", id).Count(&calculated).Error; err != nil {
+			return nil, err
+		}
+		if calculated > 0 {
+			return nil, utils.NewConflictError("薪资周期已有计算结果，不能修改币种")
+		}
+	}
+
+	// 状态与锁定标记只能通过关账流程变更
 	period.ID = id
+	period.Status = existing.Status
+	period.IsLocked = existing.IsLocked
+	period.CreatedAt = existing.CreatedAt
 	if err := s.db.Save(period).Error; err != nil {
 		return nil, err
 	}
@@ -831,12 +915,53 @@ func (s *SalaryService) GetPayrollPeriodByID(id uint) (*models.PayrollPeriod, er
 	return &period, nil
 }
 
-func (s *SalaryService) LockPayrollPeriod(id uint) error {
-	return s.db.Model(&models.PayrollPeriod{}).Where("id = ?", id).Update("status", "locked").Error
+// LockPayrollPeriod 手动锁定薪资周期，冻结周期内的薪资、考勤与请假数据
+func (s *SalaryService) LockPayrollPeriod(id uint, userID uint) error {
+	if err := validatePeriodOperator(userID); err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var period models.PayrollPeriod
+		if err := tx.First(&period, id).Error; err != nil {
+			return &utils.ValidationError{Message: "薪资周期不存在"}
+		}
+		if period.IsLocked {
+			return utils.NewConflictError("薪资周期已锁定")
+		}
+		if err := tx.Model(&period).Update("is_locked", true).Error; err != nil {
+			return err
+		}
+		return recordPeriodEvent(tx, &period, models.PeriodActionLock, period.Status, "", userID)
+	})
 }
 
-func (s *SalaryService) UnlockPayrollPeriod(id uint) error {
-	return s.db.Model(&models.PayrollPeriod{}).Where("id = ?", id).Update("status", "open").Error
+// UnlockPayrollPeriod 解除手动锁定；已进入审核及之后状态的周期需通过重新开放流程处理
+func (s *SalaryService) UnlockPayrollPeriod(id uint, reason string, userID uint) error {
+	if err := validatePeriodOperator(userID); err != nil {
+		return err
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return &utils.ValidationError{Message: "解锁薪资周期必须填写原因"}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var period models.PayrollPeriod
+		if err := tx.First(&period, id).Error; err != nil {
+			return &utils.ValidationError{Message: "薪资周期不存在"}
+		}
+		if !period.IsLocked {
+			return utils.NewConflictError("薪资周期未被手动锁定")
+		}
+		if period.Status.LocksInputs() {
+			return utils.NewConflictError(fmt.Sprintf("薪资周期处于%s状态，请使用重新开放", periodStatusLabel(period.Status)))
+		}
+		if err := tx.Model(&period).Update("is_locked", false).Error; err != nil {
+			return err
+		}
+		return recordPeriodEvent(tx, &period, models.PeriodActionUnlock, period.Status, reason, userID)
+	})
 }
 
 func (s *SalaryService) validateSalaryComponent(component *models.SalaryComponent) error {
@@ -846,6 +971,23 @@ func (s *SalaryService) validateSalaryComponent(component *models.SalaryComponen
 	if component.Name == "" {
 		return errors.New("salary component name is required")
 	}
+	if !component.RoundingRule.IsValid() {
+		return fmt.Errorf("unsupported rounding rule: %s", component.RoundingRule)
+	}
+
+	switch component.Type {
+	case models.ComponentTypePercentage:
+		if _, err := parsePercentage(component.Formula); err != nil {
+			return fmt.Errorf("invalid formula: %w", err)
+		}
+	case models.ComponentTypeFormula:
+		if err := s.ValidateFormula(component.Formula); err != nil {
+			return fmt.Errorf("invalid formula: %w", err)
+		}
+		if err := checkFormulaSelfReference(component); err != nil {
+			return fmt.Errorf("invalid formula: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -862,6 +1004,15 @@ func (s *SalaryService) CalculateEmployeeSalary(employeeID, periodID uint, userI
 	if err := s.db.First(&period, periodID).Error; err != nil {
 		return nil, fmt.Errorf("payroll period not found: %w", err)
 	}
+	switch period.PeriodType {
+	case models.PeriodTypeBonus:
+		return nil, errors.New("bonus periods must be calculated as bonus payments")
+	case models.PeriodTypeOffCycle:
+		return nil, errors.New("off-cycle runs must be calculated as off-cycle payments")
+	}
+	if err := ensurePeriodEditable(&period); err != nil {
+		return nil, err
+	}
 
 	// Check if salary already exists
 	var existingSalary models.EnhancedSalary
@@ -869,28 +1020,61 @@ func (s *SalaryService) CalculateEmployeeSalary(employeeID, periodID uint, userI
 		return nil, errors.New("salary for this period already exists")
 	}
 
-	// Create new salary record with basic calculation
+	// Get applicable salary structure
+	structure, err := s.GetApplicableStructure(employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get salary structure: %w", err)
+	}
+	// 绑定周期内生效的结构版本
+	structure, versionID, err := structureForPeriod(s.db, structure, &period)
+	if err != nil {
+		return nil, err
+	}
+
+	// Calculate salary components in dependency order
+	calculation, err := newSalaryCalculator(s.db).calculate(&employee, structure, &period)
+	if err != nil {
+		return nil, err
+	}
+
 	salary := &models.EnhancedSalary{
 		EmployeeID:      employeeID,
 		PayrollPeriodID: periodID,
-		GrossSalary:     employee.BaseSalary,
-		NetSalary:       employee.BaseSalary * 0.8, // Simple calculation for demo
-		TotalDeductions: employee.BaseSalary * 0.2,
+		StructureID:     &structure.ID,
+		StructureVersionID: versionID,
 		Status:          "calculated",
 		CalculatedBy:    &userID,
 		Version:         1,
 	}
+	calculation.applyTo(salary)
 
 	now := time.Now()
 	salary.CalculatedAt = &now
 
-	// Save salary record
-	if err := s.db.Create(salary).Error; err != nil {
-		return nil, fmt.Errorf("failed to create salary record: %w", err)
+	// Save salary record and details
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(salary).Error; err != nil {
+			return fmt.Errorf("failed to create salary record: %w", err)
+		}
+		details := calculation.Details
+		for i := range details {
+			details[i].SalaryID = salary.ID
+			details[i].Component = nil
+		}
+		if len(details) > 0 {
+			if err := tx.Create(&details).Error; err != nil {
+				return fmt.Errorf("failed to create salary details: %w", err)
+			}
+		}
+		return markRetroPayApplied(tx, calculation.RetroPay, salary)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Load complete salary record with relationships
-	if err := s.db.Preload("Employee").Preload("PayrollPeriod").First(salary, salary.ID).Error; err != nil {
+	if err := s.db.Preload("Employee").Preload("PayrollPeriod").Preload("Structure").
+		Preload("Components.Component").Preload("Components.Segments").First(salary, salary.ID).Error; err != nil {
 		return nil, err
 	}
 
@@ -898,6 +1082,17 @@ func (s *SalaryService) CalculateEmployeeSalary(employeeID, periodID uint, userI
 }
 
 func (s *SalaryService) BatchCalculateSalaries(periodID uint, departmentID *uint, employeeIDs []uint, userID uint) (*EnhancedBatchResult, error) {
+	period, err := s.GetPayrollPeriodByID(periodID)
+	if err != nil {
+		return nil, fmt.Errorf("payroll period not found: %w", err)
+	}
+	if period.IsSupplemental() {
+		return nil, errors.New("bonus and off-cycle periods cannot be batch calculated from salary structures")
+	}
+	if err := ensurePeriodEditable(period); err != nil {
+		return nil, err
+	}
+
 	var employees []models.Employee
 	query := s.db.Where("status = ?", "active")
 
@@ -935,7 +1130,7 @@ func (s *SalaryService) BatchCalculateSalaries(periodID uint, departmentID *uint
 			item.GrossAmount = salary.GrossSalary
 			item.NetAmount = salary.NetSalary
 			result.Success++
-			result.TotalAmount += salary.NetSalary
+			result.TotalAmount = result.TotalAmount.Add(salary.NetSalary)
 		}
 
 		result.Results = append(result.Results, item)
@@ -994,6 +1189,14 @@ func (s *SalaryService) UpdateSalaryDetails(salaryID uint, details []SalaryDetai
 		return nil, errors.New("salary record not found")
 	}
 
+	period, err := loadSalaryPeriod(s.db, &salary)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensurePeriodEditable(period); err != nil {
+		return nil, err
+	}
+
 	if salary.Status != "calculated" && salary.Status != "reviewed" {
 		return nil, errors.New("salary cannot be modified in current status")
 	}
@@ -1015,6 +1218,10 @@ func (s *SalaryService) ReviewSalary(id uint, reviewerID uint, notes string, app
 		return nil, errors.New("salary record not found")
 	}
 
+	if err := s.ensureSalaryPeriodNotClosed(&salary); err != nil {
+		return nil, err
+	}
+
 	if salary.Status != "calculated" {
 		return nil, errors.New("salary is not in calculated status")
 	}
@@ -1042,6 +1249,10 @@ func (s *SalaryService) ApproveEnhancedSalary(id uint, approverID uint, notes st
 		return nil, errors.New("salary record not found")
 	}
 
+	if err := s.ensureSalaryPeriodNotClosed(&salary); err != nil {
+		return nil, err
+	}
+
 	if salary.Status != "reviewed" {
 		return nil, errors.New("salary must be reviewed before approval")
 	}
@@ -1063,6 +1274,9 @@ func (s *SalaryService) RejectSalary(id uint, approverID uint, reason string) (*
 	if err := s.db.First(&salary, id).Error; err != nil {
 		return nil, errors.New("salary record not found")
 	}
+	if err := s.ensureSalaryPeriodNotClosed(&salary); err != nil {
+		return nil, err
+	}
 
 	salary.Status = "rejected"
 	salary.ApprovedBy = &approverID
@@ -1102,9 +1316,55 @@ func (s *SalaryService) BulkApproveSalaries(salaryIDs []uint, approverID uint, n
 	return result, nil
 }
 
+// ensureSalaryPeriodNotClosed 已关账周期内的薪资不允许再变更审批状态
+func (s *SalaryService) ensureSalaryPeriodNotClosed(salary *models.EnhancedSalary) error {
+	period, err := loadSalaryPeriod(s.db, salary)
+	if err != nil {
+		return err
+	}
+	return ensurePeriodNotClosed(period)
+}
+
 // ========================= Enhanced Payment Processing =========================
 
 func (s *SalaryService) CreatePaymentBatch(batch *models.PaymentBatch, salaryIDs []uint, userID uint) (*models.PaymentBatch, error) {
+	if batch.PayrollPeriodID != 0 {
+		period, err := s.GetPayrollPeriodByID(batch.PayrollPeriodID)
+		if err != nil {
+			return nil, fmt.Errorf("payroll period not found: %w", err)
+		}
+		if err := ensurePeriodNotClosed(period); err != nil {
+			return nil, err
+		}
+		batch.Currency = period.Currency
+	}
+
+	// 同一批次只能发放一种币种
+	batch.Currency = models.NormalizeCurrency(batch.Currency)
+	if len(salaryIDs) > 0 {
+		var mismatched int64
+		if err := s.db.Model(&models.EnhancedSalary{}).
+			Where("id IN ? AND COALESCE(currency, ?) <> ?", salaryIDs, models.DefaultCurrency, batch.Currency).
+			Count(&mismatched).Error; err != nil {
+			return nil, err
+		}
+		if mismatched > 0 {
+			return nil, &utils.ValidationError{Message: fmt.Sprintf("有 %d 条薪资记录的币种不是 %s，不能放入同一支付批次", mismatched, batch.Currency)}
+		}
+	}
+	// 非周期发放与父周期分别建立支付批次
+	if batch.PayrollPeriodID != 0 && len(salaryIDs) > 0 {
+		var foreign int64
+		if err := s.db.Model(&models.EnhancedSalary{}).
+			Where("id IN ? AND payroll_period_id <> ?", salaryIDs, batch.PayrollPeriodID).
+			Count(&foreign).Error; err != nil {
+			return nil, err
+		}
+		if foreign > 0 {
+			return nil, &utils.ValidationError{Message: fmt.Sprintf("有 %d 条薪资记录不属于该薪资周期，非周期发放须单独建立支付批次", foreign)}
+		}
+	}
+
 	batch.CreatedBy = &userID
 	batch.Status = "pending"
 
@@ -1112,20 +1372,38 @@ func (s *SalaryService) CreatePaymentBatch(batch *models.PaymentBatch, salaryIDs
 		return nil, err
 	}
 
-	// Create payroll records for each salary
+	// Create payroll records for each salary, carrying the payee bank account for bank file export
 	for _, salaryID := range salaryIDs {
 		var salary models.EnhancedSalary
-		if err := s.db.First(&salary, salaryID).Error; err != nil {
+		if err := s.db.Preload("Employee").First(&salary, salaryID).Error; err != nil {
 			continue
 		}
 
 		payrollRecord := models.EnhancedPayrollRecord{
-			SalaryID:      salaryID,
-			PaymentAmount: salary.NetSalary,
-			Status:        "pending",
+			SalaryID:       salaryID,
+			PaymentBatchID: &batch.ID,
+			PaymentAmount:  salary.NetSalary,
+			PaymentMethod:  models.PaymentMethodBankTransfer,
+			ScheduledDate:  batch.ScheduledDate,
+			Status:         "pending",
+		}
+		if salary.Employee != nil {
+			payrollRecord.BankAccount = salary.Employee.BankAccount
+			payrollRecord.BankName = salary.Employee.BankName
+		}
+
+		if err := s.db.Create(&payrollRecord).Error; err != nil {
+			continue
 		}
+		batch.TotalRecords++
+		batch.TotalAmount = batch.TotalAmount.Add(payrollRecord.PaymentAmount)
+	}
 
-		s.db.Create(&payrollRecord)
+	if err := s.db.Model(batch).Updates(map[string]interface{}{
+		"total_records": batch.TotalRecords,
+		"total_amount":  batch.TotalAmount,
+	}).Error; err != nil {
+		return nil, err
 	}
 
 	return batch, nil
@@ -1136,22 +1414,29 @@ func (s *SalaryService) ProcessPaymentBatch(batchID uint, userID uint) (*models.
 	if err := s.db.First(&batch, batchID).Error; err != nil {
 		return nil, errors.New("payment batch not found")
 	}
+	if batch.PayrollPeriodID != 0 {
+		period, err := s.GetPayrollPeriodByID(batch.PayrollPeriodID)
+		if err != nil {
+			return nil, fmt.Errorf("payroll period not found: %w", err)
+		}
+		if err := ensurePeriodNotClosed(period); err != nil {
+			return nil, err
+		}
+	}
 
-	batch.Status = "processed"
+	// 批次提交银行后记录进入处理中，最终结果以银行回盘对账为准
+	now := time.Now()
+	batch.Status = models.BatchStatusProcessing
 	batch.ProcessedBy = &userID
+	batch.ProcessedDate = &now
 
 	if err := s.db.Save(&batch).Error; err != nil {
 		return nil, err
 	}
 
-	// Update all payroll records in this batch
-	s.db.Model(&models.EnhancedPayrollRecord{}).Where("batch_id = ?", batchID).Update("status", "paid")
-
-	// Update salary statuses
-	s.db.Model(&models.EnhancedSalary{}).
-		Joins("JOIN enhanced_payroll_records ON enhanced_salaries.id = enhanced_payroll_records.salary_id").
-		Where("enhanced_payroll_records.batch_id = ?", batchID).
-		Update("status", "paid")
+	s.db.Model(&models.EnhancedPayrollRecord{}).
+		Where("payment_batch_id = ? AND status = ?", batchID, models.PayrollStatusPending).
+		Updates(map[string]interface{}{"status": models.PayrollStatusProcessing, "processed_date": now, "processed_by": userID})
 
 	return &batch, nil
 }
@@ -1196,6 +1481,12 @@ func (s *SalaryService) GetPaymentBatchByID(id uint) (*models.PaymentBatch, erro
 // ========================= Enhanced Salary Adjustments =========================
 
 func (s *SalaryService) CreateSalaryAdjustment(adjustment *models.SalaryAdjustment) (*models.SalaryAdjustment, error) {
+	if err := ensureAdjustmentDateAllowed(s.db, adjustment.EffectiveDate); err != nil {
+		return nil, err
+	}
+	if err := applyAdjustmentBandPolicy(s.db, adjustment); err != nil {
+		return nil, err
+	}
 	if err := s.db.Create(adjustment).Error; err != nil {
 		return nil, err
 	}
@@ -1207,13 +1498,24 @@ func (s *SalaryService) ApproveSalaryAdjustment(id uint, approverID uint) (*mode
 	if err := s.db.First(&adjustment, id).Error; err != nil {
 		return nil, errors.New("salary adjustment not found")
 	}
+	if err := ensureAdjustmentDateAllowed(s.db, adjustment.EffectiveDate); err != nil {
+		return nil, err
+	}
 
 	adjustment.Status = "approved"
 	adjustment.ApprovedBy = &approverID
 	now := time.Now()
 	adjustment.ApprovedAt = &now
 
-	if err := s.db.Save(&adjustment).Error; err != nil {
+	// 生效日期落在已发放周期内时，追溯重算并生成补发/补扣明细
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&adjustment).Error; err != nil {
+			return err
+		}
+		_, err := newRetroPayEngine(tx).process(&adjustment)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -1252,10 +1554,15 @@ func (s *SalaryService) GetSalaryAdjustments(params AdjustmentQueryParams) (*uti
 // ========================= Enhanced Analytics and Reporting =========================
 
 func (s *SalaryService) GetSalaryAnalytics(params AnalyticsParams) (*SalaryAnalytics, error) {
-	analytics := &SalaryAnalytics{}
+	converter, err := loadCurrencyConverter(s.db, ReportingCurrency())
+	if err != nil {
+		return nil, err
+	}
+	analytics := &SalaryAnalytics{ReportingCurrency: converter.Target()}
 
 	query := s.db.Model(&models.EnhancedSalary{}).
-		Joins("JOIN employees ON enhanced_salaries.employee_id = employees.id")
+		Joins("JOIN employees ON enhanced_salaries.employee_id = employees.id").
+		Joins("JOIN payroll_periods ON enhanced_salaries.payroll_period_id = payroll_periods.id")
 
 	if params.DepartmentID != nil {
 		query = query.Where("employees.department_id = ?", *params.DepartmentID)
@@ -1264,22 +1571,29 @@ func (s *SalaryService) GetSalaryAnalytics(params AnalyticsParams) (*SalaryAnaly
 		query = query.Where("enhanced_salaries.payroll_period_id = ?", *params.PeriodID)
 	}
 
-	var result struct {
-		Count      int     `gorm:"column:count"`
-		TotalGross float64 `gorm:"column:total_gross"`
-		TotalNet   float64 `gorm:"column:total_net"`
-		AvgGross   float64 `gorm:"column:avg_gross"`
-		AvgNet     float64 `gorm:"column:avg_net"`
+	// 按币种和周期分组汇总，各组按周期结束日汇率折算后再相加
+	var totals []CurrencyPeriodTotal
+	if err := query.Select("enhanced_salaries.currency AS currency, payroll_periods.end_date AS period_end, COUNT(*) AS count, " +
+		"SUM(enhanced_salaries.gross_salary) AS total_gross, SUM(enhanced_salaries.net_salary) AS total_net").
+		Group("enhanced_salaries.currency, payroll_periods.id, payroll_periods.end_date").
+		Scan(&totals).Error; err != nil {
+		return nil, err
 	}
 
-	query.Select("COUNT(*) as count, SUM(gross_salary) as total_gross, SUM(net_salary) as total_net, AVG(gross_salary) as avg_gross, AVG(net_salary) as avg_net").
-		Scan(&result)
-
-	analytics.TotalEmployees = result.Count
-	analytics.TotalGrossAmount = result.TotalGross
-	analytics.TotalNetAmount = result.TotalNet
-	analytics.AverageGross = result.AvgGross
-	analytics.AverageNet = result.AvgNet
+	breakdown, err := converter.SummarizeCurrencies(totals)
+	if err != nil {
+		return nil, err
+	}
+	analytics.CurrencyBreakdown = breakdown
+	for _, currency := range breakdown {
+		analytics.TotalEmployees += currency.EmployeeCount
+		analytics.TotalGrossAmount = analytics.TotalGrossAmount.Add(currency.ReportingGross)
+		analytics.TotalNetAmount = analytics.TotalNetAmount.Add(currency.ReportingNet)
+	}
+	if analytics.TotalEmployees > 0 {
+		analytics.AverageGross = roundAmount(analytics.TotalGrossAmount.Float64() / float64(analytics.TotalEmployees))
+		analytics.AverageNet = roundAmount(analytics.TotalNetAmount.Float64() / float64(analytics.TotalEmployees))
+	}
 
 	return analytics, nil
 }
@@ -1324,10 +1638,9 @@ func (s *SalaryService) GetDepartmentSalaryReport(departmentID uint, periodID ui
 	return report, nil
 }
 
-func (s *SalaryService) ExportEnhancedSalaryReport(params ExportParams) ([]byte, string, error) {
-	// Simplified implementation
-	filename := fmt.Sprintf("salary_report_%s_%d.%s", time.Now().Format("20060102"), time.Now().Unix(), params.Format)
-	return []byte("Mock export data"), filename, nil
+// ExportEnhancedSalaryReport 导出薪资登记表：每个薪资项目一列，含部门小计与总计
+func (s *SalaryService) ExportEnhancedSalaryReport(params ExportParams) (*PayrollRegisterExport, error) {
+	return newPayrollRegisterExport(s.db, params)
 }
 
 // ========================= Personal Salary Management =========================
@@ -1336,7 +1649,8 @@ func (s *SalaryService) GetPersonalSalaryDetail(params PersonalSalaryParams) (*P
 	var salary models.EnhancedSalary
 	query := s.db.Preload("Employee").Preload("PayrollPeriod")
 
-	query = query.Where("employee_id = ?", params.EmployeeID)
+	// 与工资单一致，员工只能看到已批准或已发放的薪资
+	query = query.Where("employee_id = ? AND status IN ?", params.EmployeeID, payslipVisibleStatuses)
 
 	if params.PeriodID != nil {
 		query = query.Where("payroll_period_id = ?", *params.PeriodID)
@@ -1355,7 +1669,7 @@ func (s *SalaryService) GetPersonalSalaryDetail(params PersonalSalaryParams) (*P
 
 func (s *SalaryService) GetSalaryHistory(params SalaryHistoryParams) (*SalaryHistoryResponse, error) {
 	var salaries []models.EnhancedSalary
-	query := s.db.Preload("PayrollPeriod").Where("employee_id = ?", params.EmployeeID)
+	query := s.db.Preload("PayrollPeriod").Where("employee_id = ? AND status IN ?", params.EmployeeID, payslipVisibleStatuses)
 
 	if params.Limit > 0 {
 		query = query.Limit(params.Limit)
@@ -1366,8 +1680,7 @@ func (s *SalaryService) GetSalaryHistory(params SalaryHistoryParams) (*SalaryHis
 	}
 
 	var historyItems []SalaryHistoryItem
-	var totalNet float64
-	var maxNet, minNet float64
+	var totalNet, maxNet, minNet models.Money
 
 	for i, salary := range salaries {
 		item := SalaryHistoryItem{
@@ -1382,12 +1695,12 @@ func (s *SalaryService) GetSalaryHistory(params SalaryHistoryParams) (*SalaryHis
 		}
 
 		historyItems = append(historyItems, item)
-		totalNet += salary.NetSalary
+		totalNet = totalNet.Add(salary.NetSalary)
 
-		if i == 0 || salary.NetSalary > maxNet {
+		if i == 0 || salary.NetSalary.Cmp(maxNet) > 0 {
 			maxNet = salary.NetSalary
 		}
-		if i == 0 || salary.NetSalary < minNet {
+		if i == 0 || salary.NetSalary.Cmp(minNet) < 0 {
 			minNet = salary.NetSalary
 		}
 	}
@@ -1399,7 +1712,7 @@ func (s *SalaryService) GetSalaryHistory(params SalaryHistoryParams) (*SalaryHis
 	}
 
 	if len(salaries) > 0 {
-		summary.AverageNet = totalNet / float64(len(salaries))
+		summary.AverageNet = roundAmount(totalNet.Float64() / float64(len(salaries)))
 		summary.LatestNet = salaries[0].NetSalary
 		summary.TrendDirection = "stable"
 	}
@@ -1467,11 +1780,11 @@ func (s *SalaryService) GetPersonalSalaryDashboard(employeeID uint) (*PersonalSa
 		Find(&pendingPayrolls)
 
 	currentYear := time.Now().Year()
-	var ytdEarnings float64
+	var ytdEarnings models.Money
 	s.db.Model(&models.EnhancedSalary{}).
 		Joins("JOIN payroll_periods ON enhanced_salaries.payroll_period_id = payroll_periods.id").
-		Where("enhanced_salaries.employee_id = ? AND payroll_periods.year = ? AND enhanced_salaries.status = ?",
-			employeeID, currentYear, "paid").
+		Where("enhanced_salaries.employee_id = ? AND payroll_periods.year = ? AND enhanced_salaries.status IN ?",
+			employeeID, currentYear, payslipVisibleStatuses).
 		Select("COALESCE(SUM(net_salary), 0)").Scan(&ytdEarnings)
 
 	return &PersonalSalaryDashboard{
@@ -1486,22 +1799,13 @@ func (s *SalaryService) GetPersonalSalaryDashboard(employeeID uint) (*PersonalSa
 // ========================= Formula Engine =========================
 
 func (s *SalaryService) EvaluateFormula(formula string, context FormulaContext) (float64, error) {
-	// Basic formula evaluation implementation
-	if formula == "" {
-		return 0, errors.New("formula cannot be empty")
-	}
-
-	// Simple implementation for demo
-	if formula == "base_salary * 0.1" {
-		return context.BaseSalary * 0.1, nil
-	}
-
-	return 0, errors.New("formula evaluation not implemented")
+	return evaluateFormula(formula, context)
 }
 
 func (s *SalaryService) ValidateFormula(formula string) error {
-	if strings.TrimSpace(formula) == "" {
-		return errors.New("formula cannot be empty")
+	codes, err := loadComponentCodes(s.db)
+	if err != nil {
+		return fmt.Errorf("failed to load salary components: %w", err)
 	}
-	return nil
-}
\ No newline at end of file
+	return validateFormula(formula, codes)
+}
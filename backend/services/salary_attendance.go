@@ -0,0 +1,206 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"gin-project/models"
+
+	"gorm.io/gorm"
+)
+
+// ========================= Attendance Payroll Inputs =========================
+
+const (
+	// standardDailyHours 标准日工时，超出部分计为加班
+	standardDailyHours = 8
+	// monthlyPayDays 法定月计薪天数，用于折算日薪
+	monthlyPayDays = 21.75
+)
+
+// AttendanceInputs 薪资周期内的考勤汇总，作为公式变量提供给薪资组件
+type AttendanceInputs struct {
+	WorkingDays          float64 `json:"working_days"`            // 应出勤天数
+	AttendanceDays       float64 `json:"attendance_days"`         // 实际出勤天数
+	AbsenceDays          float64 `json:"absence_days"`            // 缺勤天数（旷工 + 无薪假）
+	PaidLeaveDays        float64 `json:"paid_leave_days"`         // 带薪假天数
+	UnpaidLeaveDays      float64 `json:"unpaid_leave_days"`       // 无薪假天数
+	SickLeaveDays        float64 `json:"sick_leave_days"`         // 病假天数
+	LateCount            float64 `json:"late_count"`              // 迟到次数
+	EarlyCount           float64 `json:"early_count"`             // 早退次数
+	OvertimeHours        float64 `json:"overtime_hours"`          // 工作日延时加班小时数
+	RestDayOvertimeHours float64 `json:"rest_day_overtime_hours"` // 休息日加班小时数
+	HolidayOvertimeHours float64 `json:"holiday_overtime_hours"`  // 法定节假日加班小时数
+}
+
+// Variables 以公式变量名输出考勤汇总
+func (a AttendanceInputs) Variables() map[string]interface{} {
+	return map[string]interface{}{
+		"working_days":            a.WorkingDays,
+		"attendance_days":         a.AttendanceDays,
+		"absence_days":            a.AbsenceDays,
+		"paid_leave_days":         a.PaidLeaveDays,
+		"unpaid_leave_days":       a.UnpaidLeaveDays,
+		"sick_leave_days":         a.SickLeaveDays,
+		"late_count":              a.LateCount,
+		"early_count":             a.EarlyCount,
+		"overtime_hours":          a.OvertimeHours,
+		"rest_day_overtime_hours": a.RestDayOvertimeHours,
+		"holiday_overtime_hours":  a.HolidayOvertimeHours,
+	}
+}
+
+// AttendanceWindow 考勤统计区间，入职前、离职后及 Cutoff 之后（尚未发生）的日期不计缺勤
+type AttendanceWindow struct {
+	Start           time.Time
+	End             time.Time
+	HireDate        *time.Time
+	TerminationDate *time.Time
+	Cutoff          time.Time
+}
+
+// SummarizeAttendance 按工作日历汇总区间内的考勤与已批准请假
+func SummarizeAttendance(calendar *WorkCalendar, window AttendanceWindow, records []models.Attendance, leaves []models.Leave) AttendanceInputs {
+	var inputs AttendanceInputs
+
+	start := truncateDate(window.Start)
+	end := truncateDate(window.End)
+	if window.HireDate != nil && truncateDate(*window.HireDate).After(start) {
+		start = truncateDate(*window.HireDate)
+	}
+	if window.TerminationDate != nil && truncateDate(*window.TerminationDate).Before(end) {
+		end = truncateDate(*window.TerminationDate)
+	}
+
+	recordsByDay := make(map[string]models.Attendance, len(records))
+	for _, record := range records {
+		recordsByDay[calendarKey(record.Date)] = record
+	}
+
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		key := calendarKey(day)
+		record, hasRecord := recordsByDay[key]
+
+		if !calendar.IsWorkingDay(day) {
+			if hasRecord && record.WorkHours > 0 {
+				if calendar.IsStatutoryHoliday(day) {
+					inputs.HolidayOvertimeHours += record.WorkHours
+				} else {
+					inputs.RestDayOvertimeHours += record.WorkHours
+				}
+			}
+			continue
+		}
+
+		inputs.WorkingDays++
+
+		if leave, fraction := leaveCovering(calendar, leaves, day); leave != nil {
+			switch models.LeavePayCategoryOf(leave.Type) {
+			case models.LeavePaid:
+				inputs.PaidLeaveDays += fraction
+			case models.LeaveSick:
+				inputs.SickLeaveDays += fraction
+			default:
+				inputs.UnpaidLeaveDays += fraction
+			}
+			if fraction >= 1 {
+				continue
+			}
+			// 半天假：剩余时间按当天打卡情况统计
+			if hasRecord && record.Status != "absent" && record.Status != "leave" {
+				inputs.AttendanceDays += 1 - fraction
+			}
+			continue
+		}
+
+		if hasRecord && record.Status != "absent" && record.Status != "leave" {
+			inputs.AttendanceDays++
+			switch record.Status {
+			case "late":
+				inputs.LateCount++
+			case "early":
+				inputs.EarlyCount++
+			}
+			if record.WorkHours > standardDailyHours {
+				inputs.OvertimeHours += record.WorkHours - standardDailyHours
+			}
+			continue
+		}
+
+		if !window.Cutoff.IsZero() && day.After(truncateDate(window.Cutoff)) {
+			continue
+		}
+		inputs.AbsenceDays++
+	}
+
+	inputs.AbsenceDays += inputs.UnpaidLeaveDays
+	return inputs
+}
+
+// leaveCovering 查找覆盖指定工作日的请假，返回该日折算的请假天数（支持半天假）
+func leaveCovering(calendar *WorkCalendar, leaves []models.Leave, day time.Time) (*models.Leave, float64) {
+	for i := range leaves {
+		leave := &leaves[i]
+		if day.Before(truncateDate(leave.StartDate)) || day.After(truncateDate(leave.EndDate)) {
+			continue
+		}
+		fraction := 1.0
+		if leave.Days > 0 {
+			if covered := calendar.WorkingDaysBetween(leave.StartDate, leave.EndDate); covered > 0 && leave.Days < float64(covered) {
+				fraction = leave.Days / float64(covered)
+			}
+		}
+		return leave, fraction
+	}
+	return nil, 0
+}
+
+// loadAttendanceInputs 读取员工在区间内的考勤与已批准请假并汇总
+func loadAttendanceInputs(db *gorm.DB, employee *models.Employee, start, end time.Time) (*AttendanceInputs, error) {
+	var records []models.Attendance
+	if err := db.Where("employee_id = ? AND date BETWEEN ? AND ?", employee.ID,
+		start.Format("2006-01-02"), end.Format("2006-01-02")).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to load attendance: %w", err)
+	}
+
+	var leaves []models.Leave
+	if err := db.Where("employee_id = ? AND status = ? AND start_date <= ? AND end_date >= ?", employee.ID,
+		"approved", end, start).Find(&leaves).Error; err != nil {
+		return nil, fmt.Errorf("failed to load leaves: %w", err)
+	}
+
+	// 跨周期的请假需要完整日历才能折算半天假
+	calendarStart, calendarEnd := start, end
+	for _, leave := range leaves {
+		calendarStart = minTime(calendarStart, leave.StartDate)
+		calendarEnd = maxTime(calendarEnd, leave.EndDate)
+	}
+	calendar, err := loadWorkCalendar(db, calendarStart, calendarEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	window := AttendanceWindow{
+		Start:  truncateDate(start),
+		End:    truncateDate(end),
+		Cutoff: time.Now(),
+	}
+	window.HireDate, window.TerminationDate = employmentDates(employee)
+
+	inputs := SummarizeAttendance(calendar, window, records, leaves)
+	return &inputs, nil
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
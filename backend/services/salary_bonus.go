@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gin-project/models"
+
+	"gorm.io/gorm"
+)
+
+// ========================= Annual Bonus Payments =========================
+
+// AnnualBonusComponentCode 全年一次性奖金组件编码
+const AnnualBonusComponentCode = "ANNUAL_BONUS"
+
+// BonusPaymentItem 单个员工的奖金发放金额
+type BonusPaymentItem struct {
+	EmployeeID uint         `json:"employee_id" binding:"required"`
+	Amount     models.Money `json:"amount" binding:"required"`
+}
+
+// CalculateBonusPayments 在奖金类型的薪资周期内计算全年一次性奖金及其预扣个税
+func (s *SalaryService) CalculateBonusPayments(periodID uint, items []BonusPaymentItem, method models.BonusTaxMethod, userID uint) (*EnhancedBatchResult, error) {
+	var period models.PayrollPeriod
+	if err := s.db.First(&period, periodID).Error; err != nil {
+		return nil, fmt.Errorf("payroll period not found: %w", err)
+	}
+	if period.PeriodType != models.PeriodTypeBonus {
+		return nil, errors.New("payroll period is not a bonus period")
+	}
+	// 全年一次性奖金按境内个税政策计税
+	if currency := models.NormalizeCurrency(period.Currency); currency != models.DefaultCurrency {
+		return nil, fmt.Errorf("bonus payments are only supported for %s periods, got %s", models.DefaultCurrency, currency)
+	}
+	if err := ensurePeriodEditable(&period); err != nil {
+		return nil, err
+	}
+
+	switch method {
+	case "":
+		method = models.BonusTaxOptimal
+	case models.BonusTaxSeparate, models.BonusTaxCombined, models.BonusTaxOptimal:
+	default:
+		return nil, fmt.Errorf("unsupported bonus tax method: %s", method)
+	}
+
+	component, err := ensureSystemComponent(s.db, models.SalaryComponent{
+		Code:        AnnualBonusComponentCode,
+		Name:        "全年一次性奖金",
+		Category:    models.ComponentCategoryBonus,
+		Sort:        100,
+		Description: "奖金发放时由系统生成",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &EnhancedBatchResult{
+		Total:   len(items),
+		Results: make([]EnhancedCalculateItem, 0, len(items)),
+	}
+
+	for _, item := range items {
+		resultItem := EnhancedCalculateItem{EmployeeID: item.EmployeeID}
+
+		salary, err := s.calculateBonusPayment(&period, component, item, method, userID)
+		if err != nil {
+			resultItem.Success = false
+			resultItem.Message = err.Error()
+			result.Failed++
+		} else {
+			resultItem.Success = true
+			resultItem.Message = "计算成功"
+			resultItem.Salary = salary
+			resultItem.GrossAmount = salary.GrossSalary
+			resultItem.NetAmount = salary.NetSalary
+			if salary.Employee != nil {
+				resultItem.EmployeeName = salary.Employee.Name
+			}
+			result.Success++
+			result.TotalAmount = result.TotalAmount.Add(salary.NetSalary)
+		}
+
+		result.Results = append(result.Results, resultItem)
+	}
+
+	return result, nil
+}
+
+func (s *SalaryService) calculateBonusPayment(period *models.PayrollPeriod, component *models.SalaryComponent, item BonusPaymentItem, method models.BonusTaxMethod, userID uint) (*models.EnhancedSalary, error) {
+	if item.Amount.Sign() <= 0 {
+		return nil, errors.New("bonus amount must be greater than 0")
+	}
+
+	var employee models.Employee
+	if err := s.db.First(&employee, item.EmployeeID).Error; err != nil {
+		return nil, fmt.Errorf("employee not found: %w", err)
+	}
+
+	var existing models.EnhancedSalary
+	if err := s.db.Where("employee_id = ? AND payroll_period_id = ?", employee.ID, period.ID).First(&existing).Error; err == nil {
+		return nil, errors.New("bonus for this period already exists")
+	}
+
+	taxResult, err := newIncomeTaxWithholder(s.db).withholdBonus(&employee, period, item.Amount.Float64(), method)
+	if err != nil {
+		return nil, err
+	}
+
+	taxComponent, err := incomeTaxComponent(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	tax := componentAmount(taxComponent, taxResult.Tax)
+	now := time.Now()
+	salary := &models.EnhancedSalary{
+		EmployeeID:      employee.ID,
+		PayrollPeriodID: period.ID,
+		GrossSalary:     item.Amount,
+		TotalDeductions: tax,
+		NetSalary:       item.Amount.Sub(tax),
+		TaxableIncome:   item.Amount,
+		IncomeTax:       tax,
+		BonusTaxMethod:  taxResult.Method,
+		Currency:        models.DefaultCurrency,
+		Status:          models.SalaryStatusCalculated,
+		CalculatedBy:    &userID,
+		CalculatedAt:    &now,
+		Version:         1,
+	}
+
+	details := []models.SalaryDetail{
+		{
+			ComponentID:        component.ID,
+			CalculatedValue:    item.Amount,
+			FinalValue:         item.Amount,
+			CalculationFormula: fmt.Sprintf("bonus = %s", item.Amount),
+		},
+		{
+			ComponentID:        taxComponent.ID,
+			CalculatedValue:    tax,
+			FinalValue:         tax,
+			CalculationFormula: taxResult.Trace,
+		},
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(salary).Error; err != nil {
+			return fmt.Errorf("failed to create bonus record: %w", err)
+		}
+		for i := range details {
+			details[i].SalaryID = salary.ID
+		}
+		if err := tx.Create(&details).Error; err != nil {
+			return fmt.Errorf("failed to create bonus details: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Preload("Employee").Preload("PayrollPeriod").
+		Preload("Components.Component").First(salary, salary.ID).Error; err != nil {
+		return nil, err
+	}
+	return salary, nil
+}
@@ -0,0 +1,508 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gin-project/models"
+	"gin-project/utils"
+
+	"gorm.io/gorm"
+)
+
+// ========================= Structure Calculation =========================
+
+// RoundingComponentCode 系统舍入差额组件编码
+const RoundingComponentCode = "ROUNDING"
+
+// salaryCalculator 按薪资结构计算员工的各项薪资组件
+type salaryCalculator struct {
+	db     *gorm.DB
+	shadow bool // 影子重算：不并入待处理的追溯补发
+}
+
+func newSalaryCalculator(db *gorm.DB) *salaryCalculator {
+	return &salaryCalculator{db: db}
+}
+
+// newShadowSalaryCalculator 创建用于追溯比对的影子计算器
+func newShadowSalaryCalculator(db *gorm.DB) *salaryCalculator {
+	return &salaryCalculator{db: db, shadow: true}
+}
+
+// structureCalculation 薪资结构的计算结果
+type structureCalculation struct {
+	Details         []models.SalaryDetail
+	GrossSalary     models.Money
+	TotalDeductions models.Money
+	TaxableIncome   models.Money
+	TaxDeductions   models.Money
+	IncomeTax       models.Money
+	EmployerCost    models.Money
+	Rounding        models.Money // 实发取整产生的舍入差额，已计入应发
+	Currency        string
+	Tax             *CumulativeTaxResult
+	Attendance      *AttendanceInputs
+	Proration       *ProrationResult
+	RetroPay        []models.RetroPayItem
+}
+
+// applyTo 将计算结果的汇总金额写入薪资记录
+func (r *structureCalculation) applyTo(salary *models.EnhancedSalary) {
+	salary.GrossSalary = r.GrossSalary
+	salary.TotalDeductions = r.TotalDeductions
+	salary.NetSalary = r.GrossSalary.Sub(r.TotalDeductions)
+	salary.TaxableIncome = r.TaxableIncome
+	salary.TaxDeductions = r.TaxDeductions
+	salary.IncomeTax = r.IncomeTax
+	salary.EmployerCost = r.EmployerCost
+	salary.Currency = r.Currency
+}
+
+// calculate 按依赖顺序计算结构内的所有组件，后计算的组件可在公式中引用先计算组件的结果；
+// 社保公积金按城市政策、个人所得税按累计预扣法在所有组件计算完成后统一计算
+func (c *salaryCalculator) calculate(employee *models.Employee, structure *models.SalaryStructure, period *models.PayrollPeriod) (*structureCalculation, error) {
+	currency, err := payrollCurrency(structure, period)
+	if err != nil {
+		return nil, err
+	}
+	ordered, err := orderStructureComponents(structure.Components)
+	if err != nil {
+		return nil, err
+	}
+
+	proration, err := loadProration(c.db, employee, period.StartDate, period.EndDate, structure.ProrationMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := map[string]interface{}{
+		"base_salary":      proration.ProratedBase,
+		"full_base_salary": proration.FullBase,
+		"proration_ratio":  proration.Ratio,
+		"employee_id":      employee.ID,
+		"department_id":    employee.DepartmentID,
+	}
+	attendance, err := loadAttendanceInputs(c.db, employee, period.StartDate, period.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range attendance.Variables() {
+		variables[name] = value
+	}
+	computed := make(map[string]float64, len(ordered))
+	result := &structureCalculation{Attendance: attendance, Proration: proration, Currency: currency}
+
+	for _, structComp := range ordered {
+		component := structComp.Component
+		if isSystemComponent(component.Code) {
+			continue
+		}
+
+		context := FormulaContext{
+			Employee:   employee,
+			BaseSalary: proration.ProratedBase.Float64(),
+			Components: computed,
+			Variables:  variables,
+		}
+		value, trace, err := calculateComponent(component, context)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate component %s: %w", component.Name, err)
+		}
+
+		amount := componentAmount(component, value)
+		detail := models.SalaryDetail{
+			ComponentID:        component.ID,
+			Component:          component,
+			CalculatedValue:    amount,
+			FinalValue:         amount,
+			CalculationFormula: trace,
+		}
+		if usesBaseSalary(component) {
+			detail.Segments = append([]models.SalaryDetailSegment(nil), proration.Segments...)
+		}
+
+		// Apply manual override if exists
+		if structComp.DefaultValue.Sign() > 0 {
+			defaultValue := structComp.DefaultValue.Round(component.RoundingRule)
+			detail.ManualValue = &defaultValue
+			detail.FinalValue = defaultValue
+			detail.CalculationFormula = fmt.Sprintf("%s; structure default %s applied", trace, defaultValue)
+		}
+
+		computed[component.Code] = detail.FinalValue.Float64()
+		result.addDetail(detail)
+	}
+
+	if !c.shadow {
+		if err := c.applyRetroPay(employee, period, result); err != nil {
+			return nil, err
+		}
+	}
+
+	// 社保公积金和个税按境内政策计算，仅适用于人民币发薪的周期
+	if currency == models.DefaultCurrency {
+		if err := newInsuranceContributor(c.db).contribute(employee, period, result); err != nil {
+			return nil, err
+		}
+
+		if err := newIncomeTaxWithholder(c.db).withhold(employee, period, result); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := result.roundNetPay(c.db); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// payrollCurrency 薪资按周期币种计算，结构币种须与周期一致，避免按人民币金额发放外币
+func payrollCurrency(structure *models.SalaryStructure, period *models.PayrollPeriod) (string, error) {
+	currency := models.NormalizeCurrency(period.Currency)
+	if structureCurrency := models.NormalizeCurrency(structure.Currency); structureCurrency != currency {
+		return "", &utils.ValidationError{Message: fmt.Sprintf("薪资结构「%s」币种 %s 与薪资周期「%s」币种 %s 不一致",
+			structure.Name, structureCurrency, period.Name, currency)}
+	}
+	return currency, nil
+}
+
+// addDetail 追加明细并按组件分类计入应发、扣除或企业成本
+func (r *structureCalculation) addDetail(detail models.SalaryDetail) {
+	r.Details = append(r.Details, detail)
+	if detail.Component == nil {
+		r.GrossSalary = r.GrossSalary.Add(detail.FinalValue)
+		return
+	}
+
+	switch detail.Component.Category {
+	case models.ComponentCategoryDeduction, models.ComponentCategoryTax, models.ComponentCategoryInsurance:
+		r.TotalDeductions = r.TotalDeductions.Add(detail.FinalValue)
+	case models.ComponentCategoryEmployerCost:
+		r.EmployerCost = r.EmployerCost.Add(detail.FinalValue)
+	default:
+		r.GrossSalary = r.GrossSalary.Add(detail.FinalValue)
+	}
+}
+
+// roundNetPay 按舍入差额组件的舍入规则对实发取整，差额作为单独明细计入应发，
+// 保证各明细之和与实发、批次与银行汇总始终一致；默认规则为到分，不产生差额
+func (r *structureCalculation) roundNetPay(db *gorm.DB) error {
+	component, err := roundingComponent(db)
+	if err != nil {
+		return err
+	}
+
+	net := r.GrossSalary.Sub(r.TotalDeductions)
+	diff := net.Round(component.RoundingRule).Sub(net)
+	if diff.IsZero() {
+		return nil
+	}
+
+	r.Rounding = diff
+	r.addDetail(models.SalaryDetail{
+		ComponentID:        component.ID,
+		Component:          component,
+		CalculatedValue:    diff,
+		FinalValue:         diff,
+		CalculationFormula: fmt.Sprintf("round(%s, %s) - %s = %s", net, component.RoundingRule, net, diff),
+	})
+	return nil
+}
+
+// roundingComponent 获取系统舍入差额组件，其舍入规则即实发取整规则
+func roundingComponent(db *gorm.DB) (*models.SalaryComponent, error) {
+	return ensureSystemComponent(db, models.SalaryComponent{
+		Code:         RoundingComponentCode,
+		Name:         "舍入差额",
+		Category:     models.ComponentCategoryAllowance,
+		RoundingRule: models.RoundHalfUp,
+		Sort:         10000,
+		Description:  "实发按舍入规则取整产生的差额，由系统自动计算",
+	})
+}
+
+// componentAmount 按组件的舍入规则将计算结果转换为金额
+func componentAmount(component *models.SalaryComponent, value float64) models.Money {
+	return models.RoundMoney(value, component.RoundingRule)
+}
+
+// calculateComponent 计算单个组件的取值，并返回可供审核的计算过程
+func calculateComponent(component *models.SalaryComponent, context FormulaContext) (float64, string, error) {
+	switch component.Type {
+	case models.ComponentTypeFixed:
+		return component.DefaultAmount.Float64(), fmt.Sprintf("fixed = %s", component.DefaultAmount), nil
+
+	case models.ComponentTypePercentage:
+		percentage, err := parsePercentage(component.Formula)
+		if err != nil {
+			return 0, "", err
+		}
+		value := context.BaseSalary * percentage
+		trace := fmt.Sprintf("{base_salary} * %s = %s * %s = %s",
+			strings.TrimSpace(component.Formula), formatAmount(context.BaseSalary),
+			strconv.FormatFloat(percentage, 'f', -1, 64), formatAmount(value))
+		return value, trace, nil
+
+	case models.ComponentTypeFormula:
+		parsed, err := parseFormula(component.Formula)
+		if err != nil {
+			return 0, "", err
+		}
+		resolve := context.resolver()
+		value, err := parsed.root.eval(resolve)
+		if err != nil {
+			return 0, "", err
+		}
+		trace := fmt.Sprintf("%s = %s = %s", strings.TrimSpace(component.Formula), parsed.substitute(resolve), formatAmount(value))
+		return value, trace, nil
+
+	case models.ComponentTypeManual:
+		return component.DefaultAmount.Float64(), fmt.Sprintf("manual = %s", component.DefaultAmount), nil
+
+	default:
+		return component.DefaultAmount.Float64(), fmt.Sprintf("default = %s", component.DefaultAmount), nil
+	}
+}
+
+// isSystemComponent 判断组件是否由系统在结构计算之后自动生成（个税、社保公积金、追溯补发、舍入差额）
+func isSystemComponent(code string) bool {
+	if code == IncomeTaxComponentCode || code == RetroPayComponentCode || code == RoundingComponentCode ||
+		settlementComponentCodes[code] {
+		return true
+	}
+	_, ok := insuranceComponentNames[code]
+	return ok
+}
+
+// ensureSystemComponent 按编码获取系统组件，不存在时按模板创建
+func ensureSystemComponent(db *gorm.DB, template models.SalaryComponent) (*models.SalaryComponent, error) {
+	if template.Type == "" {
+		template.Type = models.ComponentTypeManual
+	}
+	if template.Status == "" {
+		template.Status = "active"
+	}
+
+	var component models.SalaryComponent
+	err := db.Where(models.SalaryComponent{Code: template.Code}).Attrs(template).FirstOrCreate(&component).Error
+	if err != nil {
+		// 并发计算时可能与其他协程同时创建而触发唯一索引冲突，重新读取已创建的组件
+		if retryErr := db.Where("code = ?", template.Code).First(&component).Error; retryErr == nil {
+			return &component, nil
+		}
+		return nil, fmt.Errorf("failed to load system component %s: %w", template.Code, err)
+	}
+	return &component, nil
+}
+
+func formatAmount(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
+
+// ========================= Component Dependencies =========================
+
+// componentDependencies 返回组件依赖的结构内组件编码（显式声明 + 从公式引用推断）
+func componentDependencies(component *models.SalaryComponent, inStructure map[string]bool) ([]string, error) {
+	seen := make(map[string]bool)
+	var deps []string
+
+	for _, code := range component.DependencyCodes() {
+		if !inStructure[code] {
+			return nil, fmt.Errorf("component %s depends on %s, which is not part of this salary structure", component.Code, code)
+		}
+		if !seen[code] {
+			seen[code] = true
+			deps = append(deps, code)
+		}
+	}
+
+	if component.Type == models.ComponentTypeFormula {
+		parsed, err := parseFormula(component.Formula)
+		if err != nil {
+			return nil, fmt.Errorf("component %s has an invalid formula: %w", component.Code, err)
+		}
+		for _, name := range parsed.referencedNames() {
+			if inStructure[name] {
+				if !seen[name] {
+					seen[name] = true
+					deps = append(deps, name)
+				}
+				continue
+			}
+			if _, ok := formulaBuiltinVariables[name]; !ok {
+				return nil, fmt.Errorf("component %s references {%s}, which is neither a known variable nor a component of this salary structure", component.Code, name)
+			}
+		}
+	}
+
+	return deps, nil
+}
+
+// orderStructureComponents 按依赖关系对结构内组件做拓扑排序；
+// 没有依赖关系的组件之间保持原有排序，存在循环依赖时返回错误
+func orderStructureComponents(items []models.SalaryStructureComponent) ([]models.SalaryStructureComponent, error) {
+	var candidates []models.SalaryStructureComponent
+	for _, item := range items {
+		if item.Component != nil {
+			candidates = append(candidates, item)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Sort != candidates[j].Sort {
+			return candidates[i].Sort < candidates[j].Sort
+		}
+		return candidates[i].Component.Sort < candidates[j].Component.Sort
+	})
+
+	index := make(map[string]int, len(candidates))
+	inStructure := make(map[string]bool, len(candidates))
+	for i, item := range candidates {
+		code := item.Component.Code
+		if inStructure[code] {
+			return nil, fmt.Errorf("component %s appears more than once in the salary structure", code)
+		}
+		inStructure[code] = true
+		index[code] = i
+	}
+
+	dependencies := make([][]string, len(candidates))
+	dependents := make([][]int, len(candidates))
+	inDegree := make([]int, len(candidates))
+	for i, item := range candidates {
+		deps, err := componentDependencies(item.Component, inStructure)
+		if err != nil {
+			return nil, err
+		}
+		dependencies[i] = deps
+		for _, dep := range deps {
+			j := index[dep]
+			dependents[j] = append(dependents[j], i)
+			inDegree[i]++
+		}
+	}
+
+	// Kahn 算法，每次选取排序最靠前的就绪组件，保证结果稳定
+	done := make([]bool, len(candidates))
+	ordered := make([]models.SalaryStructureComponent, 0, len(candidates))
+	for len(ordered) < len(candidates) {
+		next := -1
+		for i := range candidates {
+			if !done[i] && inDegree[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return nil, fmt.Errorf("circular dependency between salary components: %s", describeDependencyCycle(candidates, dependencies, index, done))
+		}
+
+		done[next] = true
+		ordered = append(ordered, candidates[next])
+		for _, dependent := range dependents[next] {
+			inDegree[dependent]--
+		}
+	}
+
+	return ordered, nil
+}
+
+// describeDependencyCycle 在未完成排序的组件中找出一条循环路径 (e.g., "A -> B -> A")
+func describeDependencyCycle(candidates []models.SalaryStructureComponent, dependencies [][]string, index map[string]int, done []bool) string {
+	state := make([]int, len(candidates)) // 0 未访问, 1 访问中, 2 已完成
+	var stack []int
+	var cycle []int
+
+	var visit func(i int) bool
+	visit = func(i int) bool {
+		state[i] = 1
+		stack = append(stack, i)
+		for _, dep := range dependencies[i] {
+			j := index[dep]
+			if done[j] {
+				continue
+			}
+			if state[j] == 1 {
+				for k := len(stack) - 1; k >= 0; k-- {
+					if stack[k] == j {
+						cycle = append(append([]int{}, stack[k:]...), j)
+						break
+					}
+				}
+				return true
+			}
+			if state[j] == 0 && visit(j) {
+				return true
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = 2
+		return false
+	}
+
+	for i := range candidates {
+		if !done[i] && state[i] == 0 && visit(i) {
+			break
+		}
+	}
+
+	codes := make([]string, 0, len(cycle))
+	for _, i := range cycle {
+		codes = append(codes, candidates[i].Component.Code)
+	}
+	return strings.Join(codes, " -> ")
+}
+
+// validateStructureDependencies 校验薪资结构内组件的依赖关系（缺失依赖、循环依赖）、折算方式及币种；
+// 未指定币种时沿用适用部门的币种
+func validateStructureDependencies(db *gorm.DB, structure *models.SalaryStructure) error {
+	if structure.ProrationMethod != "" && !structure.ProrationMethod.IsValid() {
+		return fmt.Errorf("unsupported proration method: %s", structure.ProrationMethod)
+	}
+	if structure.Currency == "" && structure.DepartmentID != nil {
+		var department models.Department
+		if err := db.Select("currency_code").First(&department, *structure.DepartmentID).Error; err == nil {
+			structure.Currency = department.CurrencyCode
+		}
+	}
+	structure.Currency = models.NormalizeCurrency(structure.Currency)
+	if !models.IsValidCurrency(structure.Currency) {
+		return fmt.Errorf("unsupported currency: %s", structure.Currency)
+	}
+
+	var missing []uint
+	for _, item := range structure.Components {
+		if item.Component == nil {
+			missing = append(missing, item.ComponentID)
+		}
+	}
+
+	components := make(map[uint]*models.SalaryComponent)
+	if len(missing) > 0 {
+		var list []models.SalaryComponent
+		if err := db.Where("id IN ?", missing).Find(&list).Error; err != nil {
+			return fmt.Errorf("failed to load salary components: %w", err)
+		}
+		for i := range list {
+			components[list[i].ID] = &list[i]
+		}
+	}
+
+	items := make([]models.SalaryStructureComponent, 0, len(structure.Components))
+	for _, item := range structure.Components {
+		if item.Component == nil {
+			component, ok := components[item.ComponentID]
+			if !ok {
+				return fmt.Errorf("salary component %d not found", item.ComponentID)
+			}
+			item.Component = component
+		}
+		items = append(items, item)
+	}
+
+	_, err := orderStructureComponents(items)
+	return err
+}
@@ -3,8 +3,6 @@ package services
 import (
 	"errors"
 	"fmt"
-	"strconv"
-	"strings"
 	"time"
 
 	"gin-project/models"
@@ -159,7 +157,8 @@ type AnalyticsParams struct {
 type ExportParams struct {
 	PeriodID     *uint
 	DepartmentID *uint
-	Format       string // excel, csv, pdf
+	Status       string
+	Format       string // excel, csv
 	Template     string
 }
 
@@ -188,7 +187,7 @@ type EnhancedBatchResult struct {
 	Success    int                        `json:"success"`
 	Failed     int                        `json:"failed"`
 	Results    []EnhancedCalculateItem    `json:"results"`
-	TotalAmount float64                   `json:"total_amount"`
+	TotalAmount models.Money              `json:"total_amount"`
 }
 
 type EnhancedCalculateItem struct {
@@ -197,8 +196,8 @@ type EnhancedCalculateItem struct {
 	Success      bool                  `json:"success"`
 	Message      string                `json:"message"`
 	Salary       *models.EnhancedSalary `json:"salary,omitempty"`
-	GrossAmount  float64               `json:"gross_amount"`
-	NetAmount    float64               `json:"net_amount"`
+	GrossAmount  models.Money          `json:"gross_amount"`
+	NetAmount    models.Money          `json:"net_amount"`
 }
 
 type BulkApprovalResult struct {
@@ -216,14 +215,16 @@ type ApprovalItem struct {
 
 type SalaryDetailUpdate struct {
 	ComponentID   uint     `json:"component_id"`
-	ManualValue   *float64 `json:"manual_value"`
+	ManualValue   *models.Money `json:"manual_value"`
 	Notes         string   `json:"notes"`
 }
 
+// SalaryAnalytics 金额均已按周期结束日汇率折算为报告币种，原币金额见 CurrencyBreakdown
 type SalaryAnalytics struct {
+	ReportingCurrency string                 `json:"reporting_currency"`
 	TotalEmployees    int                    `json:"total_employees"`
-	TotalGrossAmount  float64                `json:"total_gross_amount"`
-	TotalNetAmount    float64                `json:"total_net_amount"`
+	TotalGrossAmount  models.Money           `json:"total_gross_amount"`
+	TotalNetAmount    models.Money           `json:"total_net_amount"`
 	AverageGross      float64                `json:"average_gross"`
 	AverageNet        float64                `json:"average_net"`
 	MedianGross       float64                `json:"median_gross"`
@@ -231,14 +232,25 @@ type SalaryAnalytics struct {
 	DepartmentBreakdown []DepartmentAnalytics `json:"department_breakdown"`
 	GradeBreakdown    []GradeAnalytics       `json:"grade_breakdown"`
 	ComponentBreakdown []ComponentAnalytics  `json:"component_breakdown"`
+	CurrencyBreakdown []CurrencyAnalytics    `json:"currency_breakdown"`
+}
+
+// CurrencyAnalytics 单一币种的原币汇总及折算后的报告币种金额
+type CurrencyAnalytics struct {
+	Currency       string       `json:"currency"`
+	EmployeeCount  int          `json:"employee_count"`
+	TotalGross     models.Money `json:"total_gross"`
+	TotalNet       models.Money `json:"total_net"`
+	ReportingGross models.Money `json:"reporting_gross"`
+	ReportingNet   models.Money `json:"reporting_net"`
 }
 
 type DepartmentAnalytics struct {
 	DepartmentID   uint    `json:"department_id"`
 	DepartmentName string  `json:"department_name"`
 	EmployeeCount  int     `json:"employee_count"`
-	TotalGross     float64 `json:"total_gross"`
-	TotalNet       float64 `json:"total_net"`
+	TotalGross     models.Money `json:"total_gross"`
+	TotalNet       models.Money `json:"total_net"`
 	AverageGross   float64 `json:"average_gross"`
 	AverageNet     float64 `json:"average_net"`
 }
@@ -254,7 +266,7 @@ type GradeAnalytics struct {
 type ComponentAnalytics struct {
 	ComponentID   uint    `json:"component_id"`
 	ComponentName string  `json:"component_name"`
-	TotalAmount   float64 `json:"total_amount"`
+	TotalAmount   models.Money `json:"total_amount"`
 	AverageAmount float64 `json:"average_amount"`
 }
 
@@ -274,7 +286,7 @@ type EmployeeSalaryInfo struct {
 
 type ComponentBreakdown struct {
 	Component *models.SalaryComponent `json:"component"`
-	Amount    float64                 `json:"amount"`
+	Amount    models.Money            `json:"amount"`
 }
 
 // Personal Salary Response Types
@@ -293,7 +305,7 @@ type SalaryHistoryResponse struct {
 type SalaryHistoryItem struct {
 	Period    *models.PayrollPeriod  `json:"period"`
 	Salary    *models.EnhancedSalary `json:"salary"`
-	NetSalary float64                `json:"net_salary"`
+	NetSalary models.Money           `json:"net_salary"`
 	Month     string                 `json:"month"`
 	Year      int                    `json:"year"`
 }
@@ -301,9 +313,9 @@ type SalaryHistoryItem struct {
 type SalaryHistorySummary struct {
 	TotalRecords   int     `json:"total_records"`
 	AverageNet     float64 `json:"average_net"`
-	HighestNet     float64 `json:"highest_net"`
-	LowestNet      float64 `json:"lowest_net"`
-	LatestNet      float64 `json:"latest_net"`
+	HighestNet     models.Money `json:"highest_net"`
+	LowestNet      models.Money `json:"lowest_net"`
+	LatestNet      models.Money `json:"latest_net"`
 	TrendDirection string  `json:"trend_direction"` // up, down, stable
 }
 
@@ -313,7 +325,7 @@ type PersonalSalaryDashboard struct {
 	Statistics      SalaryHistorySummary  `json:"statistics"`
 	PendingPayrolls []models.EnhancedPayrollRecord `json:"pending_payrolls"`
 	NextPayDate     *time.Time            `json:"next_pay_date"`
-	YearToDateEarnings float64           `json:"year_to_date_earnings"`
+	YearToDateEarnings models.Money      `json:"year_to_date_earnings"`
 }
 
 type FormulaContext struct {
@@ -449,8 +461,7 @@ func (s *EnhancedSalaryService) CalculateEmployeeSalary(employeeID, periodID uin
 	salary.CalculatedAt = &now
 
 	// Calculate salary components
-	totalGross := 0.0
-	totalDeductions := 0.0
+	var totalGross, totalDeductions models.Money
 	var details []models.SalaryDetail
 
 	for _, structComp := range structure.Components {
@@ -472,7 +483,7 @@ func (s *EnhancedSalaryService) CalculateEmployeeSalary(employeeID, periodID uin
 		}
 
 		// Apply manual override if exists
-		if structComp.DefaultValue > 0 {
+		if structComp.DefaultValue.Sign() > 0 {
 			detail.ManualValue = &structComp.DefaultValue
 			detail.FinalValue = structComp.DefaultValue
 		}
@@ -482,15 +493,15 @@ func (s *EnhancedSalaryService) CalculateEmployeeSalary(employeeID, periodID uin
 		// Add to totals based on category
 		switch component.Category {
 		case models.ComponentCategoryDeduction, models.ComponentCategoryTax:
-			totalDeductions += detail.FinalValue
+			totalDeductions = totalDeductions.Add(detail.FinalValue)
 		default:
-			totalGross += detail.FinalValue
+			totalGross = totalGross.Add(detail.FinalValue)
 		}
 	}
 
 	salary.GrossSalary = totalGross
 	salary.TotalDeductions = totalDeductions
-	salary.NetSalary = totalGross - totalDeductions
+	salary.NetSalary = totalGross.Sub(totalDeductions)
 	salary.Status = models.SalaryStatusCalculated
 
 	// Save salary record
@@ -554,33 +565,34 @@ func (s *EnhancedSalaryService) GetApplicableStructure(employeeID uint) (*models
 	return &structure, nil
 }
 
-func (s *EnhancedSalaryService) calculateComponentValue(component *models.SalaryComponent, employee *models.Employee, structure *models.SalaryStructure, salary *models.EnhancedSalary) (float64, error) {
+func (s *EnhancedSalaryService) calculateComponentValue(component *models.SalaryComponent, employee *models.Employee, structure *models.SalaryStructure, salary *models.EnhancedSalary) (models.Money, error) {
 	switch component.Type {
 	case models.ComponentTypeFixed:
 		return component.DefaultAmount, nil
 
 	case models.ComponentTypePercentage:
 		// Extract percentage from formula (e.g., "15%" -> 0.15)
-		percentage, err := s.parsePercentage(component.Formula)
+		percentage, err := parsePercentage(component.Formula)
 		if err != nil {
-			return 0, err
+			return models.Money{}, err
 		}
-		return employee.BaseSalary * percentage, nil
+		return employee.BaseSalary.Mul(percentage, models.RoundHalfUp), nil
 
 	case models.ComponentTypeFormula:
 		context := FormulaContext{
 			Employee:   employee,
-			BaseSalary: employee.BaseSalary,
+			BaseSalary: employee.BaseSalary.Float64(),
 			Components: make(map[string]float64),
 			Variables:  make(map[string]interface{}),
 		}
 		
 		// Add common variables
-		context.Variables["base_salary"] = employee.BaseSalary
+		context.Variables["base_salary"] = employee.BaseSalary.Float64()
 		context.Variables["employee_id"] = employee.ID
 		context.Variables["department_id"] = employee.DepartmentID
 		
-		return s.EvaluateFormula(component.Formula, context)
+		value, err := s.EvaluateFormula(component.Formula, context)
+		return models.NewMoney(value), err
 
 	case models.ComponentTypeManual:
 		return component.DefaultAmount, nil
@@ -590,93 +602,18 @@ func (s *EnhancedSalaryService) calculateComponentValue(component *models.Salary
 	}
 }
 
-func (s *EnhancedSalaryService) parsePercentage(formula string) (float64, error) {
-	formula = strings.TrimSpace(formula)
-	if strings.HasSuffix(formula, "%") {
-		percentStr := strings.TrimSuffix(formula, "%")
-		percent, err := strconv.ParseFloat(percentStr, 64)
-		if err != nil {
-			return 0, fmt.Errorf("invalid percentage format: %s", formula)
-		}
-		return percent / 100.0, nil
-	}
-	return 0, fmt.Errorf("invalid percentage format: %s", formula)
-}
-
 // ========================= Formula Engine =========================
 
 func (s *EnhancedSalaryService) EvaluateFormula(formula string, context FormulaContext) (float64, error) {
-	// Basic formula evaluation - can be enhanced with a proper expression evaluator
-	formula = strings.TrimSpace(formula)
-	
-	// Replace variables in formula
-	for key, value := range context.Variables {
-		placeholder := fmt.Sprintf("{%s}", key)
-		var valueStr string
-		switch v := value.(type) {
-		case float64:
-			valueStr = fmt.Sprintf("%.2f", v)
-		case int:
-			valueStr = fmt.Sprintf("%d", v)
-		case uint:
-			valueStr = fmt.Sprintf("%d", v)
-		default:
-			valueStr = fmt.Sprintf("%v", v)
-		}
-		formula = strings.ReplaceAll(formula, placeholder, valueStr)
-	}
-
-	// Simple expression evaluation
-	// This is a basic implementation - in production, use a proper expression evaluator
-	if result, err := s.evaluateSimpleExpression(formula); err == nil {
-		return result, nil
-	}
-
-	return 0, fmt.Errorf("unable to evaluate formula: %s", formula)
-}
-
-func (s *EnhancedSalaryService) evaluateSimpleExpression(expr string) (float64, error) {
-	// Basic implementation for simple expressions like "5000 * 0.08"
-	expr = strings.ReplaceAll(expr, " ", "")
-	
-	// Handle simple multiplication
-	if strings.Contains(expr, "*") {
-		parts := strings.Split(expr, "*")
-		if len(parts) == 2 {
-			left, err1 := strconv.ParseFloat(parts[0], 64)
-			right, err2 := strconv.ParseFloat(parts[1], 64)
-			if err1 == nil && err2 == nil {
-				return left * right, nil
-			}
-		}
-	}
-	
-	// Handle simple addition
-	if strings.Contains(expr, "+") {
-		parts := strings.Split(expr, "+")
-		total := 0.0
-		for _, part := range parts {
-			if val, err := strconv.ParseFloat(part, 64); err == nil {
-				total += val
-			}
-		}
-		return total, nil
-	}
-	
-	// Try to parse as a number
-	if val, err := strconv.ParseFloat(expr, 64); err == nil {
-		return val, nil
-	}
-	
-	return 0, fmt.Errorf("cannot evaluate expression: %s", expr)
+	return evaluateFormula(formula, context)
 }
 
 func (s *EnhancedSalaryService) ValidateFormula(formula string) error {
-	// Basic validation - can be enhanced
-	if strings.TrimSpace(formula) == "" {
-		return errors.New("formula cannot be empty")
+	codes, err := loadComponentCodes(s.db)
+	if err != nil {
+		return fmt.Errorf("failed to load salary components: %w", err)
 	}
-	return nil
+	return validateFormula(formula, codes)
 }
 
 // ========================= Validation =========================
@@ -704,7 +641,7 @@ func (s *EnhancedSalaryService) validateSalaryComponent(component *models.Salary
 
 	// Validate amount ranges
 	if component.MinAmount != nil && component.MaxAmount != nil {
-		if *component.MinAmount > *component.MaxAmount {
+		if component.MinAmount.Cmp(*component.MaxAmount) > 0 {
 			return errors.New("minimum amount cannot be greater than maximum amount")
 		}
 	}
@@ -960,8 +897,7 @@ func (s *EnhancedSalaryService) GetSalaryHistory(params SalaryHistoryParams) (*S
 	
 	// Build history items
 	var historyItems []SalaryHistoryItem
-	var totalNet float64
-	var maxNet, minNet float64
+	var totalNet, maxNet, minNet models.Money
 	
 	for i, salary := range salaries {
 		item := SalaryHistoryItem{
@@ -976,12 +912,12 @@ func (s *EnhancedSalaryService) GetSalaryHistory(params SalaryHistoryParams) (*S
 		}
 		
 		historyItems = append(historyItems, item)
-		totalNet += salary.NetSalary
+		totalNet = totalNet.Add(salary.NetSalary)
 		
-		if i == 0 || salary.NetSalary > maxNet {
+		if i == 0 || salary.NetSalary.Cmp(maxNet) > 0 {
 			maxNet = salary.NetSalary
 		}
-		if i == 0 || salary.NetSalary < minNet {
+		if i == 0 || salary.NetSalary.Cmp(minNet) < 0 {
 			minNet = salary.NetSalary
 		}
 	}
@@ -994,16 +930,16 @@ func (s *EnhancedSalaryService) GetSalaryHistory(params SalaryHistoryParams) (*S
 	}
 	
 	if len(salaries) > 0 {
-		summary.AverageNet = totalNet / float64(len(salaries))
+		summary.AverageNet = roundAmount(totalNet.Float64() / float64(len(salaries)))
 		summary.LatestNet = salaries[0].NetSalary
 		
 		// Determine trend (simple: compare latest with previous)
 		if len(salaries) > 1 {
 			latest := salaries[0].NetSalary
 			previous := salaries[1].NetSalary
-			if latest > previous {
+			if latest.Cmp(previous) > 0 {
 				summary.TrendDirection = "up"
-			} else if latest < previous {
+			} else if latest.Cmp(previous) < 0 {
 				summary.TrendDirection = "down"
 			} else {
 				summary.TrendDirection = "stable"
@@ -1083,7 +1019,7 @@ func (s *EnhancedSalaryService) GetPersonalSalaryDashboard(employeeID uint) (*Pe
 	
 	// Calculate year-to-date earnings
 	currentYear := time.Now().Year()
-	var ytdEarnings float64
+	var ytdEarnings models.Money
 	s.db.Model(&models.EnhancedSalary{}).
 		Joins("JOIN payroll_periods ON enhanced_salaries.payroll_period_id = payroll_periods.id").
 		Where("enhanced_salaries.employee_id = ? AND payroll_periods.year = ? AND enhanced_salaries.status = ?", 
@@ -0,0 +1,714 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gin-project/models"
+
+	"gorm.io/gorm"
+)
+
+// ========================= Formula Engine =========================
+//
+// 薪资公式语法:
+//   - 数字: 8000, 0.08, 21.75
+//   - 变量: {base_salary}、{absence_days}，也可直接写 base_salary
+//   - 组件引用: {BASE_SALARY}，引用同一薪资结构内已计算组件的结果（按组件编码）
+//   - 运算符: + - * / ，一元负号，比较 < <= > >= == !=，逻辑 && || !
+//   - 函数: if(cond, a, b)、min(a, b, ...)、max(a, b, ...)、round(x[, digits])、floor(x)、ceil(x)、abs(x)
+//
+// 比较和逻辑运算的结果为 1 (真) 或 0 (假)。
+
+// FormulaError 公式错误，Pos 为出错位置（从1开始的字符序号）
+type FormulaError struct {
+	Pos     int    `json:"position"`
+	Message string `json:"message"`
+}
+
+func (e *FormulaError) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Message)
+}
+
+// FormulaValidationError 公式校验失败时返回的错误集合
+type FormulaValidationError struct {
+	Formula string          `json:"formula"`
+	Issues  []*FormulaError `json:"issues"`
+}
+
+func (e *FormulaValidationError) Error() string {
+	messages := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		messages = append(messages, issue.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// formulaBuiltinVariables 公式中可直接使用的内置变量
+var formulaBuiltinVariables = map[string]string{
+	"base_salary":      "员工基本薪资(按月中入离职、调薪折算后)",
+	"full_base_salary": "周期末适用的月基本薪资(未折算)",
+	"proration_ratio":  "在职天数折算比例",
+	"employee_id":      "员工ID",
+	"department_id":    "部门ID",
+
+	// 考勤变量（按薪资周期起止日期与工作日历统计）
+	"working_days":            "应出勤天数",
+	"attendance_days":         "实际出勤天数",
+	"absence_days":            "缺勤天数(旷工+无薪假)",
+	"paid_leave_days":         "带薪假天数",
+	"unpaid_leave_days":       "无薪假天数",
+	"sick_leave_days":         "病假天数",
+	"late_count":              "迟到次数",
+	"early_count":             "早退次数",
+	"overtime_hours":          "工作日延时加班小时数",
+	"rest_day_overtime_hours": "休息日加班小时数",
+	"holiday_overtime_hours":  "法定节假日加班小时数",
+}
+
+// formulaFunctions 内置函数及其参数个数范围（max < 0 表示不限）
+var formulaFunctions = map[string]struct{ min, max int }{
+	"if":    {3, 3},
+	"min":   {1, -1},
+	"max":   {1, -1},
+	"round": {1, 2},
+	"floor": {1, 1},
+	"ceil":  {1, 1},
+	"abs":   {1, 1},
+}
+
+// ------------------------- Lexer -------------------------
+
+type formulaTokenKind int
+
+const (
+	tokenEOF formulaTokenKind = iota
+	tokenNumber
+	tokenVariable // {name}
+	tokenIdent    // 函数名或裸变量名
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type formulaToken struct {
+	kind   formulaTokenKind
+	text   string
+	value  float64
+	pos    int
+	length int
+}
+
+func isFormulaIdentRune(r rune, first bool) bool {
+	if r == '_' || unicode.IsLetter(r) {
+		return true
+	}
+	return !first && unicode.IsDigit(r)
+}
+
+func tokenizeFormula(src string) ([]formulaToken, error) {
+	runes := []rune(src)
+	var tokens []formulaToken
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, &FormulaError{Pos: pos, Message: fmt.Sprintf("invalid number %q", text)}
+			}
+			tokens = append(tokens, formulaToken{kind: tokenNumber, text: text, value: value, pos: pos})
+
+		case r == '{':
+			end := i + 1
+			for end < len(runes) && runes[end] != '}' {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, &FormulaError{Pos: pos, Message: "unclosed variable reference, expected '}'"}
+			}
+			name := strings.TrimSpace(string(runes[i+1 : end]))
+			if name == "" {
+				return nil, &FormulaError{Pos: pos, Message: "empty variable reference {}"}
+			}
+			for j, nr := range name {
+				if !isFormulaIdentRune(nr, j == 0) {
+					return nil, &FormulaError{Pos: pos, Message: fmt.Sprintf("invalid variable name {%s}", name)}
+				}
+			}
+			tokens = append(tokens, formulaToken{kind: tokenVariable, text: name, pos: pos, length: end - i + 1})
+			i = end + 1
+
+		case isFormulaIdentRune(r, true):
+			start := i
+			for i < len(runes) && isFormulaIdentRune(runes[i], false) {
+				i++
+			}
+			tokens = append(tokens, formulaToken{kind: tokenIdent, text: string(runes[start:i]), pos: pos, length: i - start})
+
+		case r == '(':
+			tokens = append(tokens, formulaToken{kind: tokenLParen, text: "(", pos: pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, formulaToken{kind: tokenRParen, text: ")", pos: pos})
+			i++
+		case r == ',':
+			tokens = append(tokens, formulaToken{kind: tokenComma, text: ",", pos: pos})
+			i++
+
+		default:
+			op := ""
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "<=", ">=", "==", "!=", "&&", "||":
+					op = two
+				}
+			}
+			if op == "" {
+				switch r {
+				case '+', '-', '*', '/', '<', '>', '!':
+					op = string(r)
+				default:
+					return nil, &FormulaError{Pos: pos, Message: fmt.Sprintf("unexpected character %q", r)}
+				}
+			}
+			tokens = append(tokens, formulaToken{kind: tokenOperator, text: op, pos: pos})
+			i += len([]rune(op))
+		}
+	}
+
+	tokens = append(tokens, formulaToken{kind: tokenEOF, pos: len(runes) + 1})
+	return tokens, nil
+}
+
+// ------------------------- AST -------------------------
+
+// formulaResolver 根据名称解析变量或组件引用的值
+type formulaResolver func(name string) (float64, bool)
+
+type formulaNode interface {
+	eval(resolve formulaResolver) (float64, error)
+}
+
+type numberNode struct {
+	value float64
+}
+
+type variableNode struct {
+	name string
+	pos  int
+}
+
+type unaryNode struct {
+	op      string
+	operand formulaNode
+}
+
+type binaryNode struct {
+	op          string
+	left, right formulaNode
+	pos         int
+}
+
+type callNode struct {
+	name string
+	args []formulaNode
+	pos  int
+}
+
+func (n *numberNode) eval(formulaResolver) (float64, error) { return n.value, nil }
+
+func (n *variableNode) eval(resolve formulaResolver) (float64, error) {
+	if value, ok := resolve(n.name); ok {
+		return value, nil
+	}
+	return 0, &FormulaError{Pos: n.pos, Message: fmt.Sprintf("unknown variable {%s}", n.name)}
+}
+
+func (n *unaryNode) eval(resolve formulaResolver) (float64, error) {
+	value, err := n.operand.eval(resolve)
+	if err != nil {
+		return 0, err
+	}
+	if n.op == "!" {
+		return boolToFloat(value == 0), nil
+	}
+	return -value, nil
+}
+
+func (n *binaryNode) eval(resolve formulaResolver) (float64, error) {
+	left, err := n.left.eval(resolve)
+	if err != nil {
+		return 0, err
+	}
+
+	// 逻辑运算短路求值
+	switch n.op {
+	case "&&":
+		if left == 0 {
+			return 0, nil
+		}
+	case "||":
+		if left != 0 {
+			return 1, nil
+		}
+	}
+
+	right, err := n.right.eval(resolve)
+	if err != nil {
+		return 0, err
+	}
+
+	switch n.op {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/":
+		if right == 0 {
+			return 0, &FormulaError{Pos: n.pos, Message: "division by zero"}
+		}
+		return left / right, nil
+	case "<":
+		return boolToFloat(left < right), nil
+	case "<=":
+		return boolToFloat(left <= right), nil
+	case ">":
+		return boolToFloat(left > right), nil
+	case ">=":
+		return boolToFloat(left >= right), nil
+	case "==":
+		return boolToFloat(left == right), nil
+	case "!=":
+		return boolToFloat(left != right), nil
+	case "&&", "||":
+		return boolToFloat(right != 0), nil
+	}
+	return 0, &FormulaError{Pos: n.pos, Message: fmt.Sprintf("unsupported operator %q", n.op)}
+}
+
+func (n *callNode) eval(resolve formulaResolver) (float64, error) {
+	// if 仅对选中的分支求值
+	if n.name == "if" {
+		cond, err := n.args[0].eval(resolve)
+		if err != nil {
+			return 0, err
+		}
+		if cond != 0 {
+			return n.args[1].eval(resolve)
+		}
+		return n.args[2].eval(resolve)
+	}
+
+	values := make([]float64, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(resolve)
+		if err != nil {
+			return 0, err
+		}
+		values[i] = value
+	}
+
+	switch n.name {
+	case "min":
+		result := values[0]
+		for _, v := range values[1:] {
+			result = math.Min(result, v)
+		}
+		return result, nil
+	case "max":
+		result := values[0]
+		for _, v := range values[1:] {
+			result = math.Max(result, v)
+		}
+		return result, nil
+	case "round":
+		digits := 0.0
+		if len(values) == 2 {
+			digits = math.Trunc(values[1])
+		}
+		scale := math.Pow(10, digits)
+		return math.Round(values[0]*scale) / scale, nil
+	case "floor":
+		return math.Floor(values[0]), nil
+	case "ceil":
+		return math.Ceil(values[0]), nil
+	case "abs":
+		return math.Abs(values[0]), nil
+	}
+	return 0, &FormulaError{Pos: n.pos, Message: fmt.Sprintf("unknown function %s", n.name)}
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ------------------------- Parser -------------------------
+
+// formulaReference 公式中引用的变量及其位置
+type formulaReference struct {
+	Name string
+	Pos  int
+	Len  int // 引用在源公式中占用的字符数
+}
+
+// parsedFormula 已解析的公式
+type parsedFormula struct {
+	source     string
+	root       formulaNode
+	references []formulaReference
+}
+
+type formulaParser struct {
+	tokens     []formulaToken
+	current    int
+	references []formulaReference
+}
+
+// 运算符优先级（数值越大优先级越高）
+var formulaBinaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6,
+}
+
+// parseFormula 解析公式并返回语法树，语法错误带有出错位置
+func parseFormula(src string) (*parsedFormula, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, errors.New("formula cannot be empty")
+	}
+
+	tokens, err := tokenizeFormula(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &formulaParser{tokens: tokens}
+	root, err := p.parseExpression(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, &FormulaError{Pos: tok.pos, Message: fmt.Sprintf("unexpected %q", tok.text)}
+	}
+
+	return &parsedFormula{source: src, root: root, references: p.references}, nil
+}
+
+func (p *formulaParser) peek() formulaToken {
+	return p.tokens[p.current]
+}
+
+func (p *formulaParser) next() formulaToken {
+	tok := p.tokens[p.current]
+	if tok.kind != tokenEOF {
+		p.current++
+	}
+	return tok
+}
+
+// parseExpression 使用优先级爬升法解析二元表达式
+func (p *formulaParser) parseExpression(minPrecedence int) (formulaNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		if tok.kind != tokenOperator {
+			return left, nil
+		}
+		precedence, ok := formulaBinaryPrecedence[tok.text]
+		if !ok || precedence <= minPrecedence {
+			return left, nil
+		}
+		p.next()
+
+		right, err := p.parseExpression(precedence)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: tok.text, left: left, right: right, pos: tok.pos}
+	}
+}
+
+func (p *formulaParser) parseUnary() (formulaNode, error) {
+	tok := p.peek()
+	if tok.kind == tokenOperator && (tok.text == "-" || tok.text == "+" || tok.text == "!") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if tok.text == "+" {
+			return operand, nil
+		}
+		return &unaryNode{op: tok.text, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *formulaParser) parsePrimary() (formulaNode, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokenNumber:
+		return &numberNode{value: tok.value}, nil
+
+	case tokenVariable:
+		p.references = append(p.references, formulaReference{Name: tok.text, Pos: tok.pos, Len: tok.length})
+		return &variableNode{name: tok.text, pos: tok.pos}, nil
+
+	case tokenIdent:
+		if p.peek().kind == tokenLParen {
+			return p.parseCall(tok)
+		}
+		p.references = append(p.references, formulaReference{Name: tok.text, Pos: tok.pos, Len: tok.length})
+		return &variableNode{name: tok.text, pos: tok.pos}, nil
+
+	case tokenLParen:
+		expr, err := p.parseExpression(0)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &FormulaError{Pos: closing.pos, Message: "expected ')'"}
+		}
+		return expr, nil
+
+	case tokenEOF:
+		return nil, &FormulaError{Pos: tok.pos, Message: "unexpected end of formula"}
+	}
+
+	return nil, &FormulaError{Pos: tok.pos, Message: fmt.Sprintf("unexpected %q", tok.text)}
+}
+
+func (p *formulaParser) parseCall(nameTok formulaToken) (formulaNode, error) {
+	name := strings.ToLower(nameTok.text)
+	arity, ok := formulaFunctions[name]
+	if !ok {
+		return nil, &FormulaError{Pos: nameTok.pos, Message: fmt.Sprintf("unknown function %s", nameTok.text)}
+	}
+	p.next() // (
+
+	var args []formulaNode
+	if p.peek().kind != tokenRParen {
+		for {
+			arg, err := p.parseExpression(0)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if closing := p.next(); closing.kind != tokenRParen {
+		return nil, &FormulaError{Pos: closing.pos, Message: fmt.Sprintf("expected ')' to close %s(", name)}
+	}
+
+	if len(args) < arity.min || (arity.max >= 0 && len(args) > arity.max) {
+		return nil, &FormulaError{Pos: nameTok.pos, Message: fmt.Sprintf("function %s expects %s, got %d", name, describeArity(arity.min, arity.max), len(args))}
+	}
+
+	return &callNode{name: name, args: args, pos: nameTok.pos}, nil
+}
+
+func describeArity(min, max int) string {
+	switch {
+	case max < 0:
+		return fmt.Sprintf("at least %d argument(s)", min)
+	case min == max:
+		return fmt.Sprintf("%d argument(s)", min)
+	default:
+		return fmt.Sprintf("%d to %d arguments", min, max)
+	}
+}
+
+// referencedNames 返回公式引用的去重变量名（按字母排序）
+func (f *parsedFormula) referencedNames() []string {
+	seen := make(map[string]bool, len(f.references))
+	var names []string
+	for _, ref := range f.references {
+		if !seen[ref.Name] {
+			seen[ref.Name] = true
+			names = append(names, ref.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// substitute 将公式中的引用替换为实际取值，用于生成计算过程说明
+func (f *parsedFormula) substitute(resolve formulaResolver) string {
+	runes := []rune(f.source)
+	var b strings.Builder
+	last := 0
+	for _, ref := range f.references {
+		start := ref.Pos - 1
+		if start < last {
+			continue
+		}
+		b.WriteString(string(runes[last:start]))
+		if value, ok := resolve(ref.Name); ok {
+			b.WriteString(strconv.FormatFloat(value, 'f', 2, 64))
+		} else {
+			b.WriteString(string(runes[start : start+ref.Len]))
+		}
+		last = start + ref.Len
+	}
+	b.WriteString(string(runes[last:]))
+	return strings.TrimSpace(b.String())
+}
+
+// ------------------------- Evaluation helpers -------------------------
+
+// evaluateFormula 在给定上下文中计算公式：优先查找变量，其次查找组件结果
+func evaluateFormula(formula string, context FormulaContext) (float64, error) {
+	parsed, err := parseFormula(formula)
+	if err != nil {
+		return 0, err
+	}
+	return parsed.root.eval(context.resolver())
+}
+
+// resolver 构造上下文的变量解析函数
+func (context FormulaContext) resolver() formulaResolver {
+	return func(name string) (float64, bool) {
+		if raw, ok := context.Variables[name]; ok {
+			if value, ok := formulaNumber(raw); ok {
+				return value, true
+			}
+		}
+		if value, ok := context.Components[name]; ok {
+			return value, true
+		}
+		if name == "base_salary" {
+			return context.BaseSalary, true
+		}
+		return 0, false
+	}
+}
+
+func formulaNumber(raw interface{}) (float64, bool) {
+	switch v := raw.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case bool:
+		return boolToFloat(v), true
+	case models.Money:
+		return v.Float64(), true
+	}
+	return 0, false
+}
+
+// validateFormula 校验公式语法，并检查引用是否为已知变量或组件编码
+func validateFormula(formula string, componentCodes map[string]bool) error {
+	parsed, err := parseFormula(formula)
+	if err != nil {
+		var formulaErr *FormulaError
+		if errors.As(err, &formulaErr) {
+			return &FormulaValidationError{Formula: formula, Issues: []*FormulaError{formulaErr}}
+		}
+		return err
+	}
+
+	var issues []*FormulaError
+	for _, ref := range parsed.references {
+		if _, ok := formulaBuiltinVariables[ref.Name]; ok {
+			continue
+		}
+		if componentCodes[ref.Name] {
+			continue
+		}
+		issues = append(issues, &FormulaError{Pos: ref.Pos, Message: fmt.Sprintf("unknown variable {%s}", ref.Name)})
+	}
+
+	if len(issues) > 0 {
+		return &FormulaValidationError{Formula: formula, Issues: issues}
+	}
+	return nil
+}
+
+// checkFormulaSelfReference 组件公式不能引用自身的计算结果
+func checkFormulaSelfReference(component *models.SalaryComponent) error {
+	parsed, err := parseFormula(component.Formula)
+	if err != nil {
+		return err
+	}
+	for _, ref := range parsed.references {
+		if ref.Name == component.Code {
+			return &FormulaError{Pos: ref.Pos, Message: fmt.Sprintf("component %s cannot reference itself", component.Code)}
+		}
+	}
+	return nil
+}
+
+// parsePercentage 解析百分比格式 (e.g., "15%" -> 0.15)
+func parsePercentage(formula string) (float64, error) {
+	formula = strings.TrimSpace(formula)
+	if strings.HasSuffix(formula, "%") {
+		percentStr := strings.TrimSuffix(formula, "%")
+		percent, err := strconv.ParseFloat(strings.TrimSpace(percentStr), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid percentage format: %s", formula)
+		}
+		return percent / 100.0, nil
+	}
+	return 0, fmt.Errorf("invalid percentage format: %s", formula)
+}
+
+// loadComponentCodes 读取所有薪资组件编码，供公式校验识别组件引用
+func loadComponentCodes(db *gorm.DB) (map[string]bool, error) {
+	codes := make(map[string]bool)
+	if db == nil {
+		return codes, nil
+	}
+
+	var list []string
+	if err := db.Model(&models.SalaryComponent{}).Pluck("code", &list).Error; err != nil {
+		return nil, err
+	}
+	for _, code := range list {
+		codes[code] = true
+	}
+	return codes, nil
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gin-project/models"
+
+	"gorm.io/gorm"
+)
+
+// ========================= Mid-period Proration =========================
+
+// ProrationInput 基本薪资折算的输入
+type ProrationInput struct {
+	PeriodStart     time.Time
+	PeriodEnd       time.Time
+	HireDate        *time.Time
+	TerminationDate *time.Time
+	BaseSalary      models.Money              // 员工档案中的基本薪资，无调薪记录时使用
+	Adjustments     []models.SalaryAdjustment // 已批准的调薪记录
+	Method          models.ProrationMethod
+}
+
+// ProrationResult 周期内的基本薪资折算结果
+type ProrationResult struct {
+	Method       models.ProrationMethod       `json:"method"`
+	FullBase     models.Money                 `json:"full_base"`     // 周期末适用的月基本薪资
+	ProratedBase models.Money                 `json:"prorated_base"` // 各区段折算金额合计
+	Ratio        float64                      `json:"ratio"`         // 在职天数折算比例
+	Segments     []models.SalaryDetailSegment `json:"segments"`
+}
+
+// ProrateBaseSalary 按入职、离职及调薪生效日期将周期切分为区段，并按折算方式计算各区段的基本薪资。
+// 整个周期在职时各方式均发放全月基数；按 21.75 折算时，非全月在职按日薪乘以应出勤天数计算且不超过全月
+func ProrateBaseSalary(calendar *WorkCalendar, input ProrationInput) *ProrationResult {
+	method := input.Method
+	if !method.IsValid() {
+		method = models.ProrationWorkingDays
+	}
+
+	periodStart := truncateDate(input.PeriodStart)
+	periodEnd := truncateDate(input.PeriodEnd)
+	adjustments := make([]models.SalaryAdjustment, len(input.Adjustments))
+	copy(adjustments, input.Adjustments)
+	sort.SliceStable(adjustments, func(i, j int) bool {
+		return adjustments[i].EffectiveDate.Before(adjustments[j].EffectiveDate)
+	})
+
+	result := &ProrationResult{
+		Method:   method,
+		FullBase: baseSalaryAt(adjustments, input.BaseSalary, periodEnd),
+	}
+
+	start, startReason := periodStart, models.SegmentReasonPeriodStart
+	if input.HireDate != nil && truncateDate(*input.HireDate).After(start) {
+		start, startReason = truncateDate(*input.HireDate), models.SegmentReasonHire
+	}
+	end := periodEnd
+	if input.TerminationDate != nil && truncateDate(*input.TerminationDate).Before(end) {
+		end = truncateDate(*input.TerminationDate)
+	}
+	if end.Before(start) {
+		return result
+	}
+
+	segmentStart, reason := start, startReason
+	var adjustmentID *uint
+	for i := range adjustments {
+		effective := truncateDate(adjustments[i].EffectiveDate)
+		if !effective.After(segmentStart) || effective.After(end) {
+			continue
+		}
+		result.Segments = append(result.Segments, newProrationSegment(adjustments, input.BaseSalary, segmentStart, effective.AddDate(0, 0, -1), reason, adjustmentID))
+		id := adjustments[i].ID
+		segmentStart, reason, adjustmentID = effective, models.SegmentReasonAdjustment, &id
+	}
+	result.Segments = append(result.Segments, newProrationSegment(adjustments, input.BaseSalary, segmentStart, end, reason, adjustmentID))
+
+	fullPeriod := start.Equal(periodStart) && end.Equal(periodEnd)
+	var totalDays, workedDays float64
+	for i := range result.Segments {
+		segment := &result.Segments[i]
+		segment.Method = method
+		switch method {
+		case models.ProrationCalendarDays:
+			segment.Days = float64(daysBetween(segment.StartDate, segment.EndDate))
+			totalDays = float64(daysBetween(periodStart, periodEnd))
+		case models.ProrationStatutoryDays:
+			segment.Days = float64(calendar.WorkingDaysBetween(segment.StartDate, segment.EndDate))
+			if fullPeriod {
+				totalDays = float64(calendar.WorkingDaysBetween(periodStart, periodEnd))
+			} else {
+				totalDays = monthlyPayDays
+			}
+		default:
+			segment.Days = float64(calendar.WorkingDaysBetween(segment.StartDate, segment.EndDate))
+			totalDays = float64(calendar.WorkingDaysBetween(periodStart, periodEnd))
+		}
+		workedDays += segment.Days
+	}
+	if workedDays > totalDays {
+		totalDays = workedDays
+	}
+
+	for i := range result.Segments {
+		segment := &result.Segments[i]
+		segment.TotalDays = totalDays
+		if totalDays > 0 {
+			segment.Amount = segment.BaseSalary.Mul(segment.Days/totalDays, models.RoundHalfUp)
+		}
+		result.ProratedBase = result.ProratedBase.Add(segment.Amount)
+	}
+	if totalDays > 0 {
+		result.Ratio = workedDays / totalDays
+	}
+	return result
+}
+
+func newProrationSegment(adjustments []models.SalaryAdjustment, fallback models.Money, start, end time.Time, reason string, adjustmentID *uint) models.SalaryDetailSegment {
+	return models.SalaryDetailSegment{
+		StartDate:    start,
+		EndDate:      end,
+		Reason:       reason,
+		AdjustmentID: adjustmentID,
+		BaseSalary:   baseSalaryAt(adjustments, fallback, start),
+	}
+}
+
+// baseSalaryAt 返回指定日期适用的月基本薪资：取此前最近一次生效调薪的新薪资，
+// 若尚无生效调薪则取之后第一次调薪的原薪资，均无记录时使用员工档案中的基本薪资
+func baseSalaryAt(adjustments []models.SalaryAdjustment, fallback models.Money, date time.Time) models.Money {
+	base := fallback
+	found := false
+	for _, adjustment := range adjustments {
+		if !truncateDate(adjustment.EffectiveDate).After(date) {
+			base, found = adjustment.NewBaseSalary, true
+		}
+	}
+	if !found {
+		for _, adjustment := range adjustments {
+			if adjustment.OldBaseSalary.Sign() > 0 {
+				return adjustment.OldBaseSalary
+			}
+		}
+	}
+	return base
+}
+
+func daysBetween(start, end time.Time) int {
+	return int(truncateDate(end).Sub(truncateDate(start)).Hours()/24+0.5) + 1
+}
+
+// loadProration 读取员工入离职日期与已批准调薪，计算周期内的基本薪资折算
+func loadProration(db *gorm.DB, employee *models.Employee, start, end time.Time, method models.ProrationMethod) (*ProrationResult, error) {
+	calendar, err := loadWorkCalendar(db, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var adjustments []models.SalaryAdjustment
+	if err := db.Where("employee_id = ? AND status = ?", employee.ID, "approved").
+		Order("effective_date ASC").Find(&adjustments).Error; err != nil {
+		return nil, fmt.Errorf("failed to load salary adjustments: %w", err)
+	}
+
+	input := ProrationInput{
+		PeriodStart: start,
+		PeriodEnd:   end,
+		BaseSalary:  employee.BaseSalary,
+		Adjustments: adjustments,
+		Method:      method,
+	}
+	input.HireDate, input.TerminationDate = employmentDates(employee)
+
+	result := ProrateBaseSalary(calendar, input)
+	if len(result.Segments) == 0 {
+		return nil, errors.New("employee is not employed during the payroll period")
+	}
+	return result, nil
+}
+
+// employmentDates 返回员工的入职日期与离职日期（未设置时为 nil）
+func employmentDates(employee *models.Employee) (hireDate, terminationDate *time.Time) {
+	if employee.HireDate != nil && !employee.HireDate.IsZero() {
+		date := employee.HireDate.Time
+		hireDate = &date
+	}
+	if employee.TerminationDate != nil && !employee.TerminationDate.IsZero() {
+		date := employee.TerminationDate.Time
+		terminationDate = &date
+	}
+	return hireDate, terminationDate
+}
+
+// usesBaseSalary 判断组件取值是否基于基本薪资（需要附带折算区段）
+func usesBaseSalary(component *models.SalaryComponent) bool {
+	switch component.Type {
+	case models.ComponentTypePercentage:
+		return true
+	case models.ComponentTypeFormula:
+		parsed, err := parseFormula(component.Formula)
+		if err != nil {
+			return false
+		}
+		for _, name := range parsed.referencedNames() {
+			if name == "base_salary" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,498 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-project/models"
+	"gin-project/utils"
+
+	"gorm.io/gorm"
+)
+
+// ========================= Salary Self-Service =========================
+
+// stepUpTokenTTL 二次验证令牌有效期，过期后需重新验证
+const stepUpTokenTTL = 10 * time.Minute
+
+// payrollAdminRoleCodes 接收工资单异议通知的角色
+var payrollAdminRoleCodes = []string{"admin", "hr"}
+
+var payslipPINPattern = regexp.MustCompile(`^\d{6}$`)
+
+type SalarySelfServiceInterface interface {
+	// 二次验证
+	VerifyStepUp(userID uint, role string, req StepUpRequest) (*StepUpToken, error)
+	SetPayslipPIN(userID uint, password, pin string) error
+
+	// 工资单确认与异议
+	ConfirmPayslip(employeeID, salaryID uint) (*models.PayslipAcknowledgement, error)
+	DisputePayslip(employeeID, userID, salaryID uint, req PayslipDisputeRequest) (*models.PayslipDispute, error)
+	GetMyDisputes(employeeID uint) ([]models.PayslipDispute, error)
+
+	// 异议处理
+	GetDisputes(params PayslipDisputeQueryParams) ([]models.PayslipDispute, error)
+	GetDispute(id uint) (*models.PayslipDispute, error)
+	AssignDispute(id, assigneeID uint) (*models.PayslipDispute, error)
+	ResolveDispute(id, userID uint, req PayslipDisputeResolution) (*models.PayslipDispute, error)
+}
+
+type SalarySelfService struct {
+	db            *gorm.DB
+	notifications *NotificationService
+}
+
+func NewSalarySelfService(db *gorm.DB, notifications *NotificationService) SalarySelfServiceInterface {
+	return &SalarySelfService{db: db, notifications: notifications}
+}
+
+// StepUpRequest 二次验证请求，Secret 为工资单查询密码或登录密码
+type StepUpRequest struct {
+	Method models.StepUpMethod `json:"method" binding:"required"`
+	Secret string              `json:"secret" binding:"required"`
+}
+
+// StepUpToken 二次验证通过后签发的范围令牌，请求个人薪资接口时放在 X-Step-Up-Token 头中
+type StepUpToken struct {
+	Token     string    `json:"token"`
+	Scope     string    `json:"scope"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// StepUpError 二次验证未通过；LockedUntil 非空表示已被锁定
+type StepUpError struct {
+	Message     string
+	LockedUntil *time.Time
+}
+
+func (e *StepUpError) Error() string {
+	return e.Message
+}
+
+// AsStepUpError 判断错误是否为二次验证未通过
+func AsStepUpError(err error) (*StepUpError, bool) {
+	var stepUpErr *StepUpError
+	if errors.As(err, &stepUpErr) {
+		return stepUpErr, true
+	}
+	return nil, false
+}
+
+// PayslipDisputeRequest 员工提出工资单异议
+type PayslipDisputeRequest struct {
+	Category    models.PayslipDisputeCategory `json:"category" binding:"required"`
+	Description string                        `json:"description" binding:"required"`
+}
+
+// PayslipDisputeResolution 处理结果，Status 只能为 resolved 或 rejected
+type PayslipDisputeResolution struct {
+	Status     models.PayslipDisputeStatus `json:"status" binding:"required"`
+	Resolution string                      `json:"resolution" binding:"required"`
+}
+
+// PayslipDisputeQueryParams 异议查询条件
+type PayslipDisputeQueryParams struct {
+	Status     string
+	EmployeeID uint
+	AssignedTo uint
+}
+
+// ValidatePayslipPIN 校验工资单查询密码：6 位数字，不能全部相同或连续
+func ValidatePayslipPIN(pin string) error {
+	if !payslipPINPattern.MatchString(pin) {
+		return &utils.ValidationError{Message: "工资单查询密码必须为6位数字"}
+	}
+
+	same, ascending, descending := true, true, true
+	for i := 1; i < len(pin); i++ {
+		diff := int(pin[i]) - int(pin[i-1])
+		same = same && diff == 0
+		ascending = ascending && diff == 1
+		descending = descending && diff == -1
+	}
+	if same || ascending || descending {
+		return &utils.ValidationError{Message: "工资单查询密码过于简单，不能使用相同或连续的数字"}
+	}
+	return nil
+}
+
+// VerifyStepUp 校验工资单查询密码或登录密码，通过后签发绑定员工的短期范围令牌
+func (s *SalarySelfService) VerifyStepUp(userID uint, role string, req StepUpRequest) (*StepUpToken, error) {
+	if !req.Method.IsValid() {
+		return nil, &utils.ValidationError{Message: "不支持的验证方式: " + string(req.Method)}
+	}
+
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	if user.EmployeeID == nil {
+		return nil, &utils.ValidationError{Message: "当前账号未关联员工档案"}
+	}
+
+	credential, err := s.loadCredential(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if credential.IsLocked(now) {
+		return nil, stepUpLockedError(credential.LockedUntil)
+	}
+
+	var verified bool
+	switch req.Method {
+	case models.StepUpPIN:
+		if credential.PINHash == "" {
+			return nil, &utils.ValidationError{Message: "尚未设置工资单查询密码，请使用登录密码验证"}
+		}
+		verified = utils.CheckPassword(credential.PINHash, req.Secret) == nil
+	case models.StepUpPassword:
+		verified = utils.CheckPassword(user.Password, req.Secret) == nil
+	}
+
+	if !verified {
+		credential.RecordFailure(now)
+		if err := s.db.Save(credential).Error; err != nil {
+			return nil, err
+		}
+		if credential.IsLocked(now) {
+			return nil, stepUpLockedError(credential.LockedUntil)
+		}
+		return nil, &StepUpError{Message: fmt.Sprintf("验证失败，还可尝试%d次", credential.RemainingAttempts())}
+	}
+
+	credential.RecordSuccess(now)
+	if err := s.db.Save(credential).Error; err != nil {
+		return nil, err
+	}
+
+	token, expiresAt, err := utils.DefaultJWTService.GenerateScopedToken(
+		strconv.FormatUint(uint64(userID), 10), role, utils.ScopeSalarySelfService, *user.EmployeeID, stepUpTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StepUpToken{Token: token, Scope: utils.ScopeSalarySelfService, ExpiresAt: expiresAt}, nil
+}
+
+// SetPayslipPIN 设置或修改工资单查询密码，需要重新输入登录密码
+func (s *SalarySelfService) SetPayslipPIN(userID uint, password, pin string) error {
+	if err := ValidatePayslipPIN(pin); err != nil {
+		return err
+	}
+
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return err
+	}
+
+	credential, err := s.loadCredential(userID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if credential.IsLocked(now) {
+		return stepUpLockedError(credential.LockedUntil)
+	}
+
+	if utils.CheckPassword(user.Password, password) != nil {
+		credential.RecordFailure(now)
+		if err := s.db.Save(credential).Error; err != nil {
+			return err
+		}
+		if credential.IsLocked(now) {
+			return stepUpLockedError(credential.LockedUntil)
+		}
+		return &StepUpError{Message: fmt.Sprintf("登录密码错误，还可尝试%d次", credential.RemainingAttempts())}
+	}
+
+	hash, err := utils.HashPassword(pin)
+	if err != nil {
+		return err
+	}
+	credential.PINHash = hash
+	credential.RecordSuccess(now)
+	return s.db.Save(credential).Error
+}
+
+// ConfirmPayslip 员工确认工资单无误；存在未处理完的异议时不能确认
+func (s *SalarySelfService) ConfirmPayslip(employeeID, salaryID uint) (*models.PayslipAcknowledgement, error) {
+	if _, err := s.loadOwnPayslip(employeeID, salaryID); err != nil {
+		return nil, err
+	}
+
+	open, err := s.hasOpenDispute(salaryID)
+	if err != nil {
+		return nil, err
+	}
+	if open {
+		return nil, utils.NewConflictError("该工资单有尚未处理完的异议，处理完毕后再确认")
+	}
+
+	var ack *models.PayslipAcknowledgement
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		ack, err = saveAcknowledgement(tx, employeeID, salaryID, models.PayslipConfirmed)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ack, nil
+}
+
+// DisputePayslip 员工对工资单提出异议，并通知薪资管理员
+func (s *SalarySelfService) DisputePayslip(employeeID, userID, salaryID uint, req PayslipDisputeRequest) (*models.PayslipDispute, error) {
+	if !req.Category.IsValid() {
+		return nil, &utils.ValidationError{Message: "不支持的异议类别: " + string(req.Category)}
+	}
+	description := strings.TrimSpace(req.Description)
+	if description == "" {
+		return nil, &utils.ValidationError{Message: "请填写异议说明"}
+	}
+
+	salary, err := s.loadOwnPayslip(employeeID, salaryID)
+	if err != nil {
+		return nil, err
+	}
+
+	open, err := s.hasOpenDispute(salaryID)
+	if err != nil {
+		return nil, err
+	}
+	if open {
+		return nil, utils.NewConflictError("该工资单已有尚未处理完的异议")
+	}
+
+	dispute := &models.PayslipDispute{
+		SalaryID:    salaryID,
+		EmployeeID:  employeeID,
+		RaisedBy:    userID,
+		Category:    req.Category,
+		Description: description,
+		Status:      models.DisputeOpen,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(dispute).Error; err != nil {
+			return err
+		}
+		_, err := saveAcknowledgement(tx, employeeID, salaryID, models.PayslipDisputed)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	period := ""
+	if salary.PayrollPeriod != nil {
+		period = salary.PayrollPeriod.Name
+	}
+	s.notifyPayrollAdmins("工资单异议",
+		fmt.Sprintf("员工ID %d 对 %s 工资单提出异议：%s", employeeID, period, description),
+		map[string]interface{}{"dispute_id": dispute.ID, "salary_id": salaryID, "category": dispute.Category})
+
+	return dispute, nil
+}
+
+// GetMyDisputes 获取员工本人提出的异议
+func (s *SalarySelfService) GetMyDisputes(employeeID uint) ([]models.PayslipDispute, error) {
+	return s.GetDisputes(PayslipDisputeQueryParams{EmployeeID: employeeID})
+}
+
+// GetDisputes 获取工资单异议列表
+func (s *SalarySelfService) GetDisputes(params PayslipDisputeQueryParams) ([]models.PayslipDispute, error) {
+	query := s.db.Preload("Employee").Preload("Salary.PayrollPeriod")
+	if params.Status != "" {
+		query = query.Where("status = ?", params.Status)
+	}
+	if params.EmployeeID != 0 {
+		query = query.Where("employee_id = ?", params.EmployeeID)
+	}
+	if params.AssignedTo != 0 {
+		query = query.Where("assigned_to = ?", params.AssignedTo)
+	}
+
+	var disputes []models.PayslipDispute
+	if err := query.Order("created_at DESC").Find(&disputes).Error; err != nil {
+		return nil, err
+	}
+	return disputes, nil
+}
+
+// GetDispute 获取工资单异议详情
+func (s *SalarySelfService) GetDispute(id uint) (*models.PayslipDispute, error) {
+	var dispute models.PayslipDispute
+	if err := s.db.Preload("Employee").Preload("Salary.PayrollPeriod").First(&dispute, id).Error; err != nil {
+		return nil, err
+	}
+	return &dispute, nil
+}
+
+// AssignDispute 指派异议处理人
+func (s *SalarySelfService) AssignDispute(id, assigneeID uint) (*models.PayslipDispute, error) {
+	var dispute models.PayslipDispute
+	if err := s.db.First(&dispute, id).Error; err != nil {
+		return nil, err
+	}
+	if dispute.Status.IsClosed() {
+		return nil, utils.NewConflictError("异议已处理完毕，不能再指派")
+	}
+
+	var assignee models.User
+	if err := s.db.First(&assignee, assigneeID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &utils.ValidationError{Message: "处理人不存在"}
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	dispute.AssignedTo = &assigneeID
+	dispute.AssignedAt = &now
+	dispute.Status = models.DisputeInReview
+	if err := s.db.Save(&dispute).Error; err != nil {
+		return nil, err
+	}
+
+	s.notifyUser(assigneeID, "工资单异议待处理",
+		fmt.Sprintf("您被指派处理工资单异议 #%d", dispute.ID),
+		map[string]interface{}{"dispute_id": dispute.ID, "salary_id": dispute.SalaryID})
+
+	return s.GetDispute(dispute.ID)
+}
+
+// ResolveDispute 完结异议并通知提出人；驳回后员工可重新确认工资单
+func (s *SalarySelfService) ResolveDispute(id, userID uint, req PayslipDisputeResolution) (*models.PayslipDispute, error) {
+	if !req.Status.IsClosed() {
+		return nil, &utils.ValidationError{Message: "处理结果只能为 resolved 或 rejected"}
+	}
+	resolution := strings.TrimSpace(req.Resolution)
+	if resolution == "" {
+		return nil, &utils.ValidationError{Message: "请填写处理结果"}
+	}
+
+	var dispute models.PayslipDispute
+	if err := s.db.First(&dispute, id).Error; err != nil {
+		return nil, err
+	}
+	if dispute.Status.IsClosed() {
+		return nil, utils.NewConflictError("异议已处理完毕")
+	}
+
+	now := time.Now()
+	dispute.Status = req.Status
+	dispute.Resolution = resolution
+	dispute.ResolvedBy = &userID
+	dispute.ResolvedAt = &now
+	if dispute.AssignedTo == nil {
+		dispute.AssignedTo = &userID
+		dispute.AssignedAt = &now
+	}
+	if err := s.db.Save(&dispute).Error; err != nil {
+		return nil, err
+	}
+
+	title := "工资单异议已解决"
+	if req.Status == models.DisputeRejected {
+		title = "工资单异议已驳回"
+	}
+	s.notifyUser(dispute.RaisedBy, title, resolution,
+		map[string]interface{}{"dispute_id": dispute.ID, "salary_id": dispute.SalaryID, "status": dispute.Status})
+
+	return s.GetDispute(dispute.ID)
+}
+
+// loadCredential 读取用户的二次验证凭据，不存在时返回未保存的空凭据
+func (s *SalarySelfService) loadCredential(userID uint) (*models.SalaryAccessCredential, error) {
+	var credential models.SalaryAccessCredential
+	err := s.db.Where("user_id = ?", userID).First(&credential).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.SalaryAccessCredential{UserID: userID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// loadOwnPayslip 读取员工本人已批准或已发放的薪资记录
+func (s *SalarySelfService) loadOwnPayslip(employeeID, salaryID uint) (*models.EnhancedSalary, error) {
+	var salary models.EnhancedSalary
+	err := s.db.Preload("PayrollPeriod").
+		Where("id = ? AND employee_id = ?", salaryID, employeeID).
+		First(&salary).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if PayslipVisibleToEmployee(&salary, employeeID) {
+		return &salary, nil
+	}
+	return nil, &utils.ValidationError{Message: "工资单尚未发布，暂不能确认或提出异议"}
+}
+
+func (s *SalarySelfService) hasOpenDispute(salaryID uint) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.PayslipDispute{}).
+		Where("salary_id = ? AND status IN ?", salaryID, []models.PayslipDisputeStatus{models.DisputeOpen, models.DisputeInReview}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// saveAcknowledgement 记录员工对工资单的最新确认状态
+func saveAcknowledgement(tx *gorm.DB, employeeID, salaryID uint, status models.PayslipAckStatus) (*models.PayslipAcknowledgement, error) {
+	var ack models.PayslipAcknowledgement
+	err := tx.Where("salary_id = ?", salaryID).First(&ack).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	ack.SalaryID = salaryID
+	ack.EmployeeID = employeeID
+	ack.Status = status
+	ack.AcknowledgedAt = time.Now()
+	if err := tx.Save(&ack).Error; err != nil {
+		return nil, err
+	}
+	return &ack, nil
+}
+
+func stepUpLockedError(lockedUntil *time.Time) error {
+	return &StepUpError{
+		Message:     fmt.Sprintf("验证失败次数过多，请于 %s 后重试", lockedUntil.Format("15:04")),
+		LockedUntil: lockedUntil,
+	}
+}
+
+// notifyPayrollAdmins 通知所有薪资管理员（admin / hr 角色）
+func (s *SalarySelfService) notifyPayrollAdmins(title, message string, data map[string]interface{}) {
+	if s.notifications == nil {
+		return
+	}
+
+	var userIDs []uint
+	err := s.db.Model(&models.UserRole{}).
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("roles.code IN ?", payrollAdminRoleCodes).
+		Distinct().Pluck("user_roles.user_id", &userIDs).Error
+	if err != nil {
+		return
+	}
+
+	for _, userID := range userIDs {
+		s.notifyUser(userID, title, message, data)
+	}
+}
+
+func (s *SalarySelfService) notifyUser(userID uint, title, message string, data map[string]interface{}) {
+	if s.notifications == nil || userID == 0 {
+		return
+	}
+	_ = s.notifications.SendToUser(strconv.FormatUint(uint64(userID), 10), NotificationInfo, title, message, data)
+}
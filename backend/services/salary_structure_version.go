@@ -0,0 +1,397 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gin-project/models"
+	"gin-project/utils"
+
+	"gorm.io/gorm"
+)
+
+// ========================= Salary Structure Versioning =========================
+
+type SalaryStructureVersionServiceInterface interface {
+	GetVersions(structureID uint) ([]models.SalaryStructureVersion, error)
+	GetVersion(structureID uint, version int) (*models.SalaryStructureVersion, error)
+	CompareVersions(structureID uint, fromVersion, toVersion int) (*StructureVersionDiff, error)
+}
+
+type SalaryStructureVersionService struct {
+	db *gorm.DB
+}
+
+func NewSalaryStructureVersionService(db *gorm.DB) SalaryStructureVersionServiceInterface {
+	return &SalaryStructureVersionService{db: db}
+}
+
+// StructureFieldChange 版本间字段变化
+type StructureFieldChange struct {
+	Field string      `json:"field"`
+	Label string      `json:"label"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// StructureComponentChange 版本间组件变化，ChangeType 为 added / removed / modified
+type StructureComponentChange struct {
+	ComponentID uint                   `json:"component_id"`
+	Code        string                 `json:"code"`
+	Name        string                 `json:"name"`
+	ChangeType  string                 `json:"change_type"`
+	Fields      []StructureFieldChange `json:"fields,omitempty"`
+}
+
+// StructureVersionDiff 薪资结构两个版本的差异
+type StructureVersionDiff struct {
+	StructureID       uint                       `json:"structure_id"`
+	FromVersion       int                        `json:"from_version"`
+	ToVersion         int                        `json:"to_version"`
+	FromEffectiveDate time.Time                  `json:"from_effective_date"`
+	ToEffectiveDate   time.Time                  `json:"to_effective_date"`
+	Fields            []StructureFieldChange     `json:"fields"`
+	Components        []StructureComponentChange `json:"components"`
+}
+
+func (s *SalaryStructureVersionService) GetVersions(structureID uint) ([]models.SalaryStructureVersion, error) {
+	var structure models.SalaryStructure
+	if err := s.db.Preload("Components").First(&structure, structureID).Error; err != nil {
+		return nil, &utils.ValidationError{Message: "薪资结构不存在"}
+	}
+	if _, err := ensureBaselineStructureVersion(s.db, &structure); err != nil {
+		return nil, err
+	}
+
+	var versions []models.SalaryStructureVersion
+	if err := s.db.Preload("Components.Component").
+		Where("structure_id = ?", structureID).Order("version DESC").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (s *SalaryStructureVersionService) GetVersion(structureID uint, version int) (*models.SalaryStructureVersion, error) {
+	var result models.SalaryStructureVersion
+	if err := s.db.Preload("Components.Component").
+		Where("structure_id = ? AND version = ?", structureID, version).First(&result).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &utils.ValidationError{Message: fmt.Sprintf("薪资结构版本 v%d 不存在", version)}
+		}
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CompareVersions 对比同一薪资结构的两个版本
+func (s *SalaryStructureVersionService) CompareVersions(structureID uint, fromVersion, toVersion int) (*StructureVersionDiff, error) {
+	from, err := s.GetVersion(structureID, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.GetVersion(structureID, toVersion)
+	if err != nil {
+		return nil, err
+	}
+	return DiffStructureVersions(from, to), nil
+}
+
+// DiffStructureVersions 计算两个版本间的字段与组件差异，组件按 ComponentID 对齐
+func DiffStructureVersions(from, to *models.SalaryStructureVersion) *StructureVersionDiff {
+	diff := &StructureVersionDiff{
+		StructureID:       to.StructureID,
+		FromVersion:       from.Version,
+		ToVersion:         to.Version,
+		FromEffectiveDate: from.EffectiveDate,
+		ToEffectiveDate:   to.EffectiveDate,
+		Fields:            []StructureFieldChange{},
+		Components:        []StructureComponentChange{},
+	}
+
+	addField := func(changes []StructureFieldChange, field, label string, a, b interface{}) []StructureFieldChange {
+		if a == b {
+			return changes
+		}
+		return append(changes, StructureFieldChange{Field: field, Label: label, From: a, To: b})
+	}
+	diff.Fields = addField(diff.Fields, "name", "结构名称", from.Name, to.Name)
+	diff.Fields = addField(diff.Fields, "description", "描述", from.Description, to.Description)
+	diff.Fields = addField(diff.Fields, "proration_method", "月中折算方式", string(from.ProrationMethod), string(to.ProrationMethod))
+	diff.Fields = addField(diff.Fields, "currency", "发薪币种", models.NormalizeCurrency(from.Currency), models.NormalizeCurrency(to.Currency))
+	diff.Fields = addField(diff.Fields, "salary_grade_id", "薪资等级", optionalID(from.SalaryGradeID), optionalID(to.SalaryGradeID))
+
+	previous := make(map[uint]models.SalaryStructureVersionComponent, len(from.Components))
+	for _, item := range from.Components {
+		previous[item.ComponentID] = item
+	}
+	current := make(map[uint]bool, len(to.Components))
+	for _, item := range to.Components {
+		current[item.ComponentID] = true
+		change := StructureComponentChange{ComponentID: item.ComponentID}
+		describeVersionComponent(&change, item)
+
+		old, ok := previous[item.ComponentID]
+		if !ok {
+			change.ChangeType = "added"
+			diff.Components = append(diff.Components, change)
+			continue
+		}
+		change.Fields = addField(change.Fields, "default_value", "默认值", old.DefaultValue, item.DefaultValue)
+		change.Fields = addField(change.Fields, "is_required", "是否必填", old.IsRequired, item.IsRequired)
+		change.Fields = addField(change.Fields, "can_edit", "是否可编辑", old.CanEdit, item.CanEdit)
+		change.Fields = addField(change.Fields, "sort", "排序", old.Sort, item.Sort)
+		if len(change.Fields) > 0 {
+			change.ChangeType = "modified"
+			diff.Components = append(diff.Components, change)
+		}
+	}
+	for _, item := range from.Components {
+		if current[item.ComponentID] {
+			continue
+		}
+		change := StructureComponentChange{ComponentID: item.ComponentID, ChangeType: "removed"}
+		describeVersionComponent(&change, item)
+		diff.Components = append(diff.Components, change)
+	}
+
+	sort.SliceStable(diff.Components, func(i, j int) bool {
+		return diff.Components[i].ComponentID < diff.Components[j].ComponentID
+	})
+	return diff
+}
+
+func describeVersionComponent(change *StructureComponentChange, item models.SalaryStructureVersionComponent) {
+	if item.Component != nil {
+		change.Code = item.Component.Code
+		change.Name = item.Component.Name
+	}
+}
+
+func optionalID(id *uint) interface{} {
+	if id == nil {
+		return nil
+	}
+	return *id
+}
+
+// ========================= Versioned Structure Persistence =========================
+
+// createVersionedStructure 创建薪资结构并生成第 1 版
+func createVersionedStructure(db *gorm.DB, structure *models.SalaryStructure) (*models.SalaryStructure, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(structure).Error; err != nil {
+			return err
+		}
+		return saveStructureVersion(tx, structure, versionEffectiveDate(structure), structure.ChangeNote)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return structure, nil
+}
+
+// updateVersionedStructure 更新薪资结构并生成新版本；已有周期的计算仍绑定其生效版本，不受本次修改影响
+func updateVersionedStructure(db *gorm.DB, id uint, structure *models.SalaryStructure) (*models.SalaryStructure, error) {
+	var existing models.SalaryStructure
+	if err := db.Preload("Components").First(&existing, id).Error; err != nil {
+		return nil, &utils.ValidationError{Message: "薪资结构不存在"}
+	}
+
+	structure.ID = id
+	structure.CreatedAt = existing.CreatedAt
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 早于版本化的结构先以修改前的内容补建基线版本
+		if _, err := ensureBaselineStructureVersion(tx, &existing); err != nil {
+			return err
+		}
+
+		components := structure.Components
+		structure.Components = nil
+		if err := tx.Save(structure).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("structure_id = ?", id).Delete(&models.SalaryStructureComponent{}).Error; err != nil {
+			return err
+		}
+		for i := range components {
+			components[i].ID = 0
+			components[i].StructureID = id
+		}
+		if len(components) > 0 {
+			if err := tx.Omit("Component", "Structure").Create(&components).Error; err != nil {
+				return err
+			}
+		}
+		structure.Components = components
+
+		return saveStructureVersion(tx, structure, versionEffectiveDate(structure), structure.ChangeNote)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return structure, nil
+}
+
+// versionEffectiveDate 新版本生效日期：请求指定优先，其次为结构生效日期（仅首版），默认当天
+func versionEffectiveDate(structure *models.SalaryStructure) time.Time {
+	if structure.VersionEffectiveDate != nil && !structure.VersionEffectiveDate.IsZero() {
+		return truncateDate(structure.VersionEffectiveDate.Time)
+	}
+	if structure.CreatedAt.IsZero() && structure.EffectiveDate != nil {
+		return truncateDate(*structure.EffectiveDate)
+	}
+	return truncateDate(time.Now())
+}
+
+// saveStructureVersion 以结构当前内容生成新版本并截止上一版本；
+// 生效日期与最新版本相同时覆盖该版本，但已被薪资计算引用的版本不允许覆盖
+func saveStructureVersion(tx *gorm.DB, structure *models.SalaryStructure, effective time.Time, note string) error {
+	version := snapshotStructure(structure, effective, note)
+
+	var latest models.SalaryStructureVersion
+	err := tx.Where("structure_id = ?", structure.ID).Order("version DESC").First(&latest).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		version.Version = 1
+	case err != nil:
+		return err
+	case effective.Before(latest.EffectiveDate):
+		return &utils.ValidationError{Message: fmt.Sprintf("新版本生效日期不能早于当前版本 v%d 的生效日期 %s",
+			latest.Version, latest.EffectiveDate.Format("2006-01-02"))}
+	case effective.Equal(latest.EffectiveDate):
+		var used int64
+		if err := tx.Model(&models.EnhancedSalary{}).Where("structure_version_id = ?", latest.ID).Count(&used).Error; err != nil {
+			return err
+		}
+		if used > 0 {
+			return utils.NewConflictError(fmt.Sprintf("版本 v%d 已被 %d 条薪资记录引用，请指定新的生效日期", latest.Version, used))
+		}
+		if err := tx.Where("version_id = ?", latest.ID).Delete(&models.SalaryStructureVersionComponent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&latest).Error; err != nil {
+			return err
+		}
+		version.Version = latest.Version
+	default:
+		expiry := effective.AddDate(0, 0, -1)
+		if err := tx.Model(&latest).Update("expiry_date", expiry).Error; err != nil {
+			return err
+		}
+		version.Version = latest.Version + 1
+	}
+
+	return tx.Omit("Components.Component").Create(&version).Error
+}
+
+// ensureBaselineStructureVersion 结构尚无版本时按当前内容补建第 1 版
+func ensureBaselineStructureVersion(db *gorm.DB, structure *models.SalaryStructure) (bool, error) {
+	var count int64
+	if err := db.Model(&models.SalaryStructureVersion{}).Where("structure_id = ?", structure.ID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	effective := structure.CreatedAt
+	if structure.EffectiveDate != nil {
+		effective = *structure.EffectiveDate
+	}
+	version := snapshotStructure(structure, truncateDate(effective), "初始版本")
+	version.Version = 1
+	if err := db.Omit("Components.Component").Create(&version).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func snapshotStructure(structure *models.SalaryStructure, effective time.Time, note string) models.SalaryStructureVersion {
+	version := models.SalaryStructureVersion{
+		StructureID:     structure.ID,
+		EffectiveDate:   effective,
+		Name:            structure.Name,
+		Description:     structure.Description,
+		SalaryGradeID:   structure.SalaryGradeID,
+		ProrationMethod: structure.ProrationMethod,
+		Currency:        models.NormalizeCurrency(structure.Currency),
+		ChangeNote:      note,
+		Components:      make([]models.SalaryStructureVersionComponent, len(structure.Components)),
+	}
+	for i, item := range structure.Components {
+		version.Components[i] = models.SalaryStructureVersionComponent{
+			ComponentID:  item.ComponentID,
+			DefaultValue: item.DefaultValue,
+			IsRequired:   item.IsRequired,
+			CanEdit:      item.CanEdit,
+			Sort:         item.Sort,
+		}
+	}
+	return version
+}
+
+// ========================= Period Binding =========================
+
+// structureForPeriod 取周期结束日有效的结构版本；周期早于首个版本时使用首版。
+// 返回按版本内容还原的结构（组件已加载）及版本ID，供计算器使用
+func structureForPeriod(db *gorm.DB, structure *models.SalaryStructure, period *models.PayrollPeriod) (*models.SalaryStructure, *uint, error) {
+	if structure.Components == nil {
+		if err := db.Preload("Components").First(structure, structure.ID).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+	if _, err := ensureBaselineStructureVersion(db, structure); err != nil {
+		return nil, nil, fmt.Errorf("failed to create baseline structure version: %w", err)
+	}
+
+	var version models.SalaryStructureVersion
+	err := db.Preload("Components.Component").
+		Where("structure_id = ? AND effective_date <= ?", structure.ID, truncateDate(period.EndDate)).
+		Order("effective_date DESC, version DESC").First(&version).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = db.Preload("Components.Component").
+			Where("structure_id = ?", structure.ID).Order("version ASC").First(&version).Error
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load structure version: %w", err)
+	}
+	return applyStructureVersion(structure, &version), &version.ID, nil
+}
+
+// structureForVersion 按指定版本还原结构，用于重算历史薪资
+func structureForVersion(db *gorm.DB, versionID uint) (*models.SalaryStructure, error) {
+	var version models.SalaryStructureVersion
+	if err := db.Preload("Structure").Preload("Components.Component").First(&version, versionID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load structure version: %w", err)
+	}
+	if version.Structure == nil {
+		return nil, fmt.Errorf("salary structure %d not found", version.StructureID)
+	}
+	return applyStructureVersion(version.Structure, &version), nil
+}
+
+func applyStructureVersion(structure *models.SalaryStructure, version *models.SalaryStructureVersion) *models.SalaryStructure {
+	resolved := *structure
+	resolved.Name = version.Name
+	resolved.Description = version.Description
+	resolved.SalaryGradeID = version.SalaryGradeID
+	resolved.ProrationMethod = version.ProrationMethod
+	if version.Currency != "" {
+		resolved.Currency = version.Currency
+	}
+	resolved.Components = make([]models.SalaryStructureComponent, len(version.Components))
+	for i, item := range version.Components {
+		resolved.Components[i] = models.SalaryStructureComponent{
+			StructureID:  structure.ID,
+			ComponentID:  item.ComponentID,
+			Component:    item.Component,
+			DefaultValue: item.DefaultValue,
+			IsRequired:   item.IsRequired,
+			CanEdit:      item.CanEdit,
+			Sort:         item.Sort,
+		}
+	}
+	return &resolved
+}
@@ -0,0 +1,359 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gin-project/models"
+
+	"gorm.io/gorm"
+)
+
+// ========================= Individual Income Tax =========================
+
+// IncomeTaxComponentCode 个人所得税组件编码，薪资计算时由系统按累计预扣法生成
+const IncomeTaxComponentCode = "IIT"
+
+// iitMonthlyThreshold 个人所得税基本减除费用（每月）
+const iitMonthlyThreshold = 5000.0
+
+type iitBracket struct {
+	limit          float64
+	rate           float64
+	quickDeduction float64
+}
+
+// iitCumulativeBrackets 居民个人工资、薪金所得预扣预缴率表（按累计应纳税所得额）
+var iitCumulativeBrackets = []iitBracket{
+	{36000, 0.03, 0},
+	{144000, 0.10, 2520},
+	{300000, 0.20, 16920},
+	{420000, 0.25, 31920},
+	{660000, 0.30, 52920},
+	{960000, 0.35, 85920},
+	{math.Inf(1), 0.45, 181920},
+}
+
+// iitMonthlyBrackets 按月换算后的综合所得税率表（全年一次性奖金单独计税适用）
+var iitMonthlyBrackets = []iitBracket{
+	{3000, 0.03, 0},
+	{12000, 0.10, 210},
+	{25000, 0.20, 1410},
+	{35000, 0.25, 2660},
+	{55000, 0.30, 4410},
+	{80000, 0.35, 7160},
+	{math.Inf(1), 0.45, 15160},
+}
+
+// CumulativeTaxInput 累计预扣法计算输入，金额均为本纳税年度截至当期（含当期）的累计值
+type CumulativeTaxInput struct {
+	Months               int     `json:"months"`                // 累计任职受雇月份数
+	Income               float64 `json:"income"`                // 累计收入
+	SpecialDeductions    float64 `json:"special_deductions"`    // 累计专项扣除（三险一金个人部分）
+	AdditionalDeductions float64 `json:"additional_deductions"` // 累计专项附加扣除
+	OtherDeductions      float64 `json:"other_deductions"`      // 累计依法确定的其他扣除
+	WithheldTax          float64 `json:"withheld_tax"`          // 累计已预扣预缴税额
+}
+
+// CumulativeTaxResult 累计预扣法计算结果
+type CumulativeTaxResult struct {
+	CumulativeThreshold     float64 `json:"cumulative_threshold"`      // 累计减除费用
+	CumulativeTaxableIncome float64 `json:"cumulative_taxable_income"` // 累计预扣预缴应纳税所得额
+	Rate                    float64 `json:"rate"`                      // 预扣率
+	QuickDeduction          float64 `json:"quick_deduction"`           // 速算扣除数
+	CumulativeTax           float64 `json:"cumulative_tax"`            // 累计应预扣预缴税额
+	WithheldTax             float64 `json:"withheld_tax"`              // 累计已预扣预缴税额
+	CurrentTax              float64 `json:"current_tax"`               // 本期应预扣预缴税额
+}
+
+// CalculateCumulativeTax 按累计预扣法计算本期应预扣预缴税额；
+// 累计应纳税额小于已预扣税额时本期不预扣，多缴部分在年度汇算时退还
+func CalculateCumulativeTax(input CumulativeTaxInput) CumulativeTaxResult {
+	result := CumulativeTaxResult{
+		CumulativeThreshold: iitMonthlyThreshold * float64(input.Months),
+		WithheldTax:         roundAmount(input.WithheldTax),
+	}
+
+	taxable := input.Income - result.CumulativeThreshold - input.SpecialDeductions -
+		input.AdditionalDeductions - input.OtherDeductions
+	if taxable <= 0 {
+		return result
+	}
+	result.CumulativeTaxableIncome = roundAmount(taxable)
+
+	for _, bracket := range iitCumulativeBrackets {
+		if result.CumulativeTaxableIncome <= bracket.limit {
+			result.Rate = bracket.rate
+			result.QuickDeduction = bracket.quickDeduction
+			break
+		}
+	}
+
+	result.CumulativeTax = roundAmount(result.CumulativeTaxableIncome*result.Rate - result.QuickDeduction)
+	if current := result.CumulativeTax - result.WithheldTax; current > 0 {
+		result.CurrentTax = roundAmount(current)
+	}
+	return result
+}
+
+// CalculateSeparateBonusTax 全年一次性奖金单独计税：以奖金除以12的商数查找月度税率表，
+// 应纳税额 = 奖金 × 税率 - 速算扣除数
+func CalculateSeparateBonusTax(bonus float64) (tax, rate, quickDeduction float64) {
+	if bonus <= 0 {
+		return 0, 0, 0
+	}
+	monthly := bonus / 12
+	for _, bracket := range iitMonthlyBrackets {
+		if monthly <= bracket.limit {
+			rate = bracket.rate
+			quickDeduction = bracket.quickDeduction
+			break
+		}
+	}
+	tax = roundAmount(bonus*rate - quickDeduction)
+	if tax < 0 {
+		tax = 0
+	}
+	return tax, rate, quickDeduction
+}
+
+// CalculateSeveranceTax 解除劳动合同一次性补偿收入超过免税额度的部分，不并入当年综合所得，
+// 单独适用综合所得税率表计税
+func CalculateSeveranceTax(taxable float64) (tax, rate, quickDeduction float64) {
+	if taxable <= 0 {
+		return 0, 0, 0
+	}
+	for _, bracket := range iitCumulativeBrackets {
+		if taxable <= bracket.limit {
+			rate = bracket.rate
+			quickDeduction = bracket.quickDeduction
+			break
+		}
+	}
+	return roundAmount(taxable*rate - quickDeduction), rate, quickDeduction
+}
+
+// taxableMonths 返回纳税年度内截至当期的任职受雇月份数，年中入职从入职当月起算
+func taxableMonths(hireDate *models.CustomDate, year int, month time.Month) int {
+	start := time.January
+	if hireDate != nil && !hireDate.IsZero() && hireDate.Year() == year && hireDate.Month() > start {
+		start = hireDate.Month()
+	}
+	if month < start {
+		return 1
+	}
+	return int(month-start) + 1
+}
+
+// incomeTaxComponent 获取系统个人所得税组件
+func incomeTaxComponent(db *gorm.DB) (*models.SalaryComponent, error) {
+	return ensureSystemComponent(db, models.SalaryComponent{
+		Code:        IncomeTaxComponentCode,
+		Name:        "个人所得税",
+		Category:    models.ComponentCategoryTax,
+		Sort:        9999,
+		Description: "按累计预扣法由系统自动计算",
+	})
+}
+
+func roundAmount(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// isTaxableIncome 判断薪资明细是否计入本期工资薪金收入
+func isTaxableIncome(component *models.SalaryComponent) bool {
+	switch component.Category {
+	case models.ComponentCategoryDeduction, models.ComponentCategoryTax, models.ComponentCategoryInsurance,
+		models.ComponentCategoryEmployerCost:
+		return false
+	}
+	return component.IsTaxable
+}
+
+// incomeTaxWithholder 根据同一纳税年度的 EnhancedSalary 历史按累计预扣法计算个税
+type incomeTaxWithholder struct {
+	db *gorm.DB
+}
+
+func newIncomeTaxWithholder(db *gorm.DB) *incomeTaxWithholder {
+	return &incomeTaxWithholder{db: db}
+}
+
+// taxYearToDate 本纳税年度此前已计算薪资的累计计税数据
+type taxYearToDate struct {
+	Income        models.Money
+	TaxDeductions models.Money
+	IncomeTax     models.Money
+}
+
+// yearToDate 汇总员工本纳税年度其他已计算薪资（开始日期不晚于当期）的计税数据，单独计税的奖金不参与累计
+func (w *incomeTaxWithholder) yearToDate(employeeID uint, period *models.PayrollPeriod) (*taxYearToDate, error) {
+	var ytd taxYearToDate
+	err := w.db.Model(&models.EnhancedSalary{}).
+		Select("COALESCE(SUM(enhanced_salaries.taxable_income), 0) AS income, "+
+			"COALESCE(SUM(enhanced_salaries.tax_deductions), 0) AS tax_deductions, "+
+			"COALESCE(SUM(enhanced_salaries.income_tax), 0) AS income_tax").
+		Joins("JOIN payroll_periods ON payroll_periods.id = enhanced_salaries.payroll_period_id").
+		Where("enhanced_salaries.employee_id = ? AND payroll_periods.year = ? AND payroll_periods.start_date <= ? AND payroll_periods.id <> ?",
+			employeeID, period.Year, period.StartDate, period.ID).
+		Where("enhanced_salaries.status NOT IN ?", []models.SalaryStatus{models.SalaryStatusCancelled, models.SalaryStatusRejected}).
+		Where("COALESCE(enhanced_salaries.bonus_tax_method, '') <> ?", models.BonusTaxSeparate).
+		Where("COALESCE(enhanced_salaries.currency, ?) = ?", models.DefaultCurrency, models.DefaultCurrency).
+		Scan(&ytd).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load year-to-date tax data: %w", err)
+	}
+	return &ytd, nil
+}
+
+// withhold 计算本期预扣个税，并将个税明细追加到计算结果中
+func (w *incomeTaxWithholder) withhold(employee *models.Employee, period *models.PayrollPeriod, calculation *structureCalculation) error {
+	for _, detail := range calculation.Details {
+		if detail.Component == nil {
+			continue
+		}
+		if isTaxableIncome(detail.Component) {
+			calculation.TaxableIncome = calculation.TaxableIncome.Add(detail.FinalValue)
+		}
+		if detail.Component.Category == models.ComponentCategoryInsurance {
+			calculation.TaxDeductions = calculation.TaxDeductions.Add(detail.FinalValue)
+		}
+	}
+
+	ytd, err := w.yearToDate(employee.ID, period)
+	if err != nil {
+		return err
+	}
+
+	month := period.StartDate.Month()
+	if period.Month != nil {
+		month = time.Month(*period.Month)
+	}
+
+	months := taxableMonths(employee.HireDate, period.Year, month)
+	additional, err := cumulativeSpecialDeductions(w.db, employee.ID, period.Year, int(month)-months+1, int(month))
+	if err != nil {
+		return err
+	}
+
+	input := CumulativeTaxInput{
+		Months:               months,
+		Income:               ytd.Income.Add(calculation.TaxableIncome).Float64(),
+		SpecialDeductions:    ytd.TaxDeductions.Add(calculation.TaxDeductions).Float64(),
+		AdditionalDeductions: additional.Float64(),
+		WithheldTax:          ytd.IncomeTax.Float64(),
+	}
+	result := CalculateCumulativeTax(input)
+	calculation.Tax = &result
+
+	component, err := incomeTaxComponent(w.db)
+	if err != nil {
+		return err
+	}
+
+	tax := componentAmount(component, result.CurrentTax)
+	calculation.IncomeTax = tax
+	calculation.addDetail(models.SalaryDetail{
+		ComponentID:     component.ID,
+		Component:       component,
+		CalculatedValue: tax,
+		FinalValue:      tax,
+		CalculationFormula: fmt.Sprintf(
+			"(%s - %s - %s - %s) × %s%% - %s = %s; %s - %s withheld = %s",
+			formatAmount(input.Income), formatAmount(result.CumulativeThreshold), formatAmount(input.SpecialDeductions),
+			formatAmount(input.AdditionalDeductions),
+			formatAmount(result.Rate*100), formatAmount(result.QuickDeduction), formatAmount(result.CumulativeTax),
+			formatAmount(result.CumulativeTax), formatAmount(result.WithheldTax), formatAmount(result.CurrentTax)),
+	})
+	return nil
+}
+
+// BonusTaxResult 全年一次性奖金计税结果
+type BonusTaxResult struct {
+	Bonus             float64               `json:"bonus"`
+	Method            models.BonusTaxMethod `json:"method"`             // 实际采用的计税方式
+	Tax               float64               `json:"tax"`                // 实际预扣税额
+	SeparateTax       *float64              `json:"separate_tax"`       // 单独计税税额（不可用或未试算时为空）
+	CombinedTax       *float64              `json:"combined_tax"`       // 并入综合所得的增量税额（未试算时为空）
+	SeparateAvailable bool                  `json:"separate_available"` // 本年度是否仍可选择单独计税
+	Trace             string                `json:"trace"`
+}
+
+// separateBonusUsed 单独计税每个纳税年度只能使用一次
+func (w *incomeTaxWithholder) separateBonusUsed(employeeID uint, year int) (bool, error) {
+	var count int64
+	err := w.db.Model(&models.EnhancedSalary{}).
+		Joins("JOIN payroll_periods ON payroll_periods.id = enhanced_salaries.payroll_period_id").
+		Where("enhanced_salaries.employee_id = ? AND payroll_periods.year = ? AND enhanced_salaries.bonus_tax_method = ?",
+			employeeID, year, models.BonusTaxSeparate).
+		Where("enhanced_salaries.status NOT IN ?", []models.SalaryStatus{models.SalaryStatusCancelled, models.SalaryStatusRejected}).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check separate bonus taxation: %w", err)
+	}
+	return count > 0, nil
+}
+
+// withholdBonus 按指定方式计算全年一次性奖金的预扣税额；
+// optimal 方式下两种方法都试算，税额相同时优先并入综合所得以保留单独计税机会
+func (w *incomeTaxWithholder) withholdBonus(employee *models.Employee, period *models.PayrollPeriod, bonus float64, method models.BonusTaxMethod) (*BonusTaxResult, error) {
+	used, err := w.separateBonusUsed(employee.ID, period.Year)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BonusTaxResult{Bonus: bonus, SeparateAvailable: !used}
+	if method == models.BonusTaxSeparate && used {
+		return nil, fmt.Errorf("employee %d has already used separate bonus taxation in %d", employee.ID, period.Year)
+	}
+
+	var traces []string
+	if result.SeparateAvailable && method != models.BonusTaxCombined {
+		tax, rate, quickDeduction := CalculateSeparateBonusTax(bonus)
+		result.SeparateTax = &tax
+		traces = append(traces, fmt.Sprintf("separate: %s × %s%% - %s = %s",
+			formatAmount(bonus), formatAmount(rate*100), formatAmount(quickDeduction), formatAmount(tax)))
+	}
+
+	if method != models.BonusTaxSeparate {
+		ytd, err := w.yearToDate(employee.ID, period)
+		if err != nil {
+			return nil, err
+		}
+
+		month := period.StartDate.Month()
+		if period.Month != nil {
+			month = time.Month(*period.Month)
+		}
+		months := taxableMonths(employee.HireDate, period.Year, month)
+		additional, err := cumulativeSpecialDeductions(w.db, employee.ID, period.Year, int(month)-months+1, int(month))
+		if err != nil {
+			return nil, err
+		}
+
+		cumulative := CalculateCumulativeTax(CumulativeTaxInput{
+			Months:               months,
+			Income:               ytd.Income.Float64() + bonus,
+			SpecialDeductions:    ytd.TaxDeductions.Float64(),
+			AdditionalDeductions: additional.Float64(),
+			WithheldTax:          ytd.IncomeTax.Float64(),
+		})
+		tax := cumulative.CurrentTax
+		result.CombinedTax = &tax
+		traces = append(traces, fmt.Sprintf("combined: %s - %s withheld = %s",
+			formatAmount(cumulative.CumulativeTax), formatAmount(cumulative.WithheldTax), formatAmount(tax)))
+	}
+
+	switch {
+	case result.SeparateTax != nil && (result.CombinedTax == nil || *result.SeparateTax < *result.CombinedTax):
+		result.Method = models.BonusTaxSeparate
+		result.Tax = *result.SeparateTax
+	default:
+		result.Method = models.BonusTaxCombined
+		result.Tax = *result.CombinedTax
+	}
+	result.Trace = strings.Join(traces, "; ") + "; applied " + string(result.Method)
+	return result, nil
+}
@@ -0,0 +1,366 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gin-project/models"
+	"gin-project/utils"
+
+	"gorm.io/gorm"
+)
+
+type SocialInsuranceServiceInterface interface {
+	GetPolicies(params PolicyQueryParams) (*utils.PaginationResponse, error)
+	GetPolicyByID(id uint) (*models.SocialInsurancePolicy, error)
+	CreatePolicy(policy *models.SocialInsurancePolicy) (*models.SocialInsurancePolicy, error)
+	UpdatePolicy(id uint, policy *models.SocialInsurancePolicy) (*models.SocialInsurancePolicy, error)
+	DeletePolicy(id uint) error
+	PreviewContributions(employeeID uint, date time.Time) (*ContributionPreview, error)
+}
+
+type SocialInsuranceService struct {
+	db *gorm.DB
+}
+
+type PolicyQueryParams struct {
+	CityCode string
+	Status   string
+	Page     int
+	PageSize int
+}
+
+// InsuranceContribution 单项社保公积金缴费
+type InsuranceContribution struct {
+	Code     string  `json:"code"`
+	Name     string  `json:"name"`
+	Base     float64 `json:"base"`
+	Rate     float64 `json:"rate"`
+	Amount   float64 `json:"amount"`
+	Employer bool    `json:"employer"`
+}
+
+// ContributionPreview 员工社保公积金缴费试算结果
+type ContributionPreview struct {
+	Policy        *models.SocialInsurancePolicy `json:"policy"`
+	Contributions []InsuranceContribution       `json:"contributions"`
+	EmployeeTotal float64                       `json:"employee_total"`
+	EmployerTotal float64                       `json:"employer_total"`
+}
+
+// 社保公积金系统组件编码
+const (
+	PensionEmployeeCode      = "SI_PENSION_EE"
+	PensionEmployerCode      = "SI_PENSION_ER"
+	MedicalEmployeeCode      = "SI_MEDICAL_EE"
+	MedicalEmployerCode      = "SI_MEDICAL_ER"
+	UnemploymentEmployeeCode = "SI_UNEMPLOYMENT_EE"
+	UnemploymentEmployerCode = "SI_UNEMPLOYMENT_ER"
+	InjuryEmployerCode       = "SI_INJURY_ER"
+	MaternityEmployerCode    = "SI_MATERNITY_ER"
+	HousingFundEmployeeCode  = "HF_EE"
+	HousingFundEmployerCode  = "HF_ER"
+)
+
+var insuranceComponentNames = map[string]string{
+	PensionEmployeeCode:      "养老保险(个人)",
+	PensionEmployerCode:      "养老保险(单位)",
+	MedicalEmployeeCode:      "医疗保险(个人)",
+	MedicalEmployerCode:      "医疗保险(单位)",
+	UnemploymentEmployeeCode: "失业保险(个人)",
+	UnemploymentEmployerCode: "失业保险(单位)",
+	InjuryEmployerCode:       "工伤保险(单位)",
+	MaternityEmployerCode:    "生育保险(单位)",
+	HousingFundEmployeeCode:  "住房公积金(个人)",
+	HousingFundEmployerCode:  "住房公积金(单位)",
+}
+
+func NewSocialInsuranceService(db *gorm.DB) SocialInsuranceServiceInterface {
+	return &SocialInsuranceService{
+		db: db,
+	}
+}
+
+func (s *SocialInsuranceService) GetPolicies(params PolicyQueryParams) (*utils.PaginationResponse, error) {
+	var policies []models.SocialInsurancePolicy
+	var total int64
+
+	query := s.db.Model(&models.SocialInsurancePolicy{})
+
+	if params.CityCode != "" {
+		query = query.Where("city_code = ?", params.CityCode)
+	}
+	if params.Status != "" {
+		query = query.Where("status = ?", params.Status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	offset := (params.Page - 1) * params.PageSize
+	if err := query.Offset(offset).Limit(params.PageSize).Order("city_code ASC, effective_date DESC").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+
+	response := utils.CreatePaginationResponse(policies, params.Page, params.PageSize, total)
+	return &response, nil
+}
+
+func (s *SocialInsuranceService) GetPolicyByID(id uint) (*models.SocialInsurancePolicy, error) {
+	var policy models.SocialInsurancePolicy
+	if err := s.db.First(&policy, id).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (s *SocialInsuranceService) CreatePolicy(policy *models.SocialInsurancePolicy) (*models.SocialInsurancePolicy, error) {
+	if policy.Status == "" {
+		policy.Status = "active"
+	}
+	if err := validatePolicy(policy); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(policy).Error; err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (s *SocialInsuranceService) UpdatePolicy(id uint, policy *models.SocialInsurancePolicy) (*models.SocialInsurancePolicy, error) {
+	existing, err := s.GetPolicyByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	policy.ID = id
+	policy.CreatedAt = existing.CreatedAt
+	if policy.Status == "" {
+		policy.Status = existing.Status
+	}
+	if err := validatePolicy(policy); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(policy).Error; err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (s *SocialInsuranceService) DeletePolicy(id uint) error {
+	return s.db.Delete(&models.SocialInsurancePolicy{}, id).Error
+}
+
+// PreviewContributions 按员工所在城市当日有效的政策试算社保公积金
+func (s *SocialInsuranceService) PreviewContributions(employeeID uint, date time.Time) (*ContributionPreview, error) {
+	var employee models.Employee
+	if err := s.db.First(&employee, employeeID).Error; err != nil {
+		return nil, errors.New("员工不存在")
+	}
+
+	policy, err := findInsurancePolicy(s.db, &employee, date)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, utils.NewValidationError("未找到适用的社保公积金政策")
+	}
+
+	preview := &ContributionPreview{
+		Policy:        policy,
+		Contributions: CalculateContributions(policy, employee.BaseSalary.Float64()),
+	}
+	for _, contribution := range preview.Contributions {
+		if contribution.Employer {
+			preview.EmployerTotal += contribution.Amount
+		} else {
+			preview.EmployeeTotal += contribution.Amount
+		}
+	}
+	preview.EmployeeTotal = roundAmount(preview.EmployeeTotal)
+	preview.EmployerTotal = roundAmount(preview.EmployerTotal)
+	return preview, nil
+}
+
+func validatePolicy(policy *models.SocialInsurancePolicy) error {
+	if policy.CityCode == "" {
+		return utils.NewValidationError("城市编码不能为空")
+	}
+	if policy.EffectiveDate.IsZero() {
+		return utils.NewValidationError("生效日期不能为空")
+	}
+	if policy.ExpiryDate != nil && policy.ExpiryDate.Before(policy.EffectiveDate) {
+		return utils.NewValidationError("失效日期不能早于生效日期")
+	}
+	if policy.BaseCeiling > 0 && policy.BaseCeiling < policy.BaseFloor {
+		return utils.NewValidationError("社保缴费基数上限不能低于下限")
+	}
+	if policy.HousingFundBaseCeiling > 0 && policy.HousingFundBaseCeiling < policy.HousingFundBaseFloor {
+		return utils.NewValidationError("公积金缴存基数上限不能低于下限")
+	}
+	if policy.AverageMonthlyWage < 0 {
+		return utils.NewValidationError("职工月平均工资不能为负数")
+	}
+
+	rates := []float64{
+		policy.PensionEmployeeRate, policy.PensionEmployerRate,
+		policy.MedicalEmployeeRate, policy.MedicalEmployerRate,
+		policy.UnemploymentEmployeeRate, policy.UnemploymentEmployerRate,
+		policy.InjuryEmployerRate, policy.MaternityEmployerRate,
+		policy.HousingFundEmployeeRate, policy.HousingFundEmployerRate,
+	}
+	for _, rate := range rates {
+		if rate < 0 || rate >= 1 {
+			return utils.NewValidationError("缴费比例必须在0到1之间")
+		}
+	}
+	return nil
+}
+
+// CalculateContributions 按政策计算各项社保公积金缴费，缴费基数按政策上下限调整
+func CalculateContributions(policy *models.SocialInsurancePolicy, salaryBase float64) []InsuranceContribution {
+	base := policy.ClampBase(salaryBase)
+	fundBase := policy.ClampHousingFundBase(salaryBase)
+
+	items := []struct {
+		code     string
+		base     float64
+		rate     float64
+		employer bool
+	}{
+		{PensionEmployeeCode, base, policy.PensionEmployeeRate, false},
+		{MedicalEmployeeCode, base, policy.MedicalEmployeeRate, false},
+		{UnemploymentEmployeeCode, base, policy.UnemploymentEmployeeRate, false},
+		{HousingFundEmployeeCode, fundBase, policy.HousingFundEmployeeRate, false},
+		{PensionEmployerCode, base, policy.PensionEmployerRate, true},
+		{MedicalEmployerCode, base, policy.MedicalEmployerRate, true},
+		{UnemploymentEmployerCode, base, policy.UnemploymentEmployerRate, true},
+		{InjuryEmployerCode, base, policy.InjuryEmployerRate, true},
+		{MaternityEmployerCode, base, policy.MaternityEmployerRate, true},
+		{HousingFundEmployerCode, fundBase, policy.HousingFundEmployerRate, true},
+	}
+
+	contributions := make([]InsuranceContribution, 0, len(items))
+	for _, item := range items {
+		if item.rate <= 0 {
+			continue
+		}
+		contributions = append(contributions, InsuranceContribution{
+			Code:     item.code,
+			Name:     insuranceComponentNames[item.code],
+			Base:     item.base,
+			Rate:     item.rate,
+			Amount:   roundAmount(item.base * item.rate),
+			Employer: item.employer,
+		})
+	}
+	return contributions
+}
+
+// employeeCityCode 沿员工所属部门向上查找地理位置类型的部门，以其编码作为参保城市
+func employeeCityCode(db *gorm.DB, employee *models.Employee) (string, error) {
+	departmentID := employee.DepartmentID
+	for depth := 0; departmentID > 0 && depth < 20; depth++ {
+		var department models.Department
+		if err := db.Select("id", "code", "type", "parent_id").First(&department, departmentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return "", nil
+			}
+			return "", err
+		}
+		if department.Type == models.LocationDept {
+			return department.Code, nil
+		}
+		if department.ParentID == nil {
+			break
+		}
+		departmentID = *department.ParentID
+	}
+	return "", nil
+}
+
+// findInsurancePolicy 查找员工在指定日期适用的社保公积金政策，找不到城市政策时使用默认政策
+func findInsurancePolicy(db *gorm.DB, employee *models.Employee, date time.Time) (*models.SocialInsurancePolicy, error) {
+	cityCode, err := employeeCityCode(db, employee)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve employee city: %w", err)
+	}
+
+	query := func() *gorm.DB {
+		return db.Where("status = ? AND effective_date <= ? AND (expiry_date IS NULL OR expiry_date >= ?)", "active", date, date).
+			Order("effective_date DESC")
+	}
+
+	var policy models.SocialInsurancePolicy
+	if cityCode != "" {
+		err := query().Where("city_code = ?", cityCode).First(&policy).Error
+		if err == nil {
+			return &policy, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	err = query().Where("is_default = ?", true).First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// insuranceContributor 在薪资计算中按城市政策生成社保公积金明细
+type insuranceContributor struct {
+	db *gorm.DB
+}
+
+func newInsuranceContributor(db *gorm.DB) *insuranceContributor {
+	return &insuranceContributor{db: db}
+}
+
+// contribute 追加个人缴费（扣款）与单位缴费（企业成本）明细；没有适用政策时不做处理
+func (ic *insuranceContributor) contribute(employee *models.Employee, period *models.PayrollPeriod, calculation *structureCalculation) error {
+	policy, err := findInsurancePolicy(ic.db, employee, period.StartDate)
+	if err != nil {
+		return err
+	}
+	if policy == nil {
+		return nil
+	}
+
+	for _, contribution := range CalculateContributions(policy, employee.BaseSalary.Float64()) {
+		category := models.ComponentCategoryInsurance
+		if contribution.Employer {
+			category = models.ComponentCategoryEmployerCost
+		}
+
+		component, err := ensureSystemComponent(ic.db, models.SalaryComponent{
+			Code:        contribution.Code,
+			Name:        contribution.Name,
+			Category:    category,
+			Sort:        9000,
+			Description: "按城市社保公积金政策由系统自动计算",
+		})
+		if err != nil {
+			return err
+		}
+
+		amount := componentAmount(component, contribution.Amount)
+		calculation.addDetail(models.SalaryDetail{
+			ComponentID:     component.ID,
+			Component:       component,
+			CalculatedValue: amount,
+			FinalValue:      amount,
+			CalculationFormula: fmt.Sprintf("%s: %s × %s%% = %s",
+				policy.CityCode, formatAmount(contribution.Base), formatAmount(contribution.Rate*100), formatAmount(contribution.Amount)),
+		})
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"fmt"
+
+	"gin-project/models"
+	"gin-project/utils"
+
+	"gorm.io/gorm"
+)
+
+type TaxDeductionServiceInterface interface {
+	GetDeductions(params DeductionQueryParams) (*utils.PaginationResponse, error)
+	GetDeductionByID(id uint) (*models.SpecialDeduction, error)
+	CreateDeduction(deduction *models.SpecialDeduction) (*models.SpecialDeduction, error)
+	UpdateDeduction(id uint, deduction *models.SpecialDeduction) (*models.SpecialDeduction, error)
+	DeleteDeduction(id uint) error
+	RollOverDeductions(fromYear int) (*DeductionRollOverResult, error)
+}
+
+type TaxDeductionService struct {
+	db *gorm.DB
+}
+
+type DeductionQueryParams struct {
+	EmployeeID uint
+	TaxYear    int
+	Type       string
+	Status     string
+	Page       int
+	PageSize   int
+}
+
+// DeductionRollOverResult 年度结转结果
+type DeductionRollOverResult struct {
+	FromYear int `json:"from_year"`
+	ToYear   int `json:"to_year"`
+	Created  int `json:"created"`
+	Skipped  int `json:"skipped"`
+}
+
+func NewTaxDeductionService(db *gorm.DB) TaxDeductionServiceInterface {
+	return &TaxDeductionService{
+		db: db,
+	}
+}
+
+func (s *TaxDeductionService) GetDeductions(params DeductionQueryParams) (*utils.PaginationResponse, error) {
+	var deductions []models.SpecialDeduction
+	var total int64
+
+	query := s.db.Model(&models.SpecialDeduction{}).Preload("Employee")
+
+	if params.EmployeeID > 0 {
+		query = query.Where("employee_id = ?", params.EmployeeID)
+	}
+	if params.TaxYear > 0 {
+		query = query.Where("tax_year = ?", params.TaxYear)
+	}
+	if params.Type != "" {
+		query = query.Where("type = ?", params.Type)
+	}
+	if params.Status != "" {
+		query = query.Where("status = ?", params.Status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	offset := (params.Page - 1) * params.PageSize
+	if err := query.Offset(offset).Limit(params.PageSize).Order("tax_year DESC, employee_id ASC, type ASC").Find(&deductions).Error; err != nil {
+		return nil, err
+	}
+
+	response := utils.CreatePaginationResponse(deductions, params.Page, params.PageSize, total)
+	return &response, nil
+}
+
+func (s *TaxDeductionService) GetDeductionByID(id uint) (*models.SpecialDeduction, error) {
+	var deduction models.SpecialDeduction
+	if err := s.db.Preload("Employee").First(&deduction, id).Error; err != nil {
+		return nil, err
+	}
+	return &deduction, nil
+}
+
+func (s *TaxDeductionService) CreateDeduction(deduction *models.SpecialDeduction) (*models.SpecialDeduction, error) {
+	if deduction.Status == "" {
+		deduction.Status = "active"
+	}
+	if err := s.validateDeduction(deduction); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(deduction).Error; err != nil {
+		return nil, err
+	}
+	return s.GetDeductionByID(deduction.ID)
+}
+
+func (s *TaxDeductionService) UpdateDeduction(id uint, deduction *models.SpecialDeduction) (*models.SpecialDeduction, error) {
+	existing, err := s.GetDeductionByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	deduction.ID = id
+	deduction.CreatedAt = existing.CreatedAt
+	deduction.Employee = nil
+	if deduction.Status == "" {
+		deduction.Status = existing.Status
+	}
+	if err := s.validateDeduction(deduction); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(deduction).Error; err != nil {
+		return nil, err
+	}
+	return s.GetDeductionByID(id)
+}
+
+func (s *TaxDeductionService) DeleteDeduction(id uint) error {
+	return s.db.Delete(&models.SpecialDeduction{}, id).Error
+}
+
+// RollOverDeductions 将上一年度持续到年末的有效申报结转到下一年度（1-12月），已结转的申报不会重复结转
+func (s *TaxDeductionService) RollOverDeductions(fromYear int) (*DeductionRollOverResult, error) {
+	if fromYear <= 0 {
+		return nil, utils.NewValidationError("纳税年度无效")
+	}
+
+	result := &DeductionRollOverResult{FromYear: fromYear, ToYear: fromYear + 1}
+
+	var deductions []models.SpecialDeduction
+	if err := s.db.Where("tax_year = ? AND end_month = ? AND status = ?", fromYear, 12, "active").
+		Find(&deductions).Error; err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, deduction := range deductions {
+			var count int64
+			if err := tx.Model(&models.SpecialDeduction{}).
+				Where("rolled_over_from = ? AND tax_year = ?", deduction.ID, result.ToYear).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				result.Skipped++
+				continue
+			}
+
+			next := RollOverDeduction(deduction, result.ToYear)
+			if err := tx.Create(&next).Error; err != nil {
+				return err
+			}
+			result.Created++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RollOverDeduction 生成结转到指定年度的申报，全年有效，金额与扣除对象沿用原申报
+func RollOverDeduction(source models.SpecialDeduction, toYear int) models.SpecialDeduction {
+	sourceID := source.ID
+	return models.SpecialDeduction{
+		EmployeeID:     source.EmployeeID,
+		Type:           source.Type,
+		TaxYear:        toYear,
+		StartMonth:     1,
+		EndMonth:       12,
+		MonthlyAmount:  source.MonthlyAmount,
+		Beneficiary:    source.Beneficiary,
+		Status:         "active",
+		RolledOverFrom: &sourceID,
+		Notes:          source.Notes,
+	}
+}
+
+func (s *TaxDeductionService) validateDeduction(deduction *models.SpecialDeduction) error {
+	if deduction.EmployeeID == 0 {
+		return utils.NewValidationError("员工ID不能为空")
+	}
+	limit := deduction.Type.MonthlyLimit()
+	if limit == 0 {
+		return utils.NewValidationError("不支持的专项附加扣除类型")
+	}
+	if deduction.TaxYear <= 0 {
+		return utils.NewValidationError("纳税年度不能为空")
+	}
+	if deduction.StartMonth < 1 || deduction.EndMonth > 12 || deduction.StartMonth > deduction.EndMonth {
+		return utils.NewValidationError("扣除月份范围无效")
+	}
+	if deduction.MonthlyAmount.Sign() <= 0 {
+		return utils.NewValidationError("扣除金额必须大于0")
+	}
+	if deduction.MonthlyAmount.Cmp(models.NewMoney(limit)) > 0 {
+		return utils.NewValidationError(fmt.Sprintf("扣除金额超过该类型每月上限 %.2f", limit))
+	}
+
+	// 住房贷款利息与住房租金不能在同一月份同时扣除
+	var conflicting models.SpecialDeductionType
+	switch deduction.Type {
+	case models.DeductionHousingLoanInterest:
+		conflicting = models.DeductionHousingRent
+	case models.DeductionHousingRent:
+		conflicting = models.DeductionHousingLoanInterest
+	default:
+		return nil
+	}
+
+	var count int64
+	if err := s.db.Model(&models.SpecialDeduction{}).
+		Where("employee_id = ? AND tax_year = ? AND type = ? AND status = ? AND id != ?",
+			deduction.EmployeeID, deduction.TaxYear, conflicting, "active", deduction.ID).
+		Where("start_month <= ? AND end_month >= ?", deduction.EndMonth, deduction.StartMonth).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return utils.NewValidationError("住房贷款利息和住房租金不能在同一月份同时扣除")
+	}
+	return nil
+}
+
+// cumulativeSpecialDeductions 计算纳税年度内 [fromMonth, toMonth] 累计可预扣的专项附加扣除
+func cumulativeSpecialDeductions(db *gorm.DB, employeeID uint, year, fromMonth, toMonth int) (models.Money, error) {
+	var deductions []models.SpecialDeduction
+	if err := db.Where("employee_id = ? AND tax_year = ? AND status = ?", employeeID, year, "active").
+		Find(&deductions).Error; err != nil {
+		return models.Money{}, fmt.Errorf("failed to load special deductions: %w", err)
+	}
+	return SumSpecialDeductions(deductions, fromMonth, toMonth), nil
+}
+
+// SumSpecialDeductions 按申报的起止月份累计 [fromMonth, toMonth] 内可预扣的专项附加扣除，大病医疗不参与月度预扣
+func SumSpecialDeductions(deductions []models.SpecialDeduction, fromMonth, toMonth int) models.Money {
+	var total models.Money
+	for _, deduction := range deductions {
+		if !deduction.Type.WithheldMonthly() {
+			continue
+		}
+		start, end := deduction.StartMonth, deduction.EndMonth
+		if start < fromMonth {
+			start = fromMonth
+		}
+		if end > toMonth {
+			end = toMonth
+		}
+		if end >= start {
+			total = total.Add(deduction.MonthlyAmount.Mul(float64(end-start+1), models.RoundHalfUp))
+		}
+	}
+	return total
+}
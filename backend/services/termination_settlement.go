@@ -0,0 +1,658 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gin-project/models"
+	"gin-project/utils"
+
+	"gorm.io/gorm"
+)
+
+// ========================= Termination Settlement =========================
+
+// 离职结算使用的系统组件编码
+const (
+	SeveranceComponentCode    = "SEVERANCE"     // 经济补偿金/赔偿金（免税额度内不计税，超出部分单独计税）
+	NoticePayComponentCode    = "NOTICE_PAY"    // 代通知金
+	LeavePayoutComponentCode  = "LEAVE_PAYOUT"  // 未休年假工资报酬
+	SeveranceTaxComponentCode = "SEVERANCE_IIT" // 经济补偿金单独计税的个人所得税
+)
+
+var settlementComponentCodes = map[string]bool{
+	SeveranceComponentCode:    true,
+	NoticePayComponentCode:    true,
+	LeavePayoutComponentCode:  true,
+	SeveranceTaxComponentCode: true,
+}
+
+const (
+	// leavePayoutRate 应休未休年假按日工资的 300% 支付，其中 100% 已随正常工资发放
+	leavePayoutRate = 2.0
+	// compensationWageCap 月工资高于当地上年度职工月平均工资 3 倍的，按 3 倍计算且补偿年限最高 12 年
+	compensationWageCap   = 3.0
+	compensationCapMonths = 12.0
+	// severanceExemptMultiple 一次性补偿收入免税额度为当地上年职工平均工资的 3 倍（按年）
+	severanceExemptMultiple = 36.0
+)
+
+type TerminationSettlementServiceInterface interface {
+	PreviewSettlement(req SettlementRequest) (*models.TerminationSettlement, error)
+	CreateSettlement(req SettlementRequest, userID uint) (*models.TerminationSettlement, error)
+	GetSettlements(params SettlementQueryParams) ([]models.TerminationSettlement, error)
+	GetSettlement(id uint) (*models.TerminationSettlement, error)
+	ApproveSettlement(id, userID uint, notes string) (*models.TerminationSettlement, error)
+	CancelSettlement(id, userID uint, reason string) (*models.TerminationSettlement, error)
+	IssueSettlement(id uint, req SettlementIssueRequest, userID uint) (*models.TerminationSettlement, error)
+
+	// 年假额度
+	GetLeaveBalances(employeeID uint, year int) ([]models.LeaveBalance, error)
+	SaveLeaveBalance(balance *models.LeaveBalance) (*models.LeaveBalance, error)
+}
+
+type TerminationSettlementService struct {
+	db *gorm.DB
+}
+
+func NewTerminationSettlementService(db *gorm.DB) TerminationSettlementServiceInterface {
+	return &TerminationSettlementService{db: db}
+}
+
+// SettlementRequest 离职结算计算请求，未指定离职日期时取员工档案中的离职日期
+type SettlementRequest struct {
+	EmployeeID      uint                  `json:"employee_id" binding:"required"`
+	Type            models.SettlementType `json:"type" binding:"required"`
+	TerminationDate *models.CustomDate    `json:"termination_date"`
+	Notes           string                `json:"notes"`
+}
+
+// SettlementIssueRequest 生成非周期发放的参数，未指定父周期时使用离职日期所在的常规周期
+type SettlementIssueRequest struct {
+	ParentPeriodID *uint              `json:"parent_period_id"`
+	PayDate        *models.CustomDate `json:"pay_date"`
+}
+
+// SettlementQueryParams 离职结算查询条件
+type SettlementQueryParams struct {
+	EmployeeID uint
+	Status     string
+}
+
+// SettlementInput 离职结算计算输入
+type SettlementInput struct {
+	Type                models.SettlementType
+	HireDate            time.Time
+	TerminationDate     time.Time
+	MonthlyWages        []models.Money // 离职前各月应发工资，由近及远排列，超过 12 个月的部分不参与计算
+	LocalAverageWage    models.Money   // 当地上年度职工月平均工资
+	AnnualLeaveEntitled float64        // 当年全年应休年假天数
+	CarriedOverLeave    float64        // 上年结转的年假天数
+	AnnualLeaveUsed     float64        // 当年已休年假天数
+}
+
+// CalculateSettlement 计算离职结算：
+// 经济补偿按工作年限每满一年支付一个月工资，六个月以上不满一年按一年、不满六个月支付半个月；
+// 月工资为离职前十二个月平均工资，高于当地职工月平均工资三倍的按三倍计算且年限最高十二年；
+// 违法解除按经济补偿的二倍支付赔偿金，未提前通知解除的另支付一个月上月工资作为代通知金；
+// 未休年假按当年已工作时间折算，不足一天的部分不支付
+func CalculateSettlement(input SettlementInput) (*models.TerminationSettlement, error) {
+	if !input.Type.IsValid() {
+		return nil, &utils.ValidationError{Message: fmt.Sprintf("不支持的补偿方式: %s", input.Type)}
+	}
+	hire, termination := truncateDate(input.HireDate), truncateDate(input.TerminationDate)
+	if termination.Before(hire) {
+		return nil, &utils.ValidationError{Message: "离职日期不能早于入职日期"}
+	}
+	if input.LocalAverageWage.Sign() <= 0 {
+		return nil, &utils.ValidationError{Message: "未配置当地上年度职工月平均工资，无法计算经济补偿封顶与免税额度"}
+	}
+	wages := input.MonthlyWages
+	if len(wages) == 0 {
+		return nil, &utils.ValidationError{Message: "缺少离职前的薪资记录，无法计算月平均工资"}
+	}
+	if len(wages) > 12 {
+		wages = wages[:12]
+	}
+
+	settlement := &models.TerminationSettlement{
+		Type:             input.Type,
+		HireDate:         hire,
+		TerminationDate:  termination,
+		WageMonths:       len(wages),
+		LocalAverageWage: input.LocalAverageWage,
+		LastMonthWage:    wages[0],
+	}
+	var trace []string
+
+	// 经济补偿
+	settlement.ServiceMonths, settlement.CompensationMonths = compensationMonths(hire, termination)
+	settlement.AverageMonthlyWage = models.SumMoney(wages...).Mul(1/float64(len(wages)), models.RoundHalfUp)
+	settlement.CompensationBase = settlement.AverageMonthlyWage
+	trace = append(trace, fmt.Sprintf("service %d months => N = %s; average wage of %d months = %s",
+		settlement.ServiceMonths, formatAmount(settlement.CompensationMonths), len(wages), settlement.AverageMonthlyWage))
+
+	if wageCap := input.LocalAverageWage.Mul(compensationWageCap, models.RoundHalfUp); settlement.AverageMonthlyWage.Cmp(wageCap) > 0 {
+		settlement.WageCapped = true
+		settlement.CompensationBase = wageCap
+		settlement.CompensationMonths = math.Min(settlement.CompensationMonths, compensationCapMonths)
+		trace = append(trace, fmt.Sprintf("capped at 3 × %s = %s, N = %s",
+			input.LocalAverageWage, wageCap, formatAmount(settlement.CompensationMonths)))
+	}
+
+	settlement.Compensation = settlement.CompensationBase.Mul(settlement.CompensationMonths, models.RoundHalfUp)
+	if input.Type == models.SettlementDoubleN {
+		settlement.Compensation = settlement.Compensation.Mul(2, models.RoundHalfUp)
+		trace = append(trace, fmt.Sprintf("damages = 2 × %s × %s = %s",
+			settlement.CompensationBase, formatAmount(settlement.CompensationMonths), settlement.Compensation))
+	} else {
+		trace = append(trace, fmt.Sprintf("compensation = %s × %s = %s",
+			settlement.CompensationBase, formatAmount(settlement.CompensationMonths), settlement.Compensation))
+	}
+
+	// 代通知金
+	if input.Type == models.SettlementNPlusOne {
+		settlement.NoticePay = settlement.LastMonthWage
+		trace = append(trace, fmt.Sprintf("notice pay = last month wage %s", settlement.NoticePay))
+	}
+
+	// 未休年假
+	settlement.AnnualLeaveEntitled = proratedAnnualLeave(hire, termination, input.AnnualLeaveEntitled) + input.CarriedOverLeave
+	settlement.AnnualLeaveUsed = input.AnnualLeaveUsed
+	settlement.UnusedLeaveDays = math.Max(settlement.AnnualLeaveEntitled-settlement.AnnualLeaveUsed, 0)
+	settlement.DailyWage = settlement.AverageMonthlyWage.Mul(1/monthlyPayDays, models.RoundHalfUp)
+	settlement.LeavePayout = settlement.DailyWage.Mul(settlement.UnusedLeaveDays*leavePayoutRate, models.RoundHalfUp)
+	trace = append(trace, fmt.Sprintf("leave %s entitled - %s used = %s days × %s × 200%% = %s",
+		formatAmount(settlement.AnnualLeaveEntitled), formatAmount(settlement.AnnualLeaveUsed),
+		formatAmount(settlement.UnusedLeaveDays), settlement.DailyWage, settlement.LeavePayout))
+
+	// 经济补偿个税
+	settlement.TaxExemptLimit = input.LocalAverageWage.Mul(severanceExemptMultiple, models.RoundHalfUp)
+	if excess := settlement.Compensation.Sub(settlement.TaxExemptLimit); excess.Sign() > 0 {
+		settlement.TaxableCompensation = excess
+		tax, rate, quickDeduction := CalculateSeveranceTax(excess.Float64())
+		settlement.CompensationTax = models.NewMoney(tax)
+		trace = append(trace, fmt.Sprintf("severance tax = (%s - %s) × %s%% - %s = %s",
+			settlement.Compensation, settlement.TaxExemptLimit, formatAmount(rate*100),
+			formatAmount(quickDeduction), settlement.CompensationTax))
+	}
+
+	settlement.TotalAmount = models.SumMoney(settlement.Compensation, settlement.NoticePay, settlement.LeavePayout)
+	settlement.Trace = strings.Join(trace, "; ")
+	return settlement, nil
+}
+
+// compensationMonths 返回工作满月数及经济补偿月数，离职日期为最后工作日
+func compensationMonths(hire, termination time.Time) (int, float64) {
+	end := termination.AddDate(0, 0, 1)
+	months := 0
+	for !hire.AddDate(0, months+1, 0).After(end) {
+		months++
+	}
+
+	n := float64(months / 12)
+	switch rest := months % 12; {
+	case rest >= 6:
+		n++
+	case rest > 0 || hire.AddDate(0, months, 0).Before(end):
+		n += 0.5
+	}
+	return months, n
+}
+
+// proratedAnnualLeave 按当年度在本单位已过日历天数 ÷ 365 × 全年应休天数折算，不足一整天的部分不计
+func proratedAnnualLeave(hire, termination time.Time, entitled float64) float64 {
+	if entitled <= 0 {
+		return 0
+	}
+	from := time.Date(termination.Year(), time.January, 1, 0, 0, 0, 0, termination.Location())
+	if hire.After(from) {
+		from = hire
+	}
+	days := math.Round(termination.Sub(from).Hours()/24) + 1
+	return math.Min(math.Floor(days/365*entitled), entitled)
+}
+
+// PreviewSettlement 试算离职结算，不保存
+func (s *TerminationSettlementService) PreviewSettlement(req SettlementRequest) (*models.TerminationSettlement, error) {
+	return s.buildSettlement(req)
+}
+
+// CreateSettlement 计算并保存离职结算草稿，同一员工只能有一张未取消的结算单
+func (s *TerminationSettlementService) CreateSettlement(req SettlementRequest, userID uint) (*models.TerminationSettlement, error) {
+	if err := validateSettlementOperator(userID); err != nil {
+		return nil, err
+	}
+	settlement, err := s.buildSettlement(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var active int64
+	if err := s.db.Model(&models.TerminationSettlement{}).
+		Where("employee_id = ? AND status <> ?", req.EmployeeID, models.SettlementCancelled).
+		Count(&active).Error; err != nil {
+		return nil, err
+	}
+	if active > 0 {
+		return nil, utils.NewConflictError("该员工已有离职结算单，如需重新计算请先取消原结算单")
+	}
+
+	settlement.Notes = req.Notes
+	settlement.Status = models.SettlementDraft
+	settlement.CreatedBy = userID
+	if err := s.db.Create(settlement).Error; err != nil {
+		return nil, err
+	}
+	return settlement, nil
+}
+
+// buildSettlement 读取员工的入职日期、离职前薪资记录、当地平均工资与年假额度并计算结算金额
+func (s *TerminationSettlementService) buildSettlement(req SettlementRequest) (*models.TerminationSettlement, error) {
+	var employee models.Employee
+	if err := s.db.First(&employee, req.EmployeeID).Error; err != nil {
+		return nil, &utils.ValidationError{Message: "员工不存在"}
+	}
+	if employee.HireDate == nil || employee.HireDate.IsZero() {
+		return nil, &utils.ValidationError{Message: "员工未登记入职日期"}
+	}
+
+	var termination time.Time
+	switch {
+	case req.TerminationDate != nil && !req.TerminationDate.IsZero():
+		termination = req.TerminationDate.Time
+	case employee.TerminationDate != nil && !employee.TerminationDate.IsZero():
+		termination = employee.TerminationDate.Time
+	default:
+		return nil, &utils.ValidationError{Message: "请指定离职日期"}
+	}
+	termination = truncateDate(termination)
+
+	wages, err := s.recentMonthlyWages(employee.ID, termination)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := findInsurancePolicy(s.db, &employee, termination)
+	if err != nil {
+		return nil, err
+	}
+	input := SettlementInput{
+		Type:            req.Type,
+		HireDate:        employee.HireDate.Time,
+		TerminationDate: termination,
+		MonthlyWages:    wages,
+	}
+	if policy != nil {
+		input.LocalAverageWage = models.NewMoney(policy.AverageMonthlyWage)
+	}
+
+	var balance models.LeaveBalance
+	err = s.db.Where("employee_id = ? AND year = ? AND leave_type = ?", employee.ID, termination.Year(), models.LeaveTypeAnnual).
+		First(&balance).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	input.AnnualLeaveEntitled, input.CarriedOverLeave = balance.EntitledDays, balance.CarriedOverDays
+
+	yearStart := time.Date(termination.Year(), time.January, 1, 0, 0, 0, 0, termination.Location())
+	if err := s.db.Model(&models.Leave{}).
+		Select("COALESCE(SUM(days), 0)").
+		Where("employee_id = ? AND type = ? AND status = ? AND start_date >= ? AND start_date < ?",
+			employee.ID, models.LeaveTypeAnnual, "approved", yearStart, termination.AddDate(0, 0, 1)).
+		Scan(&input.AnnualLeaveUsed).Error; err != nil {
+		return nil, err
+	}
+
+	settlement, err := CalculateSettlement(input)
+	if err != nil {
+		return nil, err
+	}
+	settlement.EmployeeID = employee.ID
+	settlement.Notes = req.Notes
+	return settlement, nil
+}
+
+// recentMonthlyWages 离职前最近 12 条常规周期人民币薪资的应发金额，由近及远排列
+func (s *TerminationSettlementService) recentMonthlyWages(employeeID uint, termination time.Time) ([]models.Money, error) {
+	var salaries []models.EnhancedSalary
+	if err := s.db.Joins("JOIN payroll_periods ON payroll_periods.id = enhanced_salaries.payroll_period_id").
+		Where("enhanced_salaries.employee_id = ? AND payroll_periods.period_type NOT IN ? AND payroll_periods.start_date <= ?",
+			employeeID, models.SupplementalPeriodTypes, termination).
+		Where("enhanced_salaries.status NOT IN ?", []models.SalaryStatus{models.SalaryStatusCancelled, models.SalaryStatusRejected}).
+		Where("COALESCE(enhanced_salaries.currency, ?) = ?", models.DefaultCurrency, models.DefaultCurrency).
+		Preload("PayrollPeriod").
+		Find(&salaries).Error; err != nil {
+		return nil, fmt.Errorf("failed to load salary history: %w", err)
+	}
+
+	salaries = LatestSalaryVersions(salaries)
+	wages := make([]models.Money, 0, 12)
+	for i := len(salaries) - 1; i >= 0 && len(wages) < 12; i-- {
+		wages = append(wages, salaries[i].GrossSalary)
+	}
+	return wages, nil
+}
+
+// GetSettlements 获取离职结算列表
+func (s *TerminationSettlementService) GetSettlements(params SettlementQueryParams) ([]models.TerminationSettlement, error) {
+	query := s.db.Preload("Employee")
+	if params.EmployeeID > 0 {
+		query = query.Where("employee_id = ?", params.EmployeeID)
+	}
+	if params.Status != "" {
+		query = query.Where("status = ?", params.Status)
+	}
+
+	var settlements []models.TerminationSettlement
+	if err := query.Order("created_at DESC").Find(&settlements).Error; err != nil {
+		return nil, err
+	}
+	return settlements, nil
+}
+
+// GetSettlement 获取离职结算详情
+func (s *TerminationSettlementService) GetSettlement(id uint) (*models.TerminationSettlement, error) {
+	var settlement models.TerminationSettlement
+	if err := s.db.Preload("Employee").Preload("OffCyclePeriod").First(&settlement, id).Error; err != nil {
+		return nil, err
+	}
+	return &settlement, nil
+}
+
+// ApproveSettlement 人事复核离职结算
+func (s *TerminationSettlementService) ApproveSettlement(id, userID uint, notes string) (*models.TerminationSettlement, error) {
+	if err := validateSettlementOperator(userID); err != nil {
+		return nil, err
+	}
+	settlement, err := s.GetSettlement(id)
+	if err != nil {
+		return nil, &utils.ValidationError{Message: "离职结算不存在"}
+	}
+	if settlement.Status != models.SettlementDraft {
+		return nil, utils.NewConflictError("只能复核草稿状态的离职结算")
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":      models.SettlementApproved,
+		"approved_by": userID,
+		"approved_at": now,
+	}
+	if notes = strings.TrimSpace(notes); notes != "" {
+		updates["notes"] = appendNote(settlement.Notes, notes)
+	}
+	if err := s.updateStatus(id, models.SettlementDraft, updates); err != nil {
+		return nil, err
+	}
+	return s.GetSettlement(id)
+}
+
+// CancelSettlement 取消尚未生成发放的离职结算
+func (s *TerminationSettlementService) CancelSettlement(id, userID uint, reason string) (*models.TerminationSettlement, error) {
+	if err := validateSettlementOperator(userID); err != nil {
+		return nil, err
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, &utils.ValidationError{Message: "取消离职结算必须填写原因"}
+	}
+	settlement, err := s.GetSettlement(id)
+	if err != nil {
+		return nil, &utils.ValidationError{Message: "离职结算不存在"}
+	}
+	if settlement.Status != models.SettlementDraft && settlement.Status != models.SettlementApproved {
+		return nil, utils.NewConflictError("已生成发放或已取消的离职结算不能取消")
+	}
+
+	if err := s.updateStatus(id, settlement.Status, map[string]interface{}{
+		"status": models.SettlementCancelled,
+		"notes":  appendNote(settlement.Notes, fmt.Sprintf("取消原因(用户%d): %s", userID, reason)),
+	}); err != nil {
+		return nil, err
+	}
+	return s.GetSettlement(id)
+}
+
+// IssueSettlement 为已复核的离职结算生成离职结算类型的非周期发放及薪资记录，
+// 之后按非周期发放的审批与支付批次流程发放
+func (s *TerminationSettlementService) IssueSettlement(id uint, req SettlementIssueRequest, userID uint) (*models.TerminationSettlement, error) {
+	if err := validateSettlementOperator(userID); err != nil {
+		return nil, err
+	}
+	settlement, err := s.GetSettlement(id)
+	if err != nil {
+		return nil, &utils.ValidationError{Message: "离职结算不存在"}
+	}
+	if settlement.Status != models.SettlementApproved {
+		return nil, utils.NewConflictError("离职结算须复核后才能生成发放")
+	}
+	if settlement.TotalAmount.Sign() <= 0 {
+		return nil, &utils.ValidationError{Message: "离职结算金额为 0，无需发放"}
+	}
+
+	parent, err := s.settlementParentPeriod(settlement, req.ParentPeriodID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		details, extra, err := settlementDetails(tx, settlement)
+		if err != nil {
+			return err
+		}
+		codes := make([]string, 0, len(details))
+		for _, detail := range details {
+			codes = append(codes, detail.Component.Code)
+		}
+
+		run := &models.PayrollPeriod{
+			OffCycleReason:    models.OffCycleTermination,
+			AllowedComponents: strings.Join(codes, ","),
+		}
+		if settlement.Employee != nil {
+			run.Name = fmt.Sprintf("%s 离职结算 %s", parent.Name, settlement.Employee.Name)
+		}
+		if req.PayDate != nil && !req.PayDate.IsZero() {
+			run.PayDate = &req.PayDate.Time
+		}
+		if err := createOffCycleRun(tx, parent, run); err != nil {
+			return err
+		}
+
+		salary, err := createOffCycleSalary(tx, run, settlement.EmployeeID, details, userID, extra...)
+		if err != nil {
+			return err
+		}
+
+		return (&TerminationSettlementService{db: tx}).updateStatus(id, models.SettlementApproved, map[string]interface{}{
+			"status":              models.SettlementIssued,
+			"off_cycle_period_id": run.ID,
+			"salary_id":           salary.ID,
+			"issued_at":           time.Now(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSettlement(id)
+}
+
+// settlementParentPeriod 确定离职结算挂靠的常规周期，须为人民币周期
+func (s *TerminationSettlementService) settlementParentPeriod(settlement *models.TerminationSettlement, parentID *uint) (*models.PayrollPeriod, error) {
+	var parent models.PayrollPeriod
+	if parentID != nil {
+		if err := s.db.First(&parent, *parentID).Error; err != nil {
+			return nil, &utils.ValidationError{Message: "薪资周期不存在"}
+		}
+		if parent.IsSupplemental() {
+			return nil, &utils.ValidationError{Message: "非周期发放只能挂靠常规薪资周期"}
+		}
+	} else {
+		err := s.db.Where("period_type NOT IN ? AND start_date <= ? AND end_date >= ?",
+			models.SupplementalPeriodTypes, settlement.TerminationDate, settlement.TerminationDate).
+			Order("start_date DESC").First(&parent).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &utils.ValidationError{Message: "未找到离职日期所在的薪资周期，请指定挂靠周期"}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if models.NormalizeCurrency(parent.Currency) != models.DefaultCurrency {
+		return nil, &utils.ValidationError{Message: "离职结算只能在人民币薪资周期下发放"}
+	}
+	return &parent, nil
+}
+
+// settlementDetails 将结算金额转换为薪资明细；经济补偿单独计税的税额作为额外扣款明细，不参与累计预扣
+func settlementDetails(db *gorm.DB, settlement *models.TerminationSettlement) ([]models.SalaryDetail, []models.SalaryDetail, error) {
+	lines := []struct {
+		template models.SalaryComponent
+		amount   models.Money
+		formula  string
+	}{
+		{
+			models.SalaryComponent{Code: SeveranceComponentCode, Name: "经济补偿金", Category: models.ComponentCategoryAllowance, Sort: 200},
+			settlement.Compensation,
+			fmt.Sprintf("%s × %s months", settlement.CompensationBase, formatAmount(settlement.CompensationMonths)),
+		},
+		{
+			models.SalaryComponent{Code: NoticePayComponentCode, Name: "代通知金", Category: models.ComponentCategoryAllowance, Sort: 201},
+			settlement.NoticePay,
+			fmt.Sprintf("last month wage %s", settlement.LastMonthWage),
+		},
+		{
+			models.SalaryComponent{Code: LeavePayoutComponentCode, Name: "未休年假工资", Category: models.ComponentCategoryAllowance, Sort: 202},
+			settlement.LeavePayout,
+			fmt.Sprintf("%s days × %s × 200%%", formatAmount(settlement.UnusedLeaveDays), settlement.DailyWage),
+		},
+	}
+	if settlement.Type == models.SettlementDoubleN {
+		lines[0].template.Name = "违法解除赔偿金"
+		lines[0].formula = "2 × " + lines[0].formula
+	}
+
+	var details []models.SalaryDetail
+	for _, line := range lines {
+		if line.amount.Sign() <= 0 {
+			continue
+		}
+		line.template.Description = "离职结算时由系统生成"
+		component, err := ensureSystemComponent(db, line.template)
+		if err != nil {
+			return nil, nil, err
+		}
+		// 经济补偿不并入综合所得，由结算单按免税额度单独计税
+		if component.Code == SeveranceComponentCode && component.IsTaxable {
+			if err := db.Model(component).Update("is_taxable", false).Error; err != nil {
+				return nil, nil, err
+			}
+			component.IsTaxable = false
+		}
+		details = append(details, models.SalaryDetail{
+			ComponentID:        component.ID,
+			Component:          component,
+			CalculatedValue:    line.amount,
+			FinalValue:         line.amount,
+			CalculationFormula: line.formula,
+		})
+	}
+
+	var extra []models.SalaryDetail
+	if settlement.CompensationTax.Sign() > 0 {
+		component, err := ensureSystemComponent(db, models.SalaryComponent{
+			Code:        SeveranceTaxComponentCode,
+			Name:        "经济补偿金个人所得税",
+			Category:    models.ComponentCategoryTax,
+			Sort:        9998,
+			Description: "超过免税额度部分单独计税，由系统生成",
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		extra = append(extra, models.SalaryDetail{
+			ComponentID:     component.ID,
+			Component:       component,
+			CalculatedValue: settlement.CompensationTax,
+			FinalValue:      settlement.CompensationTax,
+			CalculationFormula: fmt.Sprintf("(%s - %s exempt) separately taxed = %s",
+				settlement.Compensation, settlement.TaxExemptLimit, settlement.CompensationTax),
+		})
+	}
+	return details, extra, nil
+}
+
+// validateSettlementOperator 离职结算的创建、复核、取消与发放必须记录操作人
+func validateSettlementOperator(userID uint) error {
+	if userID == 0 {
+		return &utils.ValidationError{Message: "无法识别操作人，不能处理离职结算"}
+	}
+	return nil
+}
+
+// updateStatus 以原状态为条件更新结算单，避免并发操作相互覆盖
+func (s *TerminationSettlementService) updateStatus(id uint, from models.SettlementStatus, updates map[string]interface{}) error {
+	result := s.db.Model(&models.TerminationSettlement{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return utils.NewConflictError("离职结算状态已被其他操作修改，请刷新后重试")
+	}
+	return nil
+}
+
+func appendNote(notes, note string) string {
+	if notes == "" {
+		return note
+	}
+	return notes + "\n" + note
+}
+
+// GetLeaveBalances 获取员工年假额度
+func (s *TerminationSettlementService) GetLeaveBalances(employeeID uint, year int) ([]models.LeaveBalance, error) {
+	query := s.db.Where("employee_id = ?", employeeID)
+	if year > 0 {
+		query = query.Where("year = ?", year)
+	}
+
+	var balances []models.LeaveBalance
+	if err := query.Order("year DESC").Find(&balances).Error; err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// SaveLeaveBalance 登记或更新员工某年度的假期额度
+func (s *TerminationSettlementService) SaveLeaveBalance(balance *models.LeaveBalance) (*models.LeaveBalance, error) {
+	if balance.LeaveType == "" {
+		balance.LeaveType = models.LeaveTypeAnnual
+	}
+	if balance.Year <= 0 {
+		return nil, &utils.ValidationError{Message: "请指定年度"}
+	}
+	if balance.EntitledDays < 0 || balance.CarriedOverDays < 0 {
+		return nil, &utils.ValidationError{Message: "假期天数不能为负数"}
+	}
+
+	var existing models.LeaveBalance
+	err := s.db.Where("employee_id = ? AND year = ? AND leave_type = ?", balance.EmployeeID, balance.Year, balance.LeaveType).
+		First(&existing).Error
+	switch {
+	case err == nil:
+		balance.ID = existing.ID
+		balance.CreatedAt = existing.CreatedAt
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+	if err := s.db.Save(balance).Error; err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
@@ -19,6 +19,7 @@ const (
 	MessageTypeChat         MessageType = "chat" 
 	MessageTypeSystem       MessageType = "system"
 	MessageTypeHeartbeat    MessageType = "heartbeat"
+	MessageTypePayrollJob   MessageType = "payroll_job"
 )
 
 // WebSocket消息结构
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"gin-project/models"
+	"gin-project/utils"
+
+	"gorm.io/gorm"
+)
+
+type WorkCalendarServiceInterface interface {
+	GetCalendarDays(year int) ([]models.WorkCalendarDay, error)
+	SaveCalendarDays(days []models.WorkCalendarDay) ([]models.WorkCalendarDay, error)
+	DeleteCalendarDay(id uint) error
+	GetWorkingDays(start, end time.Time) (*WorkingDaysSummary, error)
+}
+
+type WorkCalendarService struct {
+	db *gorm.DB
+}
+
+// WorkingDaysSummary 日期区间内的工作日统计
+type WorkingDaysSummary struct {
+	StartDate   string `json:"start_date"`
+	EndDate     string `json:"end_date"`
+	WorkingDays int    `json:"working_days"`
+	Holidays    int    `json:"holidays"`
+	Adjusted    int    `json:"adjusted_workdays"`
+}
+
+func NewWorkCalendarService(db *gorm.DB) WorkCalendarServiceInterface {
+	return &WorkCalendarService{
+		db: db,
+	}
+}
+
+// GetCalendarDays 获取指定年份登记的节假日及调休日期
+func (s *WorkCalendarService) GetCalendarDays(year int) ([]models.WorkCalendarDay, error) {
+	var days []models.WorkCalendarDay
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+	end := time.Date(year, time.December, 31, 0, 0, 0, 0, time.Local)
+	if err := s.db.Where("date BETWEEN ? AND ?", start, end).Order("date ASC").Find(&days).Error; err != nil {
+		return nil, err
+	}
+	return days, nil
+}
+
+// SaveCalendarDays 批量登记节假日及调休日期，同一日期已存在时覆盖
+func (s *WorkCalendarService) SaveCalendarDays(days []models.WorkCalendarDay) ([]models.WorkCalendarDay, error) {
+	for _, day := range days {
+		if day.Date.IsZero() {
+			return nil, &utils.ValidationError{Message: "日期不能为空"}
+		}
+		if day.Type != models.CalendarDayHoliday && day.Type != models.CalendarDayWorkday {
+			return nil, &utils.ValidationError{Message: "日期类型必须为 holiday 或 workday"}
+		}
+		if day.Type == models.CalendarDayWorkday && !isWeekend(day.Date) {
+			return nil, &utils.ValidationError{Message: fmt.Sprintf("%s 不是周末，无需登记调休上班", day.Date.Format("2006-01-02"))}
+		}
+	}
+
+	saved := make([]models.WorkCalendarDay, 0, len(days))
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, day := range days {
+			var existing models.WorkCalendarDay
+			err := tx.Where("date = ?", day.Date.Format("2006-01-02")).First(&existing).Error
+			if err == nil {
+				existing.Type = day.Type
+				existing.Name = day.Name
+				existing.Statutory = day.Statutory
+				if err := tx.Save(&existing).Error; err != nil {
+					return err
+				}
+				saved = append(saved, existing)
+				continue
+			}
+			if err != gorm.ErrRecordNotFound {
+				return err
+			}
+			day.ID = 0
+			if err := tx.Create(&day).Error; err != nil {
+				return err
+			}
+			saved = append(saved, day)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+func (s *WorkCalendarService) DeleteCalendarDay(id uint) error {
+	return s.db.Delete(&models.WorkCalendarDay{}, id).Error
+}
+
+// GetWorkingDays 统计日期区间内的应出勤天数
+func (s *WorkCalendarService) GetWorkingDays(start, end time.Time) (*WorkingDaysSummary, error) {
+	if end.Before(start) {
+		return nil, &utils.ValidationError{Message: "结束日期不能早于开始日期"}
+	}
+	calendar, err := loadWorkCalendar(s.db, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &WorkingDaysSummary{
+		StartDate:   start.Format("2006-01-02"),
+		EndDate:     end.Format("2006-01-02"),
+		WorkingDays: calendar.WorkingDaysBetween(start, end),
+	}
+	for _, day := range calendar.days {
+		switch day.Type {
+		case models.CalendarDayHoliday:
+			summary.Holidays++
+		case models.CalendarDayWorkday:
+			summary.Adjusted++
+		}
+	}
+	return summary, nil
+}
+
+// ========================= Working-day Calendar =========================
+
+// WorkCalendar 工作日历：默认周一至周五上班，节假日与调休上班日期按登记覆盖
+type WorkCalendar struct {
+	days map[string]models.WorkCalendarDay
+}
+
+// NewWorkCalendar 根据登记的例外日期构建工作日历
+func NewWorkCalendar(days []models.WorkCalendarDay) *WorkCalendar {
+	calendar := &WorkCalendar{days: make(map[string]models.WorkCalendarDay, len(days))}
+	for _, day := range days {
+		calendar.days[calendarKey(day.Date)] = day
+	}
+	return calendar
+}
+
+func loadWorkCalendar(db *gorm.DB, start, end time.Time) (*WorkCalendar, error) {
+	var days []models.WorkCalendarDay
+	if err := db.Where("date BETWEEN ? AND ?", start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Find(&days).Error; err != nil {
+		return nil, fmt.Errorf("failed to load work calendar: %w", err)
+	}
+	return NewWorkCalendar(days), nil
+}
+
+// IsWorkingDay 判断指定日期是否需要出勤
+func (c *WorkCalendar) IsWorkingDay(date time.Time) bool {
+	if day, ok := c.days[calendarKey(date)]; ok {
+		return day.Type == models.CalendarDayWorkday
+	}
+	return !isWeekend(date)
+}
+
+// IsStatutoryHoliday 判断指定日期是否为法定节假日
+func (c *WorkCalendar) IsStatutoryHoliday(date time.Time) bool {
+	day, ok := c.days[calendarKey(date)]
+	return ok && day.Type == models.CalendarDayHoliday && day.Statutory
+}
+
+// WorkingDaysBetween 统计闭区间 [start, end] 内的应出勤天数
+func (c *WorkCalendar) WorkingDaysBetween(start, end time.Time) int {
+	count := 0
+	for day := truncateDate(start); !day.After(truncateDate(end)); day = day.AddDate(0, 0, 1) {
+		if c.IsWorkingDay(day) {
+			count++
+		}
+	}
+	return count
+}
+
+func isWeekend(date time.Time) bool {
+	weekday := date.Weekday()
+	return weekday == time.Saturday || weekday == time.Sunday
+}
+
+func truncateDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func calendarKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gin-project/models"
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBankFileExporters(t *testing.T) {
+	payer := services.BankPayer{Name: "示例科技有限公司", Account: "6222000011112222", BankCode: "ICBKCNBJ"}
+	lines := []services.BankPaymentLine{
+		{Reference: services.PaymentReference(1), Name: "张三", Account: "6222000000000001", BankCode: "102100099996", Amount: money(8123.45), Remark: "2024年10月工资"},
+		{Reference: services.PaymentReference(2), Name: "李四", Account: "6222000000000002", Amount: money(6000.10), Remark: "2024年10月工资"},
+	}
+	file := services.NewBankPaymentFile("PB202410", "2024年10月工资", date("2024-11-05"), payer, lines)
+	assert.Equal(t, 2, file.RecordCount)
+	assert.Equal(t, money(14123.55), file.ControlSum)
+	assert.Len(t, file.Checksum, 64)
+	assert.Equal(t, "PR0000000002", file.Lines[1].Reference)
+
+	csvExporter, ok := services.BankFileExporterFor(services.BankFileCSV)
+	require.True(t, ok)
+	content, err := csvExporter.Export(file)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "合计笔数,2,合计金额,14123.55")
+	assert.Contains(t, string(content), "校验码,"+file.Checksum)
+
+	painExporter, ok := services.BankFileExporterFor(services.BankFilePain001)
+	require.True(t, ok)
+	content, err = painExporter.Export(file)
+	require.NoError(t, err)
+	xml := string(content)
+	assert.Equal(t, 2, strings.Count(xml, "<NbOfTxs>2</NbOfTxs>"))
+	assert.Equal(t, 2, strings.Count(xml, "<CtrlSum>14123.55</CtrlSum>"))
+	assert.Contains(t, xml, `<InstdAmt Ccy="CNY">8123.45</InstdAmt>`)
+	assert.Contains(t, xml, "<BIC>ICBKCNBJ</BIC>")
+
+	icbcExporter, ok := services.BankFileExporterFor(services.BankFileICBC)
+	require.True(t, ok)
+	content, err = icbcExporter.Export(file)
+	require.NoError(t, err)
+	records := bytes.Split(bytes.TrimSuffix(content, []byte("\r\n")), []byte("\r\n"))
+	require.Len(t, records, 4)
+	assert.Len(t, records[0], 144)
+	assert.Len(t, records[1], 156)
+	assert.Len(t, records[2], 156)
+	assert.Equal(t, "T00000002000000001412355"+file.Checksum, string(records[3]))
+}
+
+func TestICBCExporterRejectsOverlongFields(t *testing.T) {
+	payer := services.BankPayer{Name: "示例科技有限公司", Account: "6222000011112222"}
+	icbcExporter, ok := services.BankFileExporterFor(services.BankFileICBC)
+	require.True(t, ok)
+
+	longAccount := strings.Repeat("6", 33)
+	file := services.NewBankPaymentFile("PB202410", "2024年10月工资", date("2024-11-05"), payer, []services.BankPaymentLine{
+		{Reference: services.PaymentReference(1), Name: "张三", Account: longAccount, Amount: money(100)},
+	})
+	_, err := icbcExporter.Export(file)
+	require.Error(t, err, "收款账号超长时不能截断后付款")
+	assert.IsType(t, &utils.ValidationError{}, err)
+	assert.Contains(t, err.Error(), longAccount)
+
+	file = services.NewBankPaymentFile("PB202410", "2024年10月工资", date("2024-11-05"), payer, []services.BankPaymentLine{
+		{Reference: services.PaymentReference(1), Name: strings.Repeat("张", 21), Account: "6222000000000001", Amount: money(100)},
+	})
+	_, err = icbcExporter.Export(file)
+	assert.IsType(t, &utils.ValidationError{}, err, "户名超过 40 字节")
+
+	file = services.NewBankPaymentFile("PB202410", "2024年10月工资", date("2024-11-05"), payer, []services.BankPaymentLine{
+		{Reference: services.PaymentReference(1), Name: "张三", Account: "6222000000000001", Amount: money(100), Remark: strings.Repeat("工资", 20)},
+	})
+	content, err := icbcExporter.Export(file)
+	require.NoError(t, err, "用途超长时截断")
+	records := bytes.Split(bytes.TrimSuffix(content, []byte("\r\n")), []byte("\r\n"))
+	assert.Len(t, records[1], 156)
+}
+
+func TestBankPaymentLinesSkipsSettledRecords(t *testing.T) {
+	batch := &models.PaymentBatch{Name: "2024年10月工资", Records: []models.EnhancedPayrollRecord{
+		{ID: 4, PaymentAmount: money(4000), PaymentMethod: models.PaymentMethodBankTransfer, BankAccount: "6222000000000004", Status: models.PayrollStatusReissued},
+		{ID: 1, PaymentAmount: money(1000), PaymentMethod: models.PaymentMethodBankTransfer, BankAccount: "6222000000000001", Status: models.PayrollStatusProcessing},
+		{ID: 2, PaymentAmount: money(2000), PaymentMethod: models.PaymentMethodBankTransfer, BankAccount: "6222000000000002", Status: models.PayrollStatusCompleted},
+		{ID: 3, PaymentAmount: money(3000), PaymentMethod: models.PaymentMethodBankTransfer, BankAccount: "6222000000000003", Status: models.PayrollStatusCancelled},
+		{ID: 5, PaymentAmount: money(5000), PaymentMethod: models.PaymentMethodCash, Status: models.PayrollStatusPending},
+	}}
+
+	lines, err := services.BankPaymentLines(batch)
+	require.NoError(t, err)
+	require.Len(t, lines, 1, "已重发的原记录不能随原批次再次付款")
+	assert.Equal(t, uint(1), lines[0].RecordID)
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"gin-project/models"
+	"gin-project/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBankReturnParsers(t *testing.T) {
+	csvParser, ok := services.BankReturnParserFor(services.BankFileCSV)
+	require.True(t, ok)
+	lines, err := csvParser.Parse([]byte("\xEF\xBB\xBF批次号,PB202410\n参考号,收款账号,金额,状态,银行流水号,失败原因\n" +
+		"PR0000000001,6222000000000001,\"8,123.45\",成功,TX001,\nPR0000000002,6222000000000002,6000.10,失败,,账户已销户\n"))
+	require.NoError(t, err)
+	require.Len(t, lines, 2)
+	assert.Equal(t, money(8123.45), lines[0].Amount)
+	assert.Equal(t, services.BankReturnSucceeded, lines[0].Status)
+	assert.Equal(t, "TX001", lines[0].TransactionRef)
+	assert.Equal(t, services.BankReturnFailed, lines[1].Status)
+	assert.Equal(t, "账户已销户", lines[1].Message)
+
+	painParser, ok := services.BankReturnParserFor(services.BankFilePain001)
+	require.True(t, ok)
+	lines, err = painParser.Parse([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.002.001.03"><CstmrPmtStsRpt><OrgnlPmtInfAndSts>
+<TxInfAndSts><OrgnlEndToEndId>PR0000000001</OrgnlEndToEndId><TxSts>ACSC</TxSts><AcctSvcrRef>B1</AcctSvcrRef></TxInfAndSts>
+<TxInfAndSts><OrgnlEndToEndId>PR0000000002</OrgnlEndToEndId><TxSts>RJCT</TxSts><StsRsnInf><Rsn><Cd>AC04</Cd></Rsn><AddtlInf>Closed account</AddtlInf></StsRsnInf></TxInfAndSts>
+</OrgnlPmtInfAndSts></CstmrPmtStsRpt></Document>`))
+	require.NoError(t, err)
+	require.Len(t, lines, 2)
+	assert.Equal(t, services.BankReturnSucceeded, lines[0].Status)
+	assert.Equal(t, "B1", lines[0].TransactionRef)
+	assert.Equal(t, "AC04 Closed account", lines[1].Message)
+}
+
+func TestReconcileBankReturn(t *testing.T) {
+	records := []models.EnhancedPayrollRecord{
+		{ID: 1, SalaryID: 11, PaymentAmount: money(8123.45), BankAccount: "6222000000000001", Status: models.PayrollStatusProcessing},
+		{ID: 2, SalaryID: 12, PaymentAmount: money(6000.10), BankAccount: "6222000000000002", Status: models.PayrollStatusProcessing},
+		{ID: 3, SalaryID: 13, PaymentAmount: money(5000), BankAccount: "6222000000000003", Status: models.PayrollStatusProcessing},
+		{ID: 4, SalaryID: 14, PaymentAmount: money(4000), BankAccount: "6222000000000004", Status: models.PayrollStatusCompleted},
+	}
+	lines := []services.BankReturnLine{
+		{LineNumber: 1, Reference: "PR0000000001", Amount: money(8123.45), Status: services.BankReturnSucceeded, TransactionRef: "TX001"},
+		{LineNumber: 2, Account: "6222000000000002", Amount: money(6000.10), Status: services.BankReturnFailed, Message: "账户已销户"},
+		{LineNumber: 3, Reference: "PR0000000003", Amount: money(5100), Status: services.BankReturnSucceeded},
+		{LineNumber: 4, Reference: "PR0000000004", Amount: money(4000), Status: services.BankReturnFailed},
+		{LineNumber: 5, Reference: "PR0000000099", Amount: money(100), Status: services.BankReturnSucceeded},
+		{LineNumber: 6, Reference: "PR0000000001", Amount: money(8123.45), Status: services.BankReturnSucceeded},
+	}
+
+	result := services.ReconcileBankReturn(records, lines)
+	assert.Equal(t, 1, result.Succeeded)
+	assert.Equal(t, 1, result.Failed)
+	require.Len(t, result.Changed, 2)
+	assert.Equal(t, models.PayrollStatusCompleted, result.Changed[0].Status)
+	assert.Equal(t, "TX001", result.Changed[0].TransactionRef)
+	assert.Equal(t, models.PayrollStatusFailed, result.Changed[1].Status)
+	assert.Equal(t, "账户已销户", result.Changed[1].FailureReason)
+	assert.Equal(t, models.PayrollStatusProcessing, records[0].Status)
+
+	reasons := make([]models.BankReturnExceptionReason, 0, len(result.Exceptions))
+	for _, exception := range result.Exceptions {
+		reasons = append(reasons, exception.Reason)
+	}
+	assert.Equal(t, []models.BankReturnExceptionReason{
+		models.ReturnExceptionAmountMismatch,
+		models.ReturnExceptionConflict,
+		models.ReturnExceptionUnmatched,
+		models.ReturnExceptionDuplicate,
+	}, reasons)
+}
+
+func icbcReturnLine(seq int, account string, cents int, reference, code, message string) string {
+	return fmt.Sprintf("D%06d%-32s%-40s%015d%-20s%-1s%-30s%-60s", seq, account, "ZHANG SAN", cents, reference, code, "", message)
+}
+
+func TestICBCReturnParserStatusCodes(t *testing.T) {
+	parser, ok := services.BankReturnParserFor(services.BankFileICBC)
+	require.True(t, ok)
+	content := strings.Join([]string{
+		"H20241105",
+		icbcReturnLine(1, "6222000000000001", 812345, "PR0000000001", "0", ""),
+		icbcReturnLine(2, "6222000000000002", 600010, "PR0000000002", "1", "ACCOUNT CLOSED"),
+		icbcReturnLine(3, "6222000000000003", 500000, "PR0000000003", "", ""),
+		icbcReturnLine(4, "6222000000000004", 400000, "PR0000000004", "2", ""),
+	}, "\r\n")
+
+	lines, err := parser.Parse([]byte(content))
+	require.NoError(t, err)
+	require.Len(t, lines, 4)
+	assert.Equal(t, services.BankReturnSucceeded, lines[0].Status)
+	assert.Equal(t, money(8123.45), lines[0].Amount)
+	assert.Equal(t, services.BankReturnFailed, lines[1].Status)
+	assert.Equal(t, "ACCOUNT CLOSED", lines[1].Message)
+	assert.Equal(t, services.BankReturnPending, lines[2].Status, "处理结果为空时不能当作退票")
+	assert.Equal(t, services.BankReturnPending, lines[3].Status, "未知处理结果视为银行仍在处理")
+}
+
+func TestReconcileBankReturnReissuedRecords(t *testing.T) {
+	records := []models.EnhancedPayrollRecord{
+		{ID: 1, PaymentAmount: money(5000), Status: models.PayrollStatusReissued},
+		{ID: 2, PaymentAmount: money(4000), Status: models.PayrollStatusReissued},
+	}
+	lines := []services.BankReturnLine{
+		{LineNumber: 1, Reference: "PR0000000001", Amount: money(5000), Status: services.BankReturnSucceeded},
+		{LineNumber: 2, Reference: "PR0000000002", Amount: money(4000), Status: services.BankReturnFailed},
+	}
+
+	result := services.ReconcileBankReturn(records, lines)
+	assert.Empty(t, result.Changed, "已转入重发批次的原记录不再改回成功或失败")
+	assert.Equal(t, 1, result.Failed)
+	require.Len(t, result.Exceptions, 1)
+	assert.Equal(t, models.ReturnExceptionConflict, result.Exceptions[0].Reason, "原记录已重发又回盘成功，可能重复入账")
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"testing"
+
+	"gin-project/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaceInBand(t *testing.T) {
+	band := &services.SalaryBand{Name: "P5", Min: 10000, Mid: 12500, Max: 15000}
+
+	placement := services.PlaceInBand(11250, band)
+	assert.Equal(t, 0.9, placement.CompaRatio)
+	assert.Equal(t, 0.25, placement.RangePenetration)
+	assert.Equal(t, services.BandWithin, placement.Position)
+
+	placement = services.PlaceInBand(16000, band)
+	assert.Equal(t, services.BandAbove, placement.Position)
+	assert.Equal(t, 1.2, placement.RangePenetration)
+
+	assert.Equal(t, services.BandBelow, services.PlaceInBand(9000, band).Position)
+}
+
+func TestBuildCompaRatioReport(t *testing.T) {
+	band := &services.SalaryBand{Name: "P5", Min: 10000, Mid: 12500, Max: 15000}
+	position := func(no string, departmentID uint, salary float64, band *services.SalaryBand) services.EmployeeBandPosition {
+		p := services.EmployeeBandPosition{EmployeeNo: no, DepartmentID: departmentID, BaseSalary: money(salary), Band: band}
+		if band != nil {
+			p.BandPlacement = services.PlaceInBand(salary, band)
+		}
+		return p
+	}
+
+	report := services.BuildCompaRatioReport([]services.EmployeeBandPosition{
+		position("E001", 1, 12500, band),
+		position("E002", 1, 9000, band),
+		position("E003", 2, 16250, band),
+		position("E004", 2, 8000, nil),
+	})
+
+	assert.Equal(t, 4, report.Summary.Employees)
+	assert.Equal(t, 1, report.Summary.WithoutBand)
+	assert.Equal(t, 1, report.Summary.BelowBand)
+	assert.Equal(t, 1, report.Summary.AboveBand)
+	assert.Equal(t, 1.0, report.Summary.MedianCompaRatio)
+	assert.Equal(t, 1.0067, report.Summary.AverageCompaRatio)
+	require.Len(t, report.OutOfBand, 2)
+	require.Len(t, report.Unbanded, 1)
+	assert.Equal(t, "E004", report.Unbanded[0].EmployeeNo)
+
+	require.Len(t, report.Departments, 2)
+	assert.Equal(t, 2, report.Departments[0].Employees)
+	assert.Equal(t, 0.86, report.Departments[0].AverageCompaRatio)
+	assert.Equal(t, 1, report.Departments[1].Employees, "未匹配带宽的员工不计入部门统计")
+}
+
+func TestBandOverlaps(t *testing.T) {
+	overlaps := services.BandOverlaps([]services.SalaryBand{
+		{Source: "grade", Name: "G3", Level: 3, Min: 14000, Max: 22000},
+		{Source: "grade", Name: "G1", Level: 1, Min: 6000, Max: 10000},
+		{Source: "grade", Name: "G2", Level: 2, Min: 9000, Max: 15000},
+	})
+
+	require.Len(t, overlaps, 2)
+	assert.Equal(t, "G1", overlaps[0].Lower)
+	assert.Equal(t, "G2", overlaps[0].Upper)
+	assert.Equal(t, 1000.0, overlaps[0].OverlapAmount)
+	assert.Equal(t, 25.0, overlaps[0].OverlapPercent)
+	assert.False(t, overlaps[0].Inverted)
+	assert.Equal(t, 1000.0, overlaps[1].OverlapAmount)
+	assert.Equal(t, 16.67, overlaps[1].OverlapPercent)
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"testing"
+
+	"gin-project/models"
+	"gin-project/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchReviewGuideline(t *testing.T) {
+	guidelines := []models.CompensationReviewGuideline{
+		{ID: 3, Sort: 3, MinPercent: 0, TargetPercent: 2, MaxPercent: 3},
+		{ID: 1, Sort: 1, MinScore: 90, MinCompaRatio: 0, MaxCompaRatio: 1, MinPercent: 8, TargetPercent: 10, MaxPercent: 12},
+		{ID: 2, Sort: 2, MinScore: 90, MinCompaRatio: 1, MinPercent: 4, TargetPercent: 6, MaxPercent: 8},
+	}
+	score, lowRatio, highRatio := 95.0, 0.92, 1.08
+
+	assert.Equal(t, uint(1), services.MatchReviewGuideline(guidelines, &score, &lowRatio).ID)
+	assert.Equal(t, uint(2), services.MatchReviewGuideline(guidelines, &score, &highRatio).ID)
+	assert.Equal(t, uint(3), services.MatchReviewGuideline(guidelines, nil, &lowRatio).ID, "无绩效得分时只匹配不限得分的规则")
+
+	lowScore := 70.0
+	assert.Equal(t, uint(3), services.MatchReviewGuideline(guidelines, &lowScore, &lowRatio).ID)
+	assert.Nil(t, services.MatchReviewGuideline(guidelines[1:], &lowScore, &lowRatio))
+}
+
+func TestEvaluateProposal(t *testing.T) {
+	guideline := &models.CompensationReviewGuideline{ID: 7, MinPercent: 4, TargetPercent: 6, MaxPercent: 8}
+
+	proposal := &models.CompensationReviewProposal{CurrentBase: money(12000), ProposedBase: money(12720)}
+	services.EvaluateProposal(proposal, guideline)
+	assert.Equal(t, money(720), proposal.IncreaseAmount)
+	assert.Equal(t, 6.0, proposal.IncreasePercent)
+	assert.False(t, proposal.OutsideGuideline)
+	require.NotNil(t, proposal.GuidelineID)
+	assert.Equal(t, uint(7), *proposal.GuidelineID)
+
+	proposal.ProposedBase = money(13200)
+	services.EvaluateProposal(proposal, guideline)
+	assert.Equal(t, 10.0, proposal.IncreasePercent)
+	assert.True(t, proposal.OutsideGuideline)
+
+	services.EvaluateProposal(proposal, nil)
+	assert.False(t, proposal.OutsideGuideline, "无适用规则时不判定超出")
+	assert.Nil(t, proposal.GuidelineID)
+}
+
+func TestSummarizeReviewBudgets(t *testing.T) {
+	budgets := []models.CompensationReviewBudget{
+		{DepartmentID: 1, Amount: money(2000), Department: &models.Department{Name: "研发部"}},
+		{DepartmentID: 2, Amount: money(500)},
+	}
+	proposals := []models.CompensationReviewProposal{
+		{DepartmentID: 1, IncreaseAmount: money(800), IncreasePercent: 8, Status: models.ProposalApproved},
+		{DepartmentID: 1, IncreaseAmount: money(600), IncreasePercent: 4, Status: models.ProposalSubmitted, OutsideGuideline: true},
+		{DepartmentID: 1, IncreaseAmount: money(900), IncreasePercent: 9, Status: models.ProposalRejected},
+		{DepartmentID: 2, IncreaseAmount: money(700), IncreasePercent: 7, Status: models.ProposalDraft},
+		{DepartmentID: 3, IncreaseAmount: money(100), IncreasePercent: 1, Status: models.ProposalDraft},
+	}
+
+	summaries := services.SummarizeReviewBudgets(budgets, proposals)
+	require.Len(t, summaries, 3)
+
+	rd := summaries[0]
+	assert.Equal(t, "研发部", rd.DepartmentName)
+	assert.Equal(t, money(1400), rd.Committed, "驳回的建议不占用预算")
+	assert.Equal(t, money(800), rd.Approved)
+	assert.Equal(t, money(600), rd.Remaining)
+	assert.Equal(t, 2, rd.Proposals)
+	assert.Equal(t, 1, rd.Pending)
+	assert.Equal(t, 1, rd.OutsideGuideline)
+	assert.Equal(t, 6.0, rd.AveragePercent)
+
+	assert.Equal(t, money(-200), summaries[1].Remaining)
+	assert.True(t, summaries[2].Budget.IsZero(), "未设置预算的部门预算为 0")
+}
+
+func TestReviewManagerScope(t *testing.T) {
+	managerEmployeeID := uint(5)
+	manager := &models.User{ID: 9, EmployeeID: &managerEmployeeID}
+
+	scope, err := services.ReviewManagerScope("user", manager)
+	require.NoError(t, err)
+	require.NotNil(t, scope)
+	assert.Equal(t, uint(5), *scope, "经理按本人员工档案限定直接下属")
+
+	scope, err = services.ReviewManagerScope("hr", nil)
+	require.NoError(t, err)
+	assert.Nil(t, scope, "人事不受下属范围限制")
+
+	_, err = services.ReviewManagerScope("user", &models.User{ID: 10})
+	assert.ErrorIs(t, err, services.ErrReviewScope, "未关联员工档案的账号没有下属范围")
+	_, err = services.ReviewManagerScope("user", nil)
+	assert.ErrorIs(t, err, services.ErrReviewScope)
+
+	reports := []models.Employee{
+		{ID: 21, Name: "下属甲", ManagerID: &managerEmployeeID},
+		{ID: 22, Name: "下属乙", ManagerID: &managerEmployeeID},
+	}
+	selected, err := services.SelectReviewEmployees(reports, []uint{22, 21})
+	require.NoError(t, err)
+	assert.Equal(t, uint(22), selected[0].ID)
+	assert.Equal(t, uint(21), selected[1].ID)
+
+	_, err = services.SelectReviewEmployees(reports, []uint{21, 30})
+	assert.ErrorIs(t, err, services.ErrReviewScope, "不能为非直接下属提交调薪建议")
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"testing"
+
+	"gin-project/models"
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrencyConverter(t *testing.T) {
+	converter := services.NewCurrencyConverter([]models.ExchangeRate{
+		{FromCurrency: "USD", ToCurrency: "CNY", EffectiveDate: date("2024-09-01"), Rate: 7.1},
+		{FromCurrency: "USD", ToCurrency: "CNY", EffectiveDate: date("2024-10-01"), Rate: 7.2},
+		{FromCurrency: "CNY", ToCurrency: "HKD", EffectiveDate: date("2024-01-01"), Rate: 1.25},
+	}, "cny")
+	assert.Equal(t, "CNY", converter.Target())
+
+	rate, ok := converter.Rate("USD", date("2024-09-30"))
+	require.True(t, ok)
+	assert.Equal(t, 7.1, rate, "按日期取当时有效的汇率")
+	rate, _ = converter.Rate("usd", date("2024-10-31"))
+	assert.Equal(t, 7.2, rate)
+	rate, ok = converter.Rate("HKD", date("2024-10-31"))
+	require.True(t, ok)
+	assert.Equal(t, 0.8, rate, "缺少直接汇率时使用反向汇率的倒数")
+	rate, _ = converter.Rate("", date("2024-10-31"))
+	assert.Equal(t, 1.0, rate)
+
+	conversion, err := converter.Convert(money(1000.55), "USD", date("2024-10-31"))
+	require.NoError(t, err)
+	assert.Equal(t, money(7203.96), conversion.ConvertedAmount)
+
+	_, err = converter.Convert(money(100), "USD", date("2024-08-31"))
+	require.Error(t, err)
+	assert.IsType(t, &utils.ValidationError{}, err)
+}
+
+func TestSummarizeCurrencies(t *testing.T) {
+	converter := services.NewCurrencyConverter([]models.ExchangeRate{
+		{FromCurrency: "USD", ToCurrency: "CNY", EffectiveDate: date("2024-09-01"), Rate: 7.1},
+		{FromCurrency: "USD", ToCurrency: "CNY", EffectiveDate: date("2024-10-01"), Rate: 7.2},
+	}, "CNY")
+
+	summary, err := converter.SummarizeCurrencies([]services.CurrencyPeriodTotal{
+		{Currency: "USD", PeriodEnd: date("2024-09-30"), Count: 2, TotalGross: money(10000), TotalNet: money(8000)},
+		{Currency: "CNY", PeriodEnd: date("2024-10-31"), Count: 3, TotalGross: money(30000), TotalNet: money(25000)},
+		{Currency: "USD", PeriodEnd: date("2024-10-31"), Count: 2, TotalGross: money(10000), TotalNet: money(8000)},
+	})
+	require.NoError(t, err)
+	require.Len(t, summary, 2)
+
+	assert.Equal(t, "CNY", summary[0].Currency)
+	assert.Equal(t, money(30000), summary[0].ReportingGross)
+
+	usd := summary[1]
+	assert.Equal(t, 4, usd.EmployeeCount)
+	assert.Equal(t, money(20000), usd.TotalGross, "原币金额只在同币种内相加")
+	assert.Equal(t, money(143000), usd.ReportingGross, "各周期按周期结束日汇率分别折算")
+	assert.Equal(t, money(114400), usd.ReportingNet)
+
+	_, err = converter.SummarizeCurrencies([]services.CurrencyPeriodTotal{
+		{Currency: "EUR", PeriodEnd: date("2024-10-31"), Count: 1, TotalGross: money(100)},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"gin-project/models"
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func journalDetail(id uint, code string, category models.SalaryComponentCategory, amount float64) models.SalaryDetail {
+	return models.SalaryDetail{
+		ComponentID: id,
+		Component:   &models.SalaryComponent{ID: id, Code: code, Name: code, Category: category},
+		FinalValue:  money(amount),
+	}
+}
+
+func TestBuildPayrollJournal(t *testing.T) {
+	period := &models.PayrollPeriod{ID: 7, Name: "2024年10月", StartDate: date("2024-10-01"), EndDate: date("2024-10-31")}
+	salaries := []models.EnhancedSalary{
+		{EmployeeID: 1, Components: []models.SalaryDetail{
+			journalDetail(1, "BASE", models.ComponentCategoryBase, 10000.01),
+			journalDetail(2, "IIT", models.ComponentCategoryTax, 290),
+		}},
+		{EmployeeID: 2, Components: []models.SalaryDetail{
+			journalDetail(1, "BASE", models.ComponentCategoryBase, 8000),
+		}},
+	}
+	mappings := map[uint]models.GLAccountMapping{
+		1: {ComponentID: 1, DebitAccount: "6602", CreditAccount: "2211"},
+		2: {ComponentID: 2, DebitAccount: "2211", CreditAccount: "2221"},
+	}
+
+	assignments := []models.EmployeeAssignment{
+		{WorkPercentage: 60, OrganizationUnit: &models.OrganizationUnit{CostCenter: "CC-RD"}},
+		{WorkPercentage: 40, OrganizationUnit: &models.OrganizationUnit{CostCenter: "CC-OPS"}},
+	}
+	allocations := map[uint][]services.CostAllocation{
+		1: services.AllocateByWorkPercentage(assignments, "CC-HQ"),
+		2: services.AllocateByWorkPercentage(nil, "CC-HQ"),
+	}
+
+	journal, err := services.BuildPayrollJournal(period, salaries, mappings, allocations)
+	require.NoError(t, err)
+	assert.Equal(t, "PAY-202410-7", journal.Reference)
+	assert.Equal(t, journal.TotalDebit, journal.TotalCredit)
+	assert.Equal(t, money(18290.01), journal.TotalDebit)
+
+	debits := map[string]models.Money{}
+	for _, line := range journal.Lines {
+		if line.Debit.Sign() > 0 {
+			key := line.Account + "/" + line.CostCenter
+			debits[key] = debits[key].Add(line.Debit)
+		}
+	}
+	assert.Equal(t, money(6000.01), debits["6602/CC-RD"], "分摊尾差按余数补齐")
+	assert.Equal(t, money(4000), debits["6602/CC-OPS"])
+	assert.Equal(t, money(8000), debits["6602/CC-HQ"])
+	assert.Equal(t, money(290), debits["2211/"], "代扣项目不分摊成本中心")
+
+	content, err := services.WriteJournalCSV(journal)
+	require.NoError(t, err)
+	records, err := csv.NewReader(bytes.NewReader(content[3:])).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PAY-202410-7", "", "", "", "合计", "", "18290.01", "18290.01", "CNY", "", ""}, records[len(records)-1])
+
+	delete(mappings, 2)
+	_, err = services.BuildPayrollJournal(period, salaries, mappings, allocations)
+	var validationErr *utils.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Contains(t, validationErr.Message, "IIT(IIT)")
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gin-project/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func money(amount float64) models.Money {
+	return models.NewMoney(amount)
+}
+
+func TestRoundMoney(t *testing.T) {
+	assert.Equal(t, "2.68", models.RoundMoney(2.675, models.RoundHalfUp).String(), "按十进制舍入，不受二进制误差影响")
+	assert.Equal(t, "2.66", models.RoundMoney(2.665, models.RoundHalfEven).String())
+	assert.Equal(t, "0.12", models.RoundMoney(0.125, models.RoundHalfEven).String())
+	assert.Equal(t, "0.14", models.RoundMoney(0.135, models.RoundHalfEven).String())
+	assert.Equal(t, "-2.68", models.RoundMoney(-2.675, models.RoundHalfUp).String())
+	assert.Equal(t, "1235.00", models.RoundMoney(1234.5, models.RoundYuan).String())
+	assert.Equal(t, "1234.00", models.RoundMoney(1234.49, models.RoundYuan).String())
+	assert.Equal(t, "1234.57", models.RoundMoney(1234.567, "").String(), "未配置规则时四舍五入到分")
+
+	assert.Equal(t, "3.33", money(10).Mul(1.0/3, models.RoundHalfUp).String())
+	assert.Equal(t, money(1235), money(1234.5).Round(models.RoundYuan))
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	// 0.1 + 0.2 在浮点下累加会产生尾差，定点金额逐分相加可精确对平
+	total := models.SumMoney(money(0.1), money(0.2), money(8123.45), money(6000.10))
+	assert.Equal(t, int64(1412385), total.Cents())
+	assert.Equal(t, "14123.85", total.String())
+	assert.Equal(t, -1, money(100).Cmp(money(100.01)))
+	assert.Equal(t, money(0.01), money(100).Sub(money(100.01)).Abs())
+
+	parsed, err := models.ParseMoney(" 1234.5 ")
+	require.NoError(t, err)
+	assert.Equal(t, models.MoneyFromCents(123450), parsed)
+	_, err = models.ParseMoney("12a")
+	assert.Error(t, err)
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var payload struct {
+		Amount models.Money  `json:"amount"`
+		Manual *models.Money `json:"manual"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":"8123.456","manual":null}`), &payload))
+	assert.Equal(t, money(8123.46), payload.Amount)
+	assert.Nil(t, payload.Manual)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":-0.5}`), &payload))
+	content, err := json.Marshal(payload)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":-0.50,"manual":null}`, string(content))
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gin-project/models"
+	"gin-project/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func jobItems(count int) []models.PayrollJobItem {
+	items := make([]models.PayrollJobItem, count)
+	for i := range items {
+		items[i] = models.PayrollJobItem{ID: uint(i + 1), EmployeeID: uint(100 + i), Status: models.PayrollJobItemPending}
+	}
+	return items
+}
+
+func TestNormalizeJobConcurrency(t *testing.T) {
+	assert.Equal(t, 4, services.NormalizeJobConcurrency(0), "未指定时使用默认并发数")
+	assert.Equal(t, 4, services.NormalizeJobConcurrency(-2))
+	assert.Equal(t, 8, services.NormalizeJobConcurrency(8))
+	assert.Equal(t, 16, services.NormalizeJobConcurrency(64), "不超过并发上限")
+}
+
+func TestDispatchJobItemsBoundedConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[uint]int)
+	var current, peak int32
+
+	dispatched := services.DispatchJobItems(context.Background(), jobItems(50), 3, func(item models.PayrollJobItem) {
+		n := atomic.AddInt32(&current, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&current, -1)
+
+		mu.Lock()
+		seen[item.ID]++
+		mu.Unlock()
+	})
+
+	assert.Equal(t, 50, dispatched)
+	assert.Len(t, seen, 50)
+	for id, count := range seen {
+		assert.Equal(t, 1, count, "员工明细 %d 只处理一次", id)
+	}
+	assert.LessOrEqual(t, int(peak), 3, "同时处理的员工数不超过并发数")
+}
+
+func TestDispatchJobItemsStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var processed []uint
+	dispatched := services.DispatchJobItems(ctx, jobItems(10), 1, func(item models.PayrollJobItem) {
+		processed = append(processed, item.ID)
+		if len(processed) == 3 {
+			cancel()
+		}
+	})
+
+	assert.Equal(t, len(processed), dispatched, "已派发的员工都会处理完毕")
+	assert.GreaterOrEqual(t, dispatched, 3)
+	assert.LessOrEqual(t, dispatched, 4, "取消后停止派发，其余员工保留待恢复")
+
+	dispatched = services.DispatchJobItems(ctx, jobItems(10), 2, func(models.PayrollJobItem) {
+		t.Fatal("已取消的任务不应派发员工")
+	})
+	assert.Zero(t, dispatched)
+}
+
+func TestPayrollJobStale(t *testing.T) {
+	now := time.Date(2024, 7, 1, 9, 0, 0, 0, time.Local)
+	job := &models.PayrollJob{Status: models.PayrollJobRunning}
+
+	assert.True(t, services.PayrollJobStale(job, now), "尚未被认领的任务可由巡检恢复")
+
+	fresh := now.Add(-30 * time.Second)
+	job.HeartbeatAt = &fresh
+	assert.False(t, services.PayrollJobStale(job, now), "心跳未过期的任务仍由原进程执行")
+
+	expired := now.Add(-2 * time.Minute)
+	job.HeartbeatAt = &expired
+	assert.True(t, services.PayrollJobStale(job, now), "进程重启后心跳过期的任务由巡检接管")
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"testing"
+
+	"gin-project/models"
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOffCyclePaymentDetails(t *testing.T) {
+	components := map[string]*models.SalaryComponent{
+		"REFERRAL":   {ID: 1, Code: "REFERRAL", Category: models.ComponentCategoryBonus, RoundingRule: models.RoundYuan},
+		"CORRECTION": {ID: 2, Code: "CORRECTION", Category: models.ComponentCategoryDeduction},
+	}
+
+	details, err := services.OffCyclePaymentDetails(components, []services.OffCyclePaymentLine{
+		{ComponentCode: "REFERRAL", Amount: money(2000.6), Notes: "推荐入职"},
+		{ComponentCode: " CORRECTION ", Amount: money(120.35)},
+	})
+	require.NoError(t, err)
+	require.Len(t, details, 2)
+	assert.Equal(t, uint(1), details[0].ComponentID)
+	assert.Equal(t, money(2001), details[0].FinalValue, "按组件舍入规则取整")
+	assert.Equal(t, "推荐入职", details[0].Notes)
+	assert.Equal(t, money(120.35), details[1].FinalValue)
+
+	cases := map[string][]services.OffCyclePaymentLine{
+		"组件不在允许范围内": {{ComponentCode: "BASE_SALARY", Amount: money(100)}},
+		"金额必须为正":    {{ComponentCode: "REFERRAL", Amount: money(-100)}},
+		"组件重复录入":    {{ComponentCode: "REFERRAL", Amount: money(100)}, {ComponentCode: "REFERRAL", Amount: money(50)}},
+		"至少一个发放项目":  nil,
+	}
+	for name, lines := range cases {
+		_, err := services.OffCyclePaymentDetails(components, lines)
+		assert.IsType(t, &utils.ValidationError{}, err, name)
+	}
+}
+
+func TestCheckOffCycleComponent(t *testing.T) {
+	assert.NoError(t, services.CheckOffCycleComponent(&models.SalaryComponent{Code: "REFERRAL", Category: models.ComponentCategoryBonus}))
+	assert.NoError(t, services.CheckOffCycleComponent(&models.SalaryComponent{Code: "SIGN_ON", Category: models.ComponentCategoryAllowance}))
+
+	assert.Error(t, services.CheckOffCycleComponent(&models.SalaryComponent{Code: services.IncomeTaxComponentCode, Category: models.ComponentCategoryTax}))
+	assert.Error(t, services.CheckOffCycleComponent(&models.SalaryComponent{Code: "CUSTOM_TAX", Category: models.ComponentCategoryTax}))
+	assert.Error(t, services.CheckOffCycleComponent(&models.SalaryComponent{Code: services.SeveranceComponentCode, Category: models.ComponentCategoryAllowance}),
+		"离职结算组件只能通过结算单生成")
+	assert.Error(t, services.CheckOffCycleComponent(&models.SalaryComponent{Code: services.AnnualBonusComponentCode, Category: models.ComponentCategoryBonus}))
+	assert.Error(t, services.CheckOffCycleComponent(&models.SalaryComponent{Code: "EMPLOYER_PENSION", Category: models.ComponentCategoryEmployerCost}))
+}
+
+func TestPayrollPeriodAllowedComponentCodes(t *testing.T) {
+	period := models.PayrollPeriod{PeriodType: models.PeriodTypeOffCycle, AllowedComponents: " REFERRAL, ,SEVERANCE,REFERRAL"}
+	assert.True(t, period.IsOffCycle())
+	assert.True(t, period.IsSupplemental())
+	assert.Equal(t, []string{"REFERRAL", "SEVERANCE"}, period.AllowedComponentCodes())
+
+	regular := models.PayrollPeriod{PeriodType: models.PeriodTypeMonthly}
+	assert.False(t, regular.IsSupplemental())
+	assert.Empty(t, regular.AllowedComponentCodes())
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"gin-project/models"
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayrollPeriodCloseWorkflow(t *testing.T) {
+	flow := []models.PayrollPeriodStatus{
+		models.PeriodStatusOpen,
+		models.PeriodStatusCalculating,
+		models.PeriodStatusReviewed,
+		models.PeriodStatusApproved,
+		models.PeriodStatusClosed,
+	}
+	for i := 0; i < len(flow)-1; i++ {
+		assert.True(t, flow[i].CanTransitionTo(flow[i+1]), "%s -> %s", flow[i], flow[i+1])
+	}
+	assert.False(t, models.PeriodStatusOpen.CanTransitionTo(models.PeriodStatusClosed))
+	assert.False(t, models.PeriodStatusClosed.CanTransitionTo(models.PeriodStatusOpen))
+
+	period := models.PayrollPeriod{Status: models.PeriodStatusCalculating}
+	assert.False(t, period.InputsLocked())
+	period.IsLocked = true
+	assert.True(t, period.InputsLocked())
+	period = models.PayrollPeriod{Status: models.PeriodStatusReviewed}
+	assert.True(t, period.InputsLocked())
+	assert.False(t, period.IsClosed())
+
+	err := fmt.Errorf("wrapped: %w", utils.NewConflictError("locked"))
+	conflictErr, ok := utils.AsConflictError(err)
+	assert.True(t, ok)
+	assert.Equal(t, "locked", conflictErr.Message)
+}
+
+func TestPayrollPeriodChangesRequireOperator(t *testing.T) {
+	// 操作人缺失时在访问数据库前拒绝，审计记录不会出现操作人为 0 的变更
+	service := &services.SalaryService{}
+
+	assert.IsType(t, &utils.ValidationError{}, service.LockPayrollPeriod(1, 0))
+	assert.IsType(t, &utils.ValidationError{}, service.UnlockPayrollPeriod(1, "补录考勤", 0))
+
+	_, err := service.TransitionPayrollPeriod(1, models.PeriodStatusReviewed, 0)
+	assert.IsType(t, &utils.ValidationError{}, err)
+	_, err = service.ReopenPayrollPeriod(1, "补发奖金", 0)
+	assert.IsType(t, &utils.ValidationError{}, err)
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"gin-project/models"
+	"gin-project/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func writeRegister(t *testing.T, format services.PayrollRegisterFormat) []byte {
+	columns := []services.PayrollRegisterColumn{
+		{ComponentID: 1, Code: "BASE", Name: "基本工资", Category: models.ComponentCategoryBase},
+		{ComponentID: 2, Code: "IIT", Name: "个人所得税", Category: models.ComponentCategoryTax},
+	}
+	rows := []services.PayrollRegisterRow{
+		{EmployeeNo: "E001", EmployeeName: "张三", DepartmentID: 1, DepartmentName: "研发部", Status: "已批准",
+			Amounts: map[uint]models.Money{1: money(10000), 2: money(290)}, GrossSalary: money(10000), Deductions: money(290), NetSalary: money(9710)},
+		{EmployeeNo: "E002", EmployeeName: "李四", DepartmentID: 1, DepartmentName: "研发部", Status: "已批准",
+			Amounts: map[uint]models.Money{1: money(8000), 2: money(90)}, GrossSalary: money(8000), Deductions: money(90), NetSalary: money(7910)},
+		{EmployeeNo: "E003", EmployeeName: "王五", DepartmentID: 2, DepartmentName: "销售部", Status: "已计算",
+			Amounts: map[uint]models.Money{1: money(6000.5)}, GrossSalary: money(6000.5), NetSalary: money(6000.5)},
+	}
+
+	var buf bytes.Buffer
+	writer, err := services.NewPayrollRegisterWriter(&buf, format, columns)
+	require.NoError(t, err)
+	for i := range rows {
+		require.NoError(t, writer.WriteRow(&rows[i]))
+	}
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestPayrollRegisterCSV(t *testing.T) {
+	content := writeRegister(t, services.PayrollRegisterCSV)
+	require.True(t, bytes.HasPrefix(content, []byte("\xEF\xBB\xBF")))
+
+	records, err := csv.NewReader(bytes.NewReader(content[3:])).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 7)
+	assert.Equal(t, []string{"工号", "姓名", "部门", "状态", "基本工资", "个人所得税", "应发合计", "扣除合计", "实发工资", "企业承担"}, records[0])
+	assert.Equal(t, []string{"小计", "", "研发部（2 人）", "", "18000.00", "380.00", "18000.00", "380.00", "17620.00", "0.00"}, records[3])
+	assert.Equal(t, "E003", records[4][0])
+	assert.Equal(t, "0.00", records[4][5])
+	assert.Equal(t, "销售部（1 人）", records[5][2])
+	assert.Equal(t, []string{"总计", "", "3 人", "", "24000.50", "380.00", "24000.50", "380.00", "23620.50", "0.00"}, records[6])
+}
+
+func TestPayrollRegisterExcel(t *testing.T) {
+	content := writeRegister(t, services.PayrollRegisterExcel)
+	workbook, err := excelize.OpenReader(bytes.NewReader(content))
+	require.NoError(t, err)
+
+	sheet := "薪资登记表"
+	panes, err := workbook.GetPanes(sheet)
+	require.NoError(t, err)
+	assert.True(t, panes.Freeze)
+	assert.Equal(t, 1, panes.YSplit)
+
+	value, err := workbook.GetCellValue(sheet, "E7")
+	require.NoError(t, err)
+	assert.Equal(t, "24,000.50", value, "金额使用千分位两位小数格式")
+	value, err = workbook.GetCellValue(sheet, "A4")
+	require.NoError(t, err)
+	assert.Equal(t, "小计", value)
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+
+	"gin-project/models"
+	"gin-project/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func varianceSalary(id, employeeID uint, no string, details ...models.SalaryDetail) models.EnhancedSalary {
+	salary := models.EnhancedSalary{
+		ID:         id,
+		EmployeeID: employeeID,
+		Employee:   &models.Employee{ID: employeeID, EmployeeID: no, Name: no},
+		Components: details,
+	}
+	for _, detail := range details {
+		salary.GrossSalary = salary.GrossSalary.Add(detail.FinalValue)
+	}
+	salary.NetSalary = salary.GrossSalary
+	return salary
+}
+
+func varianceDetail(id uint, code string, amount float64) models.SalaryDetail {
+	return models.SalaryDetail{
+		ComponentID: id,
+		Component:   &models.SalaryComponent{ID: id, Code: code, Name: code, Category: models.ComponentCategoryAllowance},
+		FinalValue:  money(amount),
+	}
+}
+
+func TestComparePayroll(t *testing.T) {
+	previousPeriod := &models.PayrollPeriod{ID: 1, Name: "2024年9月", StartDate: date("2024-09-01"), EndDate: date("2024-09-30")}
+	currentPeriod := &models.PayrollPeriod{ID: 2, Name: "2024年10月", StartDate: date("2024-10-01"), EndDate: date("2024-10-31")}
+
+	leaver := varianceSalary(3, 3, "E003", varianceDetail(1, "BASE", 6000))
+	leaver.Employee.TerminationDate = &models.CustomDate{Time: date("2024-09-30")}
+	joiner := varianceSalary(13, 4, "E004", varianceDetail(1, "BASE", 7000))
+	joiner.Employee.HireDate = &models.CustomDate{Time: date("2024-10-08")}
+
+	previous := []models.EnhancedSalary{
+		varianceSalary(1, 1, "E001", varianceDetail(1, "BASE", 10000)),
+		varianceSalary(2, 2, "E002", varianceDetail(1, "BASE", 8000), varianceDetail(2, "MEAL", 300)),
+		leaver,
+	}
+	current := []models.EnhancedSalary{
+		varianceSalary(11, 1, "E001", varianceDetail(1, "BASE", 12000), varianceDetail(3, "TRAFFIC", 200)),
+		varianceSalary(12, 2, "E002", varianceDetail(1, "BASE", 8000), varianceDetail(2, "MEAL", 320)),
+		joiner,
+	}
+
+	report := services.ComparePayroll(previousPeriod, currentPeriod, previous, current, services.DefaultVarianceThresholds)
+	require.Len(t, report.Employees, 2)
+
+	raised := report.Employees[0]
+	assert.Equal(t, "E001", raised.EmployeeNo)
+	assert.True(t, raised.Flagged)
+	assert.Equal(t, money(2200), raised.GrossChange)
+	assert.Equal(t, 22.0, raised.GrossChangePercent)
+	require.Len(t, raised.Components, 2)
+	assert.True(t, raised.Components[1].IsNew)
+	require.Len(t, raised.Explanations, 1)
+	assert.Equal(t, "new_component", raised.Explanations[0].Type)
+
+	steady := report.Employees[1]
+	assert.False(t, steady.Flagged, "20 元变动低于阈值")
+	require.Len(t, steady.Components, 1)
+	assert.Equal(t, "MEAL", steady.Components[0].Code)
+
+	require.Len(t, report.Joiners, 1)
+	assert.Equal(t, "本期入职", report.Joiners[0].Reason)
+	require.Len(t, report.Leavers, 1)
+	assert.Equal(t, "已离职", report.Leavers[0].Reason)
+
+	assert.Equal(t, 3, report.Summary.CurrentHeadcount)
+	assert.Equal(t, money(24300), report.Summary.PreviousGross)
+	assert.Equal(t, money(27520), report.Summary.CurrentGross)
+	assert.Equal(t, 1, report.Summary.FlaggedEmployees)
+
+	content, err := services.WriteVarianceWorkbook(report)
+	require.NoError(t, err)
+	workbook, err := excelize.OpenReader(bytes.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"汇总", "员工差异", "项目差异", "人员变动"}, workbook.GetSheetList())
+	value, err := workbook.GetCellValue("员工差异", "A2")
+	require.NoError(t, err)
+	assert.Equal(t, "E001", value)
+}
+
+func TestExplainAttendanceChange(t *testing.T) {
+	explanations := services.ExplainAttendanceChange(
+		services.AttendanceInputs{WorkingDays: 21, AbsenceDays: 0, OvertimeHours: 4},
+		services.AttendanceInputs{WorkingDays: 21, AbsenceDays: 1.5, OvertimeHours: 4},
+	)
+	require.Len(t, explanations, 1)
+	assert.Equal(t, "attendance", explanations[0].Type)
+	assert.Equal(t, "缺勤天数 0天 → 1.5天", explanations[0].Description)
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rc4"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gin-project/models"
+	"gin-project/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func payslipSalary() *models.EnhancedSalary {
+	component := func(id uint, code, name string, category models.SalaryComponentCategory, sort int) *models.SalaryComponent {
+		return &models.SalaryComponent{ID: id, Code: code, Name: name, Category: category, Sort: sort}
+	}
+	return &models.EnhancedSalary{
+		ID:              7,
+		Employee:        &models.Employee{EmployeeID: "E001", Name: "张三", IDCard: "11010519900307123x", Department: &models.Department{Name: "研发部"}},
+		PayrollPeriod:   &models.PayrollPeriod{Name: "2024年3月", StartDate: date("2024-03-01"), EndDate: date("2024-03-31")},
+		GrossSalary:     money(12000),
+		TotalDeductions: money(1800),
+		NetSalary:       money(10200),
+		TaxableIncome:   money(12000),
+		TaxDeductions:   money(1500),
+		IncomeTax:       money(300),
+		EmployerCost:    money(3000),
+		Components: []models.SalaryDetail{
+			{ComponentID: 4, Component: component(4, services.IncomeTaxComponentCode, "个人所得税", models.ComponentCategoryTax, 90), FinalValue: money(300), CalculationFormula: "累计计算"},
+			{ComponentID: 2, Component: component(2, "MEAL", "餐补", models.ComponentCategoryAllowance, 2), FinalValue: money(2000)},
+			{ComponentID: 1, Component: component(1, "BASE", "基本工资", models.ComponentCategoryBase, 1), FinalValue: money(10000)},
+			{ComponentID: 3, Component: component(3, "SI_PENSION", "养老保险(个人)", models.ComponentCategoryInsurance, 10), FinalValue: money(1500)},
+			{ComponentID: 5, Component: component(5, "SI_PENSION_ER", "养老保险(单位)", models.ComponentCategoryEmployerCost, 11), FinalValue: money(3000)},
+		},
+	}
+}
+
+func TestBuildPayslip(t *testing.T) {
+	template := &models.PayslipTemplate{Title: "工资单", ShowYTD: true, ShowTaxBreakdown: true}
+	ytd := &services.PayslipYTD{GrossSalary: money(36000), NetSalary: money(30600), IncomeTax: money(900), Components: map[uint]models.Money{1: money(30000)}}
+	payslip := services.BuildPayslip(payslipSalary(), template, ytd)
+
+	require.Len(t, payslip.Earnings.Lines, 2)
+	assert.Equal(t, "BASE", payslip.Earnings.Lines[0].Code)
+	assert.Equal(t, money(30000), payslip.Earnings.Lines[0].YTD)
+	assert.Equal(t, money(12000), payslip.Earnings.Total)
+	require.Len(t, payslip.Deductions.Lines, 2)
+	assert.Equal(t, services.IncomeTaxComponentCode, payslip.Deductions.Lines[1].Code)
+	assert.Nil(t, payslip.EmployerCosts, "企业承担部分默认不展示")
+	require.NotNil(t, payslip.Tax)
+	assert.Equal(t, money(900), payslip.Tax.YTDIncomeTax)
+	assert.Equal(t, "累计计算", payslip.Tax.Formula)
+	assert.Equal(t, "研发部", payslip.DepartmentName)
+
+	template.ShowEmployerCost, template.ShowTaxBreakdown = true, false
+	payslip = services.BuildPayslip(payslipSalary(), template, ytd)
+	require.NotNil(t, payslip.EmployerCosts)
+	assert.Equal(t, money(3000), payslip.EmployerCosts.Total)
+	assert.Nil(t, payslip.Tax)
+}
+
+func TestPayslipPassword(t *testing.T) {
+	employee := payslipSalary().Employee
+	password, err := services.PayslipPassword(&models.PayslipTemplate{PasswordRule: models.PayslipPasswordIDCardSuffix, PasswordLength: 6}, employee)
+	require.NoError(t, err)
+	assert.Equal(t, "07123X", password)
+
+	password, err = services.PayslipPassword(&models.PayslipTemplate{PasswordRule: models.PayslipPasswordEmployeeNo}, employee)
+	require.NoError(t, err)
+	assert.Equal(t, "E001", password)
+
+	password, err = services.PayslipPassword(&models.PayslipTemplate{PasswordRule: models.PayslipPasswordNone}, employee)
+	require.NoError(t, err)
+	assert.Empty(t, password)
+
+	_, err = services.PayslipPassword(&models.PayslipTemplate{PasswordRule: models.PayslipPasswordBirthday}, employee)
+	assert.Error(t, err)
+}
+
+func TestRenderPayslipPDF(t *testing.T) {
+	payslip := services.BuildPayslip(payslipSalary(), &models.PayslipTemplate{Title: "工资单", ShowYTD: true}, nil)
+	nameHex := "5F204E09" // 张三 (UCS-2)
+
+	plain, err := services.RenderPayslipPDF(payslip, "")
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(plain, []byte("%PDF-1.4")))
+	assert.True(t, bytes.HasSuffix(plain, []byte("%%EOF\n")))
+	assert.Contains(t, string(plain), nameHex)
+	assert.NotContains(t, string(plain), "/Encrypt")
+
+	encrypted, err := services.RenderPayslipPDF(payslip, "07123X")
+	require.NoError(t, err)
+	content := string(encrypted)
+	assert.Contains(t, content, "/Filter /Standard /V 2 /R 3 /Length 128")
+	assert.NotContains(t, content, nameHex)
+
+	// 按 PDF 标准安全处理器用打开口令推导密钥，校验 U 值并解密首页内容流
+	hexField := func(pattern string) []byte {
+		match := regexp.MustCompile(pattern).FindStringSubmatch(content)
+		require.Len(t, match, 2, pattern)
+		value, err := hex.DecodeString(match[1])
+		require.NoError(t, err)
+		return value
+	}
+	owner := hexField(`/O <([0-9a-f]+)>`)
+	user := hexField(`/U <([0-9a-f]+)>`)
+	fileID := hexField(`/ID \[<([0-9a-f]+)>`)
+
+	padding, _ := hex.DecodeString("28bf4e5e4e758a4164004e56fffa01082e2e00b6d0683e802f0ca9fe6453697a")
+	h := md5.New()
+	h.Write(append([]byte("07123X"), padding[:26]...))
+	h.Write(owner)
+	h.Write([]byte{0xC4, 0xF8, 0xFF, 0xFF})
+	h.Write(fileID)
+	key := h.Sum(nil)
+	for i := 0; i < 50; i++ {
+		sum := md5.Sum(key)
+		key = sum[:]
+	}
+
+	h = md5.New()
+	h.Write(padding)
+	h.Write(fileID)
+	expectedU := h.Sum(nil)
+	for i := 0; i <= 19; i++ {
+		roundKey := make([]byte, len(key))
+		for j := range key {
+			roundKey[j] = key[j] ^ byte(i)
+		}
+		cipher, _ := rc4.NewCipher(roundKey)
+		cipher.XORKeyStream(expectedU, expectedU)
+	}
+	assert.Equal(t, expectedU, user[:16])
+
+	// 加密时对象 8 为首页，9 为其内容流
+	start := strings.Index(content, "9 0 obj\n")
+	require.Positive(t, start)
+	stream := content[start:]
+	stream = stream[strings.Index(stream, "stream\n")+len("stream\n") : strings.Index(stream, "\nendstream")]
+	objectKey := md5.Sum(append(append([]byte(nil), key...), 9, 0, 0, 0, 0))
+	cipher, _ := rc4.NewCipher(objectKey[:])
+	decrypted := make([]byte, len(stream))
+	cipher.XORKeyStream(decrypted, []byte(stream))
+	assert.Contains(t, string(decrypted), nameHex)
+}
+
+func TestPayslipVisibleToEmployee(t *testing.T) {
+	salary := payslipSalary()
+	salary.EmployeeID = 12
+	salary.Status = models.SalaryStatusApproved
+
+	assert.True(t, services.PayslipVisibleToEmployee(salary, 12), "本人可查看已批准的工资单")
+	assert.False(t, services.PayslipVisibleToEmployee(salary, 13), "其他员工不可查看")
+	assert.False(t, services.PayslipVisibleToEmployee(salary, 0), "未经二次验证取得员工ID时不可查看")
+
+	salary.Status = models.SalaryStatusPaid
+	assert.True(t, services.PayslipVisibleToEmployee(salary, 12))
+
+	salary.Status = models.SalaryStatusCalculated
+	assert.False(t, services.PayslipVisibleToEmployee(salary, 12), "未批准的工资单本人也不可查看")
+}
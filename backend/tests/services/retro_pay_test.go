@@ -0,0 +1,72 @@
+package services
+
+import (
+	"testing"
+
+	"gin-project/models"
+	"gin-project/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetroPayShadowRecalculation(t *testing.T) {
+	calendar := nationalDayCalendar()
+	input := services.ProrationInput{
+		PeriodStart: date("2024-10-01"),
+		PeriodEnd:   date("2024-10-31"),
+		BaseSalary:  money(10000),
+		Method:      models.ProrationWorkingDays,
+	}
+	paid := services.ProrateBaseSalary(calendar, input)
+	require.Equal(t, money(10000), paid.ProratedBase)
+
+	// 10 月发放后补录 10-21 生效的调薪，影子重算按调薪前后分段折算
+	adjustment := models.SalaryAdjustment{ID: 7, EffectiveDate: date("2024-10-21"), OldBaseSalary: money(10000), NewBaseSalary: money(12000)}
+	input.Adjustments = []models.SalaryAdjustment{adjustment}
+	shadow := services.ProrateBaseSalary(calendar, input)
+	require.Equal(t, money(10947.37), shadow.ProratedBase)
+
+	salary := &models.EnhancedSalary{ID: 31, PayrollPeriodID: 10, Version: 2, GrossSalary: paid.ProratedBase.Add(money(500))}
+	recalculated := shadow.ProratedBase.Add(money(500))
+	item := services.RetroPayDiff(3, &adjustment, salary, recalculated, models.Money{})
+	require.NotNil(t, item)
+	assert.Equal(t, money(947.37), item.Amount)
+	assert.Equal(t, money(10500), item.PaidGross)
+	assert.Equal(t, money(11447.37), item.RecalculatedGross)
+	assert.Equal(t, uint(7), item.AdjustmentID)
+	assert.Equal(t, uint(10), item.SourcePeriodID)
+	assert.Equal(t, uint(31), item.SourceSalaryID)
+	assert.Equal(t, 2, item.SourceVersion)
+	assert.Equal(t, models.RetroPayPending, item.Status)
+
+	assert.Nil(t, services.RetroPayDiff(3, &adjustment, salary, recalculated, money(947.37)), "已生成的补发不重复计入")
+
+	// 已发放薪资中并入的其他补发不参与比对
+	salary.GrossSalary = salary.GrossSalary.Add(money(200))
+	salary.Components = []models.SalaryDetail{
+		{Component: &models.SalaryComponent{Code: services.RetroPayComponentCode}, FinalValue: money(200)},
+	}
+	item = services.RetroPayDiff(3, &adjustment, salary, recalculated, models.Money{})
+	require.NotNil(t, item)
+	assert.Equal(t, money(947.37), item.Amount)
+
+	item = services.RetroPayDiff(3, &adjustment, salary, money(10000), models.Money{})
+	require.NotNil(t, item)
+	assert.Equal(t, money(-500), item.Amount, "重算低于已发放时生成补扣")
+}
+
+func TestLatestSalaryVersions(t *testing.T) {
+	sep := &models.PayrollPeriod{ID: 9, StartDate: date("2024-09-01")}
+	oct := &models.PayrollPeriod{ID: 10, StartDate: date("2024-10-01")}
+	salaries := []models.EnhancedSalary{
+		{ID: 1, PayrollPeriodID: 10, Version: 1, PayrollPeriod: oct},
+		{ID: 2, PayrollPeriodID: 9, Version: 1, PayrollPeriod: sep},
+		{ID: 3, PayrollPeriodID: 10, Version: 2, PayrollPeriod: oct},
+	}
+
+	latest := services.LatestSalaryVersions(salaries)
+	require.Len(t, latest, 2)
+	assert.Equal(t, uint(2), latest[0].ID)
+	assert.Equal(t, uint(3), latest[1].ID, "同一周期只比对最新版本")
+}
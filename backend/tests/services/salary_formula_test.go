@@ -0,0 +1,137 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"gin-project/models"
+	"gin-project/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateFormula(t *testing.T) {
+	service := services.NewSalaryService(nil)
+	context := services.FormulaContext{
+		BaseSalary: 8700,
+		Components: map[string]float64{"BASE_SALARY": 8700, "ALLOWANCE": 500},
+		Variables: map[string]interface{}{
+			"base_salary":  8700.0,
+			"absence_days": 2,
+		},
+	}
+
+	tests := []struct {
+		formula  string
+		expected float64
+	}{
+		{"{base_salary} * 0.08", 696},
+		{"base_salary * 0.1", 870},
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"-2 * -3", 6},
+		{"10 - 4 - 3", 3},
+		{"({base_salary} - {absence_days} * {base_salary} / 21.75) * 0.08", 632},
+		{"{BASE_SALARY} + {ALLOWANCE}", 9200},
+		{"if({absence_days} > 1, 100, 200)", 100},
+		{"if({absence_days} == 0 && 1, 1 / 0, 5)", 5},
+		{"min(3, 1, 2) + max(4, 6)", 7},
+		{"round(2.345, 2)", 2.35},
+		{"floor(2.7) + ceil(2.1)", 5},
+	}
+
+	for _, test := range tests {
+		result, err := service.EvaluateFormula(test.formula, context)
+		assert.NoError(t, err, "Formula: %s", test.formula)
+		assert.InDelta(t, test.expected, result, 0.0001, "Formula: %s", test.formula)
+	}
+}
+
+func TestEvaluateFormulaErrors(t *testing.T) {
+	service := services.NewSalaryService(nil)
+	context := services.FormulaContext{Variables: map[string]interface{}{}}
+
+	_, err := service.EvaluateFormula("{missing} + 1", context)
+	var formulaErr *services.FormulaError
+	assert.True(t, errors.As(err, &formulaErr))
+	assert.Equal(t, 1, formulaErr.Pos)
+
+	_, err = service.EvaluateFormula("10 / (5 - 5)", context)
+	assert.True(t, errors.As(err, &formulaErr))
+	assert.Equal(t, 4, formulaErr.Pos)
+}
+
+func TestValidateFormula(t *testing.T) {
+	service := services.NewSalaryService(nil)
+
+	assert.NoError(t, service.ValidateFormula("round({base_salary} * 0.08, 2)"))
+	assert.Error(t, service.ValidateFormula(""))
+
+	var validationErr *services.FormulaValidationError
+
+	err := service.ValidateFormula("{base_salary} * (0.08")
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, 22, validationErr.Issues[0].Pos)
+
+	err = service.ValidateFormula("{base_salary} + {bonus} + {extra}")
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Len(t, validationErr.Issues, 2)
+	assert.Equal(t, 17, validationErr.Issues[0].Pos)
+
+	err = service.ValidateFormula("pow(2, 3)")
+	assert.True(t, errors.As(err, &validationErr))
+
+	err = service.ValidateFormula("if(1, 2)")
+	assert.True(t, errors.As(err, &validationErr))
+}
+
+func TestSalaryStructureDependencyCycle(t *testing.T) {
+	service := services.NewSalaryService(nil)
+	structure := &models.SalaryStructure{
+		Components: []models.SalaryStructureComponent{
+			{Component: &models.SalaryComponent{Code: "GROSS", Type: models.ComponentTypeFormula, Formula: "{BASE} + {BONUS}"}},
+			{Component: &models.SalaryComponent{Code: "BONUS", Type: models.ComponentTypeFormula, Formula: "{GROSS} * 0.1"}},
+			{Component: &models.SalaryComponent{Code: "BASE", Type: models.ComponentTypeFixed, DefaultAmount: money(8000)}},
+		},
+	}
+
+	_, err := service.CreateSalaryStructure(structure)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "GROSS -> BONUS -> GROSS")
+
+	structure.Components[1].Component.Formula = "{unknown} * 0.1"
+	_, err = service.CreateSalaryStructure(structure)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "{unknown}")
+
+	structure.Components[1].Component.Formula = "{BASE} * 0.1"
+	structure.Currency = "RMB1"
+	_, err = service.CreateSalaryStructure(structure)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported currency")
+}
+
+func TestSalaryComponentValidation(t *testing.T) {
+	service := services.NewSalaryService(nil)
+
+	_, err := service.CreateSalaryComponent(&models.SalaryComponent{Code: "OT", Name: "加班费", Type: models.ComponentTypeFixed, RoundingRule: "truncate"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "rounding rule")
+
+	_, err = service.CreateSalaryComponent(&models.SalaryComponent{Code: "PENSION", Name: "养老保险", Type: models.ComponentTypePercentage, Formula: "8"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid formula")
+}
+
+func TestEnhancedSalaryServiceUsesFormulaEngine(t *testing.T) {
+	service := services.NewEnhancedSalaryService(nil)
+	context := services.FormulaContext{Variables: map[string]interface{}{"base_salary": 8700.0, "absence_days": 2}}
+
+	result, err := service.EvaluateFormula("({base_salary} - {absence_days} * {base_salary} / 21.75) * 0.08", context)
+	assert.NoError(t, err)
+	assert.InDelta(t, 632, result, 0.0001)
+
+	var validationErr *services.FormulaValidationError
+	assert.True(t, errors.As(service.ValidateFormula("{base_salary} * (0.08"), &validationErr))
+	assert.Equal(t, 22, validationErr.Issues[0].Pos)
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"testing"
+
+	"gin-project/models"
+	"gin-project/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProrateBaseSalary(t *testing.T) {
+	calendar := nationalDayCalendar()
+	input := func(method models.ProrationMethod) services.ProrationInput {
+		return services.ProrationInput{
+			PeriodStart: date("2024-10-01"),
+			PeriodEnd:   date("2024-10-31"),
+			BaseSalary:  money(10000),
+			Method:      method,
+		}
+	}
+
+	full := services.ProrateBaseSalary(calendar, input(models.ProrationStatutoryDays))
+	assert.Equal(t, money(10000), full.ProratedBase)
+	assert.Equal(t, 1.0, full.Ratio)
+	assert.Len(t, full.Segments, 1)
+
+	hireDate := date("2024-10-17")
+	hires := map[models.ProrationMethod]models.Money{
+		models.ProrationWorkingDays:   money(5789.47),
+		models.ProrationCalendarDays:  money(4838.71),
+		models.ProrationStatutoryDays: money(5057.47),
+	}
+	for method, expected := range hires {
+		in := input(method)
+		in.HireDate = &hireDate
+		result := services.ProrateBaseSalary(calendar, in)
+		assert.Equal(t, expected, result.ProratedBase, method)
+		assert.Equal(t, models.SegmentReasonHire, result.Segments[0].Reason)
+	}
+
+	in := input(models.ProrationWorkingDays)
+	in.Adjustments = []models.SalaryAdjustment{
+		{ID: 7, EffectiveDate: date("2024-10-21"), OldBaseSalary: money(10000), NewBaseSalary: money(12000)},
+	}
+	adjusted := services.ProrateBaseSalary(calendar, in)
+	assert.Len(t, adjusted.Segments, 2)
+	assert.Equal(t, money(5263.16), adjusted.Segments[0].Amount)
+	assert.Equal(t, 10.0, adjusted.Segments[0].Days)
+	assert.Equal(t, money(5684.21), adjusted.Segments[1].Amount)
+	assert.Equal(t, models.SegmentReasonAdjustment, adjusted.Segments[1].Reason)
+	assert.Equal(t, uint(7), *adjusted.Segments[1].AdjustmentID)
+	assert.Equal(t, money(10947.37), adjusted.ProratedBase)
+	assert.Equal(t, money(12000), adjusted.FullBase)
+
+	terminationDate := date("2024-10-10")
+	in = input(models.ProrationStatutoryDays)
+	in.TerminationDate = &terminationDate
+	terminated := services.ProrateBaseSalary(calendar, in)
+	assert.Equal(t, money(1379.31), terminated.ProratedBase)
+
+	hireDate = date("2024-11-04")
+	in = input(models.ProrationWorkingDays)
+	in.HireDate = &hireDate
+	assert.Empty(t, services.ProrateBaseSalary(calendar, in).Segments)
+}
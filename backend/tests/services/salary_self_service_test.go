@@ -0,0 +1,73 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"gin-project/models"
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePayslipPIN(t *testing.T) {
+	assert.NoError(t, services.ValidatePayslipPIN("480157"))
+
+	for _, pin := range []string{"12345", "1234567", "12a456", "000000", "123456", "987654"} {
+		err := services.ValidatePayslipPIN(pin)
+		require.Error(t, err, pin)
+		assert.IsType(t, &utils.ValidationError{}, err, pin)
+	}
+}
+
+func TestSalaryAccessCredentialLockout(t *testing.T) {
+	now := time.Date(2024, 7, 1, 9, 0, 0, 0, time.Local)
+	credential := &models.SalaryAccessCredential{UserID: 1}
+
+	for i := 1; i < models.StepUpMaxAttempts; i++ {
+		credential.RecordFailure(now)
+		assert.False(t, credential.IsLocked(now))
+	}
+	assert.Equal(t, 1, credential.RemainingAttempts())
+
+	credential.RecordFailure(now)
+	assert.True(t, credential.IsLocked(now), "连续失败达到上限后锁定")
+	assert.True(t, credential.IsLocked(now.Add(14*time.Minute)))
+	assert.Equal(t, models.StepUpMaxAttempts, credential.RemainingAttempts(), "锁定后重新计数")
+
+	later := now.Add(models.StepUpLockDuration)
+	assert.False(t, credential.IsLocked(later), "锁定期满自动解除")
+	credential.RecordFailure(later)
+	assert.Nil(t, credential.LockedUntil)
+
+	credential.RecordSuccess(later)
+	assert.Zero(t, credential.FailedAttempts)
+	require.NotNil(t, credential.LastVerifiedAt)
+}
+
+func TestScopedTokenCannotActAsSession(t *testing.T) {
+	jwtService := utils.NewJWTService()
+
+	token, expiresAt, err := jwtService.GenerateScopedToken("7", "user", utils.ScopeSalarySelfService, 42, 10*time.Minute)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), expiresAt, time.Minute)
+
+	claims, err := jwtService.ValidateScopedToken(token, utils.ScopeSalarySelfService)
+	require.NoError(t, err)
+	assert.Equal(t, "7", claims.UserID)
+	assert.Equal(t, uint(42), claims.EmployeeID)
+
+	_, err = jwtService.ValidateSessionToken(token)
+	assert.ErrorIs(t, err, utils.ErrScopedToken)
+	_, err = jwtService.RefreshToken(token)
+	assert.ErrorIs(t, err, utils.ErrScopedToken, "范围令牌不能刷新为会话令牌")
+	_, err = jwtService.ValidateScopedToken(token, "other_scope")
+	assert.Error(t, err)
+
+	session, err := jwtService.GenerateToken("7", "user")
+	require.NoError(t, err)
+	_, err = jwtService.ValidateScopedToken(session, utils.ScopeSalarySelfService)
+	assert.Error(t, err, "会话令牌不能替代二次验证令牌")
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"testing"
+
+	"gin-project/models"
+	"gin-project/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func versionComponent(id uint, code string, defaultValue float64) models.SalaryStructureVersionComponent {
+	return models.SalaryStructureVersionComponent{
+		ComponentID:  id,
+		Component:    &models.SalaryComponent{ID: id, Code: code, Name: code},
+		DefaultValue: money(defaultValue),
+		CanEdit:      true,
+	}
+}
+
+func TestDiffStructureVersions(t *testing.T) {
+	expiry := date("2024-06-30")
+	from := &models.SalaryStructureVersion{
+		StructureID: 1, Version: 1, EffectiveDate: date("2024-01-01"), ExpiryDate: &expiry,
+		Name: "研发标准结构", ProrationMethod: models.ProrationWorkingDays,
+		Components: []models.SalaryStructureVersionComponent{
+			versionComponent(1, "BASE", 10000),
+			versionComponent(2, "MEAL", 300),
+			versionComponent(3, "PHONE", 100),
+		},
+	}
+	to := &models.SalaryStructureVersion{
+		StructureID: 1, Version: 2, EffectiveDate: date("2024-07-01"),
+		Name: "研发标准结构", ProrationMethod: models.ProrationCalendarDays,
+		Components: []models.SalaryStructureVersionComponent{
+			versionComponent(1, "BASE", 10000),
+			versionComponent(2, "MEAL", 400),
+			versionComponent(4, "TRAFFIC", 200),
+		},
+	}
+
+	diff := services.DiffStructureVersions(from, to)
+	assert.Equal(t, 1, diff.FromVersion)
+	assert.Equal(t, 2, diff.ToVersion)
+	require.Len(t, diff.Fields, 1)
+	assert.Equal(t, "proration_method", diff.Fields[0].Field)
+
+	require.Len(t, diff.Components, 3, "未变化的组件不列出")
+	assert.Equal(t, "modified", diff.Components[0].ChangeType)
+	assert.Equal(t, "MEAL", diff.Components[0].Code)
+	require.Len(t, diff.Components[0].Fields, 1)
+	assert.Equal(t, money(300), diff.Components[0].Fields[0].From)
+	assert.Equal(t, money(400), diff.Components[0].Fields[0].To)
+	assert.Equal(t, "removed", diff.Components[1].ChangeType)
+	assert.Equal(t, "PHONE", diff.Components[1].Code)
+	assert.Equal(t, "added", diff.Components[2].ChangeType)
+	assert.Equal(t, "TRAFFIC", diff.Components[2].Code)
+
+	assert.True(t, from.ActiveOn(date("2024-06-30")))
+	assert.False(t, from.ActiveOn(date("2024-07-01")))
+	assert.True(t, to.ActiveOn(date("2025-01-01")))
+	assert.False(t, to.ActiveOn(date("2024-06-30")))
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"testing"
+
+	"gin-project/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateCumulativeTax(t *testing.T) {
+	// 月薪 20000，三险一金 2000，逐月累计预扣
+	withheld := 0.0
+	expected := []float64{390, 390, 600, 1300}
+	for month := 1; month <= len(expected); month++ {
+		result := services.CalculateCumulativeTax(services.CumulativeTaxInput{
+			Months:            month,
+			Income:            20000 * float64(month),
+			SpecialDeductions: 2000 * float64(month),
+			WithheldTax:       withheld,
+		})
+		assert.InDelta(t, expected[month-1], result.CurrentTax, 0.001, "month %d", month)
+		withheld += result.CurrentTax
+	}
+
+	result := services.CalculateCumulativeTax(services.CumulativeTaxInput{Months: 3, Income: 14000})
+	assert.Equal(t, 0.0, result.CurrentTax)
+	assert.Equal(t, 0.0, result.CumulativeTaxableIncome)
+
+	// 专项附加扣除增加导致累计税额低于已预扣税额时，本期不预扣
+	result = services.CalculateCumulativeTax(services.CumulativeTaxInput{
+		Months:               2,
+		Income:               40000,
+		SpecialDeductions:    4000,
+		AdditionalDeductions: 10000,
+		WithheldTax:          780,
+	})
+	assert.InDelta(t, 480, result.CumulativeTax, 0.001)
+	assert.Equal(t, 0.0, result.CurrentTax)
+}
+
+func TestCalculateSeparateBonusTax(t *testing.T) {
+	tests := []struct {
+		bonus    float64
+		expected float64
+	}{
+		{0, 0},
+		{36000, 1080},
+		{36001, 3390.1},
+		{120000, 11790},
+		{1200000, 524840},
+	}
+
+	for _, test := range tests {
+		tax, _, _ := services.CalculateSeparateBonusTax(test.bonus)
+		assert.InDelta(t, test.expected, tax, 0.001, "bonus %.2f", test.bonus)
+	}
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+
+	"gin-project/models"
+	"gin-project/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateContributions(t *testing.T) {
+	policy := &models.SocialInsurancePolicy{
+		CityCode:                "SH",
+		BaseFloor:               7310,
+		BaseCeiling:             36549,
+		PensionEmployeeRate:     0.08,
+		PensionEmployerRate:     0.16,
+		MedicalEmployeeRate:     0.02,
+		InjuryEmployerRate:      0.0026,
+		HousingFundBaseFloor:    2690,
+		HousingFundBaseCeiling:  36549,
+		HousingFundEmployeeRate: 0.07,
+		HousingFundEmployerRate: 0.07,
+	}
+
+	amounts := func(contributions []services.InsuranceContribution) map[string]float64 {
+		result := make(map[string]float64)
+		for _, contribution := range contributions {
+			result[contribution.Code] = contribution.Amount
+		}
+		return result
+	}
+
+	// 低于下限：社保按下限缴费，公积金按实际工资
+	low := amounts(services.CalculateContributions(policy, 5000))
+	assert.InDelta(t, 584.80, low[services.PensionEmployeeCode], 0.001)
+	assert.InDelta(t, 350.00, low[services.HousingFundEmployeeCode], 0.001)
+	assert.InDelta(t, 19.01, low[services.InjuryEmployerCode], 0.001)
+	_, hasUnemployment := low[services.UnemploymentEmployeeCode]
+	assert.False(t, hasUnemployment)
+
+	// 高于上限：按上限缴费
+	high := amounts(services.CalculateContributions(policy, 50000))
+	assert.InDelta(t, 2923.92, high[services.PensionEmployeeCode], 0.001)
+	assert.InDelta(t, 5847.84, high[services.PensionEmployerCode], 0.001)
+	assert.InDelta(t, 2558.43, high[services.HousingFundEmployerCode], 0.001)
+}
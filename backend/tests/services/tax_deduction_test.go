@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+
+	"gin-project/models"
+	"gin-project/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollOverDeductionCarriesIntoWithholding(t *testing.T) {
+	source := models.SpecialDeduction{
+		ID: 5, EmployeeID: 3, Type: models.DeductionChildEducation, TaxYear: 2024,
+		StartMonth: 5, EndMonth: 12, MonthlyAmount: money(2000), Beneficiary: "张小明", Status: "active",
+	}
+	next := services.RollOverDeduction(source, 2025)
+	assert.Zero(t, next.ID, "结转生成新的申报，不修改原申报")
+	assert.Equal(t, 2025, next.TaxYear)
+	assert.Equal(t, 1, next.StartMonth, "结转后全年有效")
+	assert.Equal(t, 12, next.EndMonth)
+	assert.Equal(t, money(2000), next.MonthlyAmount)
+	assert.Equal(t, "张小明", next.Beneficiary)
+	require.NotNil(t, next.RolledOverFrom)
+	assert.Equal(t, uint(5), *next.RolledOverFrom)
+
+	deductions := []models.SpecialDeduction{
+		next,
+		{Type: models.DeductionHousingRent, StartMonth: 3, EndMonth: 12, MonthlyAmount: money(1500)},
+		{Type: models.DeductionSeriousIllness, StartMonth: 1, EndMonth: 12, MonthlyAmount: money(5000)},
+	}
+	// 1-3 月累计：子女教育 3 个月 + 住房租金 1 个月，大病医疗留待年度汇算
+	additional := services.SumSpecialDeductions(deductions, 1, 3)
+	assert.Equal(t, money(7500), additional)
+	assert.Equal(t, money(3500), services.SumSpecialDeductions(deductions, 3, 3))
+
+	result := services.CalculateCumulativeTax(services.CumulativeTaxInput{
+		Months:               3,
+		Income:               60000,
+		SpecialDeductions:    6000,
+		AdditionalDeductions: additional.Float64(),
+	})
+	assert.InDelta(t, 31500, result.CumulativeTaxableIncome, 0.001)
+	assert.InDelta(t, 945, result.CumulativeTax, 0.001)
+}
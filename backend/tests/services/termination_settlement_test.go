@@ -0,0 +1,118 @@
+package services
+
+import (
+	"testing"
+
+	"gin-project/models"
+	"gin-project/services"
+	"gin-project/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func monthlyWages(count int, amount float64) []models.Money {
+	wages := make([]models.Money, count)
+	for i := range wages {
+		wages[i] = money(amount)
+	}
+	return wages
+}
+
+func TestCalculateSettlementNPlusOne(t *testing.T) {
+	wages := append([]models.Money{money(21000)}, monthlyWages(11, 20000)...)
+	settlement, err := services.CalculateSettlement(services.SettlementInput{
+		Type:                models.SettlementNPlusOne,
+		HireDate:            date("2019-03-01"),
+		TerminationDate:     date("2024-08-31"),
+		MonthlyWages:        append(wages, money(99999)),
+		LocalAverageWage:    money(10000),
+		AnnualLeaveEntitled: 10,
+		AnnualLeaveUsed:     2,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 66, settlement.ServiceMonths)
+	assert.Equal(t, 6.0, settlement.CompensationMonths, "满五年零六个月按六个月计")
+	assert.Equal(t, 12, settlement.WageMonths, "只取离职前 12 个月")
+	assert.Equal(t, money(20083.33), settlement.AverageMonthlyWage)
+	assert.False(t, settlement.WageCapped)
+	assert.Equal(t, money(120499.98), settlement.Compensation)
+	assert.Equal(t, money(21000), settlement.NoticePay, "代通知金按上月工资")
+
+	assert.Equal(t, 6.0, settlement.AnnualLeaveEntitled, "244/365 × 10 天，不足一天不计")
+	assert.Equal(t, 4.0, settlement.UnusedLeaveDays)
+	assert.Equal(t, money(923.37), settlement.DailyWage)
+	assert.Equal(t, money(7386.96), settlement.LeavePayout)
+
+	assert.Equal(t, money(360000), settlement.TaxExemptLimit)
+	assert.True(t, settlement.CompensationTax.IsZero())
+	assert.Equal(t, money(148886.94), settlement.TotalAmount)
+}
+
+func TestCalculateSettlementCappedDoubleN(t *testing.T) {
+	settlement, err := services.CalculateSettlement(services.SettlementInput{
+		Type:             models.SettlementDoubleN,
+		HireDate:         date("2005-01-01"),
+		TerminationDate:  date("2024-06-30"),
+		MonthlyWages:     monthlyWages(12, 50000),
+		LocalAverageWage: money(10000),
+	})
+	require.NoError(t, err)
+
+	assert.True(t, settlement.WageCapped)
+	assert.Equal(t, money(30000), settlement.CompensationBase, "按当地平均工资 3 倍封顶")
+	assert.Equal(t, 12.0, settlement.CompensationMonths, "封顶时年限最高 12 年")
+	assert.Equal(t, money(720000), settlement.Compensation, "违法解除按 2N 支付")
+	assert.True(t, settlement.NoticePay.IsZero())
+	assert.Equal(t, money(360000), settlement.TaxableCompensation)
+	assert.Equal(t, money(58080), settlement.CompensationTax, "超出免税额度部分单独适用综合所得税率表")
+}
+
+func TestCalculateSettlementServicePeriods(t *testing.T) {
+	cases := []struct {
+		hire, termination string
+		months            float64
+	}{
+		{"2024-03-10", "2024-06-30", 0.5},
+		{"2023-07-01", "2024-06-30", 1},
+		{"2023-07-01", "2024-07-01", 1.5},
+		{"2022-01-15", "2024-09-14", 3},
+	}
+	for _, tc := range cases {
+		settlement, err := services.CalculateSettlement(services.SettlementInput{
+			Type:             models.SettlementN,
+			HireDate:         date(tc.hire),
+			TerminationDate:  date(tc.termination),
+			MonthlyWages:     monthlyWages(3, 8000),
+			LocalAverageWage: money(10000),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, tc.months, settlement.CompensationMonths, tc.hire+" ~ "+tc.termination)
+	}
+
+	_, err := services.CalculateSettlement(services.SettlementInput{
+		Type: models.SettlementN, HireDate: date("2020-01-01"), TerminationDate: date("2024-01-01"),
+		MonthlyWages: monthlyWages(12, 8000),
+	})
+	assert.IsType(t, &utils.ValidationError{}, err, "缺少当地平均工资")
+
+	_, err = services.CalculateSettlement(services.SettlementInput{
+		Type: models.SettlementN, HireDate: date("2020-01-01"), TerminationDate: date("2024-01-01"),
+		LocalAverageWage: money(10000),
+	})
+	assert.IsType(t, &utils.ValidationError{}, err, "缺少薪资记录")
+}
+
+func TestSettlementChangesRequireOperator(t *testing.T) {
+	service := services.NewTerminationSettlementService(nil)
+
+	_, err := service.CreateSettlement(services.SettlementRequest{EmployeeID: 1}, 0)
+	assert.IsType(t, &utils.ValidationError{}, err)
+	_, err = service.ApproveSettlement(1, 0, "")
+	assert.IsType(t, &utils.ValidationError{}, err)
+	_, err = service.CancelSettlement(1, 0, "员工撤回离职申请")
+	assert.IsType(t, &utils.ValidationError{}, err)
+	_, err = service.IssueSettlement(1, services.SettlementIssueRequest{}, 0)
+	assert.IsType(t, &utils.ValidationError{}, err)
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"gin-project/models"
+	"gin-project/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(value string) time.Time {
+	t, _ := time.ParseInLocation("2006-01-02", value, time.Local)
+	return t
+}
+
+// 2024 年国庆：10 月 1-7 日放假（1-3 日为法定节假日），9 月 29 日、10 月 12 日调休上班
+func nationalDayCalendar() *services.WorkCalendar {
+	days := []models.WorkCalendarDay{
+		{Date: date("2024-09-29"), Type: models.CalendarDayWorkday},
+		{Date: date("2024-10-12"), Type: models.CalendarDayWorkday},
+	}
+	for day := 1; day <= 7; day++ {
+		days = append(days, models.WorkCalendarDay{
+			Date:      time.Date(2024, time.October, day, 0, 0, 0, 0, time.Local),
+			Type:      models.CalendarDayHoliday,
+			Statutory: day <= 3,
+		})
+	}
+	return services.NewWorkCalendar(days)
+}
+
+func TestWorkCalendarWorkingDays(t *testing.T) {
+	calendar := nationalDayCalendar()
+
+	assert.Equal(t, 19, calendar.WorkingDaysBetween(date("2024-10-01"), date("2024-10-31")))
+	assert.Equal(t, 22, calendar.WorkingDaysBetween(date("2024-09-01"), date("2024-09-30")))
+	assert.True(t, calendar.IsWorkingDay(date("2024-10-12")))
+	assert.False(t, calendar.IsWorkingDay(date("2024-10-04")))
+	assert.True(t, calendar.IsStatutoryHoliday(date("2024-10-02")))
+	assert.False(t, calendar.IsStatutoryHoliday(date("2024-10-05")))
+}
+
+func TestSummarizeAttendance(t *testing.T) {
+	calendar := nationalDayCalendar()
+	window := services.AttendanceWindow{Start: date("2024-10-01"), End: date("2024-10-31")}
+
+	records := []models.Attendance{
+		{Date: date("2024-10-03"), Status: "normal", WorkHours: 8},
+		{Date: date("2024-10-05"), Status: "normal", WorkHours: 4},
+		{Date: date("2024-10-08"), Status: "late", WorkHours: 9},
+		{Date: date("2024-10-09"), Status: "normal", WorkHours: 10},
+		{Date: date("2024-10-12"), Status: "normal", WorkHours: 8},
+		{Date: date("2024-10-13"), Status: "normal", WorkHours: 6},
+		{Date: date("2024-10-14"), Status: "normal", WorkHours: 4},
+	}
+	leaves := []models.Leave{
+		{Type: models.LeaveTypeAnnual, StartDate: date("2024-10-10"), EndDate: date("2024-10-11"), Days: 2},
+		{Type: models.LeaveTypeUnpaid, StartDate: date("2024-10-14"), EndDate: date("2024-10-14"), Days: 0.5},
+		{Type: models.LeaveTypeSick, StartDate: date("2024-10-15"), EndDate: date("2024-10-15"), Days: 1},
+	}
+
+	inputs := services.SummarizeAttendance(calendar, window, records, leaves)
+	assert.Equal(t, 19.0, inputs.WorkingDays)
+	assert.Equal(t, 3.5, inputs.AttendanceDays)
+	assert.Equal(t, 2.0, inputs.PaidLeaveDays)
+	assert.Equal(t, 0.5, inputs.UnpaidLeaveDays)
+	assert.Equal(t, 1.0, inputs.SickLeaveDays)
+	assert.Equal(t, 12.5, inputs.AbsenceDays)
+	assert.Equal(t, 1.0, inputs.LateCount)
+	assert.Equal(t, 3.0, inputs.OvertimeHours)
+	assert.Equal(t, 8.0, inputs.HolidayOvertimeHours)
+	assert.Equal(t, 10.0, inputs.RestDayOvertimeHours)
+
+	hireDate := date("2024-10-21")
+	window.HireDate = &hireDate
+	window.Cutoff = date("2024-10-25")
+	inputs = services.SummarizeAttendance(calendar, window, nil, nil)
+	assert.Equal(t, 9.0, inputs.WorkingDays)
+	assert.Equal(t, 5.0, inputs.AbsenceDays)
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gin-project/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCurrentUserID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// JWTAuth 以字符串写入 user_id
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("user_id", "42")
+	userID, ok := utils.GetCurrentUserID(c)
+	assert.True(t, ok)
+	assert.Equal(t, uint(42), userID)
+
+	for _, value := range []string{"", "0", "abc"} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("user_id", value)
+		_, ok := utils.GetCurrentUserID(c)
+		assert.False(t, ok, value)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, value)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	_, ok = utils.GetCurrentUserID(c)
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
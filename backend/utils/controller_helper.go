@@ -2,6 +2,7 @@ package utils
 
 import (
 	"errors"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -53,6 +54,20 @@ func GetUserIDFromContext(c *gin.Context) (string, bool) {
 	return userID.(string), true
 }
 
+// GetCurrentUserID 会话令牌中的用户ID为字符串，这里转换为数据库主键；缺失或无效时写入 401 响应
+func GetCurrentUserID(c *gin.Context) (uint, bool) {
+	userIDStr, ok := GetUserIDFromContext(c)
+	if !ok {
+		return 0, false
+	}
+	userID, err := strconv.ParseUint(userIDStr, 10, 32)
+	if err != nil || userID == 0 {
+		UnauthorizedResponse(c, "Invalid user ID in token")
+		return 0, false
+	}
+	return uint(userID), true
+}
+
 func GetUserRoleFromContext(c *gin.Context) (string, bool) {
 	userRole, exists := c.Get("user_role")
 	if !exists {
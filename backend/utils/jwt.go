@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"errors"
 	"os"
 	"time"
 
@@ -12,11 +13,22 @@ type JWTService struct {
 }
 
 type JWTClaims struct {
-	UserID string `json:"user_id"`
-	Role   string `json:"role"`
+	UserID     string `json:"user_id"`
+	Role       string `json:"role"`
+	Scope      string `json:"scope,omitempty"`
+	EmployeeID uint   `json:"employee_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// 二次验证令牌
+const (
+	StepUpTokenHeader      = "X-Step-Up-Token"
+	ScopeSalarySelfService = "salary_self_service"
+)
+
+// ErrScopedToken 范围令牌只能配合会话令牌访问对应接口，不能当作会话令牌使用
+var ErrScopedToken = errors.New("scoped token cannot be used as a session token")
+
 func NewJWTService() *JWTService {
 	secret := os.Getenv("JWT_SECRET")
 	if secret == "" {
@@ -52,8 +64,54 @@ func (j *JWTService) ValidateToken(tokenString string) (*JWTClaims, error) {
 	return claims, nil
 }
 
-func (j *JWTService) RefreshToken(tokenString string) (string, error) {
+// GenerateScopedToken 签发二次验证后的短期范围令牌
+func (j *JWTService) GenerateScopedToken(userID, role, scope string, employeeID uint, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := &JWTClaims{
+		UserID:     userID,
+		Role:       role,
+		Scope:      scope,
+		EmployeeID: employeeID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(j.secret))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// ValidateSessionToken 校验会话令牌，拒绝范围令牌
+func (j *JWTService) ValidateSessionToken(tokenString string) (*JWTClaims, error) {
 	claims, err := j.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Scope != "" {
+		return nil, ErrScopedToken
+	}
+	return claims, nil
+}
+
+// ValidateScopedToken 校验范围令牌的签名、有效期与范围
+func (j *JWTService) ValidateScopedToken(tokenString, scope string) (*JWTClaims, error) {
+	claims, err := j.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Scope != scope {
+		return nil, errors.New("token scope mismatch")
+	}
+	return claims, nil
+}
+
+func (j *JWTService) RefreshToken(tokenString string) (string, error) {
+	claims, err := j.ValidateSessionToken(tokenString)
 	if err != nil {
 		return "", err
 	}
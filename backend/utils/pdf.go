@@ -0,0 +1,309 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// A4 页面尺寸（单位：磅）
+const (
+	PDFPageWidth  = 595.28
+	PDFPageHeight = 841.89
+)
+
+// pdfPasswordPadding PDF 标准安全处理器的口令填充串
+var pdfPasswordPadding = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+// pdfPermissions 加密文档仅允许打印（PDF 1.4 权限位，R3）
+const pdfPermissions int32 = -1852
+
+// PDFDocument 极简 PDF 生成器：使用内置 STSong-Light 中文字体（无需嵌入字体文件），
+// 支持文本、直线及基于 RC4 128 位标准安全处理器的打开口令加密
+type PDFDocument struct {
+	title         string
+	pages         []*bytes.Buffer
+	current       *bytes.Buffer
+	userPassword  string
+	ownerPassword string
+	encrypted     bool
+}
+
+// NewPDFDocument 创建 PDF 文档
+func NewPDFDocument(title string) *PDFDocument {
+	return &PDFDocument{title: title}
+}
+
+// SetPassword 设置打开口令，ownerPassword 为空时随机生成，防止口令持有人解除权限限制
+func (d *PDFDocument) SetPassword(userPassword, ownerPassword string) {
+	if ownerPassword == "" {
+		ownerPassword = randomHex(16)
+	}
+	d.userPassword, d.ownerPassword, d.encrypted = userPassword, ownerPassword, true
+}
+
+// AddPage 新增一页，后续绘制内容输出到该页
+func (d *PDFDocument) AddPage() {
+	d.current = &bytes.Buffer{}
+	d.pages = append(d.pages, d.current)
+}
+
+// PageCount 返回页数
+func (d *PDFDocument) PageCount() int {
+	return len(d.pages)
+}
+
+// Text 以左上角为原点在 (x, y) 处输出文本，y 为基线位置
+func (d *PDFDocument) Text(x, y, size float64, text string) {
+	if d.current == nil {
+		d.AddPage()
+	}
+	fmt.Fprintf(d.current, "BT /F1 %s Tf %s %s Td <%s> Tj ET\n",
+		pdfNumber(size), pdfNumber(x), pdfNumber(PDFPageHeight-y), encodeUCS2(text))
+}
+
+// TextRight 以 x 为右边界输出右对齐文本
+func (d *PDFDocument) TextRight(x, y, size float64, text string) {
+	d.Text(x-PDFTextWidth(text, size), y, size, text)
+}
+
+// TextCenter 在页面水平居中输出文本
+func (d *PDFDocument) TextCenter(y, size float64, text string) {
+	d.Text((PDFPageWidth-PDFTextWidth(text, size))/2, y, size, text)
+}
+
+// Line 绘制直线
+func (d *PDFDocument) Line(x1, y1, x2, y2, width float64) {
+	if d.current == nil {
+		d.AddPage()
+	}
+	fmt.Fprintf(d.current, "%s w %s %s m %s %s l S\n", pdfNumber(width),
+		pdfNumber(x1), pdfNumber(PDFPageHeight-y1), pdfNumber(x2), pdfNumber(PDFPageHeight-y2))
+}
+
+// PDFTextWidth 估算文本宽度：ASCII 字符为半角，其余为全角
+func PDFTextWidth(text string, size float64) float64 {
+	var width float64
+	for _, r := range text {
+		if r < 0x80 {
+			width += 0.5
+		} else {
+			width++
+		}
+	}
+	return width * size
+}
+
+// Bytes 输出完整的 PDF 文件内容
+func (d *PDFDocument) Bytes() ([]byte, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	fileID, err := hex.DecodeString(randomHex(16))
+	if err != nil {
+		return nil, err
+	}
+	var enc *pdfEncryption
+	if d.encrypted {
+		enc = newPDFEncryption(d.userPassword, d.ownerPassword, fileID)
+	}
+
+	// 对象编号：1 目录 2 页面树 3 字体 4 CID字体 5 字体描述 6 文档信息 [7 加密字典] 之后为各页及内容流
+	const fixedObjects = 6
+	firstPage := fixedObjects + 1
+	if enc != nil {
+		firstPage++
+	}
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+i*2)
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)),
+		"<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [4 0 R] >>",
+		"<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light " +
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> " +
+			"/FontDescriptor 5 0 R /DW 1000 /W [1 95 500] >>",
+		"<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] " +
+			"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>",
+		fmt.Sprintf("<< /Title <%s> /Producer <%s> >>",
+			enc.encryptString(6, utf16BOM(d.title)), enc.encryptString(6, utf16BOM("HR Management System"))),
+	}
+	if enc != nil {
+		objects = append(objects, enc.dictionary())
+	}
+	for i, page := range d.pages {
+		pageObj := firstPage + i*2
+		content := enc.encryptStream(pageObj+1, page.Bytes())
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				pdfNumber(PDFPageWidth), pdfNumber(PDFPageHeight), pageObj+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	idHex := hex.EncodeToString(fileID)
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 6 0 R /ID [<%s> <%s>]", len(objects)+1, idHex, idHex)
+	if enc != nil {
+		fmt.Fprintf(&out, " /Encrypt %d 0 R", fixedObjects+1)
+	}
+	fmt.Fprintf(&out, " >>\nstartxref\n%d\n%%%%EOF\n", xref)
+	return out.Bytes(), nil
+}
+
+// pdfEncryption PDF 标准安全处理器（V2/R3，128 位 RC4）
+type pdfEncryption struct {
+	key    []byte
+	owner  []byte
+	user   []byte
+	fileID []byte
+}
+
+func newPDFEncryption(userPassword, ownerPassword string, fileID []byte) *pdfEncryption {
+	userPad := padPDFPassword(userPassword)
+
+	// 算法 3：计算 O 值
+	ownerHash := md5.Sum(padPDFPassword(ownerPassword))
+	for i := 0; i < 50; i++ {
+		ownerHash = md5.Sum(ownerHash[:])
+	}
+	owner := rc4Rounds(ownerHash[:], userPad)
+
+	// 算法 2：计算文件加密密钥
+	flags := pdfPermissions
+	permissions := make([]byte, 4)
+	binary.LittleEndian.PutUint32(permissions, uint32(flags))
+	h := md5.New()
+	h.Write(userPad)
+	h.Write(owner)
+	h.Write(permissions)
+	h.Write(fileID)
+	key := h.Sum(nil)
+	for i := 0; i < 50; i++ {
+		sum := md5.Sum(key)
+		key = sum[:]
+	}
+
+	// 算法 5：计算 U 值
+	h = md5.New()
+	h.Write(pdfPasswordPadding)
+	h.Write(fileID)
+	user := append(rc4Rounds(key, h.Sum(nil)), make([]byte, 16)...)
+
+	return &pdfEncryption{key: key, owner: owner, user: user, fileID: fileID}
+}
+
+func (e *pdfEncryption) dictionary() string {
+	return fmt.Sprintf("<< /Filter /Standard /V 2 /R 3 /Length 128 /P %d /O <%s> /U <%s> >>",
+		pdfPermissions, hex.EncodeToString(e.owner), hex.EncodeToString(e.user))
+}
+
+// objectKey 算法 1：按对象编号派生 RC4 密钥
+func (e *pdfEncryption) objectKey(objNum int) []byte {
+	h := md5.New()
+	h.Write(e.key)
+	h.Write([]byte{byte(objNum), byte(objNum >> 8), byte(objNum >> 16), 0, 0})
+	return h.Sum(nil)
+}
+
+func (e *pdfEncryption) encryptStream(objNum int, data []byte) []byte {
+	if e == nil {
+		return data
+	}
+	return rc4Apply(e.objectKey(objNum), data)
+}
+
+// encryptString 返回十六进制字符串内容，未加密时原样编码
+func (e *pdfEncryption) encryptString(objNum int, data []byte) string {
+	return hex.EncodeToString(e.encryptStream(objNum, data))
+}
+
+func padPDFPassword(password string) []byte {
+	padded := make([]byte, 0, 32)
+	padded = append(padded, []byte(password)...)
+	if len(padded) > 32 {
+		padded = padded[:32]
+	}
+	return append(padded, pdfPasswordPadding[:32-len(padded)]...)
+}
+
+// rc4Rounds 先以原密钥加密，再依次以密钥各字节异或 1..19 的结果重复加密
+func rc4Rounds(key, data []byte) []byte {
+	out := rc4Apply(key, data)
+	roundKey := make([]byte, len(key))
+	for i := 1; i <= 19; i++ {
+		for j := range key {
+			roundKey[j] = key[j] ^ byte(i)
+		}
+		out = rc4Apply(roundKey, out)
+	}
+	return out
+}
+
+func rc4Apply(key, data []byte) []byte {
+	cipher, err := rc4.NewCipher(key)
+	if err != nil {
+		panic(err) // 密钥长度固定为 16 字节，不会出错
+	}
+	out := make([]byte, len(data))
+	cipher.XORKeyStream(out, data)
+	return out
+}
+
+// encodeUCS2 将文本编码为 UniGB-UCS2-H 所需的 UCS-2 大端十六进制串，超出基本平面的字符以问号代替
+func encodeUCS2(text string) string {
+	var buf bytes.Buffer
+	for _, r := range text {
+		if r > 0xFFFF {
+			r = '?'
+		}
+		fmt.Fprintf(&buf, "%04X", r)
+	}
+	return buf.String()
+}
+
+// utf16BOM 文档信息字符串使用带 BOM 的 UTF-16BE 编码
+func utf16BOM(text string) []byte {
+	out := []byte{0xFE, 0xFF}
+	for _, unit := range utf16.Encode([]rune(text)) {
+		out = append(out, byte(unit>>8), byte(unit))
+	}
+	return out
+}
+
+func pdfNumber(value float64) string {
+	s := strings.TrimRight(fmt.Sprintf("%.2f", value), "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -27,6 +28,29 @@ func NewValidationError(message string) error {
 	return &ValidationError{Message: message}
 }
 
+// ConflictError represents an operation rejected by the current resource state
+type ConflictError struct {
+	Message string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
+
+// NewConflictError creates a new conflict error
+func NewConflictError(message string) error {
+	return &ConflictError{Message: message}
+}
+
+// AsConflictError reports whether err wraps a ConflictError
+func AsConflictError(err error) (*ConflictError, bool) {
+	var conflictErr *ConflictError
+	if errors.As(err, &conflictErr) {
+		return conflictErr, true
+	}
+	return nil, false
+}
+
 func SuccessResponse(c *gin.Context, statusCode int, message string, data interface{}) {
 	c.JSON(statusCode, APIResponse{
 		Success: true,
@@ -65,6 +89,10 @@ func NotFoundResponse(c *gin.Context, resource string) {
 	ErrorResponse(c, http.StatusNotFound, message)
 }
 
+func ConflictResponse(c *gin.Context, message string) {
+	ErrorResponse(c, http.StatusConflict, message)
+}
+
 func BadRequestResponse(c *gin.Context, message string) {
 	if message == "" {
 		message = "Bad request"